@@ -4,7 +4,11 @@ import (
 	"candidate-backend/internal/config"
 	"candidate-backend/internal/database"
 	"candidate-backend/internal/handlers"
+	"candidate-backend/internal/mail"
 	"candidate-backend/internal/middleware"
+	"candidate-backend/internal/services"
+	"candidate-backend/internal/storage"
+	"context"
 	"log"
 
 	"github.com/gin-gonic/gin"
@@ -31,7 +35,7 @@ import (
 // @securityDefinitions.apikey Bearer
 // @in header
 // @name Authorization
-// @description Type "Bearer" followed by a space and JWT token.
+// @description Type "Bearer" followed by a space and a JWT or personal access token.
 
 func main() {
 	// Load configuration
@@ -49,13 +53,171 @@ func main() {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
+	// Initialize attachment storage
+	var store storage.Storage
+	var localStore *storage.LocalStorage
+	switch cfg.StorageDriver {
+	case "local":
+		localStore, err = storage.NewLocalStorage(cfg.StorageLocalPath, cfg.PublicURL+"/files", cfg.StorageSigningKey)
+		store = localStore
+	case "s3":
+		store, err = storage.NewS3Storage(storage.S3Config{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			UsePathStyle:    cfg.S3UsePathStyle,
+		})
+	default:
+		log.Fatalf("Unknown STORAGE_DRIVER %q (expected local or s3)", cfg.StorageDriver)
+	}
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+
+	// Initialize the mailer
+	var mailer mail.Mailer
+	switch cfg.MailDriver {
+	case "file":
+		mailer, err = mail.NewFileMailer(cfg.MailFilePath, cfg.MailFrom)
+	case "smtp":
+		mailer, err = mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		})
+	default:
+		log.Fatalf("Unknown MAIL_DRIVER %q (expected file or smtp)", cfg.MailDriver)
+	}
+	if err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+
+	// Send webhook deliveries in the background
+	webhookService := services.NewWebhookService(services.NewPostgresWebhookRepository(db.DB), services.WebhookPolicy{
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		BaseDelay:    cfg.WebhookRetryBaseDelay,
+		MaxDelay:     cfg.WebhookRetryMaxDelay,
+		DisableAfter: cfg.WebhookDisableAfter,
+		Timeout:      cfg.WebhookTimeout,
+
+		AllowPrivateNetworks: cfg.WebhookAllowPrivateNetworks,
+	})
+	go webhookService.Run(context.Background(), cfg.WebhookPollInterval)
+
+	// Publish the domain events task and comment changes write to the outbox
+	dispatcher := services.NewOutboxDispatcher(services.NewPostgresOutboxRepository(db.DB))
+	dispatcher.Subscribe("webhooks", webhookService.HandleEvent)
+
+	// Notify users of changes to their tasks, and remind them of due dates
+	notificationService := services.NewNotificationService(
+		services.NewPostgresNotificationRepository(db.DB),
+		services.NewPostgresTaskRepository(db.DB),
+		services.NewPostgresUserRepository(db.DB),
+	)
+	dispatcher.Subscribe("notifications", notificationService.HandleEvent)
+	go notificationService.Run(context.Background(), cfg.DueSoonInterval, cfg.DueSoonWindow)
+
+	// Email notifications and daily digests
+	emailService := services.NewEmailService(
+		services.NewPostgresEmailRepository(db.DB),
+		services.NewPostgresTaskRepository(db.DB),
+		services.NewPostgresUserRepository(db.DB),
+		mailer,
+		services.EmailSettings{
+			AppURL:         cfg.AppURL,
+			UnsubscribeURL: cfg.PublicURL + "/email/unsubscribe",
+			SigningKey:     cfg.MailSigningKey,
+			MaxAttempts:    cfg.MailMaxAttempts,
+		},
+	)
+	go emailService.Run(context.Background(), cfg.MailPollInterval, cfg.DigestPeriod)
+
+	// Delete the attachment blobs of deleted attachments, tasks and comments
+	attachmentService := services.NewAttachmentService(db.DB, store, cfg.AttachmentMaxBytes, cfg.SignedURLTTL)
+	dispatcher.Subscribe("attachments", attachmentService.HandleEvent)
+	go attachmentService.Run(context.Background())
+
+	// Relay events to the event streams of every instance through LISTEN/NOTIFY
+	eventHub := services.NewEventHub(cfg.EventReplayBuffer)
+	relay := services.NewPostgresEventRelay(db.DB, cfg.DatabaseURL)
+	dispatcher.Subscribe("event stream", relay.Announce)
+	go relay.Listen(context.Background(), eventHub)
+
+	// Route the events to WebSocket subscribers
+	realtimeHub := services.NewRealtimeHub(services.NewPostgresTaskRepository(db.DB))
+	go realtimeHub.Run(context.Background(), eventHub)
+
+	go dispatcher.Run(context.Background(), cfg.OutboxPollInterval, cfg.OutboxRetention)
+
+	// Two-factor authentication with authenticator apps
+	mfaService := services.NewMFAService(
+		services.NewPostgresMFARepository(db.DB),
+		services.NewPostgresUserRepository(db.DB),
+		services.MFASettings{
+			Issuer:        cfg.MFAIssuer,
+			EncryptionKey: cfg.MFAEncryptionKey,
+		},
+	)
+
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(db.DB, cfg.JWTSecret)
+	authHandler := handlers.NewAuthHandler(db.DB, cfg.JWTSecret, services.LoginThrottleSettings{
+		Window:        cfg.LoginWindow,
+		DelayAfter:    cfg.LoginDelayAfter,
+		BaseDelay:     cfg.LoginBaseDelay,
+		MaxFailures:   cfg.LoginMaxFailures,
+		IPMaxFailures: cfg.LoginIPMaxFailures,
+		Lockout:       cfg.LoginLockout,
+	}, services.NewVerificationService(
+		services.NewPostgresUserRepository(db.DB),
+		mailer,
+		services.VerificationSettings{
+			URL:            cfg.EmailVerificationURL,
+			SigningKey:     cfg.MailSigningKey,
+			TTL:            cfg.EmailVerificationTTL,
+			ResendInterval: cfg.VerificationResendInterval,
+		},
+	), mfaService)
+	mfaHandler := handlers.NewMFAHandler(mfaService)
+	passwordResetHandler := handlers.NewPasswordResetHandler(services.NewPasswordResetService(
+		services.NewPostgresPasswordResetRepository(db.DB),
+		services.NewPostgresUserRepository(db.DB),
+		mailer,
+		services.PasswordResetSettings{
+			URL:        cfg.PasswordResetURL,
+			TTL:        cfg.PasswordResetTTL,
+			EmailLimit: cfg.PasswordResetEmailLimit,
+			IPLimit:    cfg.PasswordResetIPLimit,
+		},
+	))
 	taskHandler := handlers.NewTaskHandler(db.DB)
 	commentHandler := handlers.NewCommentHandler(db.DB)
+	timeEntryHandler := handlers.NewTimeEntryHandler(db.DB)
+	sprintHandler := handlers.NewSprintHandler(db.DB)
+	projectHandler := handlers.NewProjectHandler(db.DB)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	emailHandler := handlers.NewEmailHandler(emailService)
+	attachmentHandler := handlers.NewAttachmentHandler(db.DB, store, cfg.AttachmentMaxBytes, cfg.SignedURLTTL)
+	reactionHandler := handlers.NewReactionHandler(db.DB, cfg.ReactionEmojis)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	eventStreamHandler := handlers.NewEventStreamHandler(eventHub, cfg.EventHeartbeat)
+	realtimeHandler := handlers.NewRealtimeHandler(realtimeHub, services.NewPostgresUserRepository(db.DB))
 
 	// Setup router
 	router := gin.Default()
+	middleware.UseJSONFieldNames()
+
+	// Rate limits and login throttling go by the client IP; only take it from
+	// X-Forwarded-For when the request comes through a trusted proxy
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	// Render errors from every route as problem+json
+	router.Use(middleware.ErrorHandler())
 
 	// Apply rate limiting to all routes
 	router.Use(middleware.RateLimitMiddleware())
@@ -68,42 +230,38 @@ func main() {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
-	// Auth routes (public)
-	auth := router.Group("/auth")
-	{
-		auth.POST("/register", authHandler.Register)
-		auth.POST("/login", authHandler.Login)
-	}
+	// Personal access tokens for scripts, accepted alongside JWTs
+	accessTokenService := services.NewAccessTokenService(
+		services.NewPostgresAccessTokenRepository(db.DB),
+		services.NewPostgresUserRepository(db.DB),
+	)
+
+	routes := handlers.Routes{
+		Auth:          authHandler,
+		PasswordReset: passwordResetHandler,
+		MFA:           mfaHandler,
+		AccessTokens:  handlers.NewAccessTokenHandler(accessTokenService),
+		Tasks:         taskHandler,
+		Comments:      commentHandler,
+		TimeEntries:   timeEntryHandler,
+		Attachments:   attachmentHandler,
+		Reactions:     reactionHandler,
+		Sprints:       sprintHandler,
+		Projects:      projectHandler,
+		Notifications: notificationHandler,
+		Email:         emailHandler,
+		Webhooks:      webhookHandler,
+		EventStream:   eventStreamHandler,
+		Realtime:      realtimeHandler,
 
-	// Protected routes
-	api := router.Group("/api")
-	api.Use(middleware.AuthMiddleware(cfg.JWTSecret))
-	{
-		// Task routes
-		tasks := api.Group("/tasks")
-		{
-			tasks.GET("", taskHandler.GetTasks)
-			tasks.GET("/archived", taskHandler.GetArchivedTasks)
-			tasks.POST("", taskHandler.CreateTask)
-			tasks.GET("/:id", taskHandler.GetTask)
-			tasks.PUT("/:id", taskHandler.UpdateTask)
-			tasks.DELETE("/:id", taskHandler.DeleteTask)
-			tasks.POST("/:id/archive", taskHandler.ArchiveTask)
-			tasks.POST("/:id/unarchive", taskHandler.UnarchiveTask)
-			tasks.GET("/:id/logs", taskHandler.GetTaskLogs)
-
-			// Comment routes
-			tasks.GET("/:id/comments", commentHandler.GetComments)
-			tasks.POST("/:id/comments", commentHandler.CreateComment)
-		}
-
-		// Comment update/delete routes
-		comments := api.Group("/comments")
-		{
-			comments.PUT("/:id", commentHandler.UpdateComment)
-			comments.DELETE("/:id", commentHandler.DeleteComment)
-		}
+		Authenticate:         middleware.AuthMiddleware(cfg.JWTSecret, services.NewPostgresUserRepository(db.DB), accessTokenService),
+		Users:                services.NewPostgresUserRepository(db.DB),
+		RequireVerifiedEmail: cfg.RequireVerifiedEmail,
+	}
+	if localStore != nil {
+		routes.Files = handlers.NewFileHandler(localStore)
 	}
+	handlers.RegisterRoutes(router, routes)
 
 	// Start server
 	log.Printf("Server starting on port %s", cfg.Port)
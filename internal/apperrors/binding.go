@@ -0,0 +1,45 @@
+package apperrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FromBinding converts an error from binding a JSON request body into a validation error
+func FromBinding(err error) *Error {
+	var fieldErrs validator.ValidationErrors
+	if errors.As(err, &fieldErrs) && len(fieldErrs) > 0 {
+		fields := make([]FieldError, 0, len(fieldErrs))
+		for _, fieldErr := range fieldErrs {
+			fields = append(fields, FieldError{Field: fieldErr.Field(), Message: bindingMessage(fieldErr)})
+		}
+		return &Error{Kind: ErrValidation, Code: CodeValidation, Detail: fields[0].Message, Fields: fields, Err: err}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		invalid := Invalidf(typeErr.Field, "%s must be of type %s", typeErr.Field, typeErr.Type)
+		invalid.Err = err
+		return invalid
+	}
+
+	invalid := Invalid("body", "request body must be valid JSON")
+	invalid.Err = err
+	return invalid
+}
+
+func bindingMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", fieldErr.Field())
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", fieldErr.Field())
+	case "min":
+		return fmt.Sprintf("%s must be at least %s characters", fieldErr.Field(), fieldErr.Param())
+	default:
+		return fmt.Sprintf("%s is invalid", fieldErr.Field())
+	}
+}
@@ -0,0 +1,86 @@
+// Package apperrors defines the domain errors returned by services and how they are
+// reported to clients as RFC 7807 problem details.
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error kinds. Every *Error matches exactly one of them with errors.Is.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrForbidden    = errors.New("forbidden")
+	ErrValidation   = errors.New("validation failed")
+	ErrConflict     = errors.New("conflict")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrTooLarge     = errors.New("payload too large")
+	ErrRateLimited  = errors.New("rate limited")
+)
+
+// CodeValidation is the code of every validation error; Fields says what is wrong
+const CodeValidation = "validation_failed"
+
+// FieldError describes one invalid input field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a domain error with a stable code clients can switch on. Detail is safe
+// to show to clients; Err is an internal cause that is never sent.
+type Error struct {
+	Kind   error
+	Code   string
+	Detail string
+	Fields []FieldError
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+// Is matches the error's kind, so errors.Is(err, ErrNotFound) holds for every not-found error
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func New(kind error, code, detail string) *Error {
+	return &Error{Kind: kind, Code: code, Detail: detail}
+}
+
+func NotFound(code, detail string) *Error {
+	return New(ErrNotFound, code, detail)
+}
+
+func Forbidden(code, detail string) *Error {
+	return New(ErrForbidden, code, detail)
+}
+
+func Conflict(code, detail string) *Error {
+	return New(ErrConflict, code, detail)
+}
+
+func Unauthorized(code, detail string) *Error {
+	return New(ErrUnauthorized, code, detail)
+}
+
+// Invalid returns a validation error for a single field
+func Invalid(field, message string) *Error {
+	return &Error{
+		Kind:   ErrValidation,
+		Code:   CodeValidation,
+		Detail: message,
+		Fields: []FieldError{{Field: field, Message: message}},
+	}
+}
+
+// Invalidf is Invalid with a formatted message
+func Invalidf(field, format string, args ...interface{}) *Error {
+	return Invalid(field, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,77 @@
+package apperrors
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ContentType is the media type of problem detail bodies
+const ContentType = "application/problem+json"
+
+// CodeInternal is the code of unexpected errors, whose details are never sent
+const CodeInternal = "internal_error"
+
+// Problem is an RFC 7807 problem details body. Code is a stable, machine-readable
+// extension member; Errors lists invalid fields for validation problems.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+var statuses = []struct {
+	kind   error
+	status int
+}{
+	{ErrNotFound, http.StatusNotFound},
+	{ErrForbidden, http.StatusForbidden},
+	{ErrValidation, http.StatusBadRequest},
+	{ErrConflict, http.StatusConflict},
+	{ErrUnauthorized, http.StatusUnauthorized},
+	{ErrTooLarge, http.StatusRequestEntityTooLarge},
+	{ErrRateLimited, http.StatusTooManyRequests},
+}
+
+// Status returns the HTTP status for an error; errors that are not an *Error are 500
+func Status(err error) int {
+	var appErr *Error
+	if !errors.As(err, &appErr) {
+		return http.StatusInternalServerError
+	}
+
+	for _, s := range statuses {
+		if errors.Is(appErr, s.kind) {
+			return s.status
+		}
+	}
+
+	return http.StatusInternalServerError
+}
+
+// NewProblem describes err for a request to instance. Errors that are not an *Error
+// are reported as a generic internal error so database or storage messages never
+// reach clients.
+func NewProblem(err error, instance string) Problem {
+	status := Status(err)
+	problem := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Instance: instance,
+		Code:     CodeInternal,
+		Detail:   "An unexpected error occurred",
+	}
+
+	var appErr *Error
+	if status != http.StatusInternalServerError && errors.As(err, &appErr) {
+		problem.Code = appErr.Code
+		problem.Detail = appErr.Detail
+		problem.Errors = appErr.Fields
+	}
+
+	return problem
+}
@@ -0,0 +1,53 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNewProblem(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+		wantFields int
+	}{
+		{"Not found", NotFound("task_not_found", "Task not found"), 404, "task_not_found", "Task not found", 0},
+		{"Forbidden", Forbidden("not_task_owner", "You can only modify your own tasks"), 403, "not_task_owner", "You can only modify your own tasks", 0},
+		{"Validation", Invalid("title", "title is required"), 400, CodeValidation, "title is required", 1},
+		{"Conflict", Conflict("email_taken", "Email already exists"), 409, "email_taken", "Email already exists", 0},
+		{"Wrapped", fmt.Errorf("loading task: %w", NotFound("task_not_found", "Task not found")), 404, "task_not_found", "Task not found", 0},
+		{"Unexpected error hides its message", errors.New("pq: connection refused"), 500, CodeInternal, "An unexpected error occurred", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := NewProblem(tt.err, "/api/tasks/1")
+			if problem.Status != tt.wantStatus {
+				t.Errorf("Status = %d, want %d", problem.Status, tt.wantStatus)
+			}
+			if problem.Code != tt.wantCode {
+				t.Errorf("Code = %q, want %q", problem.Code, tt.wantCode)
+			}
+			if problem.Detail != tt.wantDetail {
+				t.Errorf("Detail = %q, want %q", problem.Detail, tt.wantDetail)
+			}
+			if len(problem.Errors) != tt.wantFields {
+				t.Errorf("len(Errors) = %d, want %d", len(problem.Errors), tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestErrorIsKind(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", Invalid("title", "title is required"))
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("errors.Is(err, ErrValidation) = false, want true")
+	}
+	if errors.Is(err, ErrNotFound) {
+		t.Errorf("errors.Is(err, ErrNotFound) = true, want false")
+	}
+}
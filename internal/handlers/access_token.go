@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/middleware"
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccessTokenHandler struct {
+	accessTokenService *services.AccessTokenService
+}
+
+func NewAccessTokenHandler(accessTokenService *services.AccessTokenService) *AccessTokenHandler {
+	return &AccessTokenHandler{accessTokenService: accessTokenService}
+}
+
+// GetAccessTokens godoc
+// @Summary      Get personal access tokens
+// @Description  Retrieve the current user's personal access tokens that are not revoked, newest first. The tokens themselves are not included.
+// @Tags         Access Tokens
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {array}   models.AccessToken
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      403  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/access-tokens [get]
+func (h *AccessTokenHandler) GetAccessTokens(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	tokens, err := h.accessTokenService.ListAccessTokens(userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// CreateAccessToken godoc
+// @Summary      Create a personal access token
+// @Description  Create a token for scripts and CI, sent as "Bearer <token>" like a JWT. Scopes are tasks:read, tasks:write, comments and admin; only admins may use admin.
+// @Description  Tokens without expires_at work until revoked. The response is the only one that includes the token.
+// @Tags         Access Tokens
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        token  body      models.CreateAccessTokenRequest  true  "Token name, scopes and expiry"
+// @Success      201    {object}  models.AccessToken
+// @Failure      400    {object}  apperrors.Problem
+// @Failure      401    {object}  apperrors.Problem
+// @Failure      403    {object}  apperrors.Problem
+// @Failure      500    {object}  apperrors.Problem
+// @Router       /api/access-tokens [post]
+func (h *AccessTokenHandler) CreateAccessToken(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req models.CreateAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.FromBinding(err))
+		return
+	}
+
+	token, err := h.accessTokenService.CreateAccessToken(req, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+// RevokeAccessToken godoc
+// @Summary      Revoke a personal access token
+// @Description  Revoke one of the current user's tokens; requests with it are rejected from then on
+// @Tags         Access Tokens
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Access token ID"
+// @Success      200  {object}  map[string]string
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      403  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/access-tokens/{id} [delete]
+func (h *AccessTokenHandler) RevokeAccessToken(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	if err := h.accessTokenService.RevokeAccessToken(c.Param("id"), userID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Access token revoked"})
+}
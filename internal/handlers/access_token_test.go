@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"candidate-backend/internal/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAccessTokens(t *testing.T) {
+	router, _, _ := newTestRouter()
+	alice := register(t, router, "alice@example.com")
+
+	create := func(scopes ...models.TokenScope) models.AccessToken {
+		t.Helper()
+		var token models.AccessToken
+		w := serve(t, router, http.MethodPost, "/api/access-tokens", alice, models.CreateAccessTokenRequest{Name: "CI", Scopes: scopes}, &token)
+		if w.Code != http.StatusCreated || !strings.HasPrefix(token.Token, models.AccessTokenPrefix) || !strings.HasPrefix(token.Token, token.Prefix) {
+			t.Fatalf("create token: status = %d, body = %s", w.Code, w.Body.String())
+		}
+		return token
+	}
+	reader := create(models.ScopeTasksRead)
+	writer := create(models.ScopeTasksWrite, models.ScopeComments)
+	revoked := create(models.ScopeTasksRead)
+	serve(t, router, http.MethodDelete, "/api/access-tokens/"+strconv.Itoa(revoked.ID), alice, nil, nil)
+
+	var task models.Task
+	serve(t, router, http.MethodPost, "/api/tasks", alice, models.CreateTaskRequest{Title: "Ship it"}, &task)
+	comments := "/api/tasks/" + strconv.Itoa(task.ID) + "/comments"
+	newTask := models.CreateTaskRequest{Title: "From CI"}
+	comment := models.CreateCommentRequest{Content: "Build passed"}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		body       interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{"Read with tasks:read", http.MethodGet, "/api/tasks", reader.Token, nil, http.StatusOK, ""},
+		{"Write with tasks:read", http.MethodPost, "/api/tasks", reader.Token, newTask, http.StatusForbidden, "insufficient_scope"},
+		{"Comments without the scope", http.MethodGet, comments, reader.Token, nil, http.StatusForbidden, "insufficient_scope"},
+		{"Read with tasks:write", http.MethodGet, "/api/tasks", writer.Token, nil, http.StatusOK, ""},
+		{"Write with tasks:write", http.MethodPost, "/api/tasks", writer.Token, newTask, http.StatusCreated, ""},
+		{"Comment with comments", http.MethodPost, comments, writer.Token, comment, http.StatusCreated, ""},
+		{"Admin without the scope", http.MethodDelete, "/api/admin/users/1/mfa", writer.Token, nil, http.StatusForbidden, "insufficient_scope"},
+		{"Account routes take a session", http.MethodGet, "/api/access-tokens", writer.Token, nil, http.StatusForbidden, "session_required"},
+		{"Revoked token", http.MethodGet, "/api/tasks", revoked.Token, nil, http.StatusUnauthorized, "invalid_token"},
+		{"Unknown token", http.MethodGet, "/api/tasks", models.AccessTokenPrefix + "unknown", nil, http.StatusUnauthorized, "invalid_token"},
+		{"Admin scope for a user", http.MethodPost, "/api/access-tokens", alice, models.CreateAccessTokenRequest{Name: "Admin", Scopes: []models.TokenScope{models.ScopeAdmin}}, http.StatusForbidden, "admin_required"},
+		{"Unknown scope", http.MethodPost, "/api/access-tokens", alice, models.CreateAccessTokenRequest{Name: "CI", Scopes: []models.TokenScope{"everything"}}, http.StatusBadRequest, "validation_failed"},
+		{"Revoke again", http.MethodDelete, "/api/access-tokens/" + strconv.Itoa(revoked.ID), alice, nil, http.StatusNotFound, "access_token_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, router, tt.method, tt.path, tt.token, tt.body, nil)
+			if w.Code != tt.wantStatus || !strings.Contains(w.Body.String(), tt.wantCode) {
+				t.Fatalf("%s %s: status = %d, body = %s, want %d %s", tt.method, tt.path, w.Code, w.Body.String(), tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+
+	var tokens []models.AccessToken
+	serve(t, router, http.MethodGet, "/api/access-tokens", alice, nil, &tokens)
+	if len(tokens) != 2 || tokens[0].ID != writer.ID || tokens[0].Token != "" || tokens[0].LastUsedAt == nil {
+		t.Errorf("tokens = %+v, want the writer and the reader, used and without secrets", tokens)
+	}
+}
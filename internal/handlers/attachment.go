@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/middleware"
+	"candidate-backend/internal/services"
+	"candidate-backend/internal/storage"
+	"database/sql"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead leaves room for multipart boundaries and headers around the file
+const multipartOverhead = 1 << 20
+
+type AttachmentHandler struct {
+	attachmentService *services.AttachmentService
+	changeLogService  *services.ChangeLogService
+	maxSize           int64
+}
+
+func NewAttachmentHandler(db *sql.DB, store storage.Storage, maxSize int64, urlTTL time.Duration) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: services.NewAttachmentService(db, store, maxSize, urlTTL),
+		changeLogService:  services.NewChangeLogService(services.NewPostgresChangeLogRepository(db)),
+		maxSize:           maxSize,
+	}
+}
+
+// GetTaskAttachments godoc
+// @Summary      Get task attachments
+// @Description  Retrieve all attachments of a task, including files attached to its comments
+// @Tags         Attachments
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Task ID"
+// @Success      200  {array}   models.Attachment
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/tasks/{id}/attachments [get]
+func (h *AttachmentHandler) GetTaskAttachments(c *gin.Context) {
+	taskID := c.Param("id")
+
+	attachments, err := h.attachmentService.GetTaskAttachments(taskID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// UploadTaskAttachment godoc
+// @Summary      Upload a task attachment
+// @Description  Upload a file to a task. The content type is sniffed from the file contents;
+// @Description  images, PDFs, plain text, zip/gzip archives and mp4/webm videos are accepted.
+// @Tags         Attachments
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     Bearer
+// @Param        id    path      int   true  "Task ID"
+// @Param        file  formData  file  true  "File to upload"
+// @Success      201   {object}  models.Attachment
+// @Failure      400   {object}  apperrors.Problem
+// @Failure      401   {object}  apperrors.Problem
+// @Failure      404   {object}  apperrors.Problem
+// @Failure      413   {object}  apperrors.Problem
+// @Failure      500   {object}  apperrors.Problem
+// @Router       /api/tasks/{id}/attachments [post]
+func (h *AttachmentHandler) UploadTaskAttachment(c *gin.Context) {
+	taskID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	upload, file, ok := h.readUpload(c)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.attachmentService.UploadToTask(c.Request.Context(), taskID, upload, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	_ = h.changeLogService.CreateChangeLog(attachment.TaskID, userID, "attached",
+		fmt.Sprintf("Attached '%s'", attachment.Filename))
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// GetCommentAttachments godoc
+// @Summary      Get comment attachments
+// @Description  Retrieve the attachments of a comment
+// @Tags         Attachments
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Comment ID"
+// @Success      200  {array}   models.Attachment
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/comments/{id}/attachments [get]
+func (h *AttachmentHandler) GetCommentAttachments(c *gin.Context) {
+	commentID := c.Param("id")
+
+	attachments, err := h.attachmentService.GetCommentAttachments(commentID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// UploadCommentAttachment godoc
+// @Summary      Upload a comment attachment
+// @Description  Upload a file to a comment (only the comment creator can attach files)
+// @Tags         Attachments
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     Bearer
+// @Param        id    path      int   true  "Comment ID"
+// @Param        file  formData  file  true  "File to upload"
+// @Success      201   {object}  models.Attachment
+// @Failure      400   {object}  apperrors.Problem
+// @Failure      401   {object}  apperrors.Problem
+// @Failure      403   {object}  apperrors.Problem
+// @Failure      404   {object}  apperrors.Problem
+// @Failure      413   {object}  apperrors.Problem
+// @Failure      500   {object}  apperrors.Problem
+// @Router       /api/comments/{id}/attachments [post]
+func (h *AttachmentHandler) UploadCommentAttachment(c *gin.Context) {
+	commentID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	upload, file, ok := h.readUpload(c)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.attachmentService.UploadToComment(c.Request.Context(), commentID, upload, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	_ = h.changeLogService.CreateChangeLog(attachment.TaskID, userID, "attached",
+		fmt.Sprintf("Attached '%s' to a comment", attachment.Filename))
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// GetAttachment godoc
+// @Summary      Get attachment by ID
+// @Description  Retrieve attachment metadata
+// @Tags         Attachments
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Attachment ID"
+// @Success      200  {object}  models.Attachment
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/attachments/{id} [get]
+func (h *AttachmentHandler) GetAttachment(c *gin.Context) {
+	attachmentID := c.Param("id")
+
+	attachment, err := h.attachmentService.GetAttachment(attachmentID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attachment)
+}
+
+// GetDownloadURL godoc
+// @Summary      Get attachment download URL
+// @Description  Create a short-lived signed URL that downloads the attachment without authentication
+// @Tags         Attachments
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Attachment ID"
+// @Success      200  {object}  models.AttachmentURL
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/attachments/{id}/url [get]
+func (h *AttachmentHandler) GetDownloadURL(c *gin.Context) {
+	attachmentID := c.Param("id")
+
+	url, err := h.attachmentService.GetDownloadURL(c.Request.Context(), attachmentID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, url)
+}
+
+// DeleteAttachment godoc
+// @Summary      Delete an attachment
+// @Description  Delete an attachment and its stored file (only the uploader can delete)
+// @Tags         Attachments
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Attachment ID"
+// @Success      200  {object}  map[string]string
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      403  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/attachments/{id} [delete]
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	attachmentID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	attachment, err := h.attachmentService.DeleteAttachment(c.Request.Context(), attachmentID, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	_ = h.changeLogService.CreateChangeLog(attachment.TaskID, userID, "attachment_removed",
+		fmt.Sprintf("Removed attachment '%s'", attachment.Filename))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted successfully"})
+}
+
+// readUpload reads the "file" form field, enforcing the size limit before the
+// body is parsed. It records the error on the context and reports false on failure.
+func (h *AttachmentHandler) readUpload(c *gin.Context) (services.AttachmentUpload, multipart.File, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+multipartOverhead)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			_ = c.Error(h.fileTooLarge())
+			return services.AttachmentUpload{}, nil, false
+		}
+		_ = c.Error(apperrors.Invalid("file", "file is required"))
+		return services.AttachmentUpload{}, nil, false
+	}
+
+	if fileHeader.Size > h.maxSize {
+		_ = c.Error(h.fileTooLarge())
+		return services.AttachmentUpload{}, nil, false
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		_ = c.Error(err)
+		return services.AttachmentUpload{}, nil, false
+	}
+
+	upload := services.AttachmentUpload{
+		Filename: strings.TrimSpace(fileHeader.Filename),
+		Size:     fileHeader.Size,
+		Body:     file,
+	}
+
+	return upload, file, true
+}
+
+// fileTooLarge is the error for uploads over the size limit
+func (h *AttachmentHandler) fileTooLarge() error {
+	return apperrors.New(apperrors.ErrTooLarge, "file_too_large", fmt.Sprintf("file must be at most %d bytes", h.maxSize))
+}
+
+type FileHandler struct {
+	storage *storage.LocalStorage
+}
+
+func NewFileHandler(store *storage.LocalStorage) *FileHandler {
+	return &FileHandler{storage: store}
+}
+
+// DownloadFile godoc
+// @Summary      Download a file
+// @Description  Serve a locally stored attachment through a signed URL from /api/attachments/{id}/url
+// @Tags         Attachments
+// @Produce      octet-stream
+// @Param        key        path      string  true   "Storage key"
+// @Param        expires    query     int     true   "Expiry as a Unix timestamp"
+// @Param        signature  query     string  true   "URL signature"
+// @Param        filename   query     string  false  "Download file name"
+// @Param        content_type  query  string  false  "Download content type"
+// @Success      200  {file}    file
+// @Failure      403  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Router       /files/{key} [get]
+func (h *FileHandler) DownloadFile(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	opts, err := h.storage.Verify(key, c.Request.URL.Query())
+	if err != nil {
+		_ = c.Error(apperrors.Forbidden("invalid_signature", err.Error()))
+		return
+	}
+
+	file, err := h.storage.Open(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			_ = c.Error(apperrors.NotFound("file_not_found", "File not found"))
+			return
+		}
+		_ = c.Error(err)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	contentType := opts.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "private, no-store")
+	if opts.Filename != "" {
+		c.Header("Content-Disposition", storage.ContentDisposition(opts.Filename))
+	}
+
+	http.ServeContent(c.Writer, c.Request, "", info.ModTime(), file)
+}
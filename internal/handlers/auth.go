@@ -1,147 +1,293 @@
 package handlers
 
 import (
+	"candidate-backend/internal/apperrors"
 	"candidate-backend/internal/middleware"
 	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
 	"database/sql"
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// mfaChallengeTTL is how long a user has to enter a code after the password
+const mfaChallengeTTL = 5 * time.Minute
+
+// mfaPurpose marks the tokens of MFA challenges
+const mfaPurpose = "mfa"
+
 type AuthHandler struct {
-	db        *sql.DB
-	jwtSecret string
+	authService         *services.AuthService
+	verificationService *services.VerificationService
+	mfaService          *services.MFAService
+	jwtSecret           string
 }
 
-func NewAuthHandler(db *sql.DB, jwtSecret string) *AuthHandler {
+func NewAuthHandler(db *sql.DB, jwtSecret string, throttle services.LoginThrottleSettings, verificationService *services.VerificationService, mfaService *services.MFAService) *AuthHandler {
+	return NewAuthHandlerWithRepositories(services.NewPostgresUserRepository(db), services.NewPostgresLoginAttemptRepository(db), jwtSecret, throttle, verificationService, mfaService)
+}
+
+// NewAuthHandlerWithRepositories builds an AuthHandler on the given user and login
+// attempt repositories
+func NewAuthHandlerWithRepositories(users services.UserRepository, attempts services.LoginAttemptRepository, jwtSecret string, throttle services.LoginThrottleSettings, verificationService *services.VerificationService, mfaService *services.MFAService) *AuthHandler {
 	return &AuthHandler{
-		db:        db,
-		jwtSecret: jwtSecret,
+		authService:         services.NewAuthService(users, attempts, mfaService, throttle),
+		verificationService: verificationService,
+		mfaService:          mfaService,
+		jwtSecret:           jwtSecret,
 	}
 }
 
 // Register godoc
 // @Summary      Register a new user
-// @Description  Create a new user account with email and password
+// @Description  Create a new user account with email and password, and email a link to verify the address
 // @Tags         Authentication
 // @Accept       json
 // @Produce      json
 // @Param        user  body      models.RegisterRequest  true  "User registration data"
 // @Success      201   {object}  models.LoginResponse
-// @Failure      400   {object}  map[string]string
-// @Failure      409   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Failure      400   {object}  apperrors.Problem
+// @Failure      409   {object}  apperrors.Problem
+// @Failure      500   {object}  apperrors.Problem
 // @Router       /auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req models.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(apperrors.FromBinding(err))
 		return
 	}
 
-	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	user, err := h.authService.Register(req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		_ = c.Error(err)
 		return
 	}
 
-	// Insert user into database
-	var user models.User
-	err = h.db.QueryRow(
-		`INSERT INTO users (email, password_hash, name)
-		 VALUES ($1, $2, $3)
-		 RETURNING id, email, name, created_at, updated_at`,
-		req.Email, string(hashedPassword), req.Name,
-	).Scan(&user.ID, &user.Email, &user.Name, &user.CreatedAt, &user.UpdatedAt)
-
-	if err != nil {
-		if err.Error() == "pq: duplicate key value violates unique constraint \"users_email_key\"" {
-			c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
-		return
+	// The account exists either way; the user can ask for another link
+	if err := h.verificationService.SendVerification(user); err != nil {
+		log.Printf("sending verification email to user %d: %v", user.ID, err)
 	}
 
 	// Generate JWT token
-	token, err := h.generateToken(user.ID, user.Email)
+	token, err := h.generateToken(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		_ = c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, models.LoginResponse{
 		Token: token,
-		User:  user,
+		User:  *user,
 	})
 }
 
 // Login godoc
 // @Summary      Login user
-// @Description  Authenticate user with email and password, returns JWT token
+// @Description  Authenticate user with email and password, returns JWT token. Users with MFA on get a challenge instead, to answer at /auth/mfa/verify. Repeated failures with an email or from an IP address delay and then lock out further attempts.
 // @Tags         Authentication
 // @Accept       json
 // @Produce      json
 // @Param        credentials  body      models.LoginRequest  true  "User login credentials"
 // @Success      200          {object}  models.LoginResponse
-// @Failure      400          {object}  map[string]string
-// @Failure      401          {object}  map[string]string
-// @Failure      500          {object}  map[string]string
+// @Success      202          {object}  models.MFAChallenge
+// @Failure      400          {object}  apperrors.Problem
+// @Failure      401          {object}  apperrors.Problem
+// @Failure      429          {object}  apperrors.Problem
+// @Failure      500          {object}  apperrors.Problem
 // @Router       /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(apperrors.FromBinding(err))
 		return
 	}
 
-	// Get user from database
-	var user models.User
-	err := h.db.QueryRow(
-		`SELECT id, email, password_hash, name, created_at, updated_at
-		 FROM users WHERE email = $1`,
-		req.Email,
-	).Scan(&user.ID, &user.Email, &user.PasswordHash, &user.Name, &user.CreatedAt, &user.UpdatedAt)
-
+	user, mfaRequired, err := h.authService.Login(req, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		_ = c.Error(err)
 		return
 	}
 
-	// Verify password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	if mfaRequired {
+		expiresAt := time.Now().Add(mfaChallengeTTL)
+		token, err := h.signToken(user, mfaPurpose, expiresAt)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		c.JSON(http.StatusAccepted, models.MFAChallenge{MFARequired: true, MFAToken: token, ExpiresAt: expiresAt})
 		return
 	}
 
 	// Generate JWT token
-	token, err := h.generateToken(user.ID, user.Email)
+	token, err := h.generateToken(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		_ = c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, models.LoginResponse{
 		Token: token,
-		User:  user,
+		User:  *user,
 	})
 }
 
-func (h *AuthHandler) generateToken(userID int, email string) (string, error) {
+// VerifyMFA godoc
+// @Summary      Finish logging in with MFA
+// @Description  Exchange the token of an MFA challenge and a code from the authenticator app, or a recovery code, for a JWT token
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.MFAVerifyRequest  true  "Challenge token and code"
+// @Success      200      {object}  models.LoginResponse
+// @Failure      400      {object}  apperrors.Problem
+// @Failure      401      {object}  apperrors.Problem
+// @Failure      429      {object}  apperrors.Problem
+// @Failure      500      {object}  apperrors.Problem
+// @Router       /auth/mfa/verify [post]
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req models.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.FromBinding(err))
+		return
+	}
+
+	claims := &middleware.Claims{}
+	token, err := jwt.ParseWithClaims(req.MFAToken, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(h.jwtSecret), nil
+	})
+	if err != nil || !token.Valid || claims.Purpose != mfaPurpose {
+		_ = c.Error(services.ErrInvalidMFAToken)
+		return
+	}
+
+	// A password reset since the challenge revokes it like any session
+	user, err := h.authService.GetUser(claims.UserID)
+	if errors.Is(err, services.ErrUserNotFound) || (err == nil && user.SessionVersion != claims.SessionVersion) {
+		_ = c.Error(services.ErrInvalidMFAToken)
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if err := h.mfaService.Verify(user.ID, req.Code); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	sessionToken, err := h.generateToken(user)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LoginResponse{
+		Token: sessionToken,
+		User:  *user,
+	})
+}
+
+// VerifyEmail godoc
+// @Summary      Verify an email address
+// @Description  Mark the user's email verified with the token from a verification link
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.VerifyEmailRequest  true  "Verification token"
+// @Success      200      {object}  models.User
+// @Failure      400      {object}  apperrors.Problem
+// @Failure      500      {object}  apperrors.Problem
+// @Router       /auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.FromBinding(err))
+		return
+	}
+
+	user, err := h.verificationService.VerifyEmail(req.Token)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ResendVerification godoc
+// @Summary      Resend the verification email
+// @Description  Email the current user another link to verify their address
+// @Tags         Authentication
+// @Produce      json
+// @Security     Bearer
+// @Success      202  {object}  map[string]string
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      409  {object}  apperrors.Problem
+// @Failure      429  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /auth/resend-verification [post]
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	if err := h.verificationService.ResendVerification(userID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent"})
+}
+
+// GetSignIns godoc
+// @Summary      List recent sign-ins
+// @Description  List the latest login attempts with the current user's email, successful or not, newest first
+// @Tags         Authentication
+// @Produce      json
+// @Security     Bearer
+// @Param        limit  query     int  false  "Limit number of results (default: 20, max: 100)"
+// @Success      200    {array}   models.LoginAttempt
+// @Failure      400    {object}  apperrors.Problem
+// @Failure      401    {object}  apperrors.Problem
+// @Failure      500    {object}  apperrors.Problem
+// @Router       /api/sign-ins [get]
+func (h *AuthHandler) GetSignIns(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 {
+		_ = c.Error(apperrors.Invalid("limit", "limit must be a positive number"))
+		return
+	}
+
+	signIns, err := h.authService.ListSignIns(userID, limit)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, signIns)
+}
+
+func (h *AuthHandler) generateToken(user *models.User) (string, error) {
+	return h.signToken(user, "", time.Now().Add(24*time.Hour))
+}
+
+// signToken signs a token of the user for a purpose; sessions have none
+func (h *AuthHandler) signToken(user *models.User, purpose string, expiresAt time.Time) (string, error) {
 	claims := &middleware.Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:         user.ID,
+		Email:          user.Email,
+		SessionVersion: user.SessionVersion,
+		Purpose:        purpose,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
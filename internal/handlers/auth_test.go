@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var verifyLink = regexp.MustCompile(`verify-email\?token=([0-9a-f.]+)`)
+
+func TestEmailVerification(t *testing.T) {
+	router, _, _ := newTestRouterWith(true)
+	alice := register(t, router, "alice@example.com")
+	token := mailedToken(t, verifyLink)
+	task := map[string]string{"title": "Write docs"}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		session    string
+		body       interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{"Reads are allowed", http.MethodGet, "/api/tasks", alice, nil, http.StatusOK, ""},
+		{"Writes are blocked", http.MethodPost, "/api/tasks", alice, task, http.StatusForbidden, "email_not_verified"},
+		{"Resend too soon", http.MethodPost, "/auth/resend-verification", alice, nil, http.StatusTooManyRequests, "verification_recently_sent"},
+		{"Tampered token", http.MethodPost, "/auth/verify-email", "", models.VerifyEmailRequest{Token: strings.Replace(token, "1.", "2.", 1)}, http.StatusBadRequest, "invalid_verification_token"},
+		{"Verify", http.MethodPost, "/auth/verify-email", "", models.VerifyEmailRequest{Token: token}, http.StatusOK, ""},
+		{"Verify again", http.MethodPost, "/auth/verify-email", "", models.VerifyEmailRequest{Token: token}, http.StatusOK, ""},
+		{"Writes are allowed", http.MethodPost, "/api/tasks", alice, task, http.StatusCreated, ""},
+		{"Resend once verified", http.MethodPost, "/auth/resend-verification", alice, nil, http.StatusConflict, "email_already_verified"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, router, tt.method, tt.path, tt.session, tt.body, nil)
+			if w.Code != tt.wantStatus || !strings.Contains(w.Body.String(), tt.wantCode) {
+				t.Fatalf("%s %s: status = %d, body = %s, want %d %s", tt.method, tt.path, w.Code, w.Body.String(), tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+
+	var login models.LoginResponse
+	serve(t, router, http.MethodPost, "/auth/login", "", models.LoginRequest{Email: "alice@example.com", Password: "secret123"}, &login)
+	if login.User.EmailVerifiedAt == nil {
+		t.Errorf("user after verifying = %+v, want email_verified_at set", login.User)
+	}
+}
+
+func TestLoginThrottle(t *testing.T) {
+	router, _, _ := newTestRouter()
+	alice := register(t, router, "alice@example.com")
+	wrong := models.LoginRequest{Email: "alice@example.com", Password: "wrong-password"}
+
+	for i := 0; i < services.DefaultLoginThrottle.DelayAfter; i++ {
+		if w := serve(t, router, http.MethodPost, "/auth/login", "", wrong, nil); w.Code != http.StatusUnauthorized {
+			t.Fatalf("login with a wrong password: status = %d, body = %s", w.Code, w.Body.String())
+		}
+	}
+	w := serve(t, router, http.MethodPost, "/auth/login", "", models.LoginRequest{Email: "alice@example.com", Password: "secret123"}, nil)
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "too_many_login_attempts") {
+		t.Fatalf("login right after failures: status = %d, body = %s, want 429 too_many_login_attempts", w.Code, w.Body.String())
+	}
+
+	var signIns []models.LoginAttempt
+	if w := serve(t, router, http.MethodGet, "/api/sign-ins?limit=2", alice, nil, &signIns); w.Code != http.StatusOK {
+		t.Fatalf("sign-ins: status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if len(signIns) != 2 || signIns[0].Result != models.LoginLocked || signIns[1].Result != models.LoginFailed {
+		t.Errorf("sign-ins = %+v, want the locked attempt and the last failure", signIns)
+	}
+	if w := serve(t, router, http.MethodGet, "/api/sign-ins?limit=0", alice, nil, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("sign-ins with limit 0: status = %d, want 400", w.Code)
+	}
+}
@@ -1,85 +1,147 @@
 package handlers
 
 import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/markdown"
 	"candidate-backend/internal/middleware"
 	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
 	"database/sql"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type CommentHandler struct {
-	db *sql.DB
+	commentService  *services.CommentService
+	mentionService  *services.MentionService
+	reactionService *services.ReactionService
+	renderer        *markdown.Renderer
 }
 
 func NewCommentHandler(db *sql.DB) *CommentHandler {
-	return &CommentHandler{db: db}
+	handler := NewCommentHandlerWithRepositories(services.NewPostgresCommentRepository(db))
+	handler.mentionService = services.NewMentionService(db)
+	handler.commentService.SetMentionResolver(handler.mentionService)
+	handler.reactionService = services.NewReactionService(services.NewPostgresReactionRepository(db))
+	return handler
+}
+
+// NewCommentHandlerWithRepositories builds a CommentHandler on the given repository.
+// Mentions and reactions are database-only and left out.
+func NewCommentHandlerWithRepositories(comments services.CommentRepository) *CommentHandler {
+	return &CommentHandler{
+		commentService: services.NewCommentService(comments),
+		renderer:       markdown.NewRenderer(),
+	}
 }
 
 // GetComments godoc
 // @Summary      Get task comments
-// @Description  Retrieve all comments for a specific task
+// @Description  Retrieve a page of a task's comments with their reply threads. Pages follow creation order; pass the X-Next-Cursor header as cursor to get the next one.
+// @Description  A reply whose parent is not on the same page is listed as a top-level comment.
+// @Description  The flat view lists each reply right after its parent and includes the depth; the nested view returns top-level comments with replies inside.
+// @Description  Deleted comments that still have replies are returned as placeholders with deleted set and no content.
+// @Description  Reactions are counted per emoji, with reacted set when the current user reacted.
 // @Tags         Comments
 // @Accept       json
 // @Produce      json
 // @Security     Bearer
-// @Param        id   path      int  true  "Task ID"
-// @Success      200  {array}   models.Comment
-// @Failure      401  {object}  map[string]string
-// @Failure      404  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Param        id       path      int     true   "Task ID"
+// @Param        view     query     string  false  "Response shape: flat (default) or nested"
+// @Param        format   query     string  false  "Content format: raw, html or both (default: raw)"
+// @Param        limit    query     int     false  "Number of comments per page, 1-100 (default: 50)"
+// @Param        cursor   query     string  false  "Cursor from the X-Next-Cursor header of the previous page"
+// @Param        order    query     string  false  "Creation order: asc (default) or desc"
+// @Param        since    query     string  false  "Only comments created after this RFC 3339 time"
+// @Param        user_id  query     int     false  "Only comments by this author"
+// @Success      200      {array}   models.Comment
+// @Header       200      {string}  X-Next-Cursor  "Cursor of the next page, absent on the last page"
+// @Failure      400      {object}  apperrors.Problem
+// @Failure      401      {object}  apperrors.Problem
+// @Failure      404      {object}  apperrors.Problem
+// @Failure      500      {object}  apperrors.Problem
 // @Router       /api/tasks/{id}/comments [get]
 func (h *CommentHandler) GetComments(c *gin.Context) {
 	taskID := c.Param("id")
 
-	// Check if task exists
-	var exists bool
-	err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM tasks WHERE id = $1)", taskID).Scan(&exists)
-	if err != nil || !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+	view := c.DefaultQuery("view", "flat")
+	if view != "flat" && view != "nested" {
+		_ = c.Error(apperrors.Invalid("view", "view must be 'flat' or 'nested'"))
 		return
 	}
 
-	rows, err := h.db.Query(`
-		SELECT c.id, c.task_id, c.user_id, u.name as user_name,
-		       c.content, c.created_at, c.updated_at
-		FROM comments c
-		JOIN users u ON c.user_id = u.id
-		WHERE c.task_id = $1
-		ORDER BY c.created_at ASC
-	`, taskID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+	format, ok := contentFormat(c)
+	if !ok {
 		return
 	}
-	defer rows.Close()
-
-	var comments []models.Comment
-	for rows.Next() {
-		var comment models.Comment
-		err := rows.Scan(
-			&comment.ID, &comment.TaskID, &comment.UserID, &comment.UserName,
-			&comment.Content, &comment.CreatedAt, &comment.UpdatedAt,
-		)
+
+	filter := models.CommentFilter{
+		Limit:  50,
+		Cursor: c.Query("cursor"),
+		Order:  c.DefaultQuery("order", "asc"),
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			_ = c.Error(apperrors.Invalid("limit", "limit must be an integer"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan comment"})
+			_ = c.Error(apperrors.Invalid("since", "since must be an RFC 3339 timestamp"))
 			return
 		}
-		comments = append(comments, comment)
+		filter.Since = &since
 	}
 
-	if comments == nil {
-		comments = []models.Comment{}
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		authorID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			_ = c.Error(apperrors.Invalid("user_id", "user_id must be an integer"))
+			return
+		}
+		filter.UserID = &authorID
 	}
 
-	c.JSON(http.StatusOK, comments)
+	comments, nextCursor, err := h.commentService.GetComments(taskID, filter)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	if err := h.attachMentionsAndReactions(comments, userID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	renderComments(h.renderer, comments, format)
+
+	if nextCursor != "" {
+		c.Header("X-Next-Cursor", nextCursor)
+	}
+
+	threads := services.BuildCommentThreads(comments)
+	if view == "nested" {
+		c.JSON(http.StatusOK, threads)
+		return
+	}
+
+	c.JSON(http.StatusOK, services.FlattenCommentThreads(threads))
 }
 
 // CreateComment godoc
 // @Summary      Create a comment
-// @Description  Add a new comment to a task
+// @Description  Add a new comment to a task. Set parent_id to reply to another comment on the same task; replies can be nested up to 3 levels deep.
+// @Description  @name and @email mentions are resolved to users, returned as spans in mentions, and the mentioned users are notified.
 // @Tags         Comments
 // @Accept       json
 // @Produce      json
@@ -87,54 +149,33 @@ func (h *CommentHandler) GetComments(c *gin.Context) {
 // @Param        id       path      int                          true  "Task ID"
 // @Param        comment  body      models.CreateCommentRequest  true  "Comment data"
 // @Success      201      {object}  models.Comment
-// @Failure      400      {object}  map[string]string
-// @Failure      401      {object}  map[string]string
-// @Failure      404      {object}  map[string]string
-// @Failure      500      {object}  map[string]string
+// @Failure      400      {object}  apperrors.Problem
+// @Failure      401      {object}  apperrors.Problem
+// @Failure      404      {object}  apperrors.Problem
+// @Failure      500      {object}  apperrors.Problem
 // @Router       /api/tasks/{id}/comments [post]
 func (h *CommentHandler) CreateComment(c *gin.Context) {
 	taskID := c.Param("id")
 	userID, _ := middleware.GetUserID(c)
 
-	// Check if task exists
-	var exists bool
-	err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM tasks WHERE id = $1)", taskID).Scan(&exists)
-	if err != nil || !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
-		return
-	}
-
 	var req models.CreateCommentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(apperrors.FromBinding(err))
 		return
 	}
 
-	var comment models.Comment
-	err = h.db.QueryRow(`
-		INSERT INTO comments (task_id, user_id, content)
-		VALUES ($1, $2, $3)
-		RETURNING id, task_id, user_id, content, created_at, updated_at
-	`, taskID, userID, req.Content).Scan(
-		&comment.ID, &comment.TaskID, &comment.UserID,
-		&comment.Content, &comment.CreatedAt, &comment.UpdatedAt,
-	)
-
+	comment, err := h.commentService.CreateComment(taskID, req, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+		_ = c.Error(err)
 		return
 	}
 
-	// Log the comment creation
-	taskIDInt, _ := strconv.Atoi(taskID)
-	h.createChangeLog(taskIDInt, userID, "commented", "Added a comment")
-
 	c.JSON(http.StatusCreated, comment)
 }
 
 // UpdateComment godoc
 // @Summary      Update a comment
-// @Description  Update comment content (only the comment creator can update)
+// @Description  Update comment content (only the comment creator can update). The previous content is kept as a revision.
 // @Tags         Comments
 // @Accept       json
 // @Produce      json
@@ -142,122 +183,106 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
 // @Param        id       path      int                          true  "Comment ID"
 // @Param        comment  body      models.UpdateCommentRequest  true  "Updated comment data"
 // @Success      200      {object}  models.Comment
-// @Failure      400      {object}  map[string]string
-// @Failure      401      {object}  map[string]string
-// @Failure      403      {object}  map[string]string
-// @Failure      404      {object}  map[string]string
-// @Failure      500      {object}  map[string]string
+// @Failure      400      {object}  apperrors.Problem
+// @Failure      401      {object}  apperrors.Problem
+// @Failure      403      {object}  apperrors.Problem
+// @Failure      404      {object}  apperrors.Problem
+// @Failure      500      {object}  apperrors.Problem
 // @Router       /api/comments/{id} [put]
 func (h *CommentHandler) UpdateComment(c *gin.Context) {
 	commentID := c.Param("id")
 	userID, _ := middleware.GetUserID(c)
 
-	// Check if comment exists and user is the owner
-	var comment models.Comment
-	err := h.db.QueryRow(`
-		SELECT id, task_id, user_id, content, created_at, updated_at
-		FROM comments WHERE id = $1
-	`, commentID).Scan(
-		&comment.ID, &comment.TaskID, &comment.UserID,
-		&comment.Content, &comment.CreatedAt, &comment.UpdatedAt,
-	)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
-		return
-	}
-
-	// Check ownership - only the comment creator can update it
-	if comment.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You can only update your own comments"})
-		return
-	}
-
 	var req models.UpdateCommentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(apperrors.FromBinding(err))
 		return
 	}
 
-	err = h.db.QueryRow(`
-		UPDATE comments
-		SET content = $1, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $2
-		RETURNING id, task_id, user_id, content, created_at, updated_at
-	`, req.Content, commentID).Scan(
-		&comment.ID, &comment.TaskID, &comment.UserID,
-		&comment.Content, &comment.CreatedAt, &comment.UpdatedAt,
-	)
-
+	comment, changed, err := h.commentService.UpdateComment(commentID, req, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		_ = c.Error(err)
 		return
 	}
 
-	// Log the comment update
-	h.createChangeLog(comment.TaskID, userID, "updated_comment", "Updated a comment")
+	if !changed {
+		comments := []models.Comment{*comment}
+		if h.mentionService != nil {
+			_ = h.mentionService.AttachCommentMentions(comments)
+		}
+		c.JSON(http.StatusOK, comments[0])
+		return
+	}
 
 	c.JSON(http.StatusOK, comment)
 }
 
 // DeleteComment godoc
 // @Summary      Delete a comment
-// @Description  Delete a comment (only the comment creator can delete)
+// @Description  Delete a comment (only the comment creator can delete).
+// @Description  A comment with replies becomes a "deleted" placeholder so its replies stay in place; placeholders are removed once their last reply is deleted.
 // @Tags         Comments
 // @Accept       json
 // @Produce      json
 // @Security     Bearer
 // @Param        id   path      int  true  "Comment ID"
 // @Success      200  {object}  map[string]string
-// @Failure      401  {object}  map[string]string
-// @Failure      403  {object}  map[string]string
-// @Failure      404  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      403  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
 // @Router       /api/comments/{id} [delete]
 func (h *CommentHandler) DeleteComment(c *gin.Context) {
 	commentID := c.Param("id")
 	userID, _ := middleware.GetUserID(c)
 
-	// Check if comment exists and user is the owner
-	var taskID, ownerID int
-	err := h.db.QueryRow(`
-		SELECT task_id, user_id FROM comments WHERE id = $1
-	`, commentID).Scan(&taskID, &ownerID)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	if _, err := h.commentService.DeleteComment(commentID, userID); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	// Check ownership - only the comment creator can delete it
-	if ownerID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own comments"})
-		return
-	}
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
 
-	// Log before deletion
-	h.createChangeLog(taskID, userID, "deleted_comment", "Deleted a comment")
+// GetCommentRevisions godoc
+// @Summary      Get comment revisions
+// @Description  Retrieve the previous versions of a comment, oldest first. The current content is on the comment itself.
+// @Tags         Comments
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Comment ID"
+// @Success      200  {array}   models.CommentRevision
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/comments/{id}/revisions [get]
+func (h *CommentHandler) GetCommentRevisions(c *gin.Context) {
+	commentID := c.Param("id")
 
-	_, err = h.db.Exec("DELETE FROM comments WHERE id = $1", commentID)
+	revisions, err := h.commentService.GetRevisions(commentID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+	c.JSON(http.StatusOK, revisions)
 }
 
-func (h *CommentHandler) createChangeLog(taskID, userID int, action, details string) {
-	_, _ = h.db.Exec(
-		"INSERT INTO change_logs (task_id, user_id, action, details) VALUES ($1, $2, $3, $4)",
-		taskID, userID, action, details,
-	)
+// attachMentionsAndReactions adds mention spans and reaction counts to comments when
+// the handler has the services for them
+func (h *CommentHandler) attachMentionsAndReactions(comments []models.Comment, userID int) error {
+	if h.mentionService != nil {
+		if err := h.mentionService.AttachCommentMentions(comments); err != nil {
+			return err
+		}
+	}
+
+	if h.reactionService != nil {
+		if err := h.reactionService.AttachCommentReactions(comments, userID); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/middleware"
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unsubscribePage asks to confirm an unsubscribe link, so link scanners that
+// follow it do not unsubscribe the user; the form posts back to the same URL
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body style="font-family:-apple-system,'Segoe UI',Helvetica,Arial,sans-serif;max-width:480px;margin:48px auto;color:#172b4d;">
+{{if .Done}}<p>You will no longer get {{.Kind}} emails. You can turn them back on in your notification settings.</p>
+{{else}}<p>Stop getting {{.Kind}} emails?</p>
+<form method="post"><button type="submit">Unsubscribe</button></form>
+{{end}}</body>
+</html>
+`))
+
+// emailKindNames names the kinds of email on the unsubscribe page
+var emailKindNames = map[models.EmailKind]string{
+	models.EmailAssigned: "assignment",
+	models.EmailMention:  "mention",
+	models.EmailDueSoon:  "due date",
+	models.EmailDigest:   "daily digest",
+}
+
+type EmailHandler struct {
+	emailService *services.EmailService
+}
+
+func NewEmailHandler(emailService *services.EmailService) *EmailHandler {
+	return &EmailHandler{emailService: emailService}
+}
+
+// GetPreferences godoc
+// @Summary      Get email preferences
+// @Description  Tell for each kind of email (assigned, mention, due_soon, digest) whether the current user gets it
+// @Tags         Notifications
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {object}  map[string]bool
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/notifications/email-preferences [get]
+func (h *EmailHandler) GetPreferences(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	preferences, err := h.emailService.GetPreferences(userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// UpdatePreferences godoc
+// @Summary      Update email preferences
+// @Description  Turn kinds of email on or off. Kinds left out keep their setting.
+// @Tags         Notifications
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        preferences  body      map[string]bool  true  "Kinds of email to turn on (true) or off (false)"
+// @Success      200          {object}  map[string]bool
+// @Failure      400          {object}  apperrors.Problem
+// @Failure      401          {object}  apperrors.Problem
+// @Failure      500          {object}  apperrors.Problem
+// @Router       /api/notifications/email-preferences [put]
+func (h *EmailHandler) UpdatePreferences(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req models.EmailPreferences
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.FromBinding(err))
+		return
+	}
+
+	preferences, err := h.emailService.UpdatePreferences(userID, req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// ConfirmUnsubscribe godoc
+// @Summary      Confirm unsubscribing
+// @Description  Page behind the unsubscribe link of an email, asking to confirm
+// @Tags         Notifications
+// @Produce      html
+// @Param        token  query     string  true  "Signed unsubscribe token from the email"
+// @Success      200    {string}  string  "HTML page"
+// @Failure      403    {object}  apperrors.Problem
+// @Router       /email/unsubscribe [get]
+func (h *EmailHandler) ConfirmUnsubscribe(c *gin.Context) {
+	_, kind, err := h.emailService.VerifyUnsubscribeToken(c.Query("token"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	h.renderUnsubscribePage(c, kind, false)
+}
+
+// Unsubscribe godoc
+// @Summary      Unsubscribe
+// @Description  Turn off the kind of email an unsubscribe token is for. Mail clients post here for one-click unsubscribe (RFC 8058).
+// @Tags         Notifications
+// @Produce      html
+// @Param        token  query     string  true  "Signed unsubscribe token from the email"
+// @Success      200    {string}  string  "HTML page"
+// @Failure      403    {object}  apperrors.Problem
+// @Router       /email/unsubscribe [post]
+func (h *EmailHandler) Unsubscribe(c *gin.Context) {
+	kind, err := h.emailService.Unsubscribe(c.Query("token"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	h.renderUnsubscribePage(c, kind, true)
+}
+
+func (h *EmailHandler) renderUnsubscribePage(c *gin.Context, kind models.EmailKind, done bool) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+	_ = unsubscribePage.Execute(c.Writer, gin.H{"Kind": emailKindNames[kind], "Done": done})
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEmailUnsubscribe(t *testing.T) {
+	router, _, _ := newTestRouter()
+	register(t, router, "alice@example.com")
+	bob := register(t, router, "bob@example.com")
+
+	token := services.NewEmailService(nil, nil, nil, nil, testEmailSettings).UnsubscribeToken(2, models.EmailMention)
+
+	tests := []struct {
+		name       string
+		method     string
+		token      string
+		wantStatus int
+		wantBody   string
+		wantOn     bool
+	}{
+		{"Tampered token", http.MethodGet, strings.Replace(token, "2.", "1.", 1), http.StatusForbidden, "invalid_unsubscribe_token", true},
+		{"Missing token", http.MethodPost, "", http.StatusForbidden, "invalid_unsubscribe_token", true},
+		{"Confirmation page", http.MethodGet, token, http.StatusOK, "<form method=\"post\">", true},
+		{"Unsubscribe", http.MethodPost, token, http.StatusOK, "no longer get mention emails", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, "/email/unsubscribe?token="+tt.token, nil))
+			if w.Code != tt.wantStatus || !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Fatalf("%s: status = %d, body = %s, want %d containing %q", tt.method, w.Code, w.Body.String(), tt.wantStatus, tt.wantBody)
+			}
+
+			var preferences models.EmailPreferences
+			serve(t, router, http.MethodGet, "/api/notifications/email-preferences", bob, nil, &preferences)
+			if preferences[models.EmailMention] != tt.wantOn || !preferences[models.EmailAssigned] {
+				t.Errorf("preferences = %v, want mention on = %v", preferences, tt.wantOn)
+			}
+		})
+	}
+}
+
+func TestEmailPreferences(t *testing.T) {
+	router, _, _ := newTestRouter()
+	alice := register(t, router, "alice@example.com")
+
+	if w := serve(t, router, http.MethodPut, "/api/notifications/email-preferences", alice, map[string]bool{"spam": false}, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown kind: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	var preferences models.EmailPreferences
+	serve(t, router, http.MethodPut, "/api/notifications/email-preferences", alice, map[string]bool{"digest": false}, &preferences)
+	want := models.EmailPreferences{models.EmailAssigned: true, models.EmailMention: true, models.EmailDueSoon: true, models.EmailDigest: false}
+	if len(preferences) != len(want) {
+		t.Fatalf("preferences = %v, want %v", preferences, want)
+	}
+	for kind, enabled := range want {
+		if preferences[kind] != enabled {
+			t.Errorf("preferences[%s] = %v, want %v", kind, preferences[kind], enabled)
+		}
+	}
+}
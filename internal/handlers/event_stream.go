@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/middleware"
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EventStreamHandler struct {
+	hub       *services.EventHub
+	heartbeat time.Duration
+}
+
+func NewEventStreamHandler(hub *services.EventHub, heartbeat time.Duration) *EventStreamHandler {
+	return &EventStreamHandler{hub: hub, heartbeat: heartbeat}
+}
+
+// StreamEvents godoc
+// @Summary      Stream task and comment events
+// @Description  Server-Sent Events stream of task and comment changes. Each event has the domain event ID as id, its type as event, and the domain event as JSON data.
+// @Description  Send the Last-Event-ID header to resume after an event. When that event is no longer in the replay buffer the stream starts with a reset event, and the client should reload what it shows.
+// @Description  A comment line is sent every heartbeat interval to keep the connection open.
+// @Description  Comment events are only sent to access tokens with the comments scope.
+// @Tags         Events
+// @Produce      text/event-stream
+// @Security     Bearer
+// @Param        task_id        query     int     false  "Only send events of this task"
+// @Param        Last-Event-ID  header    string  false  "ID of the last event the client received"
+// @Success      200            {object}  models.DomainEvent
+// @Failure      400            {object}  apperrors.Problem
+// @Failure      401            {object}  apperrors.Problem
+// @Router       /api/events/stream [get]
+func (h *EventStreamHandler) StreamEvents(c *gin.Context) {
+	var taskID *int
+	if value := c.Query("task_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			_ = c.Error(apperrors.Invalid("task_id", "task_id must be a number"))
+			return
+		}
+		taskID = &id
+	}
+
+	var lastEventID *int64
+	if value := c.GetHeader("Last-Event-ID"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			_ = c.Error(apperrors.Invalid("Last-Event-ID", "Last-Event-ID must be an event ID"))
+			return
+		}
+		lastEventID = &id
+	}
+
+	// Every signed-in user can read every task and comment, but access tokens need the
+	// comments scope for comment events
+	readComments := middleware.HasScope(c, models.ScopeComments)
+	visible := func(event models.DomainEvent) bool {
+		if event.Type.IsComment() && !readComments {
+			return false
+		}
+		return taskID == nil || event.TaskID == *taskID
+	}
+
+	sub, missed, resumed := h.hub.Subscribe(lastEventID)
+	defer h.hub.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	if !resumed {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range missed {
+		if visible(event) {
+			writeEvent(w, event)
+		}
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.Events:
+			// The hub drops streams that fall behind; the client resumes from the buffer
+			if !ok {
+				return
+			}
+			if !visible(event) {
+				continue
+			}
+			writeEvent(w, event)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		w.Flush()
+	}
+}
+
+// writeEvent writes an event in the Server-Sent Events format
+func writeEvent(w io.Writer, event models.DomainEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bufio"
+	"candidate-backend/internal/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openStream connects to the event stream and returns a reader of its lines
+func openStream(t *testing.T, ctx context.Context, url, token, lastEventID string) *bufio.Scanner {
+	t.Helper()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url+"/api/events/stream", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /api/events/stream error = %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("GET /api/events/stream: status = %d, content type = %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return bufio.NewScanner(resp.Body)
+}
+
+// nextEvent returns the id and event fields of the next event on the stream
+func nextEvent(t *testing.T, lines *bufio.Scanner) (id, event string) {
+	t.Helper()
+	for lines.Scan() {
+		line := lines.Text()
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case line == "" && event != "":
+			return id, event
+		}
+	}
+	t.Fatalf("stream ended: %v", lines.Err())
+	return "", ""
+}
+
+func TestEventStream(t *testing.T) {
+	router, dispatcher, _ := newTestRouter()
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alice := register(t, router, "alice@example.com")
+	stream := openStream(t, ctx, server.URL, alice, "")
+
+	var task models.Task
+	serve(t, router, http.MethodPost, "/api/tasks", alice, models.CreateTaskRequest{Title: "Live"}, &task)
+	_, _ = dispatcher.DispatchPending(ctx)
+
+	createdID, event := nextEvent(t, stream)
+	if event != string(models.EventTaskCreated) {
+		t.Fatalf("first event = %q, want task.created", event)
+	}
+
+	serve(t, router, http.MethodPost, "/api/tasks/"+strconv.Itoa(task.ID)+"/comments", alice, models.CreateCommentRequest{Content: "Hi"}, nil)
+	_, _ = dispatcher.DispatchPending(ctx)
+	if _, event := nextEvent(t, stream); event != string(models.EventCommentCreated) {
+		t.Errorf("second event = %q, want comment.created", event)
+	}
+
+	tests := []struct {
+		name        string
+		lastEventID string
+		wantEvent   string
+	}{
+		{"Resume replays the missed events", createdID, string(models.EventCommentCreated)},
+		{"Resume from an unknown event resets", "999", "reset"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resumed := openStream(t, ctx, server.URL, alice, tt.lastEventID)
+			if _, event := nextEvent(t, resumed); event != tt.wantEvent {
+				t.Errorf("first event = %q, want %q", event, tt.wantEvent)
+			}
+		})
+	}
+
+	// Access tokens without the comments scope only get task events
+	var reader models.AccessToken
+	serve(t, router, http.MethodPost, "/api/access-tokens", alice, models.CreateAccessTokenRequest{Name: "Board", Scopes: []models.TokenScope{models.ScopeTasksRead}}, &reader)
+	tasksOnly := openStream(t, ctx, server.URL, reader.Token, "")
+	serve(t, router, http.MethodPost, "/api/tasks/"+strconv.Itoa(task.ID)+"/comments", alice, models.CreateCommentRequest{Content: "Hidden"}, nil)
+	serve(t, router, http.MethodPut, "/api/tasks/"+strconv.Itoa(task.ID), alice, map[string]string{"title": "Renamed"}, nil)
+	_, _ = dispatcher.DispatchPending(ctx)
+	if _, event := nextEvent(t, tasksOnly); event != string(models.EventTaskUpdated) {
+		t.Errorf("event for a tasks:read token = %q, want task.updated", event)
+	}
+
+	if w := serve(t, router, http.MethodGet, "/api/events/stream?task_id=abc", alice, nil, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("bad task_id: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/markdown"
+	"candidate-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// contentFormat reads the format query parameter (raw, html or both). It records a
+// validation error and returns false when the value is invalid.
+func contentFormat(c *gin.Context) (markdown.Format, bool) {
+	format, err := markdown.ParseFormat(c.Query("format"))
+	if err != nil {
+		_ = c.Error(apperrors.Invalid("format", err.Error()))
+		return "", false
+	}
+
+	return format, true
+}
+
+// renderTask fills in description_html and drops the raw description as format requires
+func renderTask(renderer *markdown.Renderer, task *models.Task, format markdown.Format) {
+	if format.IncludesHTML() {
+		task.DescriptionHTML = renderer.Render(task.Description)
+	}
+	if !format.IncludesRaw() {
+		task.Description = ""
+	}
+}
+
+func renderTasks(renderer *markdown.Renderer, tasks []models.Task, format markdown.Format) {
+	for i := range tasks {
+		renderTask(renderer, &tasks[i], format)
+	}
+}
+
+// renderComments fills in content_html and drops the raw content as format requires.
+// Replies are not visited, so render before building threads.
+func renderComments(renderer *markdown.Renderer, comments []models.Comment, format markdown.Format) {
+	for i := range comments {
+		comment := &comments[i]
+		if format.IncludesHTML() {
+			comment.ContentHTML = renderer.Render(comment.Content)
+		}
+		if !format.IncludesRaw() {
+			comment.Content = ""
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/middleware"
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	mfaService *services.MFAService
+}
+
+func NewMFAHandler(mfaService *services.MFAService) *MFAHandler {
+	return &MFAHandler{mfaService: mfaService}
+}
+
+// GetStatus godoc
+// @Summary      Get MFA status
+// @Description  Tell whether the current user has two-factor authentication on and how many recovery codes are left
+// @Tags         MFA
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {object}  models.MFAStatus
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/mfa [get]
+func (h *MFAHandler) GetStatus(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	status, err := h.mfaService.Status(userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Enroll godoc
+// @Summary      Start setting up MFA
+// @Description  Generate a TOTP secret to add to an authenticator app, by hand or as a QR code of the otpauth URI. MFA stays off until confirmed with a code; enrolling again replaces the secret.
+// @Tags         MFA
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {object}  models.MFAEnrollment
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      409  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/mfa/enroll [post]
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	enrollment, err := h.mfaService.Enroll(userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// Confirm godoc
+// @Summary      Turn MFA on
+// @Description  Confirm the enrolled secret with a code from the authenticator app. Returns the recovery codes, which are shown only this once.
+// @Tags         MFA
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        request  body      models.MFACodeRequest  true  "Code from the authenticator app"
+// @Success      200      {object}  models.MFARecoveryCodes
+// @Failure      400      {object}  apperrors.Problem
+// @Failure      401      {object}  apperrors.Problem
+// @Failure      404      {object}  apperrors.Problem
+// @Failure      409      {object}  apperrors.Problem
+// @Failure      500      {object}  apperrors.Problem
+// @Router       /api/mfa/confirm [post]
+func (h *MFAHandler) Confirm(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.FromBinding(err))
+		return
+	}
+
+	codes, err := h.mfaService.Confirm(userID, req.Code)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MFARecoveryCodes{RecoveryCodes: codes})
+}
+
+// Disable godoc
+// @Summary      Turn MFA off
+// @Description  Turn two-factor authentication off with a code from the authenticator app or a recovery code
+// @Tags         MFA
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        request  body      models.MFACodeRequest  true  "Code from the authenticator app or a recovery code"
+// @Success      200      {object}  map[string]string
+// @Failure      400      {object}  apperrors.Problem
+// @Failure      401      {object}  apperrors.Problem
+// @Failure      404      {object}  apperrors.Problem
+// @Failure      429      {object}  apperrors.Problem
+// @Failure      500      {object}  apperrors.Problem
+// @Router       /api/mfa [delete]
+func (h *MFAHandler) Disable(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.FromBinding(err))
+		return
+	}
+
+	if err := h.mfaService.Disable(userID, req.Code); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "MFA turned off"})
+}
+
+// Reset godoc
+// @Summary      Reset a user's MFA
+// @Description  Turn two-factor authentication off for a user who lost their authenticator app and recovery codes. Admins only.
+// @Tags         Admin
+// @Produce      json
+// @Security     Bearer
+// @Param        id  path      int  true  "User ID"
+// @Success      200  {object}  map[string]string
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      403  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/admin/users/{id}/mfa [delete]
+func (h *MFAHandler) Reset(c *gin.Context) {
+	if err := h.mfaService.Reset(c.Param("id")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "MFA reset"})
+}
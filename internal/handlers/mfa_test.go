@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMFALogin(t *testing.T) {
+	router, _, _ := newTestRouter()
+	alice := register(t, router, "alice@example.com")
+	bob := register(t, router, "bob@example.com")
+	credentials := models.LoginRequest{Email: "alice@example.com", Password: "secret123"}
+
+	var enrollment models.MFAEnrollment
+	serve(t, router, http.MethodPost, "/api/mfa/enroll", alice, nil, &enrollment)
+	code, err := services.TOTPCode(enrollment.Secret, time.Now())
+	if err != nil {
+		t.Fatalf("enroll = %+v: %v", enrollment, err)
+	}
+	var recovery models.MFARecoveryCodes
+	if w := serve(t, router, http.MethodPost, "/api/mfa/confirm", alice, models.MFACodeRequest{Code: code}, &recovery); w.Code != http.StatusOK {
+		t.Fatalf("confirm: status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	var challenge models.MFAChallenge
+	if w := serve(t, router, http.MethodPost, "/auth/login", "", credentials, &challenge); w.Code != http.StatusAccepted || !challenge.MFARequired {
+		t.Fatalf("login with MFA: status = %d, body = %s, want a challenge", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		session    string
+		body       interface{}
+		setup      func()
+		wantStatus int
+		wantCode   string
+	}{
+		{"Challenge is no session", http.MethodGet, "/api/tasks", challenge.MFAToken, nil, nil, http.StatusUnauthorized, "invalid_token"},
+		{"Session is no challenge", http.MethodPost, "/auth/mfa/verify", "", models.MFAVerifyRequest{MFAToken: alice, Code: recovery.RecoveryCodes[0]}, nil, http.StatusUnauthorized, "invalid_mfa_token"},
+		{"Wrong code", http.MethodPost, "/auth/mfa/verify", "", models.MFAVerifyRequest{MFAToken: challenge.MFAToken, Code: "000000"}, nil, http.StatusBadRequest, "invalid_mfa_code"},
+		{"Recovery code", http.MethodPost, "/auth/mfa/verify", "", models.MFAVerifyRequest{MFAToken: challenge.MFAToken, Code: recovery.RecoveryCodes[0]}, nil, http.StatusOK, "token"},
+		{"Status", http.MethodGet, "/api/mfa", alice, nil, nil, http.StatusOK, `"recovery_codes_left":9`},
+		{"Enroll again", http.MethodPost, "/api/mfa/enroll", alice, nil, nil, http.StatusConflict, "mfa_already_enabled"},
+		{"Reset by a user", http.MethodDelete, "/api/admin/users/1/mfa", bob, nil, nil, http.StatusForbidden, "admin_required"},
+		{"Reset by an admin", http.MethodDelete, "/api/admin/users/1/mfa", bob, nil, func() { testStore.SetAdmin(2, true) }, http.StatusOK, ""},
+		{"Reset of an unknown user", http.MethodDelete, "/api/admin/users/99/mfa", bob, nil, nil, http.StatusNotFound, "user_not_found"},
+		{"Login after the reset", http.MethodPost, "/auth/login", "", credentials, nil, http.StatusOK, "token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup()
+			}
+			w := serve(t, router, tt.method, tt.path, tt.session, tt.body, nil)
+			if w.Code != tt.wantStatus || !strings.Contains(w.Body.String(), tt.wantCode) {
+				t.Fatalf("%s %s: status = %d, body = %s, want %d %s", tt.method, tt.path, w.Code, w.Body.String(), tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
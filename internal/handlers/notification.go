@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/middleware"
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
+	"candidate-backend/internal/validators"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+	validator           *validators.TaskValidator
+}
+
+func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		validator:           validators.NewTaskValidator(),
+	}
+}
+
+// GetNotifications godoc
+// @Summary      Get notifications
+// @Description  Retrieve the current user's notifications, newest first
+// @Tags         Notifications
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        unread  query     bool  false  "Only unread notifications"
+// @Param        limit   query     int   false  "Limit number of results (default: 20)"
+// @Param        offset  query     int   false  "Offset for pagination (default: 0)"
+// @Success      200     {array}   models.Notification
+// @Failure      400     {object}  apperrors.Problem
+// @Failure      401     {object}  apperrors.Problem
+// @Failure      500     {object}  apperrors.Problem
+// @Router       /api/notifications [get]
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil {
+		_ = c.Error(apperrors.Invalid("limit", "limit must be a number"))
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		_ = c.Error(apperrors.Invalid("offset", "offset must be a number"))
+		return
+	}
+	if err := h.validator.ValidatePagination(limit, offset); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	notifications, err := h.notificationService.GetNotifications(userID, models.NotificationFilter{
+		UnreadOnly: c.Query("unread") == "true",
+		Limit:      limit,
+		Offset:     offset,
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, notifications)
+}
+
+// MarkRead godoc
+// @Summary      Mark a notification read
+// @Description  Mark one of the current user's notifications as read
+// @Tags         Notifications
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Notification ID"
+// @Success      200  {object}  map[string]string
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/notifications/{id}/read [post]
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	notificationID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	if err := h.notificationService.MarkRead(notificationID, userID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
+
+// MarkAllRead godoc
+// @Summary      Mark all notifications read
+// @Description  Mark all of the current user's notifications as read and return how many were unread
+// @Tags         Notifications
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {object}  map[string]int
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/notifications/read-all [post]
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	marked, err := h.notificationService.MarkAllRead(userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"marked_read": marked})
+}
+
+// GetPreferences godoc
+// @Summary      Get notification preferences
+// @Description  Tell for each notification type whether the current user gets it
+// @Tags         Notifications
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {object}  map[string]bool
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/notifications/preferences [get]
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	preferences, err := h.notificationService.GetPreferences(userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// UpdatePreferences godoc
+// @Summary      Update notification preferences
+// @Description  Turn notification types on or off. Types left out keep their setting.
+// @Tags         Notifications
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        preferences  body      map[string]bool  true  "Notification types to turn on (true) or off (false)"
+// @Success      200          {object}  map[string]bool
+// @Failure      400          {object}  apperrors.Problem
+// @Failure      401          {object}  apperrors.Problem
+// @Failure      500          {object}  apperrors.Problem
+// @Router       /api/notifications/preferences [put]
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req models.NotificationPreferences
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.FromBinding(err))
+		return
+	}
+
+	preferences, err := h.notificationService.UpdatePreferences(userID, req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
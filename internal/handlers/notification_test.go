@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"candidate-backend/internal/models"
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestNotificationInbox(t *testing.T) {
+	router, dispatcher, _ := newTestRouter()
+	alice := register(t, router, "alice@example.com")
+	bob := register(t, router, "bob@example.com")
+
+	bobID := 2
+	var task models.Task
+	serve(t, router, http.MethodPost, "/api/tasks", alice, map[string]interface{}{"title": "Review", "assignee_id": bobID}, &task)
+	serve(t, router, http.MethodPut, "/api/tasks/"+strconv.Itoa(task.ID), alice, map[string]string{"status": "Done"}, nil)
+	_, _ = dispatcher.DispatchPending(context.Background())
+
+	var inbox []models.Notification
+	serve(t, router, http.MethodGet, "/api/notifications?unread=true", bob, nil, &inbox)
+	if len(inbox) != 2 || inbox[0].Type != models.NotificationStatus || inbox[1].Type != models.NotificationAssigned {
+		t.Fatalf("unread notifications = %+v, want status change and assignment", inbox)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		body       interface{}
+		wantStatus int
+		wantUnread int
+	}{
+		{"Someone else's notification", http.MethodPost, "/api/notifications/" + strconv.Itoa(inbox[0].ID) + "/read", alice, nil, http.StatusNotFound, 2},
+		{"Mark one read", http.MethodPost, "/api/notifications/" + strconv.Itoa(inbox[0].ID) + "/read", bob, nil, http.StatusOK, 1},
+		{"Mark all read", http.MethodPost, "/api/notifications/read-all", bob, nil, http.StatusOK, 0},
+		{"Unknown preference", http.MethodPut, "/api/notifications/preferences", bob, map[string]bool{"gossip": false}, http.StatusBadRequest, 0},
+		{"Turn a type off", http.MethodPut, "/api/notifications/preferences", bob, map[string]bool{"status_changed": false}, http.StatusOK, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(t, router, tt.method, tt.path, tt.token, tt.body, nil); w.Code != tt.wantStatus {
+				t.Fatalf("%s %s: status = %d, want %d, body = %s", tt.method, tt.path, w.Code, tt.wantStatus, w.Body.String())
+			}
+
+			var unread []models.Notification
+			serve(t, router, http.MethodGet, "/api/notifications?unread=true", bob, nil, &unread)
+			if len(unread) != tt.wantUnread {
+				t.Errorf("unread notifications = %d, want %d", len(unread), tt.wantUnread)
+			}
+		})
+	}
+
+	var preferences models.NotificationPreferences
+	serve(t, router, http.MethodGet, "/api/notifications/preferences", bob, nil, &preferences)
+	if preferences[models.NotificationStatus] || !preferences[models.NotificationAssigned] {
+		t.Errorf("preferences = %v, want only status_changed off", preferences)
+	}
+}
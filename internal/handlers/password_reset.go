@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordResetHandler struct {
+	resetService *services.PasswordResetService
+}
+
+func NewPasswordResetHandler(resetService *services.PasswordResetService) *PasswordResetHandler {
+	return &PasswordResetHandler{resetService: resetService}
+}
+
+// ForgotPassword godoc
+// @Summary      Request a password reset
+// @Description  Email a single-use password reset link to the account with the email. The response is the same whether or not the email is registered.
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ForgotPasswordRequest  true  "Email of the account"
+// @Success      202      {object}  map[string]string
+// @Failure      400      {object}  apperrors.Problem
+// @Failure      429      {object}  apperrors.Problem
+// @Failure      500      {object}  apperrors.Problem
+// @Router       /auth/forgot-password [post]
+func (h *PasswordResetHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.FromBinding(err))
+		return
+	}
+
+	if err := h.resetService.RequestPasswordReset(req.Email, c.ClientIP()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If an account exists for this email, a password reset link has been sent to it"})
+}
+
+// ResetPassword godoc
+// @Summary      Reset a password
+// @Description  Set a new password with the token from a reset link. Every existing session of the user is signed out.
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ResetPasswordRequest  true  "Reset token and new password"
+// @Success      200      {object}  map[string]string
+// @Failure      400      {object}  apperrors.Problem
+// @Failure      500      {object}  apperrors.Problem
+// @Router       /auth/reset-password [post]
+func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.FromBinding(err))
+		return
+	}
+
+	if err := h.resetService.ResetPassword(req.Token, req.Password); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset"})
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"candidate-backend/internal/models"
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+var resetLink = regexp.MustCompile(`reset-password\?token=([A-Za-z0-9_-]+)`)
+
+func TestPasswordReset(t *testing.T) {
+	router, _, _ := newTestRouter()
+	session := register(t, router, "alice@example.com")
+
+	forgot := func(email string) (int, string) {
+		w := serve(t, router, http.MethodPost, "/auth/forgot-password", "", models.ForgotPasswordRequest{Email: email}, nil)
+		return w.Code, w.Body.String()
+	}
+
+	// Unknown and registered emails get the same answer
+	unknownStatus, unknownBody := forgot("nobody@example.com")
+	status, body := forgot("alice@example.com")
+	if status != http.StatusAccepted || status != unknownStatus || body != unknownBody {
+		t.Fatalf("forgot-password = %d %s for alice and %d %s for an unknown email, want the same 202", status, body, unknownStatus, unknownBody)
+	}
+	token := mailedToken(t, resetLink)
+
+	tests := []struct {
+		name       string
+		token      string
+		password   string
+		wantStatus int
+	}{
+		{"Unknown token", "not-a-token", "new-secret", http.StatusBadRequest},
+		{"Short password", token, "short", http.StatusBadRequest},
+		{"Reset", token, "new-secret", http.StatusOK},
+		{"Token used twice", token, "other-secret", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, router, http.MethodPost, "/auth/reset-password", "", models.ResetPasswordRequest{Token: tt.token, Password: tt.password}, nil)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+
+	if w := serve(t, router, http.MethodGet, "/api/tasks", session, nil, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("session from before the reset: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	var login models.LoginResponse
+	serve(t, router, http.MethodPost, "/auth/login", "", models.LoginRequest{Email: "alice@example.com", Password: "new-secret"}, &login)
+	if w := serve(t, router, http.MethodGet, "/api/tasks", login.Token, nil, nil); w.Code != http.StatusOK {
+		t.Errorf("session after the reset: status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// Two requests per email per hour, whatever the case of the email
+	if status, _ := forgot("Alice@example.com"); status != http.StatusAccepted {
+		t.Errorf("second request for alice: status = %d, want %d", status, http.StatusAccepted)
+	}
+	if status, _ := forgot("alice@example.com"); status != http.StatusTooManyRequests {
+		t.Errorf("third request for alice: status = %d, want %d", status, http.StatusTooManyRequests)
+	}
+}
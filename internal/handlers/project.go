@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/middleware"
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProjectHandler struct {
+	projectService *services.ProjectService
+}
+
+func NewProjectHandler(db *sql.DB) *ProjectHandler {
+	return &ProjectHandler{
+		projectService: services.NewProjectService(db),
+	}
+}
+
+// GetProjects godoc
+// @Summary      Get all projects
+// @Description  Retrieve all projects
+// @Tags         Projects
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {array}   models.Project
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/projects [get]
+func (h *ProjectHandler) GetProjects(c *gin.Context) {
+	projects, err := h.projectService.GetProjects()
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, projects)
+}
+
+// GetProject godoc
+// @Summary      Get project by ID
+// @Description  Retrieve a project with its custom field definitions
+// @Tags         Projects
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Project ID"
+// @Success      200  {object}  models.Project
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/projects/{id} [get]
+func (h *ProjectHandler) GetProject(c *gin.Context) {
+	projectID := c.Param("id")
+
+	project, err := h.projectService.GetProject(projectID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, project)
+}
+
+// CreateProject godoc
+// @Summary      Create a project
+// @Description  Create a new project
+// @Tags         Projects
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        project  body      models.CreateProjectRequest  true  "Project data"
+// @Success      201      {object}  models.Project
+// @Failure      400      {object}  apperrors.Problem
+// @Failure      401      {object}  apperrors.Problem
+// @Failure      500      {object}  apperrors.Problem
+// @Router       /api/projects [post]
+func (h *ProjectHandler) CreateProject(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req models.CreateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.FromBinding(err))
+		return
+	}
+
+	project, err := h.projectService.CreateProject(req, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, project)
+}
+
+// UpdateProject godoc
+// @Summary      Update a project
+// @Description  Update project information (only the creator can update)
+// @Tags         Projects
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id       path      int                          true  "Project ID"
+// @Param        project  body      models.UpdateProjectRequest  true  "Updated project data"
+// @Success      200      {object}  models.Project
+// @Failure      400      {object}  apperrors.Problem
+// @Failure      401      {object}  apperrors.Problem
+// @Failure      403      {object}  apperrors.Problem
+// @Failure      404      {object}  apperrors.Problem
+// @Failure      500      {object}  apperrors.Problem
+// @Router       /api/projects/{id} [put]
+func (h *ProjectHandler) UpdateProject(c *gin.Context) {
+	projectID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	var req models.UpdateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.FromBinding(err))
+		return
+	}
+
+	project, err := h.projectService.UpdateProject(projectID, req, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, project)
+}
+
+// DeleteProject godoc
+// @Summary      Delete a project
+// @Description  Delete a project; its tasks are kept without a project (only the creator can delete)
+// @Tags         Projects
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Project ID"
+// @Success      200  {object}  map[string]string
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      403  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/projects/{id} [delete]
+func (h *ProjectHandler) DeleteProject(c *gin.Context) {
+	projectID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	if err := h.projectService.DeleteProject(projectID, userID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Project deleted successfully"})
+}
+
+// GetFields godoc
+// @Summary      Get project custom fields
+// @Description  Retrieve the custom field definitions of a project
+// @Tags         Projects
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Project ID"
+// @Success      200  {array}   models.CustomField
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/projects/{id}/fields [get]
+func (h *ProjectHandler) GetFields(c *gin.Context) {
+	projectID := c.Param("id")
+
+	fields, err := h.projectService.GetFields(projectID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, fields)
+}
+
+// CreateField godoc
+// @Summary      Create a custom field
+// @Description  Add a custom field definition to a project (only the project creator can add fields).
+// @Description  Types: text, number, date, single_select, multi_select, user. Select types need options.
+// @Tags         Projects
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id     path      int                              true  "Project ID"
+// @Param        field  body      models.CreateCustomFieldRequest  true  "Field definition"
+// @Success      201    {object}  models.CustomField
+// @Failure      400    {object}  apperrors.Problem
+// @Failure      401    {object}  apperrors.Problem
+// @Failure      403    {object}  apperrors.Problem
+// @Failure      404    {object}  apperrors.Problem
+// @Failure      409    {object}  apperrors.Problem
+// @Failure      500    {object}  apperrors.Problem
+// @Router       /api/projects/{id}/fields [post]
+func (h *ProjectHandler) CreateField(c *gin.Context) {
+	projectID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	var req models.CreateCustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.FromBinding(err))
+		return
+	}
+
+	field, err := h.projectService.CreateField(projectID, req, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, field)
+}
+
+// UpdateField godoc
+// @Summary      Update a custom field
+// @Description  Rename a custom field or change its select options (only the project creator can update)
+// @Tags         Projects
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id       path      int                              true  "Project ID"
+// @Param        fieldId  path      int                              true  "Field ID"
+// @Param        field    body      models.UpdateCustomFieldRequest  true  "Updated field data"
+// @Success      200      {object}  models.CustomField
+// @Failure      400      {object}  apperrors.Problem
+// @Failure      401      {object}  apperrors.Problem
+// @Failure      403      {object}  apperrors.Problem
+// @Failure      404      {object}  apperrors.Problem
+// @Failure      500      {object}  apperrors.Problem
+// @Router       /api/projects/{id}/fields/{fieldId} [put]
+func (h *ProjectHandler) UpdateField(c *gin.Context) {
+	projectID := c.Param("id")
+	fieldID := c.Param("fieldId")
+	userID, _ := middleware.GetUserID(c)
+
+	var req models.UpdateCustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.FromBinding(err))
+		return
+	}
+
+	field, err := h.projectService.UpdateField(projectID, fieldID, req, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, field)
+}
+
+// DeleteField godoc
+// @Summary      Delete a custom field
+// @Description  Remove a custom field and all its values (only the project creator can delete)
+// @Tags         Projects
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id       path      int  true  "Project ID"
+// @Param        fieldId  path      int  true  "Field ID"
+// @Success      200      {object}  map[string]string
+// @Failure      401      {object}  apperrors.Problem
+// @Failure      403      {object}  apperrors.Problem
+// @Failure      404      {object}  apperrors.Problem
+// @Failure      500      {object}  apperrors.Problem
+// @Router       /api/projects/{id}/fields/{fieldId} [delete]
+func (h *ProjectHandler) DeleteField(c *gin.Context) {
+	projectID := c.Param("id")
+	fieldID := c.Param("fieldId")
+	userID, _ := middleware.GetUserID(c)
+
+	if err := h.projectService.DeleteField(projectID, fieldID, userID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Field deleted successfully"})
+}
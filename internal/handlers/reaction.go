@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"candidate-backend/internal/middleware"
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
+	"candidate-backend/internal/validators"
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReactionHandler struct {
+	reactionService *services.ReactionService
+	validator       *validators.ReactionValidator
+}
+
+func NewReactionHandler(db *sql.DB, emojis []string) *ReactionHandler {
+	return &ReactionHandler{
+		reactionService: services.NewReactionService(services.NewPostgresReactionRepository(db)),
+		validator:       validators.NewReactionValidator(emojis),
+	}
+}
+
+type reactionFunc func(id string, emoji string, userID int) ([]models.ReactionSummary, error)
+
+// GetEmojis godoc
+// @Summary      Get reaction emojis
+// @Description  List the emojis users can react with
+// @Tags         Reactions
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {array}   string
+// @Failure      401  {object}  apperrors.Problem
+// @Router       /api/reactions/emojis [get]
+func (h *ReactionHandler) GetEmojis(c *gin.Context) {
+	c.JSON(http.StatusOK, h.validator.Emojis())
+}
+
+// AddTaskReaction godoc
+// @Summary      React to a task
+// @Description  Add the current user's reaction with an emoji to a task. Each user can react once per emoji; reacting again has no effect.
+// @Tags         Reactions
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id     path      int     true  "Task ID"
+// @Param        emoji  path      string  true  "Emoji from the allow-list"
+// @Success      200    {array}   models.ReactionSummary
+// @Failure      400    {object}  apperrors.Problem
+// @Failure      401    {object}  apperrors.Problem
+// @Failure      404    {object}  apperrors.Problem
+// @Failure      500    {object}  apperrors.Problem
+// @Router       /api/tasks/{id}/reactions/{emoji} [post]
+func (h *ReactionHandler) AddTaskReaction(c *gin.Context) {
+	h.react(c, h.reactionService.AddTaskReaction)
+}
+
+// RemoveTaskReaction godoc
+// @Summary      Remove a task reaction
+// @Description  Remove the current user's reaction with an emoji from a task
+// @Tags         Reactions
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id     path      int     true  "Task ID"
+// @Param        emoji  path      string  true  "Emoji"
+// @Success      200    {array}   models.ReactionSummary
+// @Failure      400    {object}  apperrors.Problem
+// @Failure      401    {object}  apperrors.Problem
+// @Failure      404    {object}  apperrors.Problem
+// @Failure      500    {object}  apperrors.Problem
+// @Router       /api/tasks/{id}/reactions/{emoji} [delete]
+func (h *ReactionHandler) RemoveTaskReaction(c *gin.Context) {
+	h.react(c, h.reactionService.RemoveTaskReaction)
+}
+
+// AddCommentReaction godoc
+// @Summary      React to a comment
+// @Description  Add the current user's reaction with an emoji to a comment. Each user can react once per emoji; reacting again has no effect.
+// @Tags         Reactions
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id     path      int     true  "Comment ID"
+// @Param        emoji  path      string  true  "Emoji from the allow-list"
+// @Success      200    {array}   models.ReactionSummary
+// @Failure      400    {object}  apperrors.Problem
+// @Failure      401    {object}  apperrors.Problem
+// @Failure      404    {object}  apperrors.Problem
+// @Failure      500    {object}  apperrors.Problem
+// @Router       /api/comments/{id}/reactions/{emoji} [post]
+func (h *ReactionHandler) AddCommentReaction(c *gin.Context) {
+	h.react(c, h.reactionService.AddCommentReaction)
+}
+
+// RemoveCommentReaction godoc
+// @Summary      Remove a comment reaction
+// @Description  Remove the current user's reaction with an emoji from a comment
+// @Tags         Reactions
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id     path      int     true  "Comment ID"
+// @Param        emoji  path      string  true  "Emoji"
+// @Success      200    {array}   models.ReactionSummary
+// @Failure      400    {object}  apperrors.Problem
+// @Failure      401    {object}  apperrors.Problem
+// @Failure      404    {object}  apperrors.Problem
+// @Failure      500    {object}  apperrors.Problem
+// @Router       /api/comments/{id}/reactions/{emoji} [delete]
+func (h *ReactionHandler) RemoveCommentReaction(c *gin.Context) {
+	h.react(c, h.reactionService.RemoveCommentReaction)
+}
+
+// react validates the emoji, applies fn and responds with the updated reaction counts
+func (h *ReactionHandler) react(c *gin.Context, fn reactionFunc) {
+	userID, _ := middleware.GetUserID(c)
+	emoji := c.Param("emoji")
+
+	if err := h.validator.ValidateEmoji(emoji); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	reactions, err := fn(c.Param("id"), emoji, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reactions)
+}
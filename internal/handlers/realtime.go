@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"candidate-backend/internal/middleware"
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
+	"encoding/json"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsWriteWait is how long a write to the client may take
+	wsWriteWait = 10 * time.Second
+	// wsPongWait is how long the client may stay silent, pongs included
+	wsPongWait = 60 * time.Second
+	// wsPingPeriod must be shorter than wsPongWait
+	wsPingPeriod = wsPongWait * 9 / 10
+	// wsMaxMessage is the largest message a client may send
+	wsMaxMessage = 4096
+)
+
+type RealtimeHandler struct {
+	hub   *services.RealtimeHub
+	users services.UserRepository
+	// upgrader rejects cross-origin requests, as browsers send cookies but no
+	// Authorization header with them
+	upgrader websocket.Upgrader
+}
+
+func NewRealtimeHandler(hub *services.RealtimeHub, users services.UserRepository) *RealtimeHandler {
+	return &RealtimeHandler{hub: hub, users: users}
+}
+
+// Connect godoc
+// @Summary      Open a WebSocket
+// @Description  Upgrades to a WebSocket carrying JSON messages. Browsers cannot set the Authorization header on a WebSocket, so the token may be passed as the access_token query parameter instead.
+// @Description  Send {"type":"subscribe","task_id":1} or {"type":"subscribe","project_id":1} to receive the events of a task or of every task in a project, and "unsubscribe" to stop. Events arrive as {"type":"event","event":{...}}.
+// @Description  Everyone subscribed to a task gets a presence message listing its viewers whenever someone subscribes or leaves. Send {"type":"typing","task_id":1,"typing":true} to tell the other viewers you are writing a comment.
+// @Description  Comment events are only sent to access tokens with the comments scope.
+// @Tags         Events
+// @Security     Bearer
+// @Param        access_token  query     string  false  "JWT, for clients that cannot set the Authorization header"
+// @Success      101           {object}  models.RealtimeMessage
+// @Failure      400           {object}  apperrors.Problem
+// @Failure      401           {object}  apperrors.Problem
+// @Failure      500           {object}  apperrors.Problem
+// @Router       /api/ws [get]
+func (h *RealtimeHandler) Connect(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	user, err := h.users.GetUser(userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// Upgrade writes the error response itself
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+
+	client := h.hub.Connect(models.RealtimeUser{ID: user.ID, Name: user.Name}, middleware.HasScope(c, models.ScopeComments))
+	go h.writePump(conn, client)
+	h.readPump(conn, client)
+}
+
+// readPump hands the client's messages to the hub until the connection closes
+func (h *RealtimeHandler) readPump(conn *websocket.Conn, client *services.RealtimeClient) {
+	defer h.hub.Disconnect(client)
+
+	conn.SetReadLimit(wsMaxMessage)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		var req models.RealtimeRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			h.hub.Reject(client, req, "message must be a JSON object")
+			continue
+		}
+		h.hub.Handle(client, req)
+	}
+}
+
+// writePump writes the hub's messages to the connection and pings the client. It
+// closes the connection when the hub disconnects the client or a write fails.
+func (h *RealtimeHandler) writePump(conn *websocket.Conn, client *services.RealtimeClient) {
+	ping := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ping.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-client.Send:
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			// The hub closes Send when the client disconnects or falls too far behind
+			if !ok {
+				_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if err := conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ping.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
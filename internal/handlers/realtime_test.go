@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"candidate-backend/internal/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// nextMessage returns the next WebSocket message that is not a presence update
+func nextMessage(t *testing.T, conn *websocket.Conn) models.RealtimeMessage {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var msg models.RealtimeMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("reading message: %v", err)
+		}
+		if msg.Type != models.RealtimePresence {
+			return msg
+		}
+	}
+}
+
+func TestRealtime(t *testing.T) {
+	router, dispatcher, _ := newTestRouter()
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	alice := register(t, router, "alice@example.com")
+	var task models.Task
+	serve(t, router, http.MethodPost, "/api/tasks", alice, models.CreateTaskRequest{Title: "Live"}, &task)
+	_, _ = dispatcher.DispatchPending(context.Background())
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws"
+	if _, resp, err := websocket.DefaultDialer.Dial(url, nil); err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("dialing without a token: error = %v, want 401", err)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?access_token="+alice, nil)
+	if err != nil {
+		t.Fatalf("dialing with access_token: %v", err)
+	}
+	defer conn.Close()
+
+	tests := []struct {
+		name     string
+		send     string
+		wantType models.RealtimeType
+	}{
+		{"Invalid JSON", `not json`, models.RealtimeError},
+		{"Unknown type", `{"type":"dance"}`, models.RealtimeError},
+		{"Missing task", `{"type":"subscribe","task_id":999}`, models.RealtimeError},
+		{"Subscribe to a task", `{"type":"subscribe","task_id":` + strconv.Itoa(task.ID) + `}`, models.RealtimeSubscribed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(tt.send)); err != nil {
+				t.Fatalf("writing message: %v", err)
+			}
+			if msg := nextMessage(t, conn); msg.Type != tt.wantType {
+				t.Errorf("reply = %+v, want type %q", msg, tt.wantType)
+			}
+		})
+	}
+
+	serve(t, router, http.MethodPut, "/api/tasks/"+strconv.Itoa(task.ID), alice, map[string]string{"title": "Renamed"}, nil)
+	_, _ = dispatcher.DispatchPending(context.Background())
+
+	msg := nextMessage(t, conn)
+	if msg.Type != models.RealtimeEvent || msg.Event == nil || msg.Event.Type != models.EventTaskUpdated {
+		t.Errorf("message after update = %+v, want task.updated event", msg)
+	}
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"candidate-backend/internal/middleware"
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Routes are the handlers and middleware RegisterRoutes serves. Routes of a nil
+// handler are left out, so tests can serve the ones that need no database.
+type Routes struct {
+	Auth          *AuthHandler
+	PasswordReset *PasswordResetHandler
+	MFA           *MFAHandler
+	AccessTokens  *AccessTokenHandler
+	Tasks         *TaskHandler
+	Comments      *CommentHandler
+	TimeEntries   *TimeEntryHandler
+	Attachments   *AttachmentHandler
+	Files         *FileHandler
+	Reactions     *ReactionHandler
+	Sprints       *SprintHandler
+	Projects      *ProjectHandler
+	Notifications *NotificationHandler
+	Email         *EmailHandler
+	Webhooks      *WebhookHandler
+	EventStream   *EventStreamHandler
+	Realtime      *RealtimeHandler
+
+	// Authenticate checks the JWT or personal access token of protected routes
+	Authenticate gin.HandlerFunc
+	// Users backs the admin check and the verified email check
+	Users services.UserRepository
+	// RequireVerifiedEmail blocks users who have not verified their email from writes
+	RequireVerifiedEmail bool
+}
+
+// RegisterRoutes registers the auth, API, signed download and email unsubscribe
+// routes on router. Access tokens are limited to the routes their scopes allow;
+// account routes take a session.
+func RegisterRoutes(router gin.IRouter, r Routes) {
+	taskScopes := middleware.RequireScopes(models.ScopeTasksRead, models.ScopeTasksWrite)
+	commentScopes := middleware.RequireScopes(models.ScopeComments, models.ScopeComments)
+	adminScopes := middleware.RequireScopes(models.ScopeAdmin, models.ScopeAdmin)
+	sessionOnly := middleware.RequireSession()
+
+	// Signed file downloads (public, authorized by the URL signature)
+	if r.Files != nil {
+		router.GET("/files/*key", r.Files.DownloadFile)
+	}
+
+	// Email unsubscribe links (public, authorized by the token signature)
+	if r.Email != nil {
+		router.GET("/email/unsubscribe", r.Email.ConfirmUnsubscribe)
+		router.POST("/email/unsubscribe", r.Email.Unsubscribe)
+	}
+
+	// Auth routes (public, except resending the verification email)
+	auth := router.Group("/auth")
+	{
+		auth.POST("/register", r.Auth.Register)
+		auth.POST("/login", r.Auth.Login)
+		auth.POST("/mfa/verify", r.Auth.VerifyMFA)
+		auth.POST("/verify-email", r.Auth.VerifyEmail)
+		auth.POST("/resend-verification", r.Authenticate, sessionOnly, r.Auth.ResendVerification)
+		if r.PasswordReset != nil {
+			auth.POST("/forgot-password", r.PasswordReset.ForgotPassword)
+			auth.POST("/reset-password", r.PasswordReset.ResetPassword)
+		}
+	}
+
+	// Protected routes
+	api := router.Group("/api")
+	api.Use(r.Authenticate)
+	if r.RequireVerifiedEmail {
+		api.Use(middleware.RequireVerifiedEmail(r.Users))
+	}
+
+	// Task routes
+	tasks := api.Group("/tasks")
+	tasks.Use(taskScopes)
+	if r.Tasks != nil {
+		tasks.GET("", r.Tasks.GetTasks)
+		tasks.GET("/archived", r.Tasks.GetArchivedTasks)
+		tasks.POST("", r.Tasks.CreateTask)
+		tasks.GET("/:id", r.Tasks.GetTask)
+		tasks.PUT("/:id", r.Tasks.UpdateTask)
+		tasks.DELETE("/:id", r.Tasks.DeleteTask)
+		tasks.POST("/:id/archive", r.Tasks.ArchiveTask)
+		tasks.POST("/:id/unarchive", r.Tasks.UnarchiveTask)
+		tasks.GET("/:id/logs", r.Tasks.GetTaskLogs)
+		tasks.POST("/:id/watch", r.Tasks.WatchTask)
+		tasks.DELETE("/:id/watch", r.Tasks.UnwatchTask)
+	}
+	if r.TimeEntries != nil {
+		tasks.GET("/:id/time-entries", r.TimeEntries.GetTaskTimeEntries)
+		tasks.POST("/:id/time-entries", r.TimeEntries.CreateTimeEntry)
+		tasks.POST("/:id/time-entries/start", r.TimeEntries.StartTimer)
+	}
+	if r.Attachments != nil {
+		tasks.GET("/:id/attachments", r.Attachments.GetTaskAttachments)
+		tasks.POST("/:id/attachments", r.Attachments.UploadTaskAttachment)
+	}
+	if r.Reactions != nil {
+		tasks.POST("/:id/reactions/:emoji", r.Reactions.AddTaskReaction)
+		tasks.DELETE("/:id/reactions/:emoji", r.Reactions.RemoveTaskReaction)
+	}
+
+	// Comment routes
+	taskComments := api.Group("/tasks/:id/comments")
+	taskComments.Use(commentScopes)
+	comments := api.Group("/comments")
+	comments.Use(commentScopes)
+	if r.Comments != nil {
+		taskComments.GET("", r.Comments.GetComments)
+		taskComments.POST("", r.Comments.CreateComment)
+		comments.PUT("/:id", r.Comments.UpdateComment)
+		comments.DELETE("/:id", r.Comments.DeleteComment)
+		comments.GET("/:id/revisions", r.Comments.GetCommentRevisions)
+	}
+	if r.Attachments != nil {
+		comments.GET("/:id/attachments", r.Attachments.GetCommentAttachments)
+		comments.POST("/:id/attachments", r.Attachments.UploadCommentAttachment)
+	}
+	if r.Reactions != nil {
+		comments.POST("/:id/reactions/:emoji", r.Reactions.AddCommentReaction)
+		comments.DELETE("/:id/reactions/:emoji", r.Reactions.RemoveCommentReaction)
+	}
+
+	// Time entry routes
+	if r.TimeEntries != nil {
+		timeEntries := api.Group("/time-entries")
+		timeEntries.Use(taskScopes)
+		timeEntries.GET("/running", r.TimeEntries.GetRunningTimer)
+		timeEntries.GET("/report", r.TimeEntries.GetTimeReport)
+		timeEntries.POST("/stop", r.TimeEntries.StopTimer)
+		timeEntries.PUT("/:id", r.TimeEntries.UpdateTimeEntry)
+		timeEntries.DELETE("/:id", r.TimeEntries.DeleteTimeEntry)
+	}
+
+	// Attachment routes
+	if r.Attachments != nil {
+		attachments := api.Group("/attachments")
+		attachments.Use(taskScopes)
+		attachments.GET("/:id", r.Attachments.GetAttachment)
+		attachments.GET("/:id/url", r.Attachments.GetDownloadURL)
+		attachments.DELETE("/:id", r.Attachments.DeleteAttachment)
+	}
+
+	// Reaction routes
+	if r.Reactions != nil {
+		api.GET("/reactions/emojis", taskScopes, r.Reactions.GetEmojis)
+	}
+
+	// Notification routes
+	notifications := api.Group("/notifications")
+	notifications.Use(sessionOnly)
+	if r.Notifications != nil {
+		notifications.GET("", r.Notifications.GetNotifications)
+		notifications.POST("/read-all", r.Notifications.MarkAllRead)
+		notifications.POST("/:id/read", r.Notifications.MarkRead)
+		notifications.GET("/preferences", r.Notifications.GetPreferences)
+		notifications.PUT("/preferences", r.Notifications.UpdatePreferences)
+	}
+	if r.Email != nil {
+		notifications.GET("/email-preferences", r.Email.GetPreferences)
+		notifications.PUT("/email-preferences", r.Email.UpdatePreferences)
+	}
+
+	// Sprint routes
+	if r.Sprints != nil {
+		sprints := api.Group("/sprints")
+		sprints.Use(taskScopes)
+		sprints.GET("", r.Sprints.GetSprints)
+		sprints.POST("", r.Sprints.CreateSprint)
+		sprints.GET("/:id", r.Sprints.GetSprint)
+		sprints.PUT("/:id", r.Sprints.UpdateSprint)
+		sprints.DELETE("/:id", r.Sprints.DeleteSprint)
+		sprints.GET("/:id/tasks", r.Sprints.GetSprintTasks)
+		sprints.POST("/:id/close", r.Sprints.CloseSprint)
+		sprints.GET("/:id/burndown", r.Sprints.GetBurndown)
+	}
+
+	// Project routes
+	if r.Projects != nil {
+		projects := api.Group("/projects")
+		projects.Use(taskScopes)
+		projects.GET("", r.Projects.GetProjects)
+		projects.POST("", r.Projects.CreateProject)
+		projects.GET("/:id", r.Projects.GetProject)
+		projects.PUT("/:id", r.Projects.UpdateProject)
+		projects.DELETE("/:id", r.Projects.DeleteProject)
+		projects.GET("/:id/fields", r.Projects.GetFields)
+		projects.POST("/:id/fields", r.Projects.CreateField)
+		projects.PUT("/:id/fields/:fieldId", r.Projects.UpdateField)
+		projects.DELETE("/:id/fields/:fieldId", r.Projects.DeleteField)
+	}
+
+	// Webhook routes
+	if r.Webhooks != nil {
+		webhooks := api.Group("/webhooks")
+		webhooks.Use(sessionOnly)
+		webhooks.GET("", r.Webhooks.GetWebhooks)
+		webhooks.POST("", r.Webhooks.CreateWebhook)
+		webhooks.GET("/:id", r.Webhooks.GetWebhook)
+		webhooks.PUT("/:id", r.Webhooks.UpdateWebhook)
+		webhooks.DELETE("/:id", r.Webhooks.DeleteWebhook)
+		webhooks.GET("/:id/deliveries", r.Webhooks.GetDeliveries)
+		webhooks.POST("/:id/deliveries/:deliveryId/replay", r.Webhooks.ReplayDelivery)
+	}
+
+	// Event stream
+	if r.EventStream != nil {
+		api.GET("/events/stream", taskScopes, r.EventStream.StreamEvents)
+	}
+	if r.Realtime != nil {
+		api.GET("/ws", taskScopes, r.Realtime.Connect)
+	}
+
+	// Sign-in history
+	api.GET("/sign-ins", sessionOnly, r.Auth.GetSignIns)
+
+	// Personal access token routes
+	if r.AccessTokens != nil {
+		accessTokens := api.Group("/access-tokens")
+		accessTokens.Use(sessionOnly)
+		accessTokens.GET("", r.AccessTokens.GetAccessTokens)
+		accessTokens.POST("", r.AccessTokens.CreateAccessToken)
+		accessTokens.DELETE("/:id", r.AccessTokens.RevokeAccessToken)
+	}
+
+	// MFA routes
+	if r.MFA != nil {
+		mfa := api.Group("/mfa")
+		mfa.Use(sessionOnly)
+		mfa.GET("", r.MFA.GetStatus)
+		mfa.POST("/enroll", r.MFA.Enroll)
+		mfa.POST("/confirm", r.MFA.Confirm)
+		mfa.DELETE("", r.MFA.Disable)
+
+		// Admin routes
+		admin := api.Group("/admin")
+		admin.Use(adminScopes, middleware.RequireAdmin(r.Users))
+		admin.DELETE("/users/:id/mfa", r.MFA.Reset)
+	}
+}
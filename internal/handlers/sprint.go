@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/middleware"
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SprintHandler struct {
+	sprintService    *services.SprintService
+	changeLogService *services.ChangeLogService
+}
+
+func NewSprintHandler(db *sql.DB) *SprintHandler {
+	return &SprintHandler{
+		sprintService:    services.NewSprintService(db),
+		changeLogService: services.NewChangeLogService(services.NewPostgresChangeLogRepository(db)),
+	}
+}
+
+// GetSprints godoc
+// @Summary      Get sprints
+// @Description  Retrieve open sprints ordered by start date, with task and story point totals
+// @Tags         Sprints
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        include_closed  query     bool  false  "Include closed sprints (default: false)"
+// @Success      200             {array}   models.Sprint
+// @Failure      401             {object}  apperrors.Problem
+// @Failure      500             {object}  apperrors.Problem
+// @Router       /api/sprints [get]
+func (h *SprintHandler) GetSprints(c *gin.Context) {
+	includeClosed := c.Query("include_closed") == "true"
+
+	sprints, err := h.sprintService.GetSprints(includeClosed)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sprints)
+}
+
+// GetSprint godoc
+// @Summary      Get sprint by ID
+// @Description  Retrieve a specific sprint with task and story point totals
+// @Tags         Sprints
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Sprint ID"
+// @Success      200  {object}  models.Sprint
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/sprints/{id} [get]
+func (h *SprintHandler) GetSprint(c *gin.Context) {
+	sprintID := c.Param("id")
+
+	sprint, err := h.sprintService.GetSprint(sprintID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sprint)
+}
+
+// CreateSprint godoc
+// @Summary      Create a sprint
+// @Description  Create a new sprint or milestone with start and end dates
+// @Tags         Sprints
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        sprint  body      models.CreateSprintRequest  true  "Sprint data"
+// @Success      201     {object}  models.Sprint
+// @Failure      400     {object}  apperrors.Problem
+// @Failure      401     {object}  apperrors.Problem
+// @Failure      500     {object}  apperrors.Problem
+// @Router       /api/sprints [post]
+func (h *SprintHandler) CreateSprint(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req models.CreateSprintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.FromBinding(err))
+		return
+	}
+
+	sprint, err := h.sprintService.CreateSprint(req, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, sprint)
+}
+
+// UpdateSprint godoc
+// @Summary      Update a sprint
+// @Description  Update an open sprint (only the creator can update)
+// @Tags         Sprints
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id      path      int                         true  "Sprint ID"
+// @Param        sprint  body      models.UpdateSprintRequest  true  "Updated sprint data"
+// @Success      200     {object}  models.Sprint
+// @Failure      400     {object}  apperrors.Problem
+// @Failure      401     {object}  apperrors.Problem
+// @Failure      403     {object}  apperrors.Problem
+// @Failure      404     {object}  apperrors.Problem
+// @Failure      500     {object}  apperrors.Problem
+// @Router       /api/sprints/{id} [put]
+func (h *SprintHandler) UpdateSprint(c *gin.Context) {
+	sprintID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	var req models.UpdateSprintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.FromBinding(err))
+		return
+	}
+
+	sprint, err := h.sprintService.UpdateSprint(sprintID, req, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sprint)
+}
+
+// DeleteSprint godoc
+// @Summary      Delete a sprint
+// @Description  Delete a sprint and move its tasks back to the backlog (only the creator can delete)
+// @Tags         Sprints
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Sprint ID"
+// @Success      200  {object}  map[string]string
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      403  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/sprints/{id} [delete]
+func (h *SprintHandler) DeleteSprint(c *gin.Context) {
+	sprintID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	_, err := h.sprintService.DeleteSprint(sprintID, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sprint deleted successfully"})
+}
+
+// GetSprintTasks godoc
+// @Summary      Get sprint tasks
+// @Description  Retrieve the non-archived tasks assigned to a sprint
+// @Tags         Sprints
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Sprint ID"
+// @Success      200  {array}   models.Task
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/sprints/{id}/tasks [get]
+func (h *SprintHandler) GetSprintTasks(c *gin.Context) {
+	sprintID := c.Param("id")
+
+	tasks, err := h.sprintService.GetSprintTasks(sprintID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// CloseSprint godoc
+// @Summary      Close a sprint
+// @Description  Close a sprint and carry unfinished tasks into the next sprint (only the creator can close).
+// @Description  Without next_sprint_id the earliest open sprint starting after this one is used; if there is none, unfinished tasks return to the backlog.
+// @Tags         Sprints
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id     path      int                        true   "Sprint ID"
+// @Param        close  body      models.CloseSprintRequest  false  "Close options"
+// @Success      200    {object}  models.CloseSprintResponse
+// @Failure      400    {object}  apperrors.Problem
+// @Failure      401    {object}  apperrors.Problem
+// @Failure      403    {object}  apperrors.Problem
+// @Failure      404    {object}  apperrors.Problem
+// @Failure      409    {object}  apperrors.Problem
+// @Failure      500    {object}  apperrors.Problem
+// @Router       /api/sprints/{id}/close [post]
+func (h *SprintHandler) CloseSprint(c *gin.Context) {
+	sprintID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	var req models.CloseSprintRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.FromBinding(err))
+			return
+		}
+	}
+
+	result, err := h.sprintService.CloseSprint(sprintID, req, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// Log the carry-over on each task
+	destination := "the backlog"
+	if result.NextSprint != nil {
+		destination = fmt.Sprintf("sprint '%s'", result.NextSprint.Name)
+	}
+	for _, taskID := range result.CarriedOverTaskIDs {
+		_ = h.changeLogService.CreateChangeLog(taskID, userID, "carried_over",
+			fmt.Sprintf("Carried over from sprint '%s' to %s", result.Sprint.Name, destination))
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetBurndown godoc
+// @Summary      Get sprint burndown
+// @Description  Retrieve remaining story points for each sprint day, computed from the change log
+// @Tags         Sprints
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Sprint ID"
+// @Success      200  {object}  models.Burndown
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/sprints/{id}/burndown [get]
+func (h *SprintHandler) GetBurndown(c *gin.Context) {
+	sprintID := c.Param("id")
+
+	burndown, err := h.sprintService.GetBurndown(sprintID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, burndown)
+}
@@ -1,13 +1,15 @@
 package handlers
 
 import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/markdown"
 	"candidate-backend/internal/middleware"
 	"candidate-backend/internal/models"
 	"candidate-backend/internal/services"
 	"database/sql"
-	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,13 +18,29 @@ type TaskHandler struct {
 	taskService      *services.TaskService
 	archiveService   *services.TaskArchiveService
 	changeLogService *services.ChangeLogService
+	reactionService  *services.ReactionService
+	renderer         *markdown.Renderer
 }
 
 func NewTaskHandler(db *sql.DB) *TaskHandler {
+	handler := NewTaskHandlerWithRepositories(
+		services.NewPostgresTaskRepository(db),
+		services.NewPostgresUserRepository(db),
+		services.NewPostgresChangeLogRepository(db),
+	)
+	handler.taskService.SetMentionResolver(services.NewMentionService(db))
+	handler.reactionService = services.NewReactionService(services.NewPostgresReactionRepository(db))
+	return handler
+}
+
+// NewTaskHandlerWithRepositories builds a TaskHandler on the given repositories.
+// Mentions and reactions are database-only and left out.
+func NewTaskHandlerWithRepositories(tasks services.TaskRepository, users services.UserRepository, logs services.ChangeLogRepository) *TaskHandler {
 	return &TaskHandler{
-		taskService:      services.NewTaskService(db),
-		archiveService:   services.NewTaskArchiveService(db),
-		changeLogService: services.NewChangeLogService(db),
+		taskService:      services.NewTaskService(tasks, users),
+		archiveService:   services.NewTaskArchiveService(tasks),
+		changeLogService: services.NewChangeLogService(logs),
+		renderer:         markdown.NewRenderer(),
 	}
 }
 
@@ -33,13 +51,25 @@ func NewTaskHandler(db *sql.DB) *TaskHandler {
 // @Accept       json
 // @Produce      json
 // @Security     Bearer
-// @Param        limit   query     int  false  "Limit number of results (default: 10)"
-// @Param        offset  query     int  false  "Offset for pagination (default: 0)"
+// @Param        limit       query     int     false  "Limit number of results (default: 10)"
+// @Param        offset      query     int     false  "Offset for pagination (default: 0)"
+// @Param        project_id  query     int     false  "Only tasks in this project (required for custom field filters and sorting)"
+// @Param        cf.key      query     string  false  "Filter by custom field value, e.g. cf.severity=high (multi-select matches tasks that have the option)"
+// @Param        sort        query     string  false  "Sort by created_at, updated_at, due_date, title, story_points or cf.<key> (default: created_at)"
+// @Param        order       query     string  false  "Sort order: asc or desc (default: desc)"
+// @Param        watching    query     bool    false  "Only tasks the current user watches"
+// @Param        format      query     string  false  "Description format: raw, html or both (default: raw)"
 // @Success      200  {array}   models.Task
-// @Failure      401  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Failure      400  {object}  apperrors.Problem
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
 // @Router       /api/tasks [get]
 func (h *TaskHandler) GetTasks(c *gin.Context) {
+	format, ok := contentFormat(c)
+	if !ok {
+		return
+	}
+
 	// Get pagination params
 	limit := 10
 	offset := 0
@@ -56,40 +86,90 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 		}
 	}
 
-	tasks, err := h.taskService.GetTasks(limit, offset)
+	filter := models.TaskFilter{
+		Limit:        limit,
+		Offset:       offset,
+		CustomFields: map[string]string{},
+		Sort:         c.Query("sort"),
+		Order:        c.Query("order"),
+	}
+
+	if projectIDStr := c.Query("project_id"); projectIDStr != "" {
+		projectID, err := strconv.Atoi(projectIDStr)
+		if err != nil {
+			_ = c.Error(apperrors.Invalid("project_id", "project_id must be an integer"))
+			return
+		}
+		filter.ProjectID = &projectID
+	}
+
+	if c.Query("watching") == "true" {
+		userID, _ := middleware.GetUserID(c)
+		filter.WatcherID = &userID
+	}
+
+	for param, values := range c.Request.URL.Query() {
+		if key, ok := strings.CutPrefix(param, "cf."); ok && len(values) > 0 {
+			filter.CustomFields[key] = values[0]
+		}
+	}
+
+	tasks, err := h.taskService.GetTasks(filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
+	renderTasks(h.renderer, tasks, format)
+
 	c.JSON(http.StatusOK, tasks)
 }
 
 // GetTask godoc
 // @Summary      Get task by ID
-// @Description  Retrieve a specific task by its ID
+// @Description  Retrieve a specific task by its ID, with its reactions counted per emoji and its watchers
 // @Tags         Tasks
 // @Accept       json
 // @Produce      json
 // @Security     Bearer
-// @Param        id   path      int  true  "Task ID"
+// @Param        id      path      int     true   "Task ID"
+// @Param        format  query     string  false  "Description format: raw, html or both (default: raw)"
 // @Success      200  {object}  models.Task
-// @Failure      401  {object}  map[string]string
-// @Failure      404  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
 // @Router       /api/tasks/{id} [get]
 func (h *TaskHandler) GetTask(c *gin.Context) {
 	taskID := c.Param("id")
 
+	format, ok := contentFormat(c)
+	if !ok {
+		return
+	}
+
 	task, err := h.taskService.GetTask(taskID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		_ = c.Error(err)
+		return
+	}
+
+	if h.reactionService != nil {
+		userID, _ := middleware.GetUserID(c)
+		if err := h.reactionService.AttachTaskReactions(task, userID); err != nil {
+			_ = c.Error(err)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+
+	watchers, err := h.taskService.GetWatchers(taskID)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
+	task.Watchers = watchers
+	task.WatcherCount = len(watchers)
+
+	renderTask(h.renderer, task, format)
 
 	c.JSON(http.StatusOK, task)
 }
@@ -103,9 +183,9 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 // @Security     Bearer
 // @Param        task  body      models.CreateTaskRequest  true  "Task data"
 // @Success      201   {object}  models.Task
-// @Failure      400   {object}  map[string]string
-// @Failure      401   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Failure      400   {object}  apperrors.Problem
+// @Failure      401   {object}  apperrors.Problem
+// @Failure      500   {object}  apperrors.Problem
 // @Router       /api/tasks [post]
 func (h *TaskHandler) CreateTask(c *gin.Context) {
 	userID, _ := middleware.GetUserID(c)
@@ -115,10 +195,16 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		Description string `json:"description"`
 		Status      string `json:"status"`
 		DueDate     *string `json:"due_date"`
+		AssigneeID  *int    `json:"assignee_id"`
+		ProjectID   *int    `json:"project_id"`
+		SprintID    *int    `json:"sprint_id"`
+		StoryPoints *int    `json:"story_points"`
+
+		CustomFields map[string]interface{} `json:"custom_fields"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(apperrors.FromBinding(err))
 		return
 	}
 
@@ -127,17 +213,20 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		Title:       req.Title,
 		Description: req.Description,
 		Status:      models.TaskStatus(req.Status),
+		AssigneeID:  req.AssigneeID,
+		ProjectID:   req.ProjectID,
+		SprintID:    req.SprintID,
+		StoryPoints: req.StoryPoints,
+
+		CustomFields: req.CustomFields,
 	}
 
 	task, err := h.taskService.CreateTask(createReq, userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
-	// Log the creation
-	_ = h.changeLogService.CreateChangeLog(task.ID, userID, "created", fmt.Sprintf("Created task: %s", task.Title))
-
 	c.JSON(http.StatusCreated, task)
 }
 
@@ -151,11 +240,11 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 // @Param        id    path      int                      true  "Task ID"
 // @Param        task  body      models.UpdateTaskRequest  true  "Updated task data"
 // @Success      200   {object}  models.Task
-// @Failure      400   {object}  map[string]string
-// @Failure      401   {object}  map[string]string
-// @Failure      403   {object}  map[string]string
-// @Failure      404   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Failure      400   {object}  apperrors.Problem
+// @Failure      401   {object}  apperrors.Problem
+// @Failure      403   {object}  apperrors.Problem
+// @Failure      404   {object}  apperrors.Problem
+// @Failure      500   {object}  apperrors.Problem
 // @Router       /api/tasks/{id} [put]
 func (h *TaskHandler) UpdateTask(c *gin.Context) {
 	taskID := c.Param("id")
@@ -163,30 +252,16 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 
 	var req models.UpdateTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(apperrors.FromBinding(err))
 		return
 	}
 
-	task, changes, err := h.taskService.UpdateTask(taskID, req, userID)
+	task, _, err := h.taskService.UpdateTask(taskID, req, userID)
 	if err != nil {
-		if err.Error() == "task not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		if err.Error() == "you can only modify your own tasks" {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
-	// Log the update
-	if len(changes) > 0 {
-		changeDetails := h.changeLogService.FormatChangeDetails(changes)
-		_ = h.changeLogService.CreateChangeLog(task.ID, userID, "updated", changeDetails)
-	}
-
 	c.JSON(http.StatusOK, task)
 }
 
@@ -199,34 +274,20 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 // @Security     Bearer
 // @Param        id   path      int  true  "Task ID"
 // @Success      200  {object}  map[string]string
-// @Failure      401  {object}  map[string]string
-// @Failure      403  {object}  map[string]string
-// @Failure      404  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      403  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
 // @Router       /api/tasks/{id} [delete]
 func (h *TaskHandler) DeleteTask(c *gin.Context) {
 	taskID := c.Param("id")
 	userID, _ := middleware.GetUserID(c)
 
-	taskIDInt, _ := strconv.Atoi(taskID)
-
-	title, err := h.taskService.DeleteTask(taskID, userID)
-	if err != nil {
-		if err == sql.ErrNoRows || err.Error() == "task not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
-			return
-		}
-		if err.Error() == "you can only delete your own tasks" {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if _, err := h.taskService.DeleteTask(taskID, userID); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	// Log before deletion (because of CASCADE)
-	_ = h.changeLogService.CreateChangeLog(taskIDInt, userID, "deleted", fmt.Sprintf("Deleted task: %s", title))
-
 	c.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
 }
 
@@ -239,32 +300,21 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 // @Security     Bearer
 // @Param        id   path      int  true  "Task ID"
 // @Success      200  {object}  models.Task
-// @Failure      401  {object}  map[string]string
-// @Failure      403  {object}  map[string]string
-// @Failure      404  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      403  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
 // @Router       /api/tasks/{id}/archive [post]
 func (h *TaskHandler) ArchiveTask(c *gin.Context) {
 	taskID := c.Param("id")
 	userID, _ := middleware.GetUserID(c)
 
-	task, title, err := h.archiveService.ArchiveTask(taskID, userID)
+	task, _, err := h.archiveService.ArchiveTask(taskID, userID)
 	if err != nil {
-		if err.Error() == "task not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		if err.Error() == "you can only archive your own tasks" {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
-	// Log the archive action
-	_ = h.changeLogService.CreateChangeLog(task.ID, userID, "archived", fmt.Sprintf("Archived task: %s", title))
-
 	c.JSON(http.StatusOK, task)
 }
 
@@ -277,32 +327,21 @@ func (h *TaskHandler) ArchiveTask(c *gin.Context) {
 // @Security     Bearer
 // @Param        id   path      int  true  "Task ID"
 // @Success      200  {object}  models.Task
-// @Failure      401  {object}  map[string]string
-// @Failure      403  {object}  map[string]string
-// @Failure      404  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      403  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
 // @Router       /api/tasks/{id}/unarchive [post]
 func (h *TaskHandler) UnarchiveTask(c *gin.Context) {
 	taskID := c.Param("id")
 	userID, _ := middleware.GetUserID(c)
 
-	task, title, err := h.archiveService.UnarchiveTask(taskID, userID)
+	task, _, err := h.archiveService.UnarchiveTask(taskID, userID)
 	if err != nil {
-		if err.Error() == "task not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		if err.Error() == "you can only unarchive your own tasks" {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
-	// Log the unarchive action
-	_ = h.changeLogService.CreateChangeLog(task.ID, userID, "unarchived", fmt.Sprintf("Restored task: %s", title))
-
 	c.JSON(http.StatusOK, task)
 }
 
@@ -313,13 +352,19 @@ func (h *TaskHandler) UnarchiveTask(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Security     Bearer
-// @Param        limit   query     int  false  "Limit number of results (default: 10)"
-// @Param        offset  query     int  false  "Offset for pagination (default: 0)"
+// @Param        limit   query     int     false  "Limit number of results (default: 10)"
+// @Param        offset  query     int     false  "Offset for pagination (default: 0)"
+// @Param        format  query     string  false  "Description format: raw, html or both (default: raw)"
 // @Success      200  {array}   models.Task
-// @Failure      401  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
 // @Router       /api/tasks/archived [get]
 func (h *TaskHandler) GetArchivedTasks(c *gin.Context) {
+	format, ok := contentFormat(c)
+	if !ok {
+		return
+	}
+
 	// Get pagination params
 	limit := 10
 	offset := 0
@@ -338,10 +383,12 @@ func (h *TaskHandler) GetArchivedTasks(c *gin.Context) {
 
 	tasks, err := h.archiveService.GetArchivedTasks(limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
+	renderTasks(h.renderer, tasks, format)
+
 	c.JSON(http.StatusOK, tasks)
 }
 
@@ -354,17 +401,69 @@ func (h *TaskHandler) GetArchivedTasks(c *gin.Context) {
 // @Security     Bearer
 // @Param        id   path      int  true  "Task ID"
 // @Success      200  {array}   models.ChangeLog
-// @Failure      401  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
 // @Router       /api/tasks/{id}/logs [get]
 func (h *TaskHandler) GetTaskLogs(c *gin.Context) {
 	taskID := c.Param("id")
 
 	logs, err := h.changeLogService.GetTaskLogs(taskID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, logs)
 }
+
+// WatchTask godoc
+// @Summary      Watch a task
+// @Description  Get notified of status changes, comments and due dates on a task. Watching a task twice changes nothing.
+// @Tags         Tasks
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Task ID"
+// @Success      200  {array}   models.Watcher
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/tasks/{id}/watch [post]
+func (h *TaskHandler) WatchTask(c *gin.Context) {
+	taskID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	watchers, err := h.taskService.WatchTask(taskID, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, watchers)
+}
+
+// UnwatchTask godoc
+// @Summary      Unwatch a task
+// @Description  Stop getting notified of changes to a task
+// @Tags         Tasks
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Task ID"
+// @Success      200  {array}   models.Watcher
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/tasks/{id}/watch [delete]
+func (h *TaskHandler) UnwatchTask(c *gin.Context) {
+	taskID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	watchers, err := h.taskService.UnwatchTask(taskID, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, watchers)
+}
@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"bytes"
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/mail"
+	"candidate-backend/internal/middleware"
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testSecret = "test-secret"
+
+var testEmailSettings = services.EmailSettings{
+	AppURL:         "http://app.test",
+	UnsubscribeURL: "http://api.test/email/unsubscribe",
+	SigningKey:     testSecret,
+	MaxAttempts:    3,
+}
+
+var testResetSettings = services.PasswordResetSettings{
+	URL:        "http://app.test/reset-password",
+	TTL:        time.Hour,
+	EmailLimit: 2,
+	IPLimit:    5,
+}
+
+var testVerificationSettings = services.VerificationSettings{
+	URL:            "http://app.test/verify-email",
+	SigningKey:     testSecret,
+	TTL:            time.Hour,
+	ResendInterval: time.Minute,
+}
+
+var testMFASettings = services.MFASettings{
+	Issuer:        "Task Board",
+	EncryptionKey: testSecret,
+}
+
+// testMailer is the mailer of the router newTestRouter built last
+var testMailer *mail.MemoryMailer
+
+// testStore is the store of the router newTestRouter built last
+var testStore *services.MemoryStore
+
+// newTestRouter serves the routes of RegisterRoutes that need no database on a
+// MemoryStore: auth, sign-in, access token, password reset, MFA, task, comment,
+// webhook, event stream, WebSocket, notification and email routes.
+// Outbox events are only published when the test calls DispatchPending, and webhook
+// deliveries only sent when it calls ProcessDue.
+func newTestRouter() (*gin.Engine, *services.OutboxDispatcher, *services.WebhookService) {
+	return newTestRouterWith(false)
+}
+
+// newTestRouterWith is newTestRouter, blocking users who have not verified their
+// email from write routes when requireVerifiedEmail is set
+func newTestRouterWith(requireVerifiedEmail bool) (*gin.Engine, *services.OutboxDispatcher, *services.WebhookService) {
+	gin.SetMode(gin.TestMode)
+	middleware.UseJSONFieldNames()
+
+	store := services.NewMemoryStore()
+	testStore = store
+	testMailer = mail.NewMemoryMailer()
+	mfaService := services.NewMFAService(store, store, testMFASettings)
+
+	webhookPolicy := services.DefaultWebhookPolicy
+	webhookPolicy.AllowPrivateNetworks = true
+	webhookService := services.NewWebhookService(store, webhookPolicy)
+	dispatcher := services.NewOutboxDispatcher(store)
+	dispatcher.Subscribe("webhooks", webhookService.HandleEvent)
+	notificationService := services.NewNotificationService(store, store, store)
+	dispatcher.Subscribe("notifications", notificationService.HandleEvent)
+
+	// Events reach the event stream and WebSockets through the event hub, as they do
+	// through the relay in production
+	eventHub := services.NewEventHub(10)
+	dispatcher.Subscribe("event stream", eventHub.Publish)
+	realtimeHub := services.NewRealtimeHub(store)
+	go realtimeHub.Run(context.Background(), eventHub)
+
+	accessTokenService := services.NewAccessTokenService(store, store)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	RegisterRoutes(router, Routes{
+		Auth:          NewAuthHandlerWithRepositories(store, store, testSecret, services.DefaultLoginThrottle, services.NewVerificationService(store, testMailer, testVerificationSettings), mfaService),
+		PasswordReset: NewPasswordResetHandler(services.NewPasswordResetService(store, store, testMailer, testResetSettings)),
+		MFA:           NewMFAHandler(mfaService),
+		AccessTokens:  NewAccessTokenHandler(accessTokenService),
+		Tasks:         NewTaskHandlerWithRepositories(store, store, store),
+		Comments:      NewCommentHandlerWithRepositories(store),
+		Notifications: NewNotificationHandler(notificationService),
+		Email:         NewEmailHandler(services.NewEmailService(store, store, store, testMailer, testEmailSettings)),
+		Webhooks:      NewWebhookHandler(webhookService),
+		EventStream:   NewEventStreamHandler(eventHub, time.Hour),
+		Realtime:      NewRealtimeHandler(realtimeHub, store),
+
+		Authenticate:         middleware.AuthMiddleware(testSecret, store, accessTokenService),
+		Users:                store,
+		RequireVerifiedEmail: requireVerifiedEmail,
+	})
+
+	return router, dispatcher, webhookService
+}
+
+// serve sends a JSON request and decodes the JSON response into out, if given
+func serve(t *testing.T, router *gin.Engine, method, path, token string, body interface{}, out interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatalf("encoding body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: decoding %q: %v", method, path, w.Body.String(), err)
+		}
+	}
+	return w
+}
+
+func register(t *testing.T, router *gin.Engine, email string) string {
+	t.Helper()
+
+	var resp models.LoginResponse
+	w := serve(t, router, http.MethodPost, "/auth/register", "",
+		models.RegisterRequest{Email: email, Password: "secret123", Name: email}, &resp)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register %s: status = %d, body = %s", email, w.Code, w.Body.String())
+	}
+	return resp.Token
+}
+
+// mailedToken waits for an email sent in the background with a link matching link
+// and returns the token the link's first group captures
+func mailedToken(t *testing.T, link *regexp.Regexp) string {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		for _, msg := range testMailer.Messages() {
+			if match := link.FindStringSubmatch(msg.Text); match != nil {
+				return match[1]
+			}
+		}
+	}
+	t.Fatalf("no email with a link matching %s was sent", link)
+	return ""
+}
+
+func TestAuthRoutes(t *testing.T) {
+	router, _, _ := newTestRouter()
+	register(t, router, "alice@example.com")
+
+	tests := []struct {
+		name       string
+		path       string
+		body       interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{"Duplicate email", "/auth/register", models.RegisterRequest{Email: "alice@example.com", Password: "secret123", Name: "Alice"}, http.StatusConflict, "email_taken"},
+		{"Short password", "/auth/register", models.RegisterRequest{Email: "bob@example.com", Password: "123", Name: "Bob"}, http.StatusBadRequest, apperrors.CodeValidation},
+		{"Login", "/auth/login", models.LoginRequest{Email: "alice@example.com", Password: "secret123"}, http.StatusOK, ""},
+		{"Wrong password", "/auth/login", models.LoginRequest{Email: "alice@example.com", Password: "wrong-password"}, http.StatusUnauthorized, "invalid_credentials"},
+		{"Unknown email", "/auth/login", models.LoginRequest{Email: "carol@example.com", Password: "secret123"}, http.StatusUnauthorized, "invalid_credentials"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var problem apperrors.Problem
+			w := serve(t, router, http.MethodPost, tt.path, "", tt.body, &problem)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if problem.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", problem.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestTaskRoutes(t *testing.T) {
+	router, _, _ := newTestRouter()
+	alice := register(t, router, "alice@example.com")
+	bob := register(t, router, "bob@example.com")
+
+	var task models.Task
+	w := serve(t, router, http.MethodPost, "/api/tasks", alice, models.CreateTaskRequest{Title: "Ship it"}, &task)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d, body = %s", w.Code, w.Body.String())
+	}
+	path := "/api/tasks/" + strconv.Itoa(task.ID)
+	title := "Renamed"
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		body       interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{"No token", http.MethodGet, "/api/tasks", "", nil, http.StatusUnauthorized, "authorization_required"},
+		{"Bad token", http.MethodGet, "/api/tasks", "not-a-jwt", nil, http.StatusUnauthorized, "invalid_token"},
+		{"Missing title", http.MethodPost, "/api/tasks", alice, map[string]string{"description": "no title"}, http.StatusBadRequest, apperrors.CodeValidation},
+		{"Unknown task", http.MethodGet, "/api/tasks/999", alice, nil, http.StatusNotFound, "task_not_found"},
+		{"Bad sort", http.MethodGet, "/api/tasks?sort=creator_id", alice, nil, http.StatusBadRequest, apperrors.CodeValidation},
+		{"Update by another user", http.MethodPut, path, bob, models.UpdateTaskRequest{Title: &title}, http.StatusForbidden, "not_task_owner"},
+		{"Delete by another user", http.MethodDelete, path, bob, nil, http.StatusForbidden, "not_task_owner"},
+		{"Archive by another user", http.MethodPost, path + "/archive", bob, nil, http.StatusForbidden, "not_task_owner"},
+		{"Read by another user", http.MethodGet, path, bob, nil, http.StatusOK, ""},
+		{"Update by owner", http.MethodPut, path, alice, models.UpdateTaskRequest{Title: &title}, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, router, tt.method, tt.path, tt.token, tt.body, nil)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			if got := w.Header().Get("Content-Type"); got != apperrors.ContentType {
+				t.Errorf("Content-Type = %q, want %q", got, apperrors.ContentType)
+			}
+			var problem apperrors.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil || problem.Code != tt.wantCode {
+				t.Errorf("code = %q (%v), want %q", problem.Code, err, tt.wantCode)
+			}
+		})
+	}
+
+	t.Run("Validation errors name the field", func(t *testing.T) {
+		var problem apperrors.Problem
+		serve(t, router, http.MethodPost, "/api/tasks", alice, map[string]string{"status": "Done"}, &problem)
+		if len(problem.Errors) == 0 || problem.Errors[0].Field != "title" {
+			t.Errorf("errors = %+v, want a title error", problem.Errors)
+		}
+	})
+
+	t.Run("Archive moves the task out of the list", func(t *testing.T) {
+		if w := serve(t, router, http.MethodPost, path+"/archive", alice, nil, nil); w.Code != http.StatusOK {
+			t.Fatalf("archive: status = %d, body = %s", w.Code, w.Body.String())
+		}
+
+		var active, archived []models.Task
+		serve(t, router, http.MethodGet, "/api/tasks", alice, nil, &active)
+		serve(t, router, http.MethodGet, "/api/tasks/archived", alice, nil, &archived)
+		if len(active) != 0 || len(archived) != 1 || archived[0].ID != task.ID {
+			t.Errorf("active = %+v, archived = %+v, want the task archived", active, archived)
+		}
+
+	})
+
+	t.Run("Changes are logged", func(t *testing.T) {
+		var logs []models.ChangeLog
+		serve(t, router, http.MethodGet, path+"/logs", alice, nil, &logs)
+
+		actions := map[string]bool{}
+		for _, log := range logs {
+			actions[log.Action] = true
+		}
+		for _, action := range []string{"created", "updated", "archived"} {
+			if !actions[action] {
+				t.Errorf("logs = %+v, want a %q entry", logs, action)
+			}
+		}
+	})
+
+	t.Run("Delete by owner", func(t *testing.T) {
+		if w := serve(t, router, http.MethodDelete, path, alice, nil, nil); w.Code != http.StatusOK {
+			t.Fatalf("delete: status = %d, body = %s", w.Code, w.Body.String())
+		}
+		if w := serve(t, router, http.MethodGet, path, alice, nil, nil); w.Code != http.StatusNotFound {
+			t.Errorf("get deleted: status = %d, want 404", w.Code)
+		}
+	})
+}
+
+func TestWatchRoutes(t *testing.T) {
+	router, _, _ := newTestRouter()
+	alice := register(t, router, "alice@example.com")
+	bob := register(t, router, "bob@example.com")
+	carol := register(t, router, "carol@example.com")
+
+	var task models.Task
+	serve(t, router, http.MethodPost, "/api/tasks", alice, models.CreateTaskRequest{Title: "Ship it"}, &task)
+	path := "/api/tasks/" + strconv.Itoa(task.ID)
+	serve(t, router, http.MethodPost, path+"/comments", bob, models.CreateCommentRequest{Content: "On it"}, nil)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		wantStatus int
+		want       []int
+	}{
+		{"Creator and commenter watch", http.MethodGet, path, alice, http.StatusOK, []int{1, 2}},
+		{"Watch", http.MethodPost, path + "/watch", carol, http.StatusOK, []int{1, 2, 3}},
+		{"Watch again", http.MethodPost, path + "/watch", carol, http.StatusOK, []int{1, 2, 3}},
+		{"Unwatch", http.MethodDelete, path + "/watch", alice, http.StatusOK, []int{2, 3}},
+		{"Unknown task", http.MethodPost, "/api/tasks/999/watch", carol, http.StatusNotFound, []int{2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(t, router, tt.method, tt.path, tt.token, nil, nil); w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+
+			var detail models.Task
+			serve(t, router, http.MethodGet, path, alice, nil, &detail)
+			var got []int
+			for _, watcher := range detail.Watchers {
+				got = append(got, watcher.UserID)
+			}
+			if !reflect.DeepEqual(got, tt.want) || detail.WatcherCount != len(tt.want) {
+				t.Errorf("watchers = %v (count %d), want %v", got, detail.WatcherCount, tt.want)
+			}
+		})
+	}
+
+	t.Run("Watching filter", func(t *testing.T) {
+		serve(t, router, http.MethodPost, "/api/tasks", alice, models.CreateTaskRequest{Title: "Other"}, nil)
+
+		var watched, all []models.Task
+		serve(t, router, http.MethodGet, "/api/tasks?watching=true", carol, nil, &watched)
+		serve(t, router, http.MethodGet, "/api/tasks", carol, nil, &all)
+		if len(watched) != 1 || watched[0].ID != task.ID || len(all) != 2 {
+			t.Errorf("watched = %+v, all = %d tasks, want only the watched task", watched, len(all))
+		}
+	})
+}
+
+func TestCommentRoutes(t *testing.T) {
+	router, _, _ := newTestRouter()
+	alice := register(t, router, "alice@example.com")
+	bob := register(t, router, "bob@example.com")
+
+	var task models.Task
+	serve(t, router, http.MethodPost, "/api/tasks", alice, models.CreateTaskRequest{Title: "Discuss"}, &task)
+	path := "/api/tasks/" + strconv.Itoa(task.ID) + "/comments"
+
+	var comment models.Comment
+	w := serve(t, router, http.MethodPost, path, bob, models.CreateCommentRequest{Content: "**Looks** good"}, &comment)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create comment: status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		body       interface{}
+		wantStatus int
+	}{
+		{"Comment on a missing task", http.MethodPost, "/api/tasks/999/comments", bob, models.CreateCommentRequest{Content: "hi"}, http.StatusNotFound},
+		{"Edit by another user", http.MethodPut, "/api/comments/" + strconv.Itoa(comment.ID), alice, models.UpdateCommentRequest{Content: "edited"}, http.StatusForbidden},
+		{"Edit by author", http.MethodPut, "/api/comments/" + strconv.Itoa(comment.ID), bob, models.UpdateCommentRequest{Content: "edited"}, http.StatusOK},
+		{"List", http.MethodGet, path, alice, nil, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(t, router, tt.method, tt.path, tt.token, tt.body, nil); w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/middleware"
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TimeEntryHandler struct {
+	timeEntryService *services.TimeEntryService
+	changeLogService *services.ChangeLogService
+}
+
+func NewTimeEntryHandler(db *sql.DB) *TimeEntryHandler {
+	return &TimeEntryHandler{
+		timeEntryService: services.NewTimeEntryService(services.NewPostgresTimeEntryRepository(db)),
+		changeLogService: services.NewChangeLogService(services.NewPostgresChangeLogRepository(db)),
+	}
+}
+
+// GetTaskTimeEntries godoc
+// @Summary      Get task time entries
+// @Description  Retrieve all time entries logged on a specific task
+// @Tags         Time Tracking
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Task ID"
+// @Success      200  {array}   models.TimeEntry
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/tasks/{id}/time-entries [get]
+func (h *TimeEntryHandler) GetTaskTimeEntries(c *gin.Context) {
+	taskID := c.Param("id")
+
+	entries, err := h.timeEntryService.GetTaskTimeEntries(taskID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// StartTimer godoc
+// @Summary      Start a timer
+// @Description  Start a timer on a task (a user can only have one running timer)
+// @Tags         Time Tracking
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id     path      int                       true   "Task ID"
+// @Param        timer  body      models.StartTimerRequest  false  "Timer data"
+// @Success      201    {object}  models.TimeEntry
+// @Failure      400    {object}  apperrors.Problem
+// @Failure      401    {object}  apperrors.Problem
+// @Failure      404    {object}  apperrors.Problem
+// @Failure      409    {object}  apperrors.Problem
+// @Failure      500    {object}  apperrors.Problem
+// @Router       /api/tasks/{id}/time-entries/start [post]
+func (h *TimeEntryHandler) StartTimer(c *gin.Context) {
+	taskID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	var req models.StartTimerRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.FromBinding(err))
+			return
+		}
+	}
+
+	entry, err := h.timeEntryService.StartTimer(taskID, req, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// StopTimer godoc
+// @Summary      Stop the running timer
+// @Description  Stop the caller's running timer and record its duration
+// @Tags         Time Tracking
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {object}  models.TimeEntry
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/time-entries/stop [post]
+func (h *TimeEntryHandler) StopTimer(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	entry, err := h.timeEntryService.StopTimer(userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// Log the tracked time
+	_ = h.changeLogService.CreateChangeLog(entry.TaskID, userID, "time_logged",
+		fmt.Sprintf("Logged %s", h.timeEntryService.FormatDuration(entry.DurationSeconds)))
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// GetRunningTimer godoc
+// @Summary      Get the running timer
+// @Description  Retrieve the caller's running timer, if any
+// @Tags         Time Tracking
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {object}  models.TimeEntry
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/time-entries/running [get]
+func (h *TimeEntryHandler) GetRunningTimer(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	entry, err := h.timeEntryService.GetRunningTimer(userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// CreateTimeEntry godoc
+// @Summary      Create a manual time entry
+// @Description  Log time spent on a task with explicit start and end times
+// @Tags         Time Tracking
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id     path      int                            true  "Task ID"
+// @Param        entry  body      models.CreateTimeEntryRequest  true  "Time entry data"
+// @Success      201    {object}  models.TimeEntry
+// @Failure      400    {object}  apperrors.Problem
+// @Failure      401    {object}  apperrors.Problem
+// @Failure      404    {object}  apperrors.Problem
+// @Failure      409    {object}  apperrors.Problem
+// @Failure      500    {object}  apperrors.Problem
+// @Router       /api/tasks/{id}/time-entries [post]
+func (h *TimeEntryHandler) CreateTimeEntry(c *gin.Context) {
+	taskID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	var req models.CreateTimeEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.FromBinding(err))
+		return
+	}
+
+	entry, err := h.timeEntryService.CreateTimeEntry(taskID, req, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// Log the tracked time
+	_ = h.changeLogService.CreateChangeLog(entry.TaskID, userID, "time_logged",
+		fmt.Sprintf("Logged %s", h.timeEntryService.FormatDuration(entry.DurationSeconds)))
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// UpdateTimeEntry godoc
+// @Summary      Update a time entry
+// @Description  Update a time entry (only the entry owner can update)
+// @Tags         Time Tracking
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id     path      int                            true  "Time entry ID"
+// @Param        entry  body      models.UpdateTimeEntryRequest  true  "Updated time entry data"
+// @Success      200    {object}  models.TimeEntry
+// @Failure      400    {object}  apperrors.Problem
+// @Failure      401    {object}  apperrors.Problem
+// @Failure      403    {object}  apperrors.Problem
+// @Failure      404    {object}  apperrors.Problem
+// @Failure      409    {object}  apperrors.Problem
+// @Failure      500    {object}  apperrors.Problem
+// @Router       /api/time-entries/{id} [put]
+func (h *TimeEntryHandler) UpdateTimeEntry(c *gin.Context) {
+	entryID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	var req models.UpdateTimeEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.FromBinding(err))
+		return
+	}
+
+	entry, err := h.timeEntryService.UpdateTimeEntry(entryID, req, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// DeleteTimeEntry godoc
+// @Summary      Delete a time entry
+// @Description  Delete a time entry (only the entry owner can delete)
+// @Tags         Time Tracking
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Time entry ID"
+// @Success      200  {object}  map[string]string
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      403  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/time-entries/{id} [delete]
+func (h *TimeEntryHandler) DeleteTimeEntry(c *gin.Context) {
+	entryID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	entry, err := h.timeEntryService.DeleteTimeEntry(entryID, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// Log the removal
+	_ = h.changeLogService.CreateChangeLog(entry.TaskID, userID, "time_removed",
+		fmt.Sprintf("Removed %s of logged time", h.timeEntryService.FormatDuration(entry.DurationSeconds)))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Time entry deleted successfully"})
+}
+
+// GetTimeReport godoc
+// @Summary      Get time report
+// @Description  Aggregate completed time entries by user and task, optionally filtered by user, task and date range
+// @Tags         Time Tracking
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        user_id  query     int     false  "Filter by user ID"
+// @Param        task_id  query     int     false  "Filter by task ID"
+// @Param        from     query     string  false  "Start of range (YYYY-MM-DD or RFC3339, inclusive)"
+// @Param        to       query     string  false  "End of range (YYYY-MM-DD inclusive, or RFC3339 exclusive)"
+// @Success      200      {object}  models.TimeReport
+// @Failure      400      {object}  apperrors.Problem
+// @Failure      401      {object}  apperrors.Problem
+// @Failure      500      {object}  apperrors.Problem
+// @Router       /api/time-entries/report [get]
+func (h *TimeEntryHandler) GetTimeReport(c *gin.Context) {
+	var filter models.TimeReportFilter
+
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			_ = c.Error(apperrors.Invalid("user_id", "user_id must be an integer"))
+			return
+		}
+		filter.UserID = &userID
+	}
+
+	if taskIDStr := c.Query("task_id"); taskIDStr != "" {
+		taskID, err := strconv.Atoi(taskIDStr)
+		if err != nil {
+			_ = c.Error(apperrors.Invalid("task_id", "task_id must be an integer"))
+			return
+		}
+		filter.TaskID = &taskID
+	}
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, _, err := parseReportTime(fromStr)
+		if err != nil {
+			_ = c.Error(apperrors.Invalid("from", "from must be YYYY-MM-DD or RFC3339"))
+			return
+		}
+		filter.From = &from
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		to, dateOnly, err := parseReportTime(toStr)
+		if err != nil {
+			_ = c.Error(apperrors.Invalid("to", "to must be YYYY-MM-DD or RFC3339"))
+			return
+		}
+		// A plain date includes the whole day
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = &to
+	}
+
+	report, err := h.timeEntryService.GetReport(filter)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func parseReportTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/middleware"
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
+	"candidate-backend/internal/validators"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+	validator      *validators.TaskValidator
+}
+
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		validator:      validators.NewTaskValidator(),
+	}
+}
+
+// GetWebhooks godoc
+// @Summary      Get webhooks
+// @Description  Retrieve the current user's webhook subscriptions. Secrets are not included.
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {array}   models.Webhook
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/webhooks [get]
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	webhooks, err := h.webhookService.GetWebhooks(userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// GetWebhook godoc
+// @Summary      Get webhook by ID
+// @Description  Retrieve one of the current user's webhooks. The secret is not included.
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Webhook ID"
+// @Success      200  {object}  models.Webhook
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      403  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	webhookID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	webhook, err := h.webhookService.GetWebhook(webhookID, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// CreateWebhook godoc
+// @Summary      Create a webhook
+// @Description  Subscribe a URL to task and comment events. Deliveries are signed with the secret in the X-Signature header.
+// @Description  A random secret is generated when none is given; the response is the only one that includes it.
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        webhook  body      models.CreateWebhookRequest  true  "Webhook data"
+// @Success      201      {object}  models.Webhook
+// @Failure      400      {object}  apperrors.Problem
+// @Failure      401      {object}  apperrors.Problem
+// @Failure      500      {object}  apperrors.Problem
+// @Router       /api/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.FromBinding(err))
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(req, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// UpdateWebhook godoc
+// @Summary      Update a webhook
+// @Description  Update a webhook's URL, secret, events or state (only the owner can update). Setting active to true re-enables a disabled webhook.
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id       path      int                          true  "Webhook ID"
+// @Param        webhook  body      models.UpdateWebhookRequest  true  "Updated webhook data"
+// @Success      200      {object}  models.Webhook
+// @Failure      400      {object}  apperrors.Problem
+// @Failure      401      {object}  apperrors.Problem
+// @Failure      403      {object}  apperrors.Problem
+// @Failure      404      {object}  apperrors.Problem
+// @Failure      500      {object}  apperrors.Problem
+// @Router       /api/webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	webhookID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	var req models.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.FromBinding(err))
+		return
+	}
+
+	webhook, err := h.webhookService.UpdateWebhook(webhookID, req, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook godoc
+// @Summary      Delete a webhook
+// @Description  Delete a webhook and its delivery log (only the owner can delete)
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      int  true  "Webhook ID"
+// @Success      200  {object}  map[string]string
+// @Failure      401  {object}  apperrors.Problem
+// @Failure      403  {object}  apperrors.Problem
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /api/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	webhookID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	if err := h.webhookService.DeleteWebhook(webhookID, userID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetDeliveries godoc
+// @Summary      Get webhook deliveries
+// @Description  Retrieve a webhook's delivery log, newest first, with the status, attempts and last response of each delivery
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id      path      int  true   "Webhook ID"
+// @Param        limit   query     int  false  "Limit number of results (default: 20)"
+// @Param        offset  query     int  false  "Offset for pagination (default: 0)"
+// @Success      200     {array}   models.WebhookDelivery
+// @Failure      400     {object}  apperrors.Problem
+// @Failure      401     {object}  apperrors.Problem
+// @Failure      403     {object}  apperrors.Problem
+// @Failure      404     {object}  apperrors.Problem
+// @Failure      500     {object}  apperrors.Problem
+// @Router       /api/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	webhookID := c.Param("id")
+	userID, _ := middleware.GetUserID(c)
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil {
+		_ = c.Error(apperrors.Invalid("limit", "limit must be a number"))
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		_ = c.Error(apperrors.Invalid("offset", "offset must be a number"))
+		return
+	}
+	if err := h.validator.ValidatePagination(limit, offset); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	deliveries, err := h.webhookService.GetDeliveries(webhookID, userID, limit, offset)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// ReplayDelivery godoc
+// @Summary      Replay a webhook delivery
+// @Description  Send the payload of a past delivery again as a new delivery, right away and even when the webhook is disabled.
+// @Description  The new delivery is returned with the outcome of the attempt; a failed replay is retried with backoff once the webhook is active.
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id          path      int  true  "Webhook ID"
+// @Param        deliveryId  path      int  true  "Delivery ID"
+// @Success      201         {object}  models.WebhookDelivery
+// @Failure      401         {object}  apperrors.Problem
+// @Failure      403         {object}  apperrors.Problem
+// @Failure      404         {object}  apperrors.Problem
+// @Failure      500         {object}  apperrors.Problem
+// @Router       /api/webhooks/{id}/deliveries/{deliveryId}/replay [post]
+func (h *WebhookHandler) ReplayDelivery(c *gin.Context) {
+	webhookID := c.Param("id")
+	deliveryID := c.Param("deliveryId")
+	userID, _ := middleware.GetUserID(c)
+
+	delivery, err := h.webhookService.ReplayDelivery(c.Request.Context(), webhookID, deliveryID, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, delivery)
+}
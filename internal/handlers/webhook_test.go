@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/services"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestWebhookRoutes(t *testing.T) {
+	var events []models.WebhookPayload
+	var signatures []string
+	var bodies [][]byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload models.WebhookPayload
+		_ = json.Unmarshal(body, &payload)
+		events = append(events, payload)
+		signatures = append(signatures, r.Header.Get(services.SignatureHeader))
+		bodies = append(bodies, body)
+	}))
+	defer receiver.Close()
+
+	router, dispatcher, webhookService := newTestRouter()
+	alice := register(t, router, "alice@example.com")
+	bob := register(t, router, "bob@example.com")
+
+	const secret = "alice-webhook-secret"
+	var webhook models.Webhook
+	w := serve(t, router, http.MethodPost, "/api/webhooks", alice, models.CreateWebhookRequest{
+		URL:    receiver.URL,
+		Secret: secret,
+		Events: []models.WebhookEvent{models.WebhookTaskCreated, models.WebhookCommentCreated},
+	}, &webhook)
+	if w.Code != http.StatusCreated || webhook.Secret != secret {
+		t.Fatalf("create webhook: status = %d, body = %s", w.Code, w.Body.String())
+	}
+	path := "/api/webhooks/" + strconv.Itoa(webhook.ID)
+
+	var task models.Task
+	serve(t, router, http.MethodPost, "/api/tasks", bob, models.CreateTaskRequest{Title: "Hooked"}, &task)
+	serve(t, router, http.MethodPost, "/api/tasks/"+strconv.Itoa(task.ID)+"/archive", bob, nil, nil)
+	serve(t, router, http.MethodPost, "/api/tasks/"+strconv.Itoa(task.ID)+"/comments", alice, models.CreateCommentRequest{Content: "Noted"}, nil)
+
+	if _, err := dispatcher.DispatchPending(context.Background()); err != nil {
+		t.Fatalf("DispatchPending() error = %v", err)
+	}
+	if _, err := webhookService.ProcessDue(context.Background()); err != nil {
+		t.Fatalf("ProcessDue() error = %v", err)
+	}
+
+	if len(events) != 2 || events[0].Event != models.WebhookTaskCreated || events[1].Event != models.WebhookCommentCreated {
+		t.Fatalf("events = %+v, want task.created and comment.created only", events)
+	}
+	if events[0].ActorID != 2 {
+		t.Errorf("actor_id = %d, want the user who created the task", events[0].ActorID)
+	}
+	for i, body := range bodies {
+		if signatures[i] != services.SignPayload(secret, body) {
+			t.Errorf("delivery %d has signature %q, want the HMAC of its body", i, signatures[i])
+		}
+	}
+
+	var deliveries []models.WebhookDelivery
+	serve(t, router, http.MethodGet, path+"/deliveries", alice, nil, &deliveries)
+	if len(deliveries) != 2 || deliveries[1].Status != models.DeliverySucceeded {
+		t.Fatalf("deliveries = %+v, want 2 succeeded deliveries", deliveries)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{"Deliveries of another user's webhook", http.MethodGet, path + "/deliveries", bob, http.StatusForbidden},
+		{"Replay by another user", http.MethodPost, path + "/deliveries/" + strconv.Itoa(deliveries[1].ID) + "/replay", bob, http.StatusForbidden},
+		{"Replay of an unknown delivery", http.MethodPost, path + "/deliveries/999/replay", alice, http.StatusNotFound},
+		{"Replay", http.MethodPost, path + "/deliveries/" + strconv.Itoa(deliveries[1].ID) + "/replay", alice, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(t, router, tt.method, tt.path, tt.token, nil, nil); w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+
+	if len(events) != 3 || events[2].ID != events[0].ID {
+		t.Errorf("events = %+v, want the replay to repeat the task.created event", events)
+	}
+}
@@ -0,0 +1,113 @@
+// Package mail sends the emails users get about their tasks. Mailer is implemented
+// over SMTP, and by a file and an in-memory sink for local runs and tests.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Message is an email with a plain text and an HTML body
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	// Headers are extra headers such as List-Unsubscribe
+	Headers map[string]string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Encode builds the RFC 5322 message sent from from, with the text and HTML bodies
+// as quoted-printable multipart/alternative parts
+func Encode(msg Message, from string, date time.Time) ([]byte, error) {
+	if _, err := mail.ParseAddress(msg.To); err != nil {
+		return nil, fmt.Errorf("invalid recipient %q: %w", msg.To, err)
+	}
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender %q: %w", from, err)
+	}
+
+	var buf bytes.Buffer
+	body := multipart.NewWriter(&buf)
+
+	headers := map[string]string{
+		"From":         from,
+		"To":           msg.To,
+		"Subject":      mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date":         date.Format(time.RFC1123Z),
+		"Message-ID":   messageID(sender.Address),
+		"MIME-Version": "1.0",
+		"Content-Type": mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": body.Boundary()}),
+	}
+	for name, value := range msg.Headers {
+		headers[textproto.CanonicalMIMEHeaderKey(name)] = value
+	}
+
+	var head bytes.Buffer
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.ContainsAny(headers[name], "\r\n") {
+			return nil, fmt.Errorf("header %s contains a line break", name)
+		}
+		fmt.Fprintf(&head, "%s: %s\r\n", name, headers[name])
+	}
+	head.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		writer, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoder := quotedprintable.NewWriter(writer)
+		if _, err := encoder.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+
+	return append(head.Bytes(), buf.Bytes()...), nil
+}
+
+// messageID returns a unique Message-ID at the sender's domain
+func messageID(sender string) string {
+	random := make([]byte, 16)
+	_, _ = rand.Read(random)
+
+	domain := "localhost"
+	if at := strings.LastIndex(sender, "@"); at >= 0 {
+		domain = sender[at+1:]
+	}
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(random), domain)
+}
@@ -0,0 +1,106 @@
+package mail
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	notification := map[string]interface{}{
+		"Name":           "Bob",
+		"ActorName":      "Alice",
+		"Message":        "Alice mentioned you in <Ship it>",
+		"TaskTitle":      "Ship it",
+		"TaskURL":        "http://app.test/tasks/1",
+		"UnsubscribeURL": "http://api.test/email/unsubscribe?token=t",
+	}
+	digest := map[string]interface{}{
+		"Name": "Bob",
+		"Notifications": []map[string]interface{}{
+			{"Message": "Alice commented on Ship it", "TaskURL": "http://app.test/tasks/1", "CreatedAt": time.Now()},
+		},
+		"UnsubscribeURL": "http://api.test/email/unsubscribe?token=t",
+	}
+
+	tests := []struct {
+		name string
+		data interface{}
+	}{
+		{"assigned", notification},
+		{"mention", notification},
+		{"due_soon", notification},
+		{"digest", digest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := Render(tt.name, tt.data)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if msg.Subject == "" || strings.Contains(msg.Subject, "\n") {
+				t.Errorf("subject = %q, want one line", msg.Subject)
+			}
+			for _, body := range []string{msg.Text, msg.HTML} {
+				if !strings.Contains(body, "http://app.test/tasks/1") || !strings.Contains(body, "unsubscribe?token=t") {
+					t.Errorf("body %q lacks the task or unsubscribe link", body)
+				}
+			}
+			if strings.Contains(msg.HTML, "<Ship it>") {
+				t.Errorf("HTML body does not escape the message: %q", msg.HTML)
+			}
+		})
+	}
+
+	if _, err := Render("missing", notification); err == nil {
+		t.Error("Render() of an unknown email succeeded")
+	}
+}
+
+func TestEncode(t *testing.T) {
+	from := "Tasks <no-reply@example.com>"
+	msg := Message{To: "bob@example.com", Subject: "Déjà vu", Text: "Hi\n", HTML: "<p>Hi</p>"}
+
+	tests := []struct {
+		name    string
+		modify  func(msg *Message)
+		wantErr bool
+	}{
+		{"Valid message", func(msg *Message) {}, false},
+		{"Invalid recipient", func(msg *Message) { msg.To = "bob" }, true},
+		{"Header injection", func(msg *Message) { msg.Headers = map[string]string{"List-Unsubscribe": "<x>\r\nBcc: eve@example.com"} }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := msg
+			tt.modify(&msg)
+			data, err := Encode(msg, from, time.Now())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Encode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			parsed, err := mail.ReadMessage(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("ReadMessage() error = %v", err)
+			}
+			if subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject")); subject != msg.Subject {
+				t.Errorf("subject = %q, want %q", subject, msg.Subject)
+			}
+			if parsed.Header.Get("To") != msg.To || parsed.Header.Get("Message-Id") == "" {
+				t.Errorf("headers = %v, want To and Message-ID", parsed.Header)
+			}
+			if body, _ := io.ReadAll(parsed.Body); !bytes.Contains(body, []byte("<p>Hi</p>")) {
+				t.Errorf("body = %s, want the HTML part", body)
+			}
+		})
+	}
+}
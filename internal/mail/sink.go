@@ -0,0 +1,85 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileMailer writes each email as an .eml file into a directory instead of sending
+// it, so local runs can open them in a mail client
+type FileMailer struct {
+	dir  string
+	from string
+	now  func() time.Time
+
+	mu   sync.Mutex
+	sent int
+}
+
+// NewFileMailer creates a file sink writing into dir
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &FileMailer{dir: dir, from: from, now: time.Now}, nil
+}
+
+// Send writes the message to a new file named after the time it was sent
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	date := m.now()
+	data, err := Encode(msg, m.from, date)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.sent++
+	name := fmt.Sprintf("%s-%04d.eml", date.UTC().Format("20060102T150405"), m.sent)
+	m.mu.Unlock()
+
+	return os.WriteFile(filepath.Join(m.dir, name), data, 0o640)
+}
+
+// MemoryMailer keeps the emails it is sent, for tests
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+	err      error
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return m.err
+	}
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Fail makes every later Send return err, or succeed again when err is nil
+func (m *MemoryMailer) Fail(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.err = err
+}
+
+// Messages returns the emails sent so far, oldest first, and forgets them
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messages := m.messages
+	m.messages = nil
+	return messages
+}
@@ -0,0 +1,111 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPConfig configures delivery through an SMTP server
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Username and Password authenticate with PLAIN auth when Username is set
+	Username string
+	Password string
+	// From is the sender, e.g. "Tasks <no-reply@example.com>"
+	From string
+	// Timeout bounds sending one message
+	Timeout time.Duration
+}
+
+// SMTPMailer sends emails through an SMTP server, upgrading the connection with
+// STARTTLS when the server offers it
+type SMTPMailer struct {
+	config SMTPConfig
+	now    func() time.Time
+}
+
+// NewSMTPMailer creates an SMTP mailer
+func NewSMTPMailer(config SMTPConfig) (*SMTPMailer, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("SMTP host is required")
+	}
+	if _, err := mail.ParseAddress(config.From); err != nil {
+		return nil, fmt.Errorf("invalid sender %q: %w", config.From, err)
+	}
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	return &SMTPMailer{config: config, now: time.Now}, nil
+}
+
+// Send delivers a message to its recipient
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	data, err := Encode(msg, m.config.From, m.now())
+	if err != nil {
+		return err
+	}
+	sender, _ := mail.ParseAddress(m.config.From)
+	recipient, _ := mail.ParseAddress(msg.To)
+
+	ctx, cancel := context.WithTimeout(ctx, m.config.Timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.config.Host}); err != nil {
+			return err
+		}
+	}
+	if m.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(sender.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(recipient.Address); err != nil {
+		return err
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+// Each email has a template NAME.txt defining NAME.subject and NAME.text, and a
+// template NAME.html defining NAME.html. The layout files hold the shared header
+// and footers: notification emails end with footer, whose unsubscribe link comes
+// from the data's UnsubscribeURL, and emails about the account with account_footer.
+var (
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFiles, "templates/*.txt"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFiles, "templates/*.html"))
+)
+
+// Render builds the subject and bodies of the email called name from data
+func Render(name string, data interface{}) (Message, error) {
+	var subject, text, html bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&subject, name+".subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := textTemplates.ExecuteTemplate(&text, name+".text", data); err != nil {
+		return Message{}, err
+	}
+	if err := htmlTemplates.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
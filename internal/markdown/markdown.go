@@ -0,0 +1,99 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+)
+
+// Format selects which representation of a text field an API response carries
+type Format string
+
+const (
+	FormatRaw  Format = "raw"
+	FormatHTML Format = "html"
+	FormatBoth Format = "both"
+)
+
+// ParseFormat parses a format query value; an empty value means raw
+func ParseFormat(value string) (Format, error) {
+	switch Format(value) {
+	case "", FormatRaw:
+		return FormatRaw, nil
+	case FormatHTML, FormatBoth:
+		return Format(value), nil
+	}
+
+	return "", fmt.Errorf("format must be 'raw', 'html' or 'both'")
+}
+
+// IncludesRaw reports whether the raw text is returned
+func (f Format) IncludesRaw() bool {
+	return f != FormatHTML
+}
+
+// IncludesHTML reports whether the rendered HTML is returned
+func (f Format) IncludesHTML() bool {
+	return f == FormatHTML || f == FormatBoth
+}
+
+// AllowedTags lists the HTML elements kept in rendered output
+var AllowedTags = []string{
+	"p", "br", "hr", "h1", "h2", "h3", "h4", "h5", "h6",
+	"blockquote", "pre", "code", "em", "strong", "ul", "ol", "li", "a", "img",
+}
+
+var codeLanguage = regexp.MustCompile(`^language-[\w+-]+$`)
+
+// Renderer converts CommonMark to sanitized HTML. It is safe for concurrent use.
+type Renderer struct {
+	markdown goldmark.Markdown
+	policy   *bluemonday.Policy
+}
+
+func NewRenderer() *Renderer {
+	return &Renderer{
+		markdown: goldmark.New(),
+		policy:   newPolicy(),
+	}
+}
+
+// Render converts CommonMark source to HTML. Raw HTML in the source is dropped and
+// the output keeps only AllowedTags; links may only point to http, https and mailto
+// URLs or relative paths, and absolute links open in a new tab without a referrer.
+func (r *Renderer) Render(source string) string {
+	if source == "" {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	if err := r.markdown.Convert([]byte(source), &buf); err != nil {
+		// Converting into a buffer cannot fail; never return unsanitized text regardless
+		return ""
+	}
+
+	return strings.TrimSpace(r.policy.Sanitize(buf.String()))
+}
+
+func newPolicy() *bluemonday.Policy {
+	policy := bluemonday.NewPolicy()
+	policy.AllowElements(AllowedTags...)
+
+	policy.AllowAttrs("href", "title").OnElements("a")
+	policy.AllowAttrs("src", "alt", "title").OnElements("img")
+	policy.AllowAttrs("start").Matching(bluemonday.Integer).OnElements("ol")
+	policy.AllowAttrs("class").Matching(codeLanguage).OnElements("code")
+
+	policy.AllowURLSchemes("http", "https", "mailto")
+	policy.AllowRelativeURLs(true)
+	policy.RequireParseableURLs(true)
+	policy.RequireNoFollowOnLinks(true)
+	policy.RequireNoReferrerOnLinks(true)
+	policy.AddTargetBlankToFullyQualifiedLinks(true)
+
+	return policy
+}
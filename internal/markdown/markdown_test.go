@@ -0,0 +1,57 @@
+package markdown
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	renderer := NewRenderer()
+
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"Empty", "", ""},
+		{"Inline formatting", "**bold** _em_ `code`", "<p><strong>bold</strong> <em>em</em> <code>code</code></p>"},
+		{"Escapes text", "a & b < c", "<p>a &amp; b &lt; c</p>"},
+		{"Raw script dropped", "<script>alert(1)</script>", ""},
+		{"Raw HTML attributes dropped", `<a href="https://example.com" onclick="x">hi</a>`, "<p>hi</p>"},
+		{"Raw image dropped", "<img src=x onerror=alert(1)>", ""},
+		{"Javascript link removed", "[x](javascript:alert(1))", "<p>x</p>"},
+		{"Data link removed", "[x](data:text/html;base64,PHNjcmlwdD4=)", "<p>x</p>"},
+		{"External link opens in new tab", "[x](https://example.com)", `<p><a href="https://example.com" rel="nofollow noreferrer noopener" target="_blank">x</a></p>`},
+		{"Relative link", "[x](/tasks/1)", `<p><a href="/tasks/1" rel="nofollow noreferrer">x</a></p>`},
+		{"Code block language", "```go\nx := 1\n```", "<pre><code class=\"language-go\">x := 1\n</code></pre>"},
+		{"Ordered list start", "3. three", "<ol start=\"3\">\n<li>three</li>\n</ol>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderer.Render(tt.source); got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Format
+		wantErr bool
+	}{
+		{"", FormatRaw, false},
+		{"raw", FormatRaw, false},
+		{"html", FormatHTML, false},
+		{"both", FormatBoth, false},
+		{"markdown", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseFormat(tt.value)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseFormat() = %q, %v, want %q, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
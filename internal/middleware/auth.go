@@ -1,7 +1,12 @@
 package middleware
 
 import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/models"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -11,37 +16,90 @@ import (
 type Claims struct {
 	UserID int    `json:"user_id"`
 	Email  string `json:"email"`
+	// SessionVersion is the user's session version when the token was issued
+	SessionVersion int `json:"session_version"`
+	// Purpose is set on tokens that are not sessions, like the MFA challenge of a
+	// login, which AuthMiddleware rejects
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// Sessions tells which tokens of a user are still valid
+type Sessions interface {
+	// SessionVersion returns the version tokens issued to the user must carry, or a
+	// not-found error when the user is gone
+	SessionVersion(userID int) (int, error)
+}
+
+// AccessTokens authenticates personal access tokens
+type AccessTokens interface {
+	// AuthenticateAccessToken returns the token with the secret and records that it
+	// is used, or an unauthorized error when it is unknown, revoked or expired
+	AuthenticateAccessToken(secret string) (*models.AccessToken, error)
+}
+
+// tokenScopesKey holds the scopes of the personal access token a request is
+// authenticated with; it is not set for sessions
+const tokenScopesKey = "token_scopes"
+
+// AuthMiddleware accepts valid tokens issued with the user's current session
+// version; resetting a password bumps the version and so revokes older tokens.
+// It also accepts personal access tokens, whose scopes RequireScopes checks.
+func AuthMiddleware(jwtSecret string, sessions Sessions, accessTokens AccessTokens) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+
+		// Browsers cannot set headers on WebSocket handshakes, so those may pass the
+		// token in the access_token query parameter instead
+		if token := c.Query("access_token"); authHeader == "" && token != "" && isWebSocketUpgrade(c) {
+			authHeader = "Bearer " + token
+		}
+
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+			AbortWithError(c, apperrors.Unauthorized("authorization_required", "Authorization header required"))
 			return
 		}
 
 		// Extract token from "Bearer <token>"
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
-			c.Abort()
+			AbortWithError(c, apperrors.Unauthorized("invalid_authorization_header", "Invalid authorization header format"))
 			return
 		}
 
 		tokenString := parts[1]
 
+		if strings.HasPrefix(tokenString, models.AccessTokenPrefix) {
+			accessToken, err := accessTokens.AuthenticateAccessToken(tokenString)
+			if err != nil {
+				AbortWithError(c, err)
+				return
+			}
+
+			c.Set("user_id", accessToken.UserID)
+			c.Set(tokenScopesKey, accessToken.Scopes)
+			c.Next()
+			return
+		}
+
 		// Parse and validate token
 		claims := &Claims{}
 		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 			return []byte(jwtSecret), nil
 		})
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+		if err != nil || !token.Valid || claims.Purpose != "" {
+			AbortWithError(c, apperrors.Unauthorized("invalid_token", "Invalid or expired token"))
+			return
+		}
+
+		version, err := sessions.SessionVersion(claims.UserID)
+		if errors.Is(err, apperrors.ErrNotFound) || (err == nil && version != claims.SessionVersion) {
+			AbortWithError(c, apperrors.Unauthorized("invalid_token", "Invalid or expired token"))
+			return
+		}
+		if err != nil {
+			AbortWithError(c, err)
 			return
 		}
 
@@ -52,6 +110,108 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 	}
 }
 
+// Users looks up the users requests are authenticated as
+type Users interface {
+	GetUser(userID int) (*models.User, error)
+}
+
+// RequireVerifiedEmail rejects requests that change data from users who have not
+// verified their email. Reads go through. It runs after AuthMiddleware.
+func RequireVerifiedEmail(users Users) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		userID, _ := GetUserID(c)
+		user, err := users.GetUser(userID)
+		if err != nil {
+			AbortWithError(c, err)
+			return
+		}
+		if user.EmailVerifiedAt == nil {
+			AbortWithError(c, apperrors.Forbidden("email_not_verified", "Verify your email address before making changes"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireAdmin rejects requests from users who are not admins. It runs after
+// AuthMiddleware.
+func RequireAdmin(users Users) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := GetUserID(c)
+		user, err := users.GetUser(userID)
+		if err != nil {
+			AbortWithError(c, err)
+			return
+		}
+		if !user.IsAdmin {
+			AbortWithError(c, apperrors.Forbidden("admin_required", "Only admins may do this"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireScopes lets requests authenticated with a personal access token through
+// when the token has the write scope, or the read scope for reads. Sessions may do
+// anything. It runs after AuthMiddleware.
+func RequireScopes(read, write models.TokenScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, ok := c.Get(tokenScopesKey)
+		if !ok {
+			c.Next()
+			return
+		}
+		scopes := value.([]models.TokenScope)
+
+		allowed, required := slices.Contains(scopes, write), write
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			allowed, required = allowed || slices.Contains(scopes, read), read
+		}
+		if !allowed {
+			AbortWithError(c, apperrors.Forbidden("insufficient_scope", fmt.Sprintf("This access token needs the %s scope", required)))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// HasScope reports whether the request may use a scope. Sessions may use every
+// scope; access tokens only the ones they were given. It runs after AuthMiddleware.
+func HasScope(c *gin.Context, scope models.TokenScope) bool {
+	value, ok := c.Get(tokenScopesKey)
+	if !ok {
+		return true
+	}
+	return slices.Contains(value.([]models.TokenScope), scope)
+}
+
+// RequireSession rejects requests authenticated with a personal access token, for
+// routes that manage the account. It runs after AuthMiddleware.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(tokenScopesKey); ok {
+			AbortWithError(c, apperrors.Forbidden("session_required", "Access tokens cannot be used here, sign in instead"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func isWebSocketUpgrade(c *gin.Context) bool {
+	return strings.EqualFold(c.GetHeader("Upgrade"), "websocket")
+}
+
 func GetUserID(c *gin.Context) (int, bool) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"candidate-backend/internal/apperrors"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// ErrorHandler renders the last error attached to the context with c.Error as an
+// application/problem+json response. Unexpected errors are logged and reported
+// without their message.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		problem := apperrors.NewProblem(err, c.Request.URL.Path)
+		if problem.Code == apperrors.CodeInternal {
+			log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+
+		c.Header("Content-Type", apperrors.ContentType)
+		c.JSON(problem.Status, problem)
+	}
+}
+
+// AbortWithError stops the handler chain and leaves err for ErrorHandler to render
+func AbortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// UseJSONFieldNames makes request validation errors name fields by their JSON keys
+func UseJSONFieldNames() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
+}
@@ -1,8 +1,9 @@
 package middleware
 
 import (
+	"candidate-backend/internal/apperrors"
 	"fmt"
-	"net/http"
+	"math"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -26,8 +27,7 @@ func RateLimitMiddleware() gin.HandlerFunc {
 
 		context, err := instance.Get(c.Request.Context(), key)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Rate limiter error"})
-			c.Abort()
+			AbortWithError(c, err)
 			return
 		}
 
@@ -43,11 +43,8 @@ func RateLimitMiddleware() gin.HandlerFunc {
 				retryAfter = 0
 			}
 
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "Rate limit exceeded",
-				"retry_after": retryAfter,
-			})
-			c.Abort()
+			c.Header("Retry-After", fmt.Sprintf("%.0f", math.Ceil(retryAfter)))
+			AbortWithError(c, apperrors.New(apperrors.ErrRateLimited, "rate_limited", "Rate limit exceeded"))
 			return
 		}
 
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// AccessTokenPrefix starts every personal access token, which tells them apart
+// from JWTs
+const AccessTokenPrefix = "pat_"
+
+// TokenScope is a permission of a personal access token
+type TokenScope string
+
+const (
+	ScopeTasksRead  TokenScope = "tasks:read"
+	ScopeTasksWrite TokenScope = "tasks:write"
+	ScopeComments   TokenScope = "comments"
+	ScopeAdmin      TokenScope = "admin"
+)
+
+// TokenScopes lists the scopes a personal access token can have
+var TokenScopes = []TokenScope{
+	ScopeTasksRead,
+	ScopeTasksWrite,
+	ScopeComments,
+	ScopeAdmin,
+}
+
+// AccessToken is a personal access token, which scripts use in place of a session
+type AccessToken struct {
+	ID     int    `json:"id"`
+	UserID int    `json:"-"`
+	Name   string `json:"name"`
+	// Token is only returned when the token is created
+	Token string `json:"token,omitempty"`
+	// Prefix is the start of the token, to recognize it by
+	Prefix     string       `json:"prefix"`
+	Scopes     []TokenScope `json:"scopes"`
+	ExpiresAt  *time.Time   `json:"expires_at"`
+	LastUsedAt *time.Time   `json:"last_used_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type CreateAccessTokenRequest struct {
+	Name   string       `json:"name" binding:"required"`
+	Scopes []TokenScope `json:"scopes" binding:"required"`
+	// ExpiresAt is optional; tokens without it work until revoked
+	ExpiresAt *time.Time `json:"expires_at"`
+}
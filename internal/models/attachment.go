@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type Attachment struct {
+	ID           int       `json:"id"`
+	TaskID       int       `json:"task_id"`
+	CommentID    *int      `json:"comment_id,omitempty"`
+	UploaderID   int       `json:"uploader_id"`
+	UploaderName string    `json:"uploader_name,omitempty"`
+	Filename     string    `json:"filename"`
+	ContentType  string    `json:"content_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	StorageKey   string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type AttachmentURL struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -3,17 +3,48 @@ package models
 import "time"
 
 type Comment struct {
-	ID        int       `json:"id"`
-	TaskID    int       `json:"task_id"`
-	UserID    int       `json:"user_id"`
-	UserName  string    `json:"user_name,omitempty"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          int               `json:"id"`
+	TaskID      int               `json:"task_id"`
+	ParentID    *int              `json:"parent_id,omitempty"`
+	Depth       int               `json:"depth"`
+	UserID      int               `json:"user_id,omitempty"`
+	UserName    string            `json:"user_name,omitempty"`
+	Content     string            `json:"content,omitempty"`
+	ContentHTML string            `json:"content_html,omitempty"`
+	Deleted     bool              `json:"deleted"`
+	Edited      bool              `json:"edited"`
+	EditCount   int               `json:"edit_count"`
+	Mentions    []Mention         `json:"mentions,omitempty"`
+	Reactions   []ReactionSummary `json:"reactions,omitempty"`
+	Replies     []Comment         `json:"replies,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// CommentRevision is a previous version of a comment's content
+type CommentRevision struct {
+	ID             int       `json:"id"`
+	CommentID      int       `json:"comment_id"`
+	Revision       int       `json:"revision"`
+	Content        string    `json:"content"`
+	WrittenAt      time.Time `json:"written_at"`
+	ReplacedBy     int       `json:"replaced_by"`
+	ReplacedByName string    `json:"replaced_by_name,omitempty"`
+	ReplacedAt     time.Time `json:"replaced_at"`
+}
+
+// CommentFilter selects a page of a task's comments
+type CommentFilter struct {
+	Limit  int
+	Cursor string     // opaque position after which the page starts
+	Order  string     // "asc" or "desc" by creation time
+	Since  *time.Time // only comments created after this time
+	UserID *int       // only comments by this author
 }
 
 type CreateCommentRequest struct {
-	Content string `json:"content" binding:"required"`
+	Content  string `json:"content" binding:"required"`
+	ParentID *int   `json:"parent_id"`
 }
 
 type UpdateCommentRequest struct {
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// EmailKind is a kind of email users can turn off: one per emailed notification type,
+// and the daily digest
+type EmailKind string
+
+const (
+	EmailAssigned EmailKind = "assigned"
+	EmailMention  EmailKind = "mention"
+	EmailDueSoon  EmailKind = "due_soon"
+	EmailDigest   EmailKind = "digest"
+)
+
+// EmailKinds lists the kinds of email a user can turn on or off
+var EmailKinds = []EmailKind{
+	EmailAssigned,
+	EmailMention,
+	EmailDueSoon,
+	EmailDigest,
+}
+
+// EmailPreferences tells for each kind of email whether the user gets it
+type EmailPreferences map[EmailKind]bool
+
+// Digest is a user's unread notifications since their last digest, newest first
+type Digest struct {
+	User          User
+	Since         time.Time
+	Notifications []Notification
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventTaskCreated    EventType = "task.created"
+	EventTaskUpdated    EventType = "task.updated"
+	EventTaskArchived   EventType = "task.archived"
+	EventTaskUnarchived EventType = "task.unarchived"
+	EventTaskDeleted    EventType = "task.deleted"
+
+	EventCommentCreated EventType = "comment.created"
+	EventCommentUpdated EventType = "comment.updated"
+	EventCommentDeleted EventType = "comment.deleted"
+)
+
+// IsComment reports whether the event is about a comment, which access tokens need
+// the comments scope to read
+func (t EventType) IsComment() bool {
+	return strings.HasPrefix(string(t), "comment.")
+}
+
+// DomainEvent is a change to a task or its comments. It is written to the outbox in
+// the same transaction as the change and published to subscribers afterwards.
+type DomainEvent struct {
+	ID        int64     `json:"id"`
+	Type      EventType `json:"type"`
+	TaskID    int       `json:"task_id"`
+	CommentID *int      `json:"comment_id,omitempty"`
+	ActorID   int       `json:"actor_id"`
+	// Payload is a TaskEventPayload or CommentEventPayload
+	Payload   json.RawMessage `json:"payload" swaggertype:"object"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// TaskEventPayload is the payload of task events, with the task as it is after the
+// change (or before it, for task.deleted)
+type TaskEventPayload struct {
+	Task Task `json:"task"`
+	// Changes describes the updated fields of a task.updated event
+	Changes []string `json:"changes,omitempty"`
+	// Fields holds the JSON names of the fields a task.updated event changed
+	Fields []string `json:"fields,omitempty"`
+	// Mentioned holds the users the description mentions that it did not mention before
+	Mentioned []int `json:"mentioned,omitempty"`
+}
+
+// CommentEventPayload is the payload of comment events, with the comment as it is
+// after the change (or before it, for comment.deleted)
+type CommentEventPayload struct {
+	Comment Comment `json:"comment"`
+	// Mentioned holds the users the comment mentions that it did not mention before
+	Mentioned []int `json:"mentioned,omitempty"`
+}
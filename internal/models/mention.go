@@ -0,0 +1,10 @@
+package models
+
+// Mention is a resolved @mention. Start and End are character (Unicode code point)
+// offsets of the "@handle" text, End exclusive.
+type Mention struct {
+	UserID   int    `json:"user_id"`
+	UserName string `json:"user_name"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+type NotificationType string
+
+const (
+	NotificationMention  NotificationType = "mention"
+	NotificationAssigned NotificationType = "assigned"
+	NotificationComment  NotificationType = "comment"
+	NotificationStatus   NotificationType = "status_changed"
+	NotificationDueSoon  NotificationType = "due_soon"
+)
+
+// NotificationTypes lists the notification types a user can turn on or off
+var NotificationTypes = []NotificationType{
+	NotificationMention,
+	NotificationAssigned,
+	NotificationComment,
+	NotificationStatus,
+	NotificationDueSoon,
+}
+
+type Notification struct {
+	ID        int              `json:"id"`
+	UserID    int              `json:"user_id"`
+	ActorID   *int             `json:"actor_id,omitempty"`
+	ActorName string           `json:"actor_name,omitempty"`
+	Type      NotificationType `json:"type"`
+	TaskID    *int             `json:"task_id,omitempty"`
+	CommentID *int             `json:"comment_id,omitempty"`
+	Message   string           `json:"message"`
+	Read      bool             `json:"read"`
+	CreatedAt time.Time        `json:"created_at"`
+	// EventID is the domain event the notification is about, if any
+	EventID *int64 `json:"-"`
+}
+
+// NotificationFilter selects a page of a user's notifications
+type NotificationFilter struct {
+	UnreadOnly bool
+	Limit      int
+	Offset     int
+}
+
+// NotificationPreferences tells for each notification type whether the user gets it
+type NotificationPreferences map[NotificationType]bool
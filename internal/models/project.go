@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+type CustomFieldType string
+
+const (
+	FieldTypeText         CustomFieldType = "text"
+	FieldTypeNumber       CustomFieldType = "number"
+	FieldTypeDate         CustomFieldType = "date"
+	FieldTypeSingleSelect CustomFieldType = "single_select"
+	FieldTypeMultiSelect  CustomFieldType = "multi_select"
+	FieldTypeUser         CustomFieldType = "user"
+)
+
+type Project struct {
+	ID          int           `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	CreatorID   int           `json:"creator_id"`
+	CreatorName string        `json:"creator_name,omitempty"`
+	Fields      []CustomField `json:"fields,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+type CustomField struct {
+	ID        int             `json:"id"`
+	ProjectID int             `json:"project_id"`
+	Key       string          `json:"key"`
+	Name      string          `json:"name"`
+	Type      CustomFieldType `json:"type"`
+	Options   []string        `json:"options,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type CreateProjectRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type UpdateProjectRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
+type CreateCustomFieldRequest struct {
+	Key     string          `json:"key" binding:"required"`
+	Name    string          `json:"name" binding:"required"`
+	Type    CustomFieldType `json:"type" binding:"required"`
+	Options []string        `json:"options"`
+}
+
+type UpdateCustomFieldRequest struct {
+	Name    *string  `json:"name"`
+	Options []string `json:"options"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// ReactionSummary aggregates the reactions with one emoji on a task or comment
+type ReactionSummary struct {
+	Emoji   string `json:"emoji"`
+	Count   int    `json:"count"`
+	Reacted bool   `json:"reacted"`
+}
@@ -0,0 +1,51 @@
+package models
+
+// RealtimeType names a WebSocket message
+type RealtimeType string
+
+const (
+	// Sent by clients
+	RealtimeSubscribe   RealtimeType = "subscribe"
+	RealtimeUnsubscribe RealtimeType = "unsubscribe"
+
+	// Sent by the server
+	RealtimeSubscribed   RealtimeType = "subscribed"
+	RealtimeUnsubscribed RealtimeType = "unsubscribed"
+	RealtimeEvent        RealtimeType = "event"
+	RealtimePresence     RealtimeType = "presence"
+	RealtimeError        RealtimeType = "error"
+
+	// Sent by clients and relayed to the other viewers of the task
+	RealtimeTyping RealtimeType = "typing"
+)
+
+// RealtimeRequest is a message a client sends over the WebSocket. Subscriptions name
+// either a task or a project.
+type RealtimeRequest struct {
+	Type      RealtimeType `json:"type"`
+	TaskID    *int         `json:"task_id,omitempty"`
+	ProjectID *int         `json:"project_id,omitempty"`
+	// Typing tells whether the user started or stopped typing a comment
+	Typing bool `json:"typing,omitempty"`
+}
+
+// RealtimeMessage is a message the server sends over the WebSocket
+type RealtimeMessage struct {
+	Type      RealtimeType `json:"type"`
+	TaskID    *int         `json:"task_id,omitempty"`
+	ProjectID *int         `json:"project_id,omitempty"`
+	// Event is the domain event of an event message
+	Event *DomainEvent `json:"event,omitempty"`
+	// Viewers lists the users viewing the task of a presence message
+	Viewers []RealtimeUser `json:"viewers,omitempty"`
+	// User and Typing describe a typing message
+	User   *RealtimeUser `json:"user,omitempty"`
+	Typing *bool         `json:"typing,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// RealtimeUser identifies a connected user
+type RealtimeUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
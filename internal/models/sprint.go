@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+type Sprint struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Goal        string     `json:"goal"`
+	StartDate   time.Time  `json:"start_date"`
+	EndDate     time.Time  `json:"end_date"`
+	CreatorID   int        `json:"creator_id"`
+	CreatorName string     `json:"creator_name,omitempty"`
+	Closed      bool       `json:"closed"`
+	ClosedAt    *time.Time `json:"closed_at,omitempty"`
+	TaskCount   int        `json:"task_count"`
+	TotalPoints int        `json:"total_points"`
+	DonePoints  int        `json:"done_points"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type CreateSprintRequest struct {
+	Name      string `json:"name" binding:"required"`
+	Goal      string `json:"goal"`
+	StartDate string `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate   string `json:"end_date" binding:"required"`   // YYYY-MM-DD
+}
+
+type UpdateSprintRequest struct {
+	Name      *string `json:"name"`
+	Goal      *string `json:"goal"`
+	StartDate *string `json:"start_date"`
+	EndDate   *string `json:"end_date"`
+}
+
+type CloseSprintRequest struct {
+	NextSprintID *int `json:"next_sprint_id"`
+}
+
+type CloseSprintResponse struct {
+	Sprint             Sprint  `json:"sprint"`
+	NextSprint         *Sprint `json:"next_sprint,omitempty"`
+	CarriedOverTaskIDs []int   `json:"carried_over_task_ids"`
+}
+
+type BurndownPoint struct {
+	Date            string  `json:"date"`
+	RemainingPoints *int    `json:"remaining_points,omitempty"` // nil for days still ahead
+	CompletedPoints int     `json:"completed_points"`
+	IdealPoints     float64 `json:"ideal_points"`
+}
+
+type Burndown struct {
+	SprintID    int             `json:"sprint_id"`
+	TotalPoints int             `json:"total_points"`
+	Points      []BurndownPoint `json:"points"`
+}
@@ -11,16 +11,36 @@ const (
 )
 
 type Task struct {
-	ID          int        `json:"id"`
-	Title       string     `json:"title"`
-	Description string     `json:"description"`
-	Status      TaskStatus `json:"status"`
-	CreatorID   int        `json:"creator_id"`
-	CreatorName string     `json:"creator_name,omitempty"`
-	DueDate     *time.Time `json:"due_date,omitempty"`
-	Archived    bool       `json:"archived"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID               int        `json:"id"`
+	Title            string     `json:"title"`
+	Description      string     `json:"description,omitempty"`
+	DescriptionHTML  string     `json:"description_html,omitempty"`
+	Status           TaskStatus `json:"status"`
+	CreatorID        int        `json:"creator_id"`
+	CreatorName      string     `json:"creator_name,omitempty"`
+	AssigneeID       *int       `json:"assignee_id,omitempty"`
+	DueDate          *time.Time `json:"due_date,omitempty"`
+	Archived         bool       `json:"archived"`
+	ProjectID        *int       `json:"project_id,omitempty"`
+	SprintID         *int       `json:"sprint_id,omitempty"`
+	StoryPoints      *int       `json:"story_points,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	TotalTimeSeconds int64      `json:"total_time_seconds"`
+	CommentCount     int        `json:"comment_count"`
+	WatcherCount     int        `json:"watcher_count"`
+
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+	Reactions    []ReactionSummary      `json:"reactions,omitempty"`
+	// Watchers is only filled in on the task detail
+	Watchers []Watcher `json:"watchers,omitempty"`
+}
+
+// Watcher is a user who gets notified of changes to a task
+type Watcher struct {
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type CreateTaskRequest struct {
@@ -28,6 +48,12 @@ type CreateTaskRequest struct {
 	Description string     `json:"description"`
 	Status      TaskStatus `json:"status"`
 	DueDate     *time.Time `json:"due_date"`
+	AssigneeID  *int       `json:"assignee_id"`
+	ProjectID   *int       `json:"project_id"`
+	SprintID    *int       `json:"sprint_id"`
+	StoryPoints *int       `json:"story_points"`
+
+	CustomFields map[string]interface{} `json:"custom_fields"`
 }
 
 type UpdateTaskRequest struct {
@@ -35,4 +61,21 @@ type UpdateTaskRequest struct {
 	Description *string     `json:"description"`
 	Status      *TaskStatus `json:"status"`
 	DueDate     *time.Time  `json:"due_date"`
+	AssigneeID  *int        `json:"assignee_id"` // 0 unassigns the task
+	ProjectID   *int        `json:"project_id"`  // 0 removes the task from its project
+	SprintID    *int        `json:"sprint_id"`   // 0 removes the task from its sprint
+	StoryPoints *int        `json:"story_points"`
+
+	// CustomFields sets values by field key; a null value clears the field
+	CustomFields map[string]interface{} `json:"custom_fields"`
+}
+
+type TaskFilter struct {
+	Limit        int
+	Offset       int
+	ProjectID    *int
+	WatcherID    *int              // only tasks this user watches
+	CustomFields map[string]string // field key -> raw query value
+	Sort         string            // built-in column or "cf.<key>"
+	Order        string            // "asc" or "desc"
 }
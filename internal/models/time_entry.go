@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+type TimeEntry struct {
+	ID              int        `json:"id"`
+	TaskID          int        `json:"task_id"`
+	UserID          int        `json:"user_id"`
+	UserName        string     `json:"user_name,omitempty"`
+	Description     string     `json:"description"`
+	StartedAt       time.Time  `json:"started_at"`
+	EndedAt         *time.Time `json:"ended_at,omitempty"`
+	DurationSeconds int64      `json:"duration_seconds"`
+	Running         bool       `json:"running"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type StartTimerRequest struct {
+	Description string `json:"description"`
+}
+
+type CreateTimeEntryRequest struct {
+	Description string    `json:"description"`
+	StartedAt   time.Time `json:"started_at" binding:"required"`
+	EndedAt     time.Time `json:"ended_at" binding:"required"`
+}
+
+type UpdateTimeEntryRequest struct {
+	Description *string    `json:"description"`
+	StartedAt   *time.Time `json:"started_at"`
+	EndedAt     *time.Time `json:"ended_at"`
+}
+
+type TimeReportFilter struct {
+	UserID *int
+	TaskID *int
+	From   *time.Time
+	To     *time.Time
+}
+
+type TimeReportRow struct {
+	UserID       int    `json:"user_id"`
+	UserName     string `json:"user_name"`
+	TaskID       int    `json:"task_id"`
+	TaskTitle    string `json:"task_title"`
+	EntryCount   int    `json:"entry_count"`
+	TotalSeconds int64  `json:"total_seconds"`
+}
+
+type TimeReport struct {
+	From         *time.Time      `json:"from,omitempty"`
+	To           *time.Time      `json:"to,omitempty"`
+	TotalSeconds int64           `json:"total_seconds"`
+	Rows         []TimeReportRow `json:"rows"`
+}
@@ -3,12 +3,15 @@ package models
 import "time"
 
 type User struct {
-	ID           int       `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"`
-	Name         string    `json:"name"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID              int        `json:"id"`
+	Email           string     `json:"email"`
+	PasswordHash    string     `json:"-"`
+	Name            string     `json:"name"`
+	SessionVersion  int        `json:"-"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	IsAdmin         bool       `json:"is_admin"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 type RegisterRequest struct {
@@ -26,3 +29,75 @@ type LoginResponse struct {
 	Token string `json:"token"`
 	User  User   `json:"user"`
 }
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// MFAChallenge is the answer to a login with the right password when the user has
+// MFA on; MFAToken and a code are then exchanged for a token at /auth/mfa/verify
+type MFAChallenge struct {
+	MFARequired bool      `json:"mfa_required"`
+	MFAToken    string    `json:"mfa_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	// Code is a TOTP code or a recovery code
+	Code string `json:"code" binding:"required"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type MFAStatus struct {
+	Enabled           bool `json:"enabled"`
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+// MFAEnrollment holds a new TOTP secret; OTPAuthURI is meant for QR codes
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// MFARecoveryCodes are shown once, when MFA is confirmed
+type MFARecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// LoginResult is the outcome of a login attempt
+type LoginResult string
+
+const (
+	LoginSucceeded LoginResult = "success"
+	// LoginMFARequired is a right password of a user with MFA on; the sign-in
+	// succeeds with the code at /auth/mfa/verify
+	LoginMFARequired LoginResult = "mfa_required"
+	LoginFailed      LoginResult = "invalid_credentials"
+	// LoginLocked is an attempt refused without checking the password because of
+	// too many failures
+	LoginLocked LoginResult = "locked"
+)
+
+// LoginAttempt is an entry of the login audit log
+type LoginAttempt struct {
+	ID        int         `json:"id"`
+	UserID    *int        `json:"-"`
+	Email     string      `json:"-"`
+	IP        string      `json:"ip"`
+	UserAgent string      `json:"user_agent"`
+	Result    LoginResult `json:"result"`
+	CreatedAt time.Time   `json:"created_at"`
+}
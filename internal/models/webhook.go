@@ -0,0 +1,84 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type WebhookEvent string
+
+const (
+	WebhookTaskCreated    WebhookEvent = "task.created"
+	WebhookTaskUpdated    WebhookEvent = "task.updated"
+	WebhookTaskArchived   WebhookEvent = "task.archived"
+	WebhookCommentCreated WebhookEvent = "comment.created"
+)
+
+// WebhookEvents lists the events a webhook can subscribe to
+var WebhookEvents = []WebhookEvent{
+	WebhookTaskCreated,
+	WebhookTaskUpdated,
+	WebhookTaskArchived,
+	WebhookCommentCreated,
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+type Webhook struct {
+	ID     int    `json:"id"`
+	UserID int    `json:"user_id"`
+	URL    string `json:"url"`
+	// Secret is only returned when the webhook is created
+	Secret       string         `json:"secret,omitempty"`
+	Events       []WebhookEvent `json:"events"`
+	Active       bool           `json:"active"`
+	FailureCount int            `json:"failure_count"`
+	DisabledAt   *time.Time     `json:"disabled_at,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             int             `json:"id"`
+	WebhookID      int             `json:"webhook_id"`
+	Event          WebhookEvent    `json:"event"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus *int            `json:"response_status,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// WebhookPayload is the JSON body posted to a webhook. ID identifies the event
+// and stays the same across retries and replays.
+type WebhookPayload struct {
+	ID         string       `json:"id"`
+	Event      WebhookEvent `json:"event"`
+	OccurredAt time.Time    `json:"occurred_at"`
+	ActorID    int          `json:"actor_id"`
+	Data       interface{}  `json:"data"`
+}
+
+type CreateWebhookRequest struct {
+	URL string `json:"url" binding:"required"`
+	// Secret signs the deliveries; a random one is generated when empty
+	Secret string         `json:"secret"`
+	Events []WebhookEvent `json:"events" binding:"required"`
+}
+
+type UpdateWebhookRequest struct {
+	URL    *string        `json:"url"`
+	Secret *string        `json:"secret"`
+	Events []WebhookEvent `json:"events"`
+	// Active re-enables a disabled webhook and resets its failure count
+	Active *bool `json:"active"`
+}
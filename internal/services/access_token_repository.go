@@ -0,0 +1,124 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// AccessTokenRepository stores personal access tokens
+type AccessTokenRepository interface {
+	// CreateAccessToken stores a token with the hash of its secret
+	CreateAccessToken(token models.AccessToken, tokenHash string) (*models.AccessToken, error)
+	// ListAccessTokens returns the user's tokens that are not revoked, newest first
+	ListAccessTokens(userID int) ([]models.AccessToken, error)
+	// RevokeAccessToken revokes one of the user's tokens, or returns
+	// ErrAccessTokenNotFound when the user has no such token that is not revoked
+	RevokeAccessToken(userID, tokenID int, at time.Time) error
+	// UseAccessToken records that the token with the hash is used at the given
+	// time and returns it, or returns ErrInvalidAccessToken when there is no such
+	// token or it is revoked or expired
+	UseAccessToken(tokenHash string, at time.Time) (*models.AccessToken, error)
+}
+
+type PostgresAccessTokenRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresAccessTokenRepository(db *sql.DB) *PostgresAccessTokenRepository {
+	return &PostgresAccessTokenRepository{db: db}
+}
+
+const accessTokenColumns = `id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at`
+
+func scanAccessToken(row rowScanner) (*models.AccessToken, error) {
+	var token models.AccessToken
+	var scopes pq.StringArray
+	err := row.Scan(
+		&token.ID, &token.UserID, &token.Name, &token.Prefix, &scopes,
+		&token.ExpiresAt, &token.LastUsedAt, &token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Scopes = make([]models.TokenScope, len(scopes))
+	for i, scope := range scopes {
+		token.Scopes[i] = models.TokenScope(scope)
+	}
+	return &token, nil
+}
+
+func scopeArray(scopes []models.TokenScope) pq.StringArray {
+	array := make(pq.StringArray, len(scopes))
+	for i, scope := range scopes {
+		array[i] = string(scope)
+	}
+	return array
+}
+
+func (r *PostgresAccessTokenRepository) CreateAccessToken(token models.AccessToken, tokenHash string) (*models.AccessToken, error) {
+	return scanAccessToken(r.db.QueryRow(`
+		INSERT INTO access_tokens (user_id, name, token_hash, prefix, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+accessTokenColumns,
+		token.UserID, token.Name, tokenHash, token.Prefix, scopeArray(token.Scopes), token.ExpiresAt,
+	))
+}
+
+func (r *PostgresAccessTokenRepository) ListAccessTokens(userID int) ([]models.AccessToken, error) {
+	rows, err := r.db.Query(`
+		SELECT `+accessTokenColumns+`
+		FROM access_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC, id DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []models.AccessToken{}
+	for rows.Next() {
+		token, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+	return tokens, rows.Err()
+}
+
+func (r *PostgresAccessTokenRepository) RevokeAccessToken(userID, tokenID int, at time.Time) error {
+	result, err := r.db.Exec(`
+		UPDATE access_tokens SET revoked_at = $3
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, tokenID, userID, at)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrAccessTokenNotFound
+	}
+	return nil
+}
+
+func (r *PostgresAccessTokenRepository) UseAccessToken(tokenHash string, at time.Time) (*models.AccessToken, error) {
+	token, err := scanAccessToken(r.db.QueryRow(`
+		UPDATE access_tokens SET last_used_at = $2
+		WHERE token_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $2)
+		RETURNING `+accessTokenColumns,
+		tokenHash, at,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidAccessToken
+	}
+	return token, err
+}
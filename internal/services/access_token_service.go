@@ -0,0 +1,90 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/validators"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// accessTokenPrefixLength is how much of a token is kept to recognize it by
+const accessTokenPrefixLength = len(models.AccessTokenPrefix) + 8
+
+type AccessTokenService struct {
+	repo      AccessTokenRepository
+	users     UserRepository
+	validator *validators.AccessTokenValidator
+	now       func() time.Time
+}
+
+func NewAccessTokenService(repo AccessTokenRepository, users UserRepository) *AccessTokenService {
+	return &AccessTokenService{
+		repo:      repo,
+		users:     users,
+		validator: validators.NewAccessTokenValidator(),
+		now:       now,
+	}
+}
+
+// CreateAccessToken creates a personal access token for the user. The returned
+// token carries its secret, which is shown only this once; only a SHA-256 hash of
+// it is stored. Only admins may create tokens with the admin scope.
+func (s *AccessTokenService) CreateAccessToken(req models.CreateAccessTokenRequest, userID int) (*models.AccessToken, error) {
+	if err := s.validator.ValidateCreateAccessToken(&req, s.now()); err != nil {
+		return nil, err
+	}
+
+	if slices.Contains(req.Scopes, models.ScopeAdmin) {
+		user, err := s.users.GetUser(userID)
+		if err != nil {
+			return nil, err
+		}
+		if !user.IsAdmin {
+			return nil, ErrAdminScopeNotAllowed
+		}
+	}
+
+	// Access tokens are as random as reset tokens, and hashed the same way
+	secret, err := newResetToken()
+	if err != nil {
+		return nil, err
+	}
+	secret = models.AccessTokenPrefix + secret
+
+	token, err := s.repo.CreateAccessToken(models.AccessToken{
+		UserID:    userID,
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    secret[:accessTokenPrefixLength],
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	}, hashResetToken(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	token.Token = secret
+	return token, nil
+}
+
+// ListAccessTokens returns the user's tokens that are not revoked, newest first,
+// without their secrets
+func (s *AccessTokenService) ListAccessTokens(userID int) ([]models.AccessToken, error) {
+	return s.repo.ListAccessTokens(userID)
+}
+
+// RevokeAccessToken revokes one of the user's tokens, so it is no longer accepted
+func (s *AccessTokenService) RevokeAccessToken(tokenID string, userID int) error {
+	id, err := strconv.Atoi(tokenID)
+	if err != nil {
+		return ErrAccessTokenNotFound
+	}
+	return s.repo.RevokeAccessToken(userID, id, s.now())
+}
+
+// AuthenticateAccessToken returns the token with the secret and records that it
+// is used, or returns ErrInvalidAccessToken when it is unknown, revoked or expired
+func (s *AccessTokenService) AuthenticateAccessToken(secret string) (*models.AccessToken, error) {
+	return s.repo.UseAccessToken(hashResetToken(secret), s.now())
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"testing"
+	"time"
+)
+
+func TestAccessTokenExpiry(t *testing.T) {
+	store := newTaskStore(t)
+	service := NewAccessTokenService(store, store)
+	clock := time.Now()
+	service.now = func() time.Time { return clock }
+
+	expiresAt := clock.Add(time.Hour)
+	token, err := service.CreateAccessToken(models.CreateAccessTokenRequest{
+		Name:      " Nightly build ",
+		Scopes:    []models.TokenScope{models.ScopeTasksRead},
+		ExpiresAt: &expiresAt,
+	}, 1)
+	if err != nil {
+		t.Fatalf("CreateAccessToken() error = %v", err)
+	}
+	if token.Name != "Nightly build" || len(token.Prefix) != accessTokenPrefixLength {
+		t.Errorf("CreateAccessToken() = %+v, want a trimmed name and a prefix", token)
+	}
+	if stored := store.accessTokens[token.ID]; stored.tokenHash != hashResetToken(token.Token) || stored.token.Token != "" {
+		t.Errorf("stored token = %+v, want only the hash of the secret", stored)
+	}
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		secret  string
+		wantErr error
+	}{
+		{"Valid", time.Minute, token.Token, nil},
+		{"Wrong secret", 0, token.Token + "x", ErrInvalidAccessToken},
+		{"Expired", time.Hour, token.Token, ErrInvalidAccessToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock = clock.Add(tt.advance)
+			used, err := service.AuthenticateAccessToken(tt.secret)
+			if err != tt.wantErr {
+				t.Fatalf("AuthenticateAccessToken() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (used.UserID != 1 || used.LastUsedAt == nil || !used.LastUsedAt.Equal(clock)) {
+				t.Errorf("AuthenticateAccessToken() = %+v, want alice's token used now", used)
+			}
+		})
+	}
+
+	if err := service.RevokeAccessToken("1", 2); err != ErrAccessTokenNotFound {
+		t.Errorf("RevokeAccessToken() by another user error = %v, want %v", err, ErrAccessTokenNotFound)
+	}
+	if err := service.RevokeAccessToken("1", 1); err != nil {
+		t.Fatalf("RevokeAccessToken() error = %v", err)
+	}
+	if tokens, _ := service.ListAccessTokens(1); len(tokens) != 0 {
+		t.Errorf("ListAccessTokens() after revoking = %+v, want none", tokens)
+	}
+}
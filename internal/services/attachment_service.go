@@ -0,0 +1,380 @@
+package services
+
+import (
+	"bytes"
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/storage"
+	"candidate-backend/internal/validators"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// sniffLength is the number of bytes http.DetectContentType looks at
+const sniffLength = 512
+
+// blobSweepInterval is how often Run deletes the blobs still queued for deletion,
+// and blobSweepBatch how many queued blobs are read at a time
+const (
+	blobSweepInterval = 5 * time.Minute
+	blobSweepBatch    = 100
+)
+
+// AttachmentUpload is a file received from a multipart request
+type AttachmentUpload struct {
+	Filename string
+	Size     int64
+	Body     io.Reader
+}
+
+type AttachmentService struct {
+	db        *sql.DB
+	storage   storage.Storage
+	validator *validators.AttachmentValidator
+	urlTTL    time.Duration
+}
+
+func NewAttachmentService(db *sql.DB, store storage.Storage, maxSize int64, urlTTL time.Duration) *AttachmentService {
+	return &AttachmentService{
+		db:        db,
+		storage:   store,
+		validator: validators.NewAttachmentValidator(maxSize),
+		urlTTL:    urlTTL,
+	}
+}
+
+const attachmentSelect = `
+	SELECT a.id, a.task_id, a.comment_id, a.uploader_id, u.name as uploader_name,
+	       a.filename, a.content_type, a.size_bytes, a.storage_key, a.created_at
+	FROM attachments a
+	JOIN users u ON a.uploader_id = u.id
+`
+
+func scanAttachment(row rowScanner) (*models.Attachment, error) {
+	var attachment models.Attachment
+	var commentID sql.NullInt64
+	err := row.Scan(
+		&attachment.ID, &attachment.TaskID, &commentID, &attachment.UploaderID, &attachment.UploaderName,
+		&attachment.Filename, &attachment.ContentType, &attachment.SizeBytes, &attachment.StorageKey,
+		&attachment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if commentID.Valid {
+		id := int(commentID.Int64)
+		attachment.CommentID = &id
+	}
+
+	return &attachment, nil
+}
+
+func (s *AttachmentService) queryAttachments(query string, args ...interface{}) ([]models.Attachment, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []models.Attachment
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, *attachment)
+	}
+
+	if attachments == nil {
+		attachments = []models.Attachment{}
+	}
+
+	return attachments, nil
+}
+
+// GetTaskAttachments retrieves all attachments of a task, including those on its comments
+func (s *AttachmentService) GetTaskAttachments(taskID string) ([]models.Attachment, error) {
+	if _, err := s.taskExists(taskID); err != nil {
+		return nil, err
+	}
+
+	return s.queryAttachments(attachmentSelect+`
+		WHERE a.task_id = $1
+		ORDER BY a.created_at ASC, a.id ASC
+	`, taskID)
+}
+
+// GetCommentAttachments retrieves the attachments of a comment
+func (s *AttachmentService) GetCommentAttachments(commentID string) ([]models.Attachment, error) {
+	if _, _, err := s.getComment(commentID); err != nil {
+		return nil, err
+	}
+
+	return s.queryAttachments(attachmentSelect+`
+		WHERE a.comment_id = $1
+		ORDER BY a.created_at ASC, a.id ASC
+	`, commentID)
+}
+
+// GetAttachment retrieves a single attachment
+func (s *AttachmentService) GetAttachment(attachmentID string) (*models.Attachment, error) {
+	attachment, err := scanAttachment(s.db.QueryRow(attachmentSelect+"WHERE a.id = $1", attachmentID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// UploadToTask stores a file and attaches it to a task
+func (s *AttachmentService) UploadToTask(ctx context.Context, taskID string, upload AttachmentUpload, userID int) (*models.Attachment, error) {
+	id, err := s.taskExists(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.upload(ctx, id, nil, upload, userID)
+}
+
+// UploadToComment stores a file and attaches it to a comment (only the comment author can attach)
+func (s *AttachmentService) UploadToComment(ctx context.Context, commentID string, upload AttachmentUpload, userID int) (*models.Attachment, error) {
+	taskID, authorID, err := s.getComment(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if authorID != userID {
+		return nil, ErrNotCommentAuthor
+	}
+
+	id, _ := strconv.Atoi(commentID)
+	return s.upload(ctx, taskID, &id, upload, userID)
+}
+
+// upload sniffs the content type from the first bytes of the file, validates the
+// upload, writes the blob and records its metadata
+func (s *AttachmentService) upload(ctx context.Context, taskID int, commentID *int, upload AttachmentUpload, userID int) (*models.Attachment, error) {
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(upload.Body, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	if err := s.validator.ValidateUpload(upload.Filename, upload.Size, contentType); err != nil {
+		return nil, err
+	}
+
+	key, err := newStorageKey(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	body := io.MultiReader(bytes.NewReader(head), upload.Body)
+	if err := s.storage.Put(ctx, key, body, upload.Size, contentType); err != nil {
+		return nil, err
+	}
+
+	var attachmentID int
+	err = s.db.QueryRow(`
+		INSERT INTO attachments (task_id, comment_id, uploader_id, filename, content_type, size_bytes, storage_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, taskID, commentID, userID, upload.Filename, contentType, upload.Size, key).Scan(&attachmentID)
+	if err != nil {
+		_ = s.storage.Delete(ctx, key)
+		return nil, err
+	}
+
+	return s.GetAttachment(strconv.Itoa(attachmentID))
+}
+
+// GetDownloadURL returns a short-lived signed URL for downloading an attachment
+func (s *AttachmentService) GetDownloadURL(ctx context.Context, attachmentID string) (*models.AttachmentURL, error) {
+	attachment, err := s.GetAttachment(attachmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(s.urlTTL)
+	url, err := s.storage.SignedURL(ctx, attachment.StorageKey, storage.URLOptions{
+		TTL:         s.urlTTL,
+		Filename:    attachment.Filename,
+		ContentType: attachment.ContentType,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AttachmentURL{URL: url, ExpiresAt: expiresAt}, nil
+}
+
+// DeleteAttachment removes an attachment and its blob (only the uploader can delete).
+// The blob is queued for deletion by the statement that deletes the row, so when it
+// cannot be deleted right away, Run deletes it later.
+func (s *AttachmentService) DeleteAttachment(ctx context.Context, attachmentID string, userID int) (*models.Attachment, error) {
+	attachment, err := s.GetAttachment(attachmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if attachment.UploaderID != userID {
+		return nil, ErrNotAttachmentOwner
+	}
+
+	var queuedID int64
+	err = s.db.QueryRow(`
+		WITH deleted AS (
+			DELETE FROM attachments WHERE id = $1 RETURNING task_id, storage_key
+		)
+		INSERT INTO attachment_blob_deletions (task_id, storage_key)
+		SELECT task_id, storage_key FROM deleted
+		RETURNING id
+	`, attachment.ID).Scan(&queuedID)
+	if err == sql.ErrNoRows {
+		return nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.deleteQueuedBlobs(ctx, "WHERE id = $1", queuedID); err != nil {
+		log.Printf("attachments: deleting blob of attachment %d, left queued: %v", attachment.ID, err)
+	}
+
+	return attachment, nil
+}
+
+// HandleEvent deletes the blobs of the attachments a deleted task or comment took
+// with it, queued by the delete's transaction. It is an outbox subscriber; a blob
+// that fails to delete stays queued and the event is published again.
+func (s *AttachmentService) HandleEvent(ctx context.Context, event models.DomainEvent) error {
+	if event.Type != models.EventTaskDeleted && event.Type != models.EventCommentDeleted {
+		return nil
+	}
+
+	return s.deleteQueuedBlobs(ctx, "WHERE task_id = $1", event.TaskID)
+}
+
+// Run deletes the blobs still queued for deletion every blobSweepInterval until ctx
+// is done, such as those of attachments whose blob failed to delete with the row
+func (s *AttachmentService) Run(ctx context.Context) {
+	ticker := time.NewTicker(blobSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.deleteQueuedBlobs(ctx, ""); err != nil {
+			log.Printf("attachments: deleting queued blobs: %v", err)
+		}
+	}
+}
+
+// deleteQueuedBlobs deletes the blobs of the attachment_blob_deletions rows the
+// where clause selects, oldest first and blobSweepBatch at a time, and removes the
+// rows of the ones it deleted. Deleting a missing blob is not an error, so a row
+// handled twice at once does no harm.
+func (s *AttachmentService) deleteQueuedBlobs(ctx context.Context, where string, args ...interface{}) error {
+	type blob struct {
+		id  int64
+		key string
+	}
+	for {
+		rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+			SELECT id, storage_key FROM attachment_blob_deletions %s ORDER BY id LIMIT %d
+		`, where, blobSweepBatch), args...)
+		if err != nil {
+			return err
+		}
+		var blobs []blob
+		for rows.Next() {
+			var b blob
+			if err := rows.Scan(&b.id, &b.key); err != nil {
+				rows.Close()
+				return err
+			}
+			blobs = append(blobs, b)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, b := range blobs {
+			if err := s.storage.Delete(ctx, b.key); err != nil {
+				return fmt.Errorf("deleting blob %s: %w", b.key, err)
+			}
+			if _, err := s.db.ExecContext(ctx, "DELETE FROM attachment_blob_deletions WHERE id = $1", b.id); err != nil {
+				return err
+			}
+		}
+
+		if len(blobs) < blobSweepBatch {
+			return nil
+		}
+	}
+}
+
+// queueBlobDeletions queues the blobs of the attachments whose column matches id for
+// deletion, inside the transaction that deletes them. The attachment rows go with
+// the task or comment through ON DELETE CASCADE.
+func queueBlobDeletions(tx *sql.Tx, column string, id int) error {
+	_, err := tx.Exec(`
+		INSERT INTO attachment_blob_deletions (task_id, storage_key)
+		SELECT task_id, storage_key FROM attachments WHERE `+column+` = $1
+	`, id)
+	return err
+}
+
+func (s *AttachmentService) taskExists(taskID string) (int, error) {
+	var id int
+	err := s.db.QueryRow("SELECT id FROM tasks WHERE id = $1", taskID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrTaskNotFound
+		}
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s *AttachmentService) getComment(commentID string) (int, int, error) {
+	var taskID, authorID int
+	err := s.db.QueryRow("SELECT task_id, user_id FROM comments WHERE id = $1 AND deleted_at IS NULL", commentID).Scan(&taskID, &authorID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, 0, ErrCommentNotFound
+		}
+		return 0, 0, err
+	}
+
+	return taskID, authorID, nil
+}
+
+// newStorageKey returns an unguessable key grouped by task
+func newStorageKey(taskID int) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("tasks/%d/%s", taskID, hex.EncodeToString(random)), nil
+}
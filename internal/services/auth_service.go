@@ -0,0 +1,173 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"errors"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// LoginThrottleSettings configures how failed logins slow down further attempts
+type LoginThrottleSettings struct {
+	// Window is how long failed logins count
+	Window time.Duration
+	// After DelayAfter failures with an email, each further attempt has to wait
+	// BaseDelay after the last failure, doubling with every failure
+	DelayAfter int
+	BaseDelay  time.Duration
+	// MaxFailures with an email, or IPMaxFailures from an IP address, lock logins
+	// out for Lockout after the last failure
+	MaxFailures   int
+	IPMaxFailures int
+	Lockout       time.Duration
+}
+
+// DefaultLoginThrottle is the login throttling used unless configured otherwise
+var DefaultLoginThrottle = LoginThrottleSettings{
+	Window:        15 * time.Minute,
+	DelayAfter:    3,
+	BaseDelay:     time.Second,
+	MaxFailures:   10,
+	IPMaxFailures: 50,
+	Lockout:       15 * time.Minute,
+}
+
+// retryAt returns when the next login may be attempted after the failures, zero
+// when right away
+func (t LoginThrottleSettings) retryAt(byEmail, byIP LoginFailures) time.Time {
+	var at time.Time
+	switch {
+	case byEmail.Count >= t.MaxFailures:
+		at = byEmail.Last.Add(t.Lockout)
+	case byEmail.Count >= t.DelayAfter:
+		delay := t.BaseDelay << (byEmail.Count - t.DelayAfter)
+		if delay <= 0 || delay > t.Lockout {
+			delay = t.Lockout
+		}
+		at = byEmail.Last.Add(delay)
+	}
+	if byIP.Count >= t.IPMaxFailures && byIP.Last.Add(t.Lockout).After(at) {
+		at = byIP.Last.Add(t.Lockout)
+	}
+	return at
+}
+
+// maxSignIns is the most sign-ins ListSignIns returns
+const maxSignIns = 100
+
+type AuthService struct {
+	users    UserRepository
+	attempts LoginAttemptRepository
+	mfa      *MFAService
+	throttle LoginThrottleSettings
+	now      func() time.Time
+}
+
+func NewAuthService(users UserRepository, attempts LoginAttemptRepository, mfa *MFAService, throttle LoginThrottleSettings) *AuthService {
+	return &AuthService{users: users, attempts: attempts, mfa: mfa, throttle: throttle, now: now}
+}
+
+// Register creates a user account with a bcrypt hash of the password
+func (s *AuthService) Register(req models.RegisterRequest) (*models.User, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.users.CreateUser(models.User{
+		Email:        req.Email,
+		PasswordHash: string(hashedPassword),
+		Name:         req.Name,
+	})
+}
+
+// Login checks the credentials of a login from the IP address and user agent, and
+// records the attempt. It returns the user and whether they still have to enter
+// an MFA code. Unknown emails and wrong passwords both return
+// ErrInvalidCredentials; after too many of them with the email or from the IP
+// address, attempts are refused with ErrLoginLocked without checking the password.
+func (s *AuthService) Login(req models.LoginRequest, ip, userAgent string) (*models.User, bool, error) {
+	// Throttle variants of an email's case together, and let concurrent logins
+	// with the email take turns so each counts the failures before it
+	email := strings.ToLower(req.Email)
+	var user *models.User
+	var mfaRequired bool
+	// The attempt is recorded when the login is refused, so the refusal is
+	// returned apart from the errors that roll the recording back
+	var refused error
+	err := s.attempts.LockLoginAttempts(email, func(attempts LoginAttemptRepository) error {
+		at := s.now()
+		attempt := models.LoginAttempt{Email: req.Email, IP: ip, UserAgent: userAgent, CreatedAt: at}
+
+		byEmail, byIP, err := attempts.CountLoginFailures(email, ip, at.Add(-s.throttle.Window))
+		if err != nil {
+			return err
+		}
+		if at.Before(s.throttle.retryAt(byEmail, byIP)) {
+			attempt.Result = models.LoginLocked
+			refused = ErrLoginLocked
+			return attempts.RecordLoginAttempt(attempt)
+		}
+
+		user, err = s.authenticate(req)
+		if errors.Is(err, ErrInvalidCredentials) {
+			attempt.Result = models.LoginFailed
+			refused = ErrInvalidCredentials
+			return attempts.RecordLoginAttempt(attempt)
+		}
+		if err != nil {
+			return err
+		}
+
+		mfaRequired, err = s.mfa.Enabled(user.ID)
+		if err != nil {
+			return err
+		}
+		attempt.Result = models.LoginSucceeded
+		if mfaRequired {
+			attempt.Result = models.LoginMFARequired
+		}
+		return attempts.RecordLoginAttempt(attempt)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if refused != nil {
+		return nil, false, refused
+	}
+
+	return user, mfaRequired, nil
+}
+
+// authenticate returns the user with the given credentials, or ErrInvalidCredentials
+func (s *AuthService) authenticate(req models.LoginRequest) (*models.User, error) {
+	user, err := s.users.GetUserByEmail(req.Email)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+// ListSignIns returns the latest login attempts with the user's email, newest
+// first, at most maxSignIns of them
+func (s *AuthService) ListSignIns(userID, limit int) ([]models.LoginAttempt, error) {
+	if limit > maxSignIns {
+		limit = maxSignIns
+	}
+	return s.attempts.ListLoginAttempts(userID, limit)
+}
+
+// GetUser returns the user with the given ID, or ErrUserNotFound
+func (s *AuthService) GetUser(userID int) (*models.User, error) {
+	return s.users.GetUser(userID)
+}
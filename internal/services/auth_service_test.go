@@ -0,0 +1,153 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoginThrottle(t *testing.T) {
+	store := NewMemoryStore()
+	mfa := NewMFAService(store, store, MFASettings{Issuer: "Task Board", EncryptionKey: "secret"})
+	service := NewAuthService(store, store, mfa, LoginThrottleSettings{
+		Window:        time.Hour,
+		DelayAfter:    2,
+		BaseDelay:     time.Second,
+		MaxFailures:   4,
+		IPMaxFailures: 8,
+		Lockout:       10 * time.Minute,
+	})
+	clock := time.Now()
+	service.now = func() time.Time { return clock }
+	for _, email := range []string{"alice@example.com", "bob@example.com"} {
+		if _, err := service.Register(models.RegisterRequest{Email: email, Password: "secret123", Name: email}); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		advance  time.Duration
+		email    string
+		password string
+		ip       string
+		wantErr  error
+	}{
+		{"First failure", 0, "alice@example.com", "wrong", "10.0.0.1", ErrInvalidCredentials},
+		{"Second failure", 0, "alice@example.com", "wrong", "10.0.0.1", ErrInvalidCredentials},
+		{"Delayed", 0, "alice@example.com", "secret123", "10.0.0.1", ErrLoginLocked},
+		{"After the delay", time.Second, "alice@example.com", "secret123", "10.0.0.1", nil},
+		{"Failure after a success", 0, "alice@example.com", "wrong", "10.0.0.1", ErrInvalidCredentials},
+		{"Failure in another case", 0, "Alice@Example.com", "wrong", "10.0.0.1", ErrInvalidCredentials},
+		{"Third failure", time.Second, "alice@example.com", "wrong", "10.0.0.1", ErrInvalidCredentials},
+		{"Delay doubles", time.Second, "alice@example.com", "secret123", "10.0.0.1", ErrLoginLocked},
+		{"Fourth failure locks", time.Second, "alice@example.com", "wrong", "10.0.0.1", ErrInvalidCredentials},
+		{"Locked", 5 * time.Minute, "alice@example.com", "secret123", "10.0.0.1", ErrLoginLocked},
+		{"Locked from another IP", 0, "alice@example.com", "secret123", "10.0.0.2", ErrLoginLocked},
+		{"Other emails are not locked", 0, "bob@example.com", "secret123", "10.0.0.1", nil},
+		{"After the lockout", 5 * time.Minute, "alice@example.com", "secret123", "10.0.0.1", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock = clock.Add(tt.advance)
+			if _, _, err := service.Login(models.LoginRequest{Email: tt.email, Password: tt.password}, tt.ip, "test"); err != tt.wantErr {
+				t.Fatalf("Login() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Failures with many emails from one IP address lock the address out
+	for i := 0; i < 8; i++ {
+		email := "user" + strconv.Itoa(i) + "@example.com"
+		if _, _, err := service.Login(models.LoginRequest{Email: email, Password: "wrong"}, "10.0.0.3", "test"); err != ErrInvalidCredentials {
+			t.Fatalf("Login() with %s error = %v, want %v", email, err, ErrInvalidCredentials)
+		}
+	}
+	if _, _, err := service.Login(models.LoginRequest{Email: "bob@example.com", Password: "secret123"}, "10.0.0.3", "test"); err != ErrLoginLocked {
+		t.Errorf("Login() from a locked IP error = %v, want %v", err, ErrLoginLocked)
+	}
+
+	signIns, err := service.ListSignIns(1, 3)
+	if err != nil {
+		t.Fatalf("ListSignIns() error = %v", err)
+	}
+	want := []models.LoginResult{models.LoginSucceeded, models.LoginLocked, models.LoginLocked}
+	if len(signIns) != len(want) {
+		t.Fatalf("ListSignIns() = %+v, want %d sign-ins", signIns, len(want))
+	}
+	for i, signIn := range signIns {
+		if signIn.Result != want[i] || signIn.UserAgent != "test" {
+			t.Errorf("ListSignIns()[%d] = %+v, want result %s", i, signIn, want[i])
+		}
+	}
+}
+
+func TestConcurrentLoginsAreThrottled(t *testing.T) {
+	store := NewMemoryStore()
+	mfa := NewMFAService(store, store, MFASettings{Issuer: "Task Board", EncryptionKey: "secret"})
+	service := NewAuthService(store, store, mfa, LoginThrottleSettings{
+		Window:        time.Hour,
+		DelayAfter:    3,
+		BaseDelay:     time.Hour,
+		MaxFailures:   3,
+		IPMaxFailures: 100,
+		Lockout:       time.Hour,
+	})
+	if _, err := service.Register(models.RegisterRequest{Email: "alice@example.com", Password: "secret123", Name: "Alice"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	// Guesses sent at once must not all pass the throttle before any is recorded
+	errs := make(chan error, 10)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := service.Login(models.LoginRequest{Email: "alice@example.com", Password: "wrong"}, "10.0.0.1", "test")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	failed, locked := 0, 0
+	for err := range errs {
+		switch err {
+		case ErrInvalidCredentials:
+			failed++
+		case ErrLoginLocked:
+			locked++
+		default:
+			t.Fatalf("Login() error = %v", err)
+		}
+	}
+	if failed != 3 || locked != 7 {
+		t.Errorf("Login() checked %d passwords and locked %d logins, want 3 and 7", failed, locked)
+	}
+}
+
+func TestSignInsMatchEmailCase(t *testing.T) {
+	store := NewMemoryStore()
+	mfa := NewMFAService(store, store, MFASettings{Issuer: "Task Board", EncryptionKey: "secret"})
+	service := NewAuthService(store, store, mfa, DefaultLoginThrottle)
+	alice, err := service.Register(models.RegisterRequest{Email: "alice@example.com", Password: "secret123", Name: "Alice"})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if _, _, err := service.Login(models.LoginRequest{Email: "Alice@Example.COM", Password: "wrong"}, "10.0.0.1", "test"); err != ErrInvalidCredentials {
+		t.Fatalf("Login() error = %v, want %v", err, ErrInvalidCredentials)
+	}
+
+	signIns, err := service.ListSignIns(alice.ID, 10)
+	if err != nil {
+		t.Fatalf("ListSignIns() error = %v", err)
+	}
+	if len(signIns) != 1 || signIns[0].Email != "alice@example.com" || signIns[0].Result != models.LoginFailed {
+		t.Errorf("ListSignIns() = %+v, want the failed login typed in another case", signIns)
+	}
+}
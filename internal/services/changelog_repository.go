@@ -0,0 +1,64 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"database/sql"
+)
+
+// ChangeLogRepository stores the change log entries of tasks
+type ChangeLogRepository interface {
+	// ListChangeLogs returns a task's entries, newest first
+	ListChangeLogs(taskID int) ([]models.ChangeLog, error)
+	CreateChangeLog(log models.ChangeLog) error
+}
+
+type PostgresChangeLogRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresChangeLogRepository(db *sql.DB) *PostgresChangeLogRepository {
+	return &PostgresChangeLogRepository{db: db}
+}
+
+func (r *PostgresChangeLogRepository) ListChangeLogs(taskID int) ([]models.ChangeLog, error) {
+	rows, err := r.db.Query(`
+		SELECT cl.id, cl.task_id, cl.user_id, u.name as user_name,
+		       cl.action, cl.details, cl.created_at
+		FROM change_logs cl
+		JOIN users u ON cl.user_id = u.id
+		WHERE cl.task_id = $1
+		ORDER BY cl.created_at DESC
+	`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []models.ChangeLog
+	for rows.Next() {
+		var log models.ChangeLog
+		err := rows.Scan(
+			&log.ID, &log.TaskID, &log.UserID, &log.UserName,
+			&log.Action, &log.Details, &log.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, log)
+	}
+
+	return logs, rows.Err()
+}
+
+func (r *PostgresChangeLogRepository) CreateChangeLog(log models.ChangeLog) error {
+	return insertChangeLog(r.db, log)
+}
+
+// insertChangeLog lets task and comment repositories log a change in their own transaction
+func insertChangeLog(exec execer, log models.ChangeLog) error {
+	_, err := exec.Exec(
+		"INSERT INTO change_logs (task_id, user_id, action, details) VALUES ($1, $2, $3, $4)",
+		log.TaskID, log.UserID, log.Action, log.Details,
+	)
+	return err
+}
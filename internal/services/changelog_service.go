@@ -2,44 +2,28 @@ package services
 
 import (
 	"candidate-backend/internal/models"
-	"database/sql"
 	"fmt"
+	"strconv"
 )
 
 type ChangeLogService struct {
-	db *sql.DB
+	repo ChangeLogRepository
 }
 
-func NewChangeLogService(db *sql.DB) *ChangeLogService {
-	return &ChangeLogService{db: db}
+func NewChangeLogService(repo ChangeLogRepository) *ChangeLogService {
+	return &ChangeLogService{repo: repo}
 }
 
 // GetTaskLogs retrieves all change logs for a task
 func (s *ChangeLogService) GetTaskLogs(taskID string) ([]models.ChangeLog, error) {
-	rows, err := s.db.Query(`
-		SELECT cl.id, cl.task_id, cl.user_id, u.name as user_name,
-		       cl.action, cl.details, cl.created_at
-		FROM change_logs cl
-		JOIN users u ON cl.user_id = u.id
-		WHERE cl.task_id = $1
-		ORDER BY cl.created_at DESC
-	`, taskID)
+	id, err := strconv.Atoi(taskID)
 	if err != nil {
-		return nil, err
+		return nil, ErrTaskNotFound
 	}
-	defer rows.Close()
 
-	var logs []models.ChangeLog
-	for rows.Next() {
-		var log models.ChangeLog
-		err := rows.Scan(
-			&log.ID, &log.TaskID, &log.UserID, &log.UserName,
-			&log.Action, &log.Details, &log.CreatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		logs = append(logs, log)
+	logs, err := s.repo.ListChangeLogs(id)
+	if err != nil {
+		return nil, err
 	}
 
 	if logs == nil {
@@ -51,15 +35,20 @@ func (s *ChangeLogService) GetTaskLogs(taskID string) ([]models.ChangeLog, error
 
 // CreateChangeLog creates a new change log entry
 func (s *ChangeLogService) CreateChangeLog(taskID, userID int, action, details string) error {
-	_, err := s.db.Exec(
-		"INSERT INTO change_logs (task_id, user_id, action, details) VALUES ($1, $2, $3, $4)",
-		taskID, userID, action, details,
-	)
-	return err
+	return s.repo.CreateChangeLog(models.ChangeLog{
+		TaskID:  taskID,
+		UserID:  userID,
+		Action:  action,
+		Details: details,
+	})
 }
 
 // FormatChangeDetails formats multiple changes into a readable string
 func (s *ChangeLogService) FormatChangeDetails(changes []string) string {
+	return formatChangeDetails(changes)
+}
+
+func formatChangeDetails(changes []string) string {
 	if len(changes) == 0 {
 		return ""
 	}
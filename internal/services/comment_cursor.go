@@ -0,0 +1,46 @@
+package services
+
+import (
+	"candidate-backend/internal/apperrors"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CommentCursor is the position of a comment in a listing ordered by creation time
+type CommentCursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+// EncodeCommentCursor returns an opaque cursor string for clients
+func EncodeCommentCursor(cursor CommentCursor) string {
+	raw := cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + strconv.Itoa(cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCommentCursor parses a cursor returned by EncodeCommentCursor
+func DecodeCommentCursor(value string) (CommentCursor, error) {
+	invalid := apperrors.Invalid("cursor", "invalid cursor")
+
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return CommentCursor{}, invalid
+	}
+
+	createdAt, id, found := strings.Cut(string(raw), "|")
+	if !found {
+		return CommentCursor{}, invalid
+	}
+
+	var cursor CommentCursor
+	if cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return CommentCursor{}, invalid
+	}
+	if cursor.ID, err = strconv.Atoi(id); err != nil || cursor.ID < 1 {
+		return CommentCursor{}, invalid
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestCommentCursor(t *testing.T) {
+	cursor := CommentCursor{CreatedAt: time.Date(2024, 3, 1, 9, 30, 0, 123456000, time.UTC), ID: 42}
+
+	decoded, err := DecodeCommentCursor(EncodeCommentCursor(cursor))
+	if err != nil {
+		t.Fatalf("DecodeCommentCursor() error = %v", err)
+	}
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID {
+		t.Errorf("DecodeCommentCursor() = %+v, want %+v", decoded, cursor)
+	}
+
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	invalid := []struct {
+		name  string
+		value string
+	}{
+		{"Not base64", "%%%"},
+		{"Missing id", encode("2024-03-01T09:30:00Z")},
+		{"Bad time", encode("yesterday|42")},
+		{"Bad id", encode("2024-03-01T09:30:00Z|x")},
+		{"Zero id", encode("2024-03-01T09:30:00Z|0")},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCommentCursor(tt.value); err == nil {
+				t.Errorf("DecodeCommentCursor(%q) expected error", tt.value)
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CommentRepository stores comments. It only persists data; the rules about who may
+// change what live in CommentService.
+type CommentRepository interface {
+	TaskExists(taskID int) (bool, error)
+	// GetComment returns ErrCommentNotFound when no comment has the ID, including deleted placeholders
+	GetComment(commentID int) (*models.Comment, error)
+	// ListComments returns up to filter.Limit comments of a task in the filter's
+	// creation order, starting after the cursor position when one is given
+	ListComments(taskID int, filter models.CommentFilter, after *CommentCursor) ([]models.Comment, error)
+	CreateComment(comment models.Comment, change Change) (int, error)
+	// EditComment keeps the current content as a revision replaced by editorID and stores the new content
+	EditComment(commentID int, content string, editorID int, change Change) error
+	// DeleteComment removes a comment, or turns it into a placeholder when it has replies.
+	// The event of the change carries the comment as it was. The blobs of its
+	// attachments are deleted once the event is published.
+	DeleteComment(commentID int, change Change) error
+	ListRevisions(commentID int) ([]models.CommentRevision, error)
+}
+
+type PostgresCommentRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresCommentRepository(db *sql.DB) *PostgresCommentRepository {
+	return &PostgresCommentRepository{db: db}
+}
+
+const commentSelect = `
+	SELECT c.id, c.task_id, c.parent_id, c.depth, c.user_id, u.name as user_name,
+	       c.content, c.deleted_at IS NOT NULL,
+	       (SELECT COUNT(*) FROM comment_revisions r WHERE r.comment_id = c.id) AS edit_count,
+	       c.created_at, c.updated_at
+	FROM comments c
+	JOIN users u ON c.user_id = u.id
+`
+
+func scanComment(row rowScanner) (*models.Comment, error) {
+	var comment models.Comment
+	var parentID sql.NullInt64
+	err := row.Scan(
+		&comment.ID, &comment.TaskID, &parentID, &comment.Depth, &comment.UserID, &comment.UserName,
+		&comment.Content, &comment.Deleted, &comment.EditCount, &comment.CreatedAt, &comment.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	comment.Edited = comment.EditCount > 0
+	comment.ParentID = nullIntPtr(parentID)
+
+	return &comment, nil
+}
+
+func (r *PostgresCommentRepository) TaskExists(taskID int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM tasks WHERE id = $1)", taskID).Scan(&exists)
+	return exists, err
+}
+
+func (r *PostgresCommentRepository) GetComment(commentID int) (*models.Comment, error) {
+	comment, err := scanComment(r.db.QueryRow(commentSelect+"WHERE c.id = $1", commentID))
+	if err == sql.ErrNoRows {
+		return nil, ErrCommentNotFound
+	}
+	return comment, err
+}
+
+func (r *PostgresCommentRepository) ListComments(taskID int, filter models.CommentFilter, after *CommentCursor) ([]models.Comment, error) {
+	conditions := []string{"c.task_id = $1"}
+	args := []interface{}{taskID}
+
+	// Placeholders do not count as the author's comments
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("c.user_id = $%d", len(args)), "c.deleted_at IS NULL")
+	}
+
+	if filter.Since != nil {
+		args = append(args, filter.Since.UTC())
+		conditions = append(conditions, fmt.Sprintf("c.created_at > $%d", len(args)))
+	}
+
+	direction, comparison := "ASC", ">"
+	if filter.Order == "desc" {
+		direction, comparison = "DESC", "<"
+	}
+
+	if after != nil {
+		args = append(args, after.CreatedAt, after.ID)
+		conditions = append(conditions, fmt.Sprintf("(c.created_at, c.id) %s ($%d, $%d)", comparison, len(args)-1, len(args)))
+	}
+
+	args = append(args, filter.Limit)
+	rows, err := r.db.Query(commentSelect+fmt.Sprintf(`
+		WHERE %s
+		ORDER BY c.created_at %s, c.id %s
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), direction, direction, len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, *comment)
+	}
+
+	return comments, rows.Err()
+}
+
+func (r *PostgresCommentRepository) CreateComment(comment models.Comment, change Change) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var commentID int
+	err = tx.QueryRow(`
+		INSERT INTO comments (task_id, parent_id, depth, user_id, content)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, comment.TaskID, comment.ParentID, comment.Depth, comment.UserID, comment.Content).Scan(&commentID)
+	if err != nil {
+		return 0, err
+	}
+
+	created, err := scanComment(tx.QueryRow(commentSelect+"WHERE c.id = $1", commentID))
+	if err != nil {
+		return 0, err
+	}
+	if err := recordCommentChange(tx, change, created); err != nil {
+		return 0, err
+	}
+
+	return commentID, tx.Commit()
+}
+
+func (r *PostgresCommentRepository) EditComment(commentID int, content string, editorID int, change Change) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var previous string
+	var writtenAt time.Time
+	err = tx.QueryRow(`
+		SELECT content, updated_at FROM comments WHERE id = $1 FOR UPDATE
+	`, commentID).Scan(&previous, &writtenAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO comment_revisions (comment_id, revision, content, written_at, replaced_by)
+		VALUES ($1, (SELECT COALESCE(MAX(revision), 0) + 1 FROM comment_revisions WHERE comment_id = $1), $2, $3, $4)
+	`, commentID, previous, writtenAt, editorID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE comments SET content = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+	`, content, commentID)
+	if err != nil {
+		return err
+	}
+
+	edited, err := scanComment(tx.QueryRow(commentSelect+"WHERE c.id = $1", commentID))
+	if err != nil {
+		return err
+	}
+	if err := recordCommentChange(tx, change, edited); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteComment removes a comment, or turns it into a placeholder when it has replies.
+// Placeholders left without replies are removed as well, walking up the thread.
+func (r *PostgresCommentRepository) DeleteComment(commentID int, change Change) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	comment, err := scanComment(tx.QueryRow(commentSelect+"WHERE c.id = $1 FOR UPDATE OF c", commentID))
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := recordCommentChange(tx, change, comment); err != nil {
+		return err
+	}
+	if err := queueBlobDeletions(tx, "comment_id", commentID); err != nil {
+		return err
+	}
+
+	var hasReplies bool
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM comments WHERE parent_id = $1)", commentID).Scan(&hasReplies)
+	if err != nil {
+		return err
+	}
+
+	if hasReplies {
+		_, err = tx.Exec(`
+			UPDATE comments SET content = '', deleted_at = CURRENT_TIMESTAMP WHERE id = $1
+		`, commentID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM mentions WHERE comment_id = $1", commentID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM comment_revisions WHERE comment_id = $1", commentID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM reactions WHERE comment_id = $1", commentID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM attachments WHERE comment_id = $1", commentID); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	var parentID sql.NullInt64
+	err = tx.QueryRow("DELETE FROM comments WHERE id = $1 RETURNING parent_id", commentID).Scan(&parentID)
+	if err != nil {
+		return err
+	}
+
+	for parentID.Valid {
+		var next sql.NullInt64
+		err = tx.QueryRow(`
+			DELETE FROM comments
+			WHERE id = $1 AND deleted_at IS NOT NULL
+			  AND NOT EXISTS (SELECT 1 FROM comments WHERE parent_id = $1)
+			RETURNING parent_id
+		`, parentID.Int64).Scan(&next)
+		if err == sql.ErrNoRows {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		parentID = next
+	}
+
+	return tx.Commit()
+}
+
+// recordCommentChange records a change with the comment in its event, storing the
+// comment's mentions first when the change replaces them
+func recordCommentChange(tx *sql.Tx, change Change, comment *models.Comment) error {
+	if err := addWatchers(tx, comment.TaskID, change.Watchers); err != nil {
+		return err
+	}
+
+	payload := models.CommentEventPayload{Comment: *comment}
+	if change.ReplaceMentions {
+		mentioned, err := replaceMentions(tx, comment.TaskID, &comment.ID, change.Mentions)
+		if err != nil {
+			return err
+		}
+		payload.Comment.Mentions = change.Mentions
+		payload.Mentioned = mentioned
+	}
+	return recordChange(tx, change, comment.TaskID, &comment.ID, payload)
+}
+
+func (r *PostgresCommentRepository) ListRevisions(commentID int) ([]models.CommentRevision, error) {
+	rows, err := r.db.Query(`
+		SELECT r.id, r.comment_id, r.revision, r.content, r.written_at,
+		       r.replaced_by, u.name as replaced_by_name, r.replaced_at
+		FROM comment_revisions r
+		JOIN users u ON r.replaced_by = u.id
+		WHERE r.comment_id = $1
+		ORDER BY r.revision ASC
+	`, commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []models.CommentRevision
+	for rows.Next() {
+		var revision models.CommentRevision
+		err := rows.Scan(
+			&revision.ID, &revision.CommentID, &revision.Revision, &revision.Content, &revision.WrittenAt,
+			&revision.ReplacedBy, &revision.ReplacedByName, &revision.ReplacedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
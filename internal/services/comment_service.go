@@ -0,0 +1,270 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/validators"
+	"errors"
+	"strconv"
+)
+
+type CommentService struct {
+	repo      CommentRepository
+	mentions  MentionResolver
+	validator *validators.CommentValidator
+}
+
+func NewCommentService(repo CommentRepository) *CommentService {
+	return &CommentService{
+		repo:      repo,
+		validator: validators.NewCommentValidator(),
+	}
+}
+
+// SetMentionResolver makes the service resolve the @mentions of comments with
+// resolver and store them with the comments
+func (s *CommentService) SetMentionResolver(resolver MentionResolver) {
+	s.mentions = resolver
+}
+
+// GetComments retrieves a page of a task's comments and the cursor of the next page,
+// which is empty on the last page. Deleted placeholders have no author.
+func (s *CommentService) GetComments(taskID string, filter models.CommentFilter) ([]models.Comment, string, error) {
+	if err := s.validator.ValidateCommentFilter(&filter); err != nil {
+		return nil, "", err
+	}
+
+	var after *CommentCursor
+	if filter.Cursor != "" {
+		cursor, err := DecodeCommentCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		after = &cursor
+	}
+
+	id, err := s.taskExists(taskID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Fetch one extra comment to know whether another page follows
+	limit := filter.Limit
+	filter.Limit++
+	comments, err := s.repo.ListComments(id, filter, after)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(comments) > limit {
+		comments = comments[:limit]
+		last := comments[limit-1]
+		nextCursor = EncodeCommentCursor(CommentCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	// Placeholders keep the thread together without saying who deleted what
+	for i := range comments {
+		if comments[i].Deleted {
+			comments[i].UserID = 0
+			comments[i].UserName = ""
+		}
+	}
+	if comments == nil {
+		comments = []models.Comment{}
+	}
+
+	return comments, nextCursor, nil
+}
+
+// GetComment retrieves a comment; deleted placeholders are not found
+func (s *CommentService) GetComment(commentID string) (*models.Comment, error) {
+	comment, err := s.loadComment(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.Deleted {
+		return nil, ErrCommentNotFound
+	}
+
+	return comment, nil
+}
+
+// CreateComment adds a comment to a task. Replies must target a live comment on the
+// same task and stay within validators.MaxCommentDepth.
+func (s *CommentService) CreateComment(taskID string, req models.CreateCommentRequest, userID int) (*models.Comment, error) {
+	id, err := s.taskExists(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validator.ValidateCreateComment(&req); err != nil {
+		return nil, err
+	}
+
+	comment := models.Comment{TaskID: id, UserID: userID, Content: req.Content}
+
+	if req.ParentID != nil {
+		parent, err := s.repo.GetComment(*req.ParentID)
+		if errors.Is(err, ErrCommentNotFound) || (err == nil && parent.TaskID != id) {
+			return nil, ErrParentNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if parent.Deleted {
+			return nil, ErrParentDeleted
+		}
+		if err := s.validator.ValidateReplyDepth(parent.Depth); err != nil {
+			return nil, err
+		}
+
+		comment.ParentID = req.ParentID
+		comment.Depth = parent.Depth + 1
+	}
+
+	change := Change{
+		Event:    models.EventCommentCreated,
+		ActorID:  userID,
+		Action:   "commented",
+		Details:  "Added a comment",
+		Watchers: []int{userID},
+	}
+	if comment.ParentID != nil {
+		change.Action = "replied"
+		change.Details = "Replied to a comment"
+	}
+	if err := resolveMentionsInto(&change, s.mentions, comment.Content); err != nil {
+		return nil, err
+	}
+
+	commentID, err := s.repo.CreateComment(comment, change)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.repo.GetComment(commentID)
+	if err != nil {
+		return nil, err
+	}
+	created.Mentions = change.Mentions
+	return created, nil
+}
+
+// UpdateComment replaces a comment's content, keeping the previous content as a
+// revision (only the comment author can update). It reports whether the content
+// changed; saving unchanged content is not an edit.
+func (s *CommentService) UpdateComment(commentID string, req models.UpdateCommentRequest, userID int) (*models.Comment, bool, error) {
+	comment, err := s.loadComment(commentID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if comment.UserID != userID {
+		return nil, false, ErrNotCommentAuthor
+	}
+
+	if comment.Deleted {
+		return nil, false, ErrCommentDeleted
+	}
+
+	if err := s.validator.ValidateUpdateComment(&req); err != nil {
+		return nil, false, err
+	}
+
+	if req.Content == comment.Content {
+		return comment, false, nil
+	}
+
+	change := Change{
+		Event:   models.EventCommentUpdated,
+		ActorID: userID,
+		Action:  "updated_comment",
+		Details: "Updated a comment",
+	}
+	if err := resolveMentionsInto(&change, s.mentions, req.Content); err != nil {
+		return nil, false, err
+	}
+	if err := s.repo.EditComment(comment.ID, req.Content, userID, change); err != nil {
+		return nil, false, err
+	}
+
+	comment, err = s.repo.GetComment(comment.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	comment.Mentions = change.Mentions
+
+	return comment, true, nil
+}
+
+// DeleteComment removes a comment (only the comment author can delete). A comment
+// with replies becomes a placeholder instead. It returns the comment as it was.
+func (s *CommentService) DeleteComment(commentID string, userID int) (*models.Comment, error) {
+	comment, err := s.GetComment(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.UserID != userID {
+		return nil, ErrNotCommentAuthor
+	}
+
+	change := Change{
+		Event:   models.EventCommentDeleted,
+		ActorID: userID,
+		Action:  "deleted_comment",
+		Details: "Deleted a comment",
+	}
+	if err := s.repo.DeleteComment(comment.ID, change); err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// GetRevisions retrieves the previous versions of a comment, oldest first
+func (s *CommentService) GetRevisions(commentID string) ([]models.CommentRevision, error) {
+	comment, err := s.GetComment(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.repo.ListRevisions(comment.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if revisions == nil {
+		revisions = []models.CommentRevision{}
+	}
+
+	return revisions, nil
+}
+
+// loadComment retrieves a comment, including deleted placeholders
+func (s *CommentService) loadComment(commentID string) (*models.Comment, error) {
+	id, err := strconv.Atoi(commentID)
+	if err != nil {
+		return nil, ErrCommentNotFound
+	}
+
+	return s.repo.GetComment(id)
+}
+
+func (s *CommentService) taskExists(taskID string) (int, error) {
+	id, err := strconv.Atoi(taskID)
+	if err != nil {
+		return 0, ErrTaskNotFound
+	}
+
+	exists, err := s.repo.TaskExists(id)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, ErrTaskNotFound
+	}
+
+	return id, nil
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/models"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newCommentStore returns a MemoryStore holding the given number of tasks
+func newCommentStore(tasks int) *MemoryStore {
+	store := NewMemoryStore()
+	for i := 0; i < tasks; i++ {
+		_, _ = store.CreateTask(models.Task{Title: "task"}, nil, Change{})
+	}
+	return store
+}
+
+// addComment stores a comment created at minute n after a fixed start time
+func addComment(store *MemoryStore, comment models.Comment, minute int) int {
+	comment.CreatedAt = time.Date(2024, 1, 1, 9, minute, 0, 0, time.UTC)
+	return store.AddComment(comment)
+}
+
+func TestCreateComment(t *testing.T) {
+	repo := newCommentStore(2)
+	top := addComment(repo, models.Comment{TaskID: 1, UserID: 10, Content: "top"}, 1)
+	otherTask := addComment(repo, models.Comment{TaskID: 2, UserID: 10, Content: "elsewhere"}, 2)
+	deleted := addComment(repo, models.Comment{TaskID: 1, UserID: 10, Deleted: true}, 3)
+	deepest := addComment(repo, models.Comment{TaskID: 1, UserID: 10, Content: "deep", Depth: 3}, 4)
+	service := NewCommentService(repo)
+
+	parent := func(id int) *int { return &id }
+
+	tests := []struct {
+		name      string
+		taskID    string
+		req       models.CreateCommentRequest
+		wantErr   error
+		wantValid bool
+		wantDepth int
+	}{
+		{"Top-level comment", "1", models.CreateCommentRequest{Content: "hello"}, nil, true, 0},
+		{"Reply", "1", models.CreateCommentRequest{Content: "hi", ParentID: parent(top)}, nil, true, 1},
+		{"Unknown task", "99", models.CreateCommentRequest{Content: "hello"}, ErrTaskNotFound, true, 0},
+		{"Non-numeric task", "abc", models.CreateCommentRequest{Content: "hello"}, ErrTaskNotFound, true, 0},
+		{"Blank content", "1", models.CreateCommentRequest{Content: "   "}, nil, false, 0},
+		{"Content too long", "1", models.CreateCommentRequest{Content: strings.Repeat("a", 5001)}, nil, false, 0},
+		{"Parent on another task", "1", models.CreateCommentRequest{Content: "hi", ParentID: parent(otherTask)}, ErrParentNotFound, true, 0},
+		{"Unknown parent", "1", models.CreateCommentRequest{Content: "hi", ParentID: parent(99)}, ErrParentNotFound, true, 0},
+		{"Deleted parent", "1", models.CreateCommentRequest{Content: "hi", ParentID: parent(deleted)}, ErrParentDeleted, true, 0},
+		{"Too deep", "1", models.CreateCommentRequest{Content: "hi", ParentID: parent(deepest)}, nil, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comment, err := service.CreateComment(tt.taskID, tt.req, 10)
+
+			if !tt.wantValid {
+				if !errors.Is(err, apperrors.ErrValidation) {
+					t.Fatalf("CreateComment() error = %v, want ValidationError", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateComment() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && comment.Depth != tt.wantDepth {
+				t.Errorf("CreateComment() depth = %d, want %d", comment.Depth, tt.wantDepth)
+			}
+		})
+	}
+}
+
+func TestUpdateComment(t *testing.T) {
+	repo := newCommentStore(1)
+	own := addComment(repo, models.Comment{TaskID: 1, UserID: 10, Content: "original"}, 1)
+	deleted := addComment(repo, models.Comment{TaskID: 1, UserID: 10, Deleted: true}, 2)
+	service := NewCommentService(repo)
+
+	id := strconv.Itoa
+
+	if _, _, err := service.UpdateComment(id(own), models.UpdateCommentRequest{Content: "changed"}, 11); !errors.Is(err, ErrNotCommentAuthor) {
+		t.Errorf("update by another user: error = %v, want %v", err, ErrNotCommentAuthor)
+	}
+	if _, _, err := service.UpdateComment(id(deleted), models.UpdateCommentRequest{Content: "changed"}, 10); !errors.Is(err, ErrCommentDeleted) {
+		t.Errorf("update of a deleted comment: error = %v, want %v", err, ErrCommentDeleted)
+	}
+	if _, _, err := service.UpdateComment("99", models.UpdateCommentRequest{Content: "changed"}, 10); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("update of a missing comment: error = %v, want %v", err, ErrCommentNotFound)
+	}
+
+	if _, _, err := service.UpdateComment(id(own), models.UpdateCommentRequest{Content: " "}, 10); !errors.Is(err, apperrors.ErrValidation) {
+		t.Errorf("blank update: error = %v, want ValidationError", err)
+	}
+
+	if _, changed, err := service.UpdateComment(id(own), models.UpdateCommentRequest{Content: "original"}, 10); err != nil || changed {
+		t.Errorf("unchanged update: changed = %v, error = %v, want false, nil", changed, err)
+	}
+
+	comment, changed, err := service.UpdateComment(id(own), models.UpdateCommentRequest{Content: "changed"}, 10)
+	if err != nil || !changed {
+		t.Fatalf("update: changed = %v, error = %v, want true, nil", changed, err)
+	}
+	if comment.Content != "changed" || !comment.Edited || comment.EditCount != 1 {
+		t.Errorf("update: comment = %+v, want edited content", comment)
+	}
+
+	revisions, err := service.GetRevisions(id(own))
+	if err != nil || len(revisions) != 1 || revisions[0].Content != "original" {
+		t.Errorf("GetRevisions() = %+v, %v, want the original content", revisions, err)
+	}
+}
+
+func TestDeleteComment(t *testing.T) {
+	repo := newCommentStore(1)
+	own := addComment(repo, models.Comment{TaskID: 1, UserID: 10, Content: "mine"}, 1)
+	deleted := addComment(repo, models.Comment{TaskID: 1, UserID: 10, Deleted: true}, 2)
+	service := NewCommentService(repo)
+
+	if _, err := service.DeleteComment(strconv.Itoa(own), 11); !errors.Is(err, ErrNotCommentAuthor) {
+		t.Errorf("delete by another user: error = %v, want %v", err, ErrNotCommentAuthor)
+	}
+	if _, err := service.DeleteComment(strconv.Itoa(deleted), 10); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("delete of a placeholder: error = %v, want %v", err, ErrCommentNotFound)
+	}
+
+	comment, err := service.DeleteComment(strconv.Itoa(own), 10)
+	if err != nil || comment.TaskID != 1 {
+		t.Fatalf("DeleteComment() = %+v, %v, want the deleted comment", comment, err)
+	}
+	if _, err := service.GetComment(strconv.Itoa(own)); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("GetComment() after delete: error = %v, want %v", err, ErrCommentNotFound)
+	}
+}
+
+func TestGetCommentsHidesPlaceholderAuthors(t *testing.T) {
+	repo := newCommentStore(1)
+	deleted := addComment(repo, models.Comment{TaskID: 1, UserID: 10, Deleted: true}, 1)
+	reply := addComment(repo, models.Comment{TaskID: 1, UserID: 11, ParentID: &deleted, Depth: 1, Content: "reply"}, 2)
+	service := NewCommentService(repo)
+
+	comments, _, err := service.GetComments("1", models.CommentFilter{Limit: 10, Order: "asc"})
+	if err != nil || len(comments) != 2 {
+		t.Fatalf("GetComments() = %+v, %v, want the placeholder and the reply", comments, err)
+	}
+	if placeholder := comments[0]; placeholder.ID != deleted || placeholder.UserID != 0 || placeholder.UserName != "" {
+		t.Errorf("placeholder = %+v, want no author", placeholder)
+	}
+	if comments[1].ID != reply || comments[1].UserID != 11 {
+		t.Errorf("reply = %+v, want its author", comments[1])
+	}
+
+	author := 10
+	comments, _, err = service.GetComments("1", models.CommentFilter{Limit: 10, Order: "asc", UserID: &author})
+	if err != nil || len(comments) != 0 {
+		t.Errorf("GetComments() by the placeholder's author = %+v, %v, want none", comments, err)
+	}
+}
+
+func TestGetCommentsPaging(t *testing.T) {
+	repo := newCommentStore(1)
+	for minute := 1; minute <= 5; minute++ {
+		addComment(repo, models.Comment{TaskID: 1, UserID: 10 + minute%2}, minute)
+	}
+	service := NewCommentService(repo)
+
+	ids := func(comments []models.Comment) []int {
+		out := []int{}
+		for _, comment := range comments {
+			out = append(out, comment.ID)
+		}
+		return out
+	}
+
+	// Walk every page and collect the ids in order
+	pages := func(filter models.CommentFilter) [][]int {
+		var result [][]int
+		for {
+			comments, next, err := service.GetComments("1", filter)
+			if err != nil {
+				t.Fatalf("GetComments() error = %v", err)
+			}
+			result = append(result, ids(comments))
+			if next == "" {
+				return result
+			}
+			filter.Cursor = next
+		}
+	}
+
+	author := 11
+	since := time.Date(2024, 1, 1, 9, 2, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		filter models.CommentFilter
+		want   [][]int
+	}{
+		{"Ascending", models.CommentFilter{Limit: 2, Order: "asc"}, [][]int{{1, 2}, {3, 4}, {5}}},
+		{"Descending", models.CommentFilter{Limit: 2, Order: "desc"}, [][]int{{5, 4}, {3, 2}, {1}}},
+		{"Exact last page", models.CommentFilter{Limit: 5, Order: "asc"}, [][]int{{1, 2, 3, 4, 5}}},
+		{"By author", models.CommentFilter{Limit: 2, Order: "asc", UserID: &author}, [][]int{{1, 3}, {5}}},
+		{"Since", models.CommentFilter{Limit: 10, Order: "asc", Since: &since}, [][]int{{3, 4, 5}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pages(tt.filter); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, _, err := service.GetComments("1", models.CommentFilter{Limit: 2, Order: "asc", Cursor: "bogus"}); !errors.Is(err, apperrors.ErrValidation) {
+		t.Errorf("invalid cursor: error = %v, want ValidationError", err)
+	}
+	if _, _, err := service.GetComments("1", models.CommentFilter{Limit: 0, Order: "asc"}); !errors.Is(err, apperrors.ErrValidation) {
+		t.Errorf("invalid limit: error = %v, want ValidationError", err)
+	}
+	if _, _, err := service.GetComments("2", models.CommentFilter{Limit: 2, Order: "asc"}); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("unknown task: error = %v, want %v", err, ErrTaskNotFound)
+	}
+}
@@ -0,0 +1,59 @@
+package services
+
+import "candidate-backend/internal/models"
+
+// BuildCommentThreads nests replies under their parent comments. The input must be
+// ordered by creation time; siblings keep that order. A reply whose parent is not
+// in the list is treated as a top-level comment.
+func BuildCommentThreads(comments []models.Comment) []models.Comment {
+	present := make(map[int]bool, len(comments))
+	for _, comment := range comments {
+		present[comment.ID] = true
+	}
+
+	children := map[int][]int{}
+	var roots []int
+	for i, comment := range comments {
+		if comment.ParentID != nil && present[*comment.ParentID] {
+			children[*comment.ParentID] = append(children[*comment.ParentID], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	var build func(i int) models.Comment
+	build = func(i int) models.Comment {
+		comment := comments[i]
+		comment.Replies = nil
+		for _, child := range children[comment.ID] {
+			comment.Replies = append(comment.Replies, build(child))
+		}
+		return comment
+	}
+
+	threads := make([]models.Comment, 0, len(roots))
+	for _, i := range roots {
+		threads = append(threads, build(i))
+	}
+
+	return threads
+}
+
+// FlattenCommentThreads lists nested comments depth-first so every reply directly
+// follows its parent or an earlier sibling's subtree
+func FlattenCommentThreads(threads []models.Comment) []models.Comment {
+	flat := []models.Comment{}
+
+	var walk func(comments []models.Comment)
+	walk = func(comments []models.Comment) {
+		for _, comment := range comments {
+			replies := comment.Replies
+			comment.Replies = nil
+			flat = append(flat, comment)
+			walk(replies)
+		}
+	}
+	walk(threads)
+
+	return flat
+}
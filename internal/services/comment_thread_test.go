@@ -0,0 +1,56 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestCommentThreads(t *testing.T) {
+	parent := func(id int) *int { return &id }
+
+	// Ordered by creation time, as loaded from the database
+	comments := []models.Comment{
+		{ID: 1},
+		{ID: 2},
+		{ID: 3, ParentID: parent(1), Depth: 1},
+		{ID: 4, ParentID: parent(3), Depth: 2},
+		{ID: 5, ParentID: parent(2), Depth: 1},
+		{ID: 6, ParentID: parent(1), Depth: 1},
+		{ID: 7, ParentID: parent(99), Depth: 1},
+	}
+
+	threads := BuildCommentThreads(comments)
+
+	ids := func(list []models.Comment) []int {
+		out := []int{}
+		for _, comment := range list {
+			out = append(out, comment.ID)
+		}
+		return out
+	}
+
+	if got := ids(threads); !reflect.DeepEqual(got, []int{1, 2, 7}) {
+		t.Fatalf("top-level ids = %v, want [1 2 7]", got)
+	}
+	if got := ids(threads[0].Replies); !reflect.DeepEqual(got, []int{3, 6}) {
+		t.Errorf("replies of 1 = %v, want [3 6]", got)
+	}
+	if got := ids(threads[0].Replies[0].Replies); !reflect.DeepEqual(got, []int{4}) {
+		t.Errorf("replies of 3 = %v, want [4]", got)
+	}
+
+	flat := FlattenCommentThreads(threads)
+	if got := ids(flat); !reflect.DeepEqual(got, []int{1, 3, 4, 6, 2, 5, 7}) {
+		t.Errorf("flattened ids = %v, want [1 3 4 6 2 5 7]", got)
+	}
+	for _, comment := range flat {
+		if comment.Replies != nil {
+			t.Errorf("flattened comment %d still has replies", comment.ID)
+		}
+	}
+
+	if got := FlattenCommentThreads(BuildCommentThreads(nil)); len(got) != 0 || got == nil {
+		t.Errorf("empty input = %#v, want empty non-nil slice", got)
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
+)
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+type CustomFieldService struct {
+	db *sql.DB
+}
+
+func NewCustomFieldService(db *sql.DB) *CustomFieldService {
+	return &CustomFieldService{db: db}
+}
+
+func scanCustomField(row rowScanner) (*models.CustomField, error) {
+	var field models.CustomField
+	var options []byte
+	err := row.Scan(
+		&field.ID, &field.ProjectID, &field.Key, &field.Name, &field.Type,
+		&options, &field.CreatedAt, &field.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(options, &field.Options); err != nil {
+		return nil, err
+	}
+	if len(field.Options) == 0 {
+		field.Options = nil
+	}
+
+	return &field, nil
+}
+
+// GetFields retrieves the custom field definitions of a project
+func (s *CustomFieldService) GetFields(projectID int) ([]models.CustomField, error) {
+	rows, err := s.db.Query(`
+		SELECT id, project_id, key, name, field_type, options, created_at, updated_at
+		FROM project_custom_fields
+		WHERE project_id = $1
+		ORDER BY id ASC
+	`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fields []models.CustomField
+	for rows.Next() {
+		field, err := scanCustomField(rows)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, *field)
+	}
+
+	if fields == nil {
+		fields = []models.CustomField{}
+	}
+
+	return fields, nil
+}
+
+// SaveValues upserts or clears custom field values on a task. Values must already be validated.
+func (s *CustomFieldService) SaveValues(exec execer, taskID int, values []FieldValue) error {
+	for _, value := range values {
+		field := value.Field
+
+		if value.Value == nil {
+			_, err := exec.Exec("DELETE FROM task_custom_field_values WHERE task_id = $1 AND field_id = $2", taskID, field.ID)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		encoded, err := json.Marshal(value.Value)
+		if err != nil {
+			return err
+		}
+
+		_, err = exec.Exec(`
+			INSERT INTO task_custom_field_values (task_id, field_id, value)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (task_id, field_id) DO UPDATE SET value = EXCLUDED.value
+		`, taskID, field.ID, string(encoded))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ClearForeignValues removes values whose field does not belong to the task's current project
+func (s *CustomFieldService) ClearForeignValues(exec execer, taskID int, projectID *int) error {
+	_, err := exec.Exec(`
+		DELETE FROM task_custom_field_values v
+		USING project_custom_fields f
+		WHERE v.field_id = f.id AND v.task_id = $1
+		  AND ($2::INTEGER IS NULL OR f.project_id <> $2)
+	`, taskID, projectID)
+	return err
+}
+
+// AttachValues loads custom field values for a list of tasks in a single query
+func (s *CustomFieldService) AttachValues(tasks []models.Task) error {
+	return s.attachValues(s.db, tasks)
+}
+
+// attachValues is AttachValues on a transaction or the database
+func (s *CustomFieldService) attachValues(q queryer, tasks []models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(tasks))
+	taskIDs := make([]int, 0, len(tasks))
+	for i, task := range tasks {
+		index[task.ID] = i
+		taskIDs = append(taskIDs, task.ID)
+	}
+
+	rows, err := q.Query(`
+		SELECT v.task_id, f.key, v.value
+		FROM task_custom_field_values v
+		JOIN project_custom_fields f ON v.field_id = f.id
+		WHERE v.task_id = ANY($1)
+	`, pq.Array(taskIDs))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskID int
+		var key string
+		var raw []byte
+		if err := rows.Scan(&taskID, &key, &raw); err != nil {
+			return err
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+
+		task := &tasks[index[taskID]]
+		if task.CustomFields == nil {
+			task.CustomFields = map[string]interface{}{}
+		}
+		task.CustomFields[key] = value
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Email states of a notification
+const (
+	emailPending = "pending"
+	emailSent    = "sent"
+	emailSkipped = "skipped"
+	emailFailed  = "failed"
+)
+
+// EmailRepository tracks which notifications were emailed, which kinds of email
+// users get and when they got their last digest
+type EmailRepository interface {
+	// ClaimNotificationEmails claims up to limit notifications of the given types that
+	// wait to be emailed, oldest first, and passes each to send. send reports whether it
+	// sent an email; the notification is then marked sent or skipped. When send fails
+	// the attempt is counted, and after maxAttempts the notification is marked failed.
+	// Notifications claimed by one caller are skipped by the others until it returns.
+	ClaimNotificationEmails(types []models.NotificationType, limit, maxAttempts int, send func(models.Notification) (bool, error)) (int, error)
+
+	// EmailPreferences returns the kinds of email a user turned on or off; other kinds are on
+	EmailPreferences(userID int) (models.EmailPreferences, error)
+	SetEmailPreferences(userID int, preferences models.EmailPreferences) error
+
+	// DigestsDue returns the digests of the users who have digests on, were last sent
+	// one before cutoff and have unread notifications since. Users who never got a
+	// digest get the notifications since cutoff. Each digest holds at most limit
+	// notifications.
+	DigestsDue(cutoff time.Time, limit int) ([]models.Digest, error)
+	// DigestSent records when a user was sent a digest
+	DigestSent(userID int, at time.Time) error
+}
+
+type PostgresEmailRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresEmailRepository(db *sql.DB) *PostgresEmailRepository {
+	return &PostgresEmailRepository{db: db}
+}
+
+func (r *PostgresEmailRepository) ClaimNotificationEmails(types []models.NotificationType, limit, maxAttempts int, send func(models.Notification) (bool, error)) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	names := make([]string, len(types))
+	for i, notificationType := range types {
+		names[i] = string(notificationType)
+	}
+
+	rows, err := tx.Query(notificationSelect+`
+		WHERE n.email_status = $1 AND n.type = ANY($2)
+		ORDER BY n.created_at, n.id
+		LIMIT $3
+		FOR UPDATE OF n SKIP LOCKED
+	`, emailPending, pq.Array(names), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var notifications []models.Notification
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		notifications = append(notifications, *notification)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, notification := range notifications {
+		sent, err := send(notification)
+		if err != nil {
+			_, err = tx.Exec(`
+				UPDATE notifications
+				SET email_attempts = email_attempts + 1,
+				    email_status = CASE WHEN email_attempts + 1 >= $1 THEN $2 ELSE email_status END
+				WHERE id = $3
+			`, maxAttempts, emailFailed, notification.ID)
+		} else {
+			status := emailSkipped
+			if sent {
+				status = emailSent
+			}
+			_, err = tx.Exec(`
+				UPDATE notifications SET email_attempts = email_attempts + 1, email_status = $1 WHERE id = $2
+			`, status, notification.ID)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(notifications), tx.Commit()
+}
+
+func (r *PostgresEmailRepository) EmailPreferences(userID int) (models.EmailPreferences, error) {
+	rows, err := r.db.Query(`
+		SELECT kind, enabled FROM email_preferences WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	preferences := models.EmailPreferences{}
+	for rows.Next() {
+		var kind models.EmailKind
+		var enabled bool
+		if err := rows.Scan(&kind, &enabled); err != nil {
+			return nil, err
+		}
+		preferences[kind] = enabled
+	}
+
+	return preferences, rows.Err()
+}
+
+func (r *PostgresEmailRepository) SetEmailPreferences(userID int, preferences models.EmailPreferences) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for kind, enabled := range preferences {
+		_, err := tx.Exec(`
+			INSERT INTO email_preferences (user_id, kind, enabled)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, kind) DO UPDATE SET enabled = $3, updated_at = CURRENT_TIMESTAMP
+		`, userID, kind, enabled)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresEmailRepository) DigestsDue(cutoff time.Time, limit int) ([]models.Digest, error) {
+	rows, err := r.db.Query(`
+		SELECT u.id, u.email, u.name, COALESCE(d.sent_at, $1)
+		FROM users u
+		LEFT JOIN email_digests d ON d.user_id = u.id
+		WHERE (d.sent_at IS NULL OR d.sent_at < $1)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM email_preferences p
+		      WHERE p.user_id = u.id AND p.kind = $2 AND NOT p.enabled)
+		  AND EXISTS (
+		      SELECT 1 FROM notifications n
+		      WHERE n.user_id = u.id AND n.read_at IS NULL AND n.created_at > COALESCE(d.sent_at, $1))
+		ORDER BY u.id
+	`, cutoff, models.EmailDigest)
+	if err != nil {
+		return nil, err
+	}
+
+	var digests []models.Digest
+	for rows.Next() {
+		var digest models.Digest
+		if err := rows.Scan(&digest.User.ID, &digest.User.Email, &digest.User.Name, &digest.Since); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		digests = append(digests, digest)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range digests {
+		notifications, err := r.db.Query(notificationSelect+`
+			WHERE n.user_id = $1 AND n.read_at IS NULL AND n.created_at > $2
+			ORDER BY n.created_at DESC, n.id DESC
+			LIMIT $3
+		`, digests[i].User.ID, digests[i].Since, limit)
+		if err != nil {
+			return nil, err
+		}
+		for notifications.Next() {
+			notification, err := scanNotification(notifications)
+			if err != nil {
+				notifications.Close()
+				return nil, err
+			}
+			digests[i].Notifications = append(digests[i].Notifications, *notification)
+		}
+		notifications.Close()
+		if err := notifications.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return digests, nil
+}
+
+func (r *PostgresEmailRepository) DigestSent(userID int, at time.Time) error {
+	_, err := r.db.Exec(`
+		INSERT INTO email_digests (user_id, sent_at) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET sent_at = $2
+	`, userID, at)
+	return err
+}
@@ -0,0 +1,323 @@
+package services
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/mail"
+	"candidate-backend/internal/models"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailSettings configures the emails EmailService sends
+type EmailSettings struct {
+	// AppURL is the base URL task links in emails point to
+	AppURL string
+	// UnsubscribeURL is the public URL of the unsubscribe route. Emails link to it with
+	// a token signed with SigningKey.
+	UnsubscribeURL string
+	SigningKey     string
+	// MaxAttempts is the number of attempts before a notification email fails
+	MaxAttempts int
+}
+
+// emailBatch is the number of notifications claimed for emailing at a time
+const emailBatch = 50
+
+// digestLimit is the number of notifications a digest lists at most
+const digestLimit = 50
+
+// emailedNotifications are the notification types sent by email as they happen;
+// the others only appear in digests
+var emailedNotifications = []models.NotificationType{
+	models.NotificationAssigned,
+	models.NotificationMention,
+	models.NotificationDueSoon,
+}
+
+type EmailService struct {
+	repo     EmailRepository
+	tasks    TaskRepository
+	users    UserRepository
+	mailer   mail.Mailer
+	settings EmailSettings
+	now      func() time.Time
+}
+
+func NewEmailService(repo EmailRepository, tasks TaskRepository, users UserRepository, mailer mail.Mailer, settings EmailSettings) *EmailService {
+	return &EmailService{
+		repo:     repo,
+		tasks:    tasks,
+		users:    users,
+		mailer:   mailer,
+		settings: settings,
+		now:      now,
+	}
+}
+
+// notificationEmail fills the templates of notification emails
+type notificationEmail struct {
+	Name           string
+	ActorName      string
+	Message        string
+	TaskTitle      string
+	TaskURL        string
+	UnsubscribeURL string
+}
+
+// digestEmail fills the digest template
+type digestEmail struct {
+	Name           string
+	Notifications  []digestItem
+	UnsubscribeURL string
+}
+
+type digestItem struct {
+	Message   string
+	TaskURL   string
+	CreatedAt time.Time
+}
+
+// GetPreferences returns for every kind of email whether the user gets it
+func (s *EmailService) GetPreferences(userID int) (models.EmailPreferences, error) {
+	stored, err := s.repo.EmailPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := models.EmailPreferences{}
+	for _, kind := range models.EmailKinds {
+		enabled, ok := stored[kind]
+		preferences[kind] = enabled || !ok
+	}
+	return preferences, nil
+}
+
+// UpdatePreferences turns the given kinds of email on or off and returns the
+// resulting preferences
+func (s *EmailService) UpdatePreferences(userID int, preferences models.EmailPreferences) (models.EmailPreferences, error) {
+	if len(preferences) == 0 {
+		return nil, apperrors.Invalid("body", "no preferences to update")
+	}
+	for kind := range preferences {
+		if !slices.Contains(models.EmailKinds, kind) {
+			return nil, apperrors.Invalidf(string(kind), "unknown email kind '%s'", kind)
+		}
+	}
+
+	if err := s.repo.SetEmailPreferences(userID, preferences); err != nil {
+		return nil, err
+	}
+
+	return s.GetPreferences(userID)
+}
+
+// UnsubscribeToken returns the token of a link that turns a kind of email off for a
+// user: the user ID, the kind and their HMAC-SHA256 signature, joined by dots
+func (s *EmailService) UnsubscribeToken(userID int, kind models.EmailKind) string {
+	payload := strconv.Itoa(userID) + "." + string(kind)
+	return payload + "." + s.sign(payload)
+}
+
+// VerifyUnsubscribeToken returns the user and kind of email an unsubscribe token is for
+func (s *EmailService) VerifyUnsubscribeToken(token string) (int, models.EmailKind, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(s.sign(payload)), []byte(parts[2])) {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+
+	userID, err := strconv.Atoi(parts[0])
+	kind := models.EmailKind(parts[1])
+	if err != nil || !slices.Contains(models.EmailKinds, kind) {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+	return userID, kind, nil
+}
+
+// Unsubscribe turns off the kind of email an unsubscribe token is for and returns it
+func (s *EmailService) Unsubscribe(token string) (models.EmailKind, error) {
+	userID, kind, err := s.VerifyUnsubscribeToken(token)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.repo.SetEmailPreferences(userID, models.EmailPreferences{kind: false}); err != nil {
+		return "", err
+	}
+	return kind, nil
+}
+
+// Run emails new notifications and sends due digests every interval until ctx is
+// done. Users get a digest at most once per digestPeriod.
+func (s *EmailService) Run(ctx context.Context, interval, digestPeriod time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := s.SendNotificationEmails(ctx); err != nil {
+			log.Printf("email: sending notification emails: %v", err)
+		}
+		if _, err := s.SendDigests(ctx, digestPeriod); err != nil {
+			log.Printf("email: sending digests: %v", err)
+		}
+	}
+}
+
+// SendNotificationEmails emails the assignment, mention and due-date notifications
+// that were not emailed yet to users who have that kind of email on, and returns how
+// many it handled. Failed emails are retried on the next call.
+func (s *EmailService) SendNotificationEmails(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		failed := false
+		claimed, err := s.repo.ClaimNotificationEmails(emailedNotifications, emailBatch, s.settings.MaxAttempts,
+			func(notification models.Notification) (bool, error) {
+				sent, err := s.sendNotification(ctx, notification)
+				if err != nil {
+					failed = true
+					log.Printf("email: notification %d: %v", notification.ID, err)
+				}
+				return sent, err
+			})
+		total += claimed
+		if err != nil || failed || claimed < emailBatch {
+			return total, err
+		}
+	}
+}
+
+// SendDigests sends each user whose last digest is at least period old a digest of
+// their unread notifications since, and returns how many it sent
+func (s *EmailService) SendDigests(ctx context.Context, period time.Duration) (int, error) {
+	at := s.now()
+	digests, err := s.repo.DigestsDue(at.Add(-period), digestLimit)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var failed error
+	for _, digest := range digests {
+		data := digestEmail{
+			Name:           digest.User.Name,
+			UnsubscribeURL: s.unsubscribeURL(digest.User.ID, models.EmailDigest),
+		}
+		for _, notification := range digest.Notifications {
+			data.Notifications = append(data.Notifications, digestItem{
+				Message:   notification.Message,
+				TaskURL:   s.taskURL(notification.TaskID),
+				CreatedAt: notification.CreatedAt,
+			})
+		}
+
+		if err := s.send(ctx, digest.User, "digest", data, models.EmailDigest); err != nil {
+			failed = fmt.Errorf("digest for user %d: %w", digest.User.ID, err)
+			continue
+		}
+		if err := s.repo.DigestSent(digest.User.ID, at); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, failed
+}
+
+// sendNotification emails a notification unless the user turned its kind of email
+// off, or its user or task is gone, and reports whether it did
+func (s *EmailService) sendNotification(ctx context.Context, notification models.Notification) (bool, error) {
+	kind := models.EmailKind(notification.Type)
+	preferences, err := s.GetPreferences(notification.UserID)
+	if err != nil {
+		return false, err
+	}
+	if !preferences[kind] {
+		return false, nil
+	}
+
+	user, err := s.users.GetUser(notification.UserID)
+	if err == ErrUserNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	data := notificationEmail{
+		Name:           user.Name,
+		ActorName:      notification.ActorName,
+		Message:        notification.Message,
+		TaskURL:        s.taskURL(notification.TaskID),
+		UnsubscribeURL: s.unsubscribeURL(user.ID, kind),
+	}
+	if data.ActorName == "" {
+		data.ActorName = "Someone"
+	}
+	if notification.TaskID != nil {
+		task, err := s.tasks.GetTask(*notification.TaskID)
+		if err == ErrTaskNotFound {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		data.TaskTitle = task.Title
+	}
+
+	if err := s.send(ctx, *user, string(notification.Type), data, kind); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// send renders the email called name and sends it to the user with one-click
+// unsubscribe headers (RFC 8058) for its kind
+func (s *EmailService) send(ctx context.Context, user models.User, name string, data interface{}, kind models.EmailKind) error {
+	msg, err := mail.Render(name, data)
+	if err != nil {
+		return err
+	}
+
+	msg.To = user.Email
+	msg.Headers = map[string]string{
+		"List-Unsubscribe":      "<" + s.unsubscribeURL(user.ID, kind) + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+	return s.mailer.Send(ctx, msg)
+}
+
+func (s *EmailService) unsubscribeURL(userID int, kind models.EmailKind) string {
+	return s.settings.UnsubscribeURL + "?" + url.Values{"token": {s.UnsubscribeToken(userID, kind)}}.Encode()
+}
+
+func (s *EmailService) taskURL(taskID *int) string {
+	if taskID == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/tasks/%d", strings.TrimRight(s.settings.AppURL, "/"), *taskID)
+}
+
+func (s *EmailService) sign(payload string) string {
+	mac := hmac.New(sha256.New, []byte(s.settings.SigningKey))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
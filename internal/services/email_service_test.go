@@ -0,0 +1,195 @@
+package services
+
+import (
+	"candidate-backend/internal/mail"
+	"candidate-backend/internal/models"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newEmailService(t *testing.T) (*EmailService, *MemoryStore, *mail.MemoryMailer) {
+	t.Helper()
+	store := newTaskStore(t)
+	mailer := mail.NewMemoryMailer()
+	service := NewEmailService(store, store, store, mailer, EmailSettings{
+		AppURL:         "http://app.test",
+		UnsubscribeURL: "http://api.test/email/unsubscribe",
+		SigningKey:     "secret",
+		MaxAttempts:    2,
+	})
+	return service, store, mailer
+}
+
+func TestNotificationEmails(t *testing.T) {
+	service, store, mailer := newEmailService(t)
+	task, err := store.CreateTask(models.Task{Title: "Ship it", CreatorID: 1}, nil, Change{})
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+
+	notify := func(userID int, notificationType models.NotificationType) int {
+		t.Helper()
+		actor := 1
+		if err := store.CreateNotification(models.Notification{UserID: userID, ActorID: &actor, Type: notificationType, TaskID: &task.ID, Message: "Something happened"}); err != nil {
+			t.Fatalf("CreateNotification() error = %v", err)
+		}
+		return store.lastNotificationID
+	}
+
+	tests := []struct {
+		name       string
+		setup      func() int
+		fail       error
+		wantStatus string
+		wantSent   int
+	}{
+		{
+			name:       "Assignment is emailed",
+			setup:      func() int { return notify(2, models.NotificationAssigned) },
+			wantStatus: emailSent,
+			wantSent:   1,
+		},
+		{
+			name:       "Status changes only appear in digests",
+			setup:      func() int { return notify(2, models.NotificationStatus) },
+			wantStatus: emailPending,
+		},
+		{
+			name: "Kind turned off is skipped",
+			setup: func() int {
+				_ = store.SetEmailPreferences(2, models.EmailPreferences{models.EmailMention: false})
+				return notify(2, models.NotificationMention)
+			},
+			wantStatus: emailSkipped,
+		},
+		{
+			name:       "Failure is retried",
+			setup:      func() int { return notify(1, models.NotificationDueSoon) },
+			fail:       errors.New("connection refused"),
+			wantStatus: emailPending,
+		},
+		{
+			name:       "Failure past the attempts fails",
+			setup:      func() int { return store.lastNotificationID },
+			fail:       errors.New("connection refused"),
+			wantStatus: emailFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := tt.setup()
+			mailer.Fail(tt.fail)
+			defer mailer.Fail(nil)
+
+			if _, err := service.SendNotificationEmails(context.Background()); err != nil {
+				t.Fatalf("SendNotificationEmails() error = %v", err)
+			}
+			if status := store.NotificationEmailStatus(id); status != tt.wantStatus {
+				t.Errorf("email status = %q, want %q", status, tt.wantStatus)
+			}
+			if sent := mailer.Messages(); len(sent) != tt.wantSent {
+				t.Errorf("sent %d emails, want %d", len(sent), tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestNotificationEmailContent(t *testing.T) {
+	service, store, mailer := newEmailService(t)
+	task, _ := store.CreateTask(models.Task{Title: "Ship it", CreatorID: 1}, nil, Change{})
+	actor := 1
+	_ = store.CreateNotification(models.Notification{UserID: 2, ActorID: &actor, Type: models.NotificationAssigned, TaskID: &task.ID, Message: "You were assigned"})
+
+	if _, err := service.SendNotificationEmails(context.Background()); err != nil {
+		t.Fatalf("SendNotificationEmails() error = %v", err)
+	}
+	sent := mailer.Messages()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(sent))
+	}
+
+	msg := sent[0]
+	unsubscribe := "http://api.test/email/unsubscribe?token=" + service.UnsubscribeToken(2, models.EmailAssigned)
+	if msg.Subject != "alice@example.com assigned you to Ship it" {
+		t.Errorf("subject = %q, want alice assigned you to Ship it", msg.Subject)
+	}
+	if msg.To != "bob@example.com" || msg.Headers["List-Unsubscribe"] != "<"+unsubscribe+">" {
+		t.Errorf("email to %q with headers %v, want bob with his unsubscribe link", msg.To, msg.Headers)
+	}
+	for _, want := range []string{"You were assigned", "http://app.test/tasks/", unsubscribe} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("text %q does not contain %q", msg.Text, want)
+		}
+	}
+}
+
+func TestDigests(t *testing.T) {
+	service, store, mailer := newEmailService(t)
+	_ = store.CreateNotification(models.Notification{UserID: 1, Type: models.NotificationStatus, Message: "Status changed"})
+	_ = store.CreateNotification(models.Notification{UserID: 2, Type: models.NotificationComment, Message: "New comment"})
+	_ = store.SetEmailPreferences(2, models.EmailPreferences{models.EmailDigest: false})
+
+	clock := time.Now()
+	service.now = func() time.Time { return clock }
+
+	send := func(wantSent int) {
+		t.Helper()
+		sent, err := service.SendDigests(context.Background(), 24*time.Hour)
+		if err != nil || sent != wantSent {
+			t.Fatalf("SendDigests() = %d, %v, want %d", sent, err, wantSent)
+		}
+	}
+
+	send(1)
+	if sent := mailer.Messages(); len(sent) != 1 || sent[0].To != "alice@example.com" || !strings.Contains(sent[0].Text, "Status changed") {
+		t.Fatalf("digests = %+v, want one to alice", sent)
+	}
+
+	// Nothing new, and within the period
+	send(0)
+
+	// A period later, only new notifications are sent
+	_ = store.CreateNotification(models.Notification{UserID: 1, Type: models.NotificationComment, Message: "Another comment"})
+	send(0)
+	clock = clock.Add(25 * time.Hour)
+	send(1)
+	if sent := mailer.Messages(); len(sent) != 1 || strings.Contains(sent[0].Text, "Status changed") {
+		t.Fatalf("digests = %+v, want one without the notification already sent", sent)
+	}
+}
+
+func TestUnsubscribeToken(t *testing.T) {
+	service, _, _ := newEmailService(t)
+	token := service.UnsubscribeToken(2, models.EmailDigest)
+	other, _, _ := newEmailService(t)
+	other.settings.SigningKey = "other"
+
+	tests := []struct {
+		name    string
+		service *EmailService
+		token   string
+		wantErr error
+	}{
+		{"Valid token", service, token, nil},
+		{"Other user", service, "1" + strings.TrimPrefix(token, "2"), ErrInvalidUnsubscribeToken},
+		{"Other kind", service, strings.Replace(token, "digest", "mention", 1), ErrInvalidUnsubscribeToken},
+		{"Other key", other, token, ErrInvalidUnsubscribeToken},
+		{"Garbage", service, "not-a-token", ErrInvalidUnsubscribeToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID, kind, err := tt.service.VerifyUnsubscribeToken(tt.token)
+			if err != tt.wantErr {
+				t.Fatalf("VerifyUnsubscribeToken() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (userID != 2 || kind != models.EmailDigest) {
+				t.Errorf("VerifyUnsubscribeToken() = %d, %s, want 2, digest", userID, kind)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package services
+
+import "candidate-backend/internal/apperrors"
+
+// Domain errors returned by the services. Handlers pass them to c.Error and the
+// error middleware renders them; tests match them with errors.Is.
+var (
+	ErrUserNotFound       = apperrors.NotFound("user_not_found", "User not found")
+	ErrEmailTaken         = apperrors.Conflict("email_taken", "Email already exists")
+	ErrInvalidCredentials = apperrors.Unauthorized("invalid_credentials", "Invalid credentials")
+	ErrLoginLocked        = apperrors.New(apperrors.ErrRateLimited, "too_many_login_attempts", "Too many failed logins, try again later")
+
+	ErrAccessTokenNotFound  = apperrors.NotFound("access_token_not_found", "Access token not found")
+	ErrInvalidAccessToken   = apperrors.Unauthorized("invalid_token", "Invalid or expired token")
+	ErrAdminScopeNotAllowed = apperrors.Forbidden("admin_required", "Only admins may create tokens with the admin scope")
+
+	ErrInvalidResetToken    = apperrors.New(apperrors.ErrValidation, "invalid_reset_token", "This password reset link is invalid or has expired")
+	ErrTooManyResetRequests = apperrors.New(apperrors.ErrRateLimited, "too_many_reset_requests", "Too many password reset requests, try again later")
+
+	ErrInvalidVerificationToken = apperrors.New(apperrors.ErrValidation, "invalid_verification_token", "This verification link is invalid or has expired")
+	ErrEmailAlreadyVerified     = apperrors.Conflict("email_already_verified", "Email is already verified")
+	ErrVerificationRecentlySent = apperrors.New(apperrors.ErrRateLimited, "verification_recently_sent", "A verification email was sent recently, try again later")
+
+	ErrMFANotEnrolled    = apperrors.NotFound("mfa_not_enrolled", "MFA is not set up")
+	ErrMFAAlreadyEnabled = apperrors.Conflict("mfa_already_enabled", "MFA is already on")
+	ErrInvalidMFACode    = apperrors.New(apperrors.ErrValidation, "invalid_mfa_code", "Invalid authentication code")
+	ErrInvalidMFAToken   = apperrors.Unauthorized("invalid_mfa_token", "MFA token is invalid or expired")
+	ErrMFALocked         = apperrors.New(apperrors.ErrRateLimited, "too_many_mfa_attempts", "Too many wrong codes, try again later")
+
+	ErrTaskNotFound = apperrors.NotFound("task_not_found", "Task not found")
+	ErrNotTaskOwner = apperrors.Forbidden("not_task_owner", "You can only modify your own tasks")
+	ErrTaskArchived = apperrors.Conflict("task_archived", "Cannot track time on an archived task")
+
+	ErrCommentNotFound  = apperrors.NotFound("comment_not_found", "Comment not found")
+	ErrNotCommentAuthor = apperrors.Forbidden("not_comment_author", "You can only modify your own comments")
+	ErrParentNotFound   = apperrors.Invalid("parent_id", "parent comment not found on this task")
+	ErrParentDeleted    = apperrors.Conflict("parent_comment_deleted", "Cannot reply to a deleted comment")
+	ErrCommentDeleted   = apperrors.Conflict("comment_deleted", "Cannot edit a deleted comment")
+
+	ErrSprintNotFound      = apperrors.NotFound("sprint_not_found", "Sprint not found")
+	ErrNotSprintOwner      = apperrors.Forbidden("not_sprint_owner", "You can only modify your own sprints")
+	ErrSprintClosed        = apperrors.Conflict("sprint_closed", "Cannot modify a closed sprint")
+	ErrSprintAlreadyClosed = apperrors.Conflict("sprint_already_closed", "Sprint is already closed")
+
+	ErrProjectNotFound = apperrors.NotFound("project_not_found", "Project not found")
+	ErrNotProjectOwner = apperrors.Forbidden("not_project_owner", "You can only modify your own projects")
+	ErrFieldNotFound   = apperrors.NotFound("field_not_found", "Field not found")
+	ErrFieldKeyExists  = apperrors.Conflict("field_key_exists", "A field with this key already exists")
+	ErrOptionsInUse    = apperrors.Conflict("options_in_use", "Cannot remove options that are still used by tasks")
+
+	ErrTimeEntryNotFound   = apperrors.NotFound("time_entry_not_found", "Time entry not found")
+	ErrNotTimeEntryOwner   = apperrors.Forbidden("not_time_entry_owner", "You can only modify your own time entries")
+	ErrNoRunningTimer      = apperrors.NotFound("no_running_timer", "No running timer")
+	ErrTimerAlreadyRunning = apperrors.Conflict("timer_already_running", "You already have a running timer")
+	ErrTimeEntryOverlap    = apperrors.Conflict("time_entry_overlap", "You already logged time in this range")
+
+	ErrAttachmentNotFound = apperrors.NotFound("attachment_not_found", "Attachment not found")
+	ErrNotAttachmentOwner = apperrors.Forbidden("not_attachment_owner", "You can only delete your own attachments")
+
+	ErrWebhookNotFound  = apperrors.NotFound("webhook_not_found", "Webhook not found")
+	ErrNotWebhookOwner  = apperrors.Forbidden("not_webhook_owner", "You can only manage your own webhooks")
+	ErrDeliveryNotFound = apperrors.NotFound("delivery_not_found", "Delivery not found")
+
+	ErrNotificationNotFound    = apperrors.NotFound("notification_not_found", "Notification not found")
+	ErrInvalidUnsubscribeToken = apperrors.Forbidden("invalid_unsubscribe_token", "This unsubscribe link is invalid")
+)
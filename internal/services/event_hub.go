@@ -0,0 +1,106 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"context"
+	"sync"
+)
+
+// subscriberBuffer is the number of events a stream may fall behind before the hub
+// disconnects it
+const subscriberBuffer = 64
+
+// EventHub fans domain events out to the open event streams of this API instance. It
+// keeps the latest events so that a client reconnecting with the ID of the last event
+// it saw gets the ones it missed.
+type EventHub struct {
+	mu sync.Mutex
+	// recent holds the latest events in the order they were published, oldest first
+	recent      []models.DomainEvent
+	size        int
+	seen        map[int64]struct{}
+	subscribers map[*EventSubscription]struct{}
+}
+
+// EventSubscription receives the events published after it was opened. Events is
+// closed when the subscriber falls too far behind, so the client reconnects and
+// resumes from the replay buffer.
+type EventSubscription struct {
+	Events <-chan models.DomainEvent
+	events chan models.DomainEvent
+}
+
+// NewEventHub returns a hub that keeps the latest size events for replay
+func NewEventHub(size int) *EventHub {
+	return &EventHub{
+		size:        size,
+		seen:        map[int64]struct{}{},
+		subscribers: map[*EventSubscription]struct{}{},
+	}
+}
+
+// Publish sends an event to every subscriber and keeps it for replay. Events the hub
+// already has are ignored, since the outbox may publish an event more than once. It
+// is an outbox subscriber, for API instances that share no database notifications.
+func (h *EventHub) Publish(ctx context.Context, event models.DomainEvent) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.seen[event.ID]; ok {
+		return nil
+	}
+
+	h.seen[event.ID] = struct{}{}
+	h.recent = append(h.recent, event)
+	if len(h.recent) > h.size {
+		delete(h.seen, h.recent[0].ID)
+		h.recent = h.recent[1:]
+	}
+
+	for sub := range h.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			h.drop(sub)
+		}
+	}
+	return nil
+}
+
+// Subscribe opens a subscription. With lastEventID set it also returns the events
+// published after that one; it reports false when the event is no longer in the
+// buffer, in which case the client has to reload its state.
+func (h *EventHub) Subscribe(lastEventID *int64) (*EventSubscription, []models.DomainEvent, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	events := make(chan models.DomainEvent, subscriberBuffer)
+	sub := &EventSubscription{Events: events, events: events}
+	h.subscribers[sub] = struct{}{}
+
+	if lastEventID == nil {
+		return sub, nil, true
+	}
+
+	for i, event := range h.recent {
+		if event.ID == *lastEventID {
+			return sub, append([]models.DomainEvent(nil), h.recent[i+1:]...), true
+		}
+	}
+	return sub, nil, false
+}
+
+// Unsubscribe closes a subscription
+func (h *EventHub) Unsubscribe(sub *EventSubscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscribers[sub]; ok {
+		h.drop(sub)
+	}
+}
+
+func (h *EventHub) drop(sub *EventSubscription) {
+	delete(h.subscribers, sub)
+	close(sub.events)
+}
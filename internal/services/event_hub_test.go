@@ -0,0 +1,78 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"context"
+	"reflect"
+	"testing"
+)
+
+func eventIDs(events []models.DomainEvent) []int64 {
+	var ids []int64
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func TestEventHubReplay(t *testing.T) {
+	hub := NewEventHub(3)
+	for _, id := range []int64{1, 2, 3, 2, 4} {
+		_ = hub.Publish(context.Background(), models.DomainEvent{ID: id})
+	}
+
+	id := func(id int64) *int64 { return &id }
+	tests := []struct {
+		name        string
+		lastEventID *int64
+		wantMissed  []int64
+		wantResumed bool
+	}{
+		{"New stream", nil, nil, true},
+		{"Resume after a buffered event", id(2), []int64{3, 4}, true},
+		{"Resume after the latest event", id(4), nil, true},
+		{"Event no longer buffered", id(1), nil, false},
+		{"Unknown event", id(99), nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, missed, resumed := hub.Subscribe(tt.lastEventID)
+			defer hub.Unsubscribe(sub)
+
+			if ids := eventIDs(missed); resumed != tt.wantResumed || !reflect.DeepEqual(ids, tt.wantMissed) {
+				t.Errorf("Subscribe() = %v, %v, want %v, %v", ids, resumed, tt.wantMissed, tt.wantResumed)
+			}
+		})
+	}
+}
+
+func TestEventHubSubscribers(t *testing.T) {
+	hub := NewEventHub(subscriberBuffer * 2)
+	live, _, _ := hub.Subscribe(nil)
+	slow, _, _ := hub.Subscribe(nil)
+	defer hub.Unsubscribe(live)
+
+	for id := int64(1); id <= subscriberBuffer+1; id++ {
+		_ = hub.Publish(context.Background(), models.DomainEvent{ID: id})
+		if id <= subscriberBuffer {
+			if event := <-live.Events; event.ID != id {
+				t.Fatalf("live subscriber got event %d, want %d", event.ID, id)
+			}
+		}
+	}
+
+	// The slow subscriber gets what fit in its buffer, then its stream ends
+	received := 0
+	for range slow.Events {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("slow subscriber got %d events before being dropped, want %d", received, subscriberBuffer)
+	}
+
+	if event := <-live.Events; event.ID != subscriberBuffer+1 {
+		t.Errorf("live subscriber got event %d, want %d", event.ID, subscriberBuffer+1)
+	}
+	hub.Unsubscribe(slow)
+}
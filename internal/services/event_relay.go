@@ -0,0 +1,102 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"context"
+	"database/sql"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// eventChannel is the Postgres channel domain events are announced on
+const eventChannel = "domain_events"
+
+// PostgresEventRelay carries domain events to the event hub of every API instance.
+// The outbox dispatcher runs each event on one instance only, so that instance
+// announces the event ID with NOTIFY and every instance, itself included, loads the
+// event from the outbox and publishes it to its hub.
+type PostgresEventRelay struct {
+	db          *sql.DB
+	databaseURL string
+}
+
+func NewPostgresEventRelay(db *sql.DB, databaseURL string) *PostgresEventRelay {
+	return &PostgresEventRelay{db: db, databaseURL: databaseURL}
+}
+
+// Announce is an outbox subscriber that notifies every instance of an event. The
+// payload is only the event ID, as NOTIFY payloads are limited to 8000 bytes.
+func (r *PostgresEventRelay) Announce(ctx context.Context, event models.DomainEvent) error {
+	_, err := r.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", eventChannel, strconv.FormatInt(event.ID, 10))
+	return err
+}
+
+// Listen publishes the events announced by any instance to hub until ctx is done.
+// Events announced while the connection is being re-established are missed; clients
+// that resume from before them are told to reload.
+func (r *PostgresEventRelay) Listen(ctx context.Context, hub *EventHub) {
+	listener := pq.NewListener(r.databaseURL, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("events: listener: %v", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(eventChannel); err != nil {
+		log.Printf("events: listening on %s: %v", eventChannel, err)
+		return
+	}
+
+	ping := time.NewTicker(time.Minute)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ping.C:
+			// Detects a dead connection so the listener reconnects
+			_ = listener.Ping()
+		case notification := <-listener.Notify:
+			// A nil notification means the connection was re-established
+			if notification == nil {
+				continue
+			}
+
+			id, err := strconv.ParseInt(notification.Extra, 10, 64)
+			if err != nil {
+				continue
+			}
+
+			event, err := r.loadEvent(id)
+			if err != nil {
+				log.Printf("events: loading event %d: %v", id, err)
+				continue
+			}
+			_ = hub.Publish(ctx, *event)
+		}
+	}
+}
+
+func (r *PostgresEventRelay) loadEvent(eventID int64) (*models.DomainEvent, error) {
+	var event models.DomainEvent
+	var commentID sql.NullInt64
+	var payload []byte
+	err := r.db.QueryRow(`
+		SELECT id, event_type, task_id, comment_id, actor_id, payload, created_at
+		FROM outbox
+		WHERE id = $1
+	`, eventID).Scan(
+		&event.ID, &event.Type, &event.TaskID, &commentID, &event.ActorID, &payload, &event.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	event.CommentID = nullIntPtr(commentID)
+	event.Payload = payload
+	return &event, nil
+}
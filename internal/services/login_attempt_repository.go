@@ -0,0 +1,127 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// LoginFailures counts failed login attempts
+type LoginFailures struct {
+	Count int
+	// Last is the time of the latest failure, zero when there is none
+	Last time.Time
+}
+
+// LoginAttemptRepository keeps the login audit log, which throttles logins
+type LoginAttemptRepository interface {
+	// RecordLoginAttempt stores an attempt with its email lowercased, for the user
+	// the email belongs to, if any
+	RecordLoginAttempt(attempt models.LoginAttempt) error
+	// CountLoginFailures returns the failures since the given time with the
+	// (lowercased) email since its last right password, and from the IP address
+	CountLoginFailures(email, ip string, since time.Time) (byEmail, byIP LoginFailures, err error)
+	// ListLoginAttempts returns a user's latest attempts, newest first
+	ListLoginAttempts(userID, limit int) ([]models.LoginAttempt, error)
+	// LockLoginAttempts runs fn with the attempts passed to it while no other
+	// LockLoginAttempts with the (lowercased) email runs, so concurrent logins
+	// with an email each count the failures the others recorded
+	LockLoginAttempts(email string, fn func(attempts LoginAttemptRepository) error) error
+}
+
+// loginAttemptDB is a database or a transaction login attempts are kept in
+type loginAttemptDB interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+type PostgresLoginAttemptRepository struct {
+	db loginAttemptDB
+	// pool begins the transactions of LockLoginAttempts; nil in one of them
+	pool *sql.DB
+}
+
+func NewPostgresLoginAttemptRepository(db *sql.DB) *PostgresLoginAttemptRepository {
+	return &PostgresLoginAttemptRepository{db: db, pool: db}
+}
+
+// LockLoginAttempts runs fn in a transaction holding an advisory lock on the
+// email, which the transaction releases when it ends
+func (r *PostgresLoginAttemptRepository) LockLoginAttempts(email string, fn func(attempts LoginAttemptRepository) error) error {
+	if r.pool == nil {
+		// Already locked by the transaction r belongs to
+		return fn(r)
+	}
+	tx, err := r.pool.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('login_attempts'), hashtext($1))`, email); err != nil {
+		return err
+	}
+	if err := fn(&PostgresLoginAttemptRepository{db: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresLoginAttemptRepository) RecordLoginAttempt(attempt models.LoginAttempt) error {
+	// The email may be typed in any case; an account with exactly that email wins
+	_, err := r.db.Exec(`
+		INSERT INTO login_attempts (user_id, email, ip, user_agent, result, created_at)
+		VALUES ((
+			SELECT id FROM users WHERE LOWER(email) = LOWER($1) ORDER BY email = $1 DESC, id LIMIT 1
+		), $2, $3, $4, $5, $6)
+	`, attempt.Email, strings.ToLower(attempt.Email), attempt.IP, attempt.UserAgent, attempt.Result, attempt.CreatedAt)
+	return err
+}
+
+func (r *PostgresLoginAttemptRepository) CountLoginFailures(email, ip string, since time.Time) (LoginFailures, LoginFailures, error) {
+	var byEmail, byIP LoginFailures
+	var lastByEmail, lastByIP sql.NullTime
+	err := r.db.QueryRow(`
+		WITH last_success AS (
+			SELECT COALESCE(MAX(created_at), '-infinity') AS at
+			FROM login_attempts
+			WHERE email = $1 AND result IN ($4, $5)
+		)
+		SELECT COUNT(*) FILTER (WHERE email = $1 AND created_at > last_success.at),
+		       MAX(created_at) FILTER (WHERE email = $1 AND created_at > last_success.at),
+		       COUNT(*) FILTER (WHERE ip = $2),
+		       MAX(created_at) FILTER (WHERE ip = $2)
+		FROM login_attempts, last_success
+		WHERE (email = $1 OR ip = $2) AND result = $6 AND created_at > $3
+	`, email, ip, since, models.LoginSucceeded, models.LoginMFARequired, models.LoginFailed).Scan(
+		&byEmail.Count, &lastByEmail, &byIP.Count, &lastByIP,
+	)
+	byEmail.Last, byIP.Last = lastByEmail.Time, lastByIP.Time
+	return byEmail, byIP, err
+}
+
+func (r *PostgresLoginAttemptRepository) ListLoginAttempts(userID, limit int) ([]models.LoginAttempt, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, email, ip, user_agent, result, created_at
+		FROM login_attempts
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := []models.LoginAttempt{}
+	for rows.Next() {
+		var attempt models.LoginAttempt
+		if err := rows.Scan(&attempt.ID, &attempt.UserID, &attempt.Email, &attempt.IP, &attempt.UserAgent, &attempt.Result, &attempt.CreatedAt); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts, rows.Err()
+}
@@ -0,0 +1,2153 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps users, password resets, MFA, login attempts, access tokens,
+// tasks, comments, reactions, change logs, the outbox, webhooks, notifications and
+// their emails in memory. It implements UserRepository, PasswordResetRepository,
+// MFARepository, LoginAttemptRepository, AccessTokenRepository, TaskRepository,
+// CommentRepository, ReactionRepository, ChangeLogRepository, OutboxRepository,
+// WebhookRepository, NotificationRepository and EmailRepository with the same
+// behaviour as the Postgres repositories, so services and handlers can be tested
+// without a database.
+type MemoryStore struct {
+	mu sync.Mutex
+
+	users            map[int]*models.User
+	verificationSent map[int]time.Time // user ID -> last verification email
+	passwordResets   []*passwordReset
+	resetRequests    []resetRequest
+	mfa              map[int]*MFARecord
+	recoveryCodes    map[int]map[string]bool // user ID -> code hash -> used
+	loginAttempts    []models.LoginAttempt
+	loginLocks       map[string]*sync.Mutex // lowercased email -> LockLoginAttempts lock
+	accessTokens     map[int]*accessToken
+
+	tasks     map[int]*models.Task
+	values    map[int]map[int]interface{} // task ID -> field ID -> value
+	sprints   map[int]*models.Sprint
+	projects  map[int]*models.Project
+	comments  map[int]*models.Comment
+	revisions map[int][]models.CommentRevision
+	watchers  map[int]map[int]time.Time // task ID -> user ID -> watching since
+	mentioned map[mentionTarget]map[int]bool
+	reactions []reaction
+	logs      []models.ChangeLog
+	outbox    []*outboxEntry
+
+	timeEntries map[int]*models.TimeEntry
+
+	webhooks   map[int]*models.Webhook
+	deliveries map[int]*models.WebhookDelivery
+
+	notifications []*models.Notification
+	preferences   map[int]models.NotificationPreferences
+
+	emails           map[int]*emailState // notification ID -> email state
+	emailPreferences map[int]models.EmailPreferences
+	digests          map[int]time.Time // user ID -> last digest
+
+	lastUserID, lastTaskID, lastSprintID, lastProjectID, lastFieldID int
+	lastCommentID, lastRevisionID, lastLogID, lastTimeEntryID        int
+	lastWebhookID, lastDeliveryID, lastNotificationID                int
+	lastLoginAttemptID, lastAccessTokenID                            int
+	lastEventID                                                      int64
+}
+
+// reaction is a reactions row; commentID is 0 on a task's reactions, taskID on a
+// comment's
+type reaction struct {
+	taskID, commentID int
+	userID            int
+	emoji             string
+}
+
+// passwordReset is a password_resets row
+type passwordReset struct {
+	userID    int
+	tokenHash string
+	expiresAt time.Time
+	used      bool
+}
+
+// accessToken is an access_tokens row
+type accessToken struct {
+	token     models.AccessToken
+	tokenHash string
+	revoked   bool
+}
+
+// resetRequest is a password_reset_requests row
+type resetRequest struct {
+	email string
+	ip    string
+	at    time.Time
+}
+
+// emailState is the email state of a notification
+type emailState struct {
+	status   string
+	attempts int
+	claimed  bool
+}
+
+// outboxEntry is an outbox row: an event with the state of its publication
+type outboxEntry struct {
+	event         models.DomainEvent
+	attempts      int
+	lastError     string
+	nextAttemptAt time.Time
+	handled       []string
+	publishedAt   *time.Time
+}
+
+var (
+	_ UserRepository          = (*MemoryStore)(nil)
+	_ PasswordResetRepository = (*MemoryStore)(nil)
+	_ MFARepository           = (*MemoryStore)(nil)
+	_ LoginAttemptRepository  = (*MemoryStore)(nil)
+	_ AccessTokenRepository   = (*MemoryStore)(nil)
+	_ TaskRepository          = (*MemoryStore)(nil)
+	_ CommentRepository       = (*MemoryStore)(nil)
+	_ ReactionRepository      = (*MemoryStore)(nil)
+	_ ChangeLogRepository     = (*MemoryStore)(nil)
+	_ TimeEntryRepository     = (*MemoryStore)(nil)
+	_ OutboxRepository        = (*MemoryStore)(nil)
+	_ WebhookRepository       = (*MemoryStore)(nil)
+	_ NotificationRepository  = (*MemoryStore)(nil)
+	_ EmailRepository         = (*MemoryStore)(nil)
+)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:            map[int]*models.User{},
+		verificationSent: map[int]time.Time{},
+		mfa:              map[int]*MFARecord{},
+		recoveryCodes:    map[int]map[string]bool{},
+		loginLocks:       map[string]*sync.Mutex{},
+		accessTokens:     map[int]*accessToken{},
+
+		tasks:     map[int]*models.Task{},
+		values:    map[int]map[int]interface{}{},
+		sprints:   map[int]*models.Sprint{},
+		projects:  map[int]*models.Project{},
+		comments:  map[int]*models.Comment{},
+		revisions: map[int][]models.CommentRevision{},
+		watchers:  map[int]map[int]time.Time{},
+		mentioned: map[mentionTarget]map[int]bool{},
+
+		timeEntries: map[int]*models.TimeEntry{},
+
+		webhooks:   map[int]*models.Webhook{},
+		deliveries: map[int]*models.WebhookDelivery{},
+
+		preferences: map[int]models.NotificationPreferences{},
+
+		emails:           map[int]*emailState{},
+		emailPreferences: map[int]models.EmailPreferences{},
+		digests:          map[int]time.Time{},
+	}
+}
+
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// AddSprint stores a sprint and returns its ID
+func (s *MemoryStore) AddSprint(sprint models.Sprint) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastSprintID++
+	sprint.ID = s.lastSprintID
+	s.sprints[sprint.ID] = &sprint
+	return sprint.ID
+}
+
+// AddProject stores a project with its custom field definitions and returns its ID
+func (s *MemoryStore) AddProject(project models.Project) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastProjectID++
+	project.ID = s.lastProjectID
+	project.Fields = append([]models.CustomField(nil), project.Fields...)
+	for i := range project.Fields {
+		s.lastFieldID++
+		project.Fields[i].ID = s.lastFieldID
+		project.Fields[i].ProjectID = project.ID
+	}
+	s.projects[project.ID] = &project
+	return project.ID
+}
+
+// AddComment stores a comment as given, keeping its CreatedAt and Deleted state, and
+// returns its ID
+func (s *MemoryStore) AddComment(comment models.Comment) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastCommentID++
+	comment.ID = s.lastCommentID
+	if comment.UpdatedAt.IsZero() {
+		comment.UpdatedAt = comment.CreatedAt
+	}
+	s.comments[comment.ID] = &comment
+	return comment.ID
+}
+
+func (s *MemoryStore) CreateUser(user models.User) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.users {
+		if existing.Email == user.Email {
+			return nil, ErrEmailTaken
+		}
+	}
+
+	s.lastUserID++
+	user.ID = s.lastUserID
+	user.CreatedAt = now()
+	user.UpdatedAt = user.CreatedAt
+	s.users[user.ID] = &user
+
+	created := user
+	return &created, nil
+}
+
+func (s *MemoryStore) GetUser(userID int) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	found := *user
+	return &found, nil
+}
+
+func (s *MemoryStore) GetUserByEmail(email string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.Email == email {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (s *MemoryStore) UsersExist(userIDs []int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range userIDs {
+		if s.users[id] == nil {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (s *MemoryStore) SessionVersion(userID int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return 0, ErrUserNotFound
+	}
+	return user.SessionVersion, nil
+}
+
+func (s *MemoryStore) MarkEmailVerified(userID int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return ErrUserNotFound
+	}
+	if user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &at
+	}
+	return nil
+}
+
+func (s *MemoryStore) RecordVerificationSent(userID int, at, notBefore time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sent, ok := s.verificationSent[userID]; ok && sent.After(notBefore) {
+		return false, nil
+	}
+	s.verificationSent[userID] = at
+	return true, nil
+}
+
+func (s *MemoryStore) CountResetRequests(email, ip string, since time.Time) (int, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var byEmail, byIP int
+	for _, request := range s.resetRequests {
+		if !request.at.After(since) {
+			continue
+		}
+		if request.email == email {
+			byEmail++
+		}
+		if request.ip == ip {
+			byIP++
+		}
+	}
+	return byEmail, byIP, nil
+}
+
+func (s *MemoryStore) RecordResetRequest(email, ip string, at, expired time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resetRequests = slices.DeleteFunc(s.resetRequests, func(request resetRequest) bool {
+		return !request.at.After(expired)
+	})
+	s.resetRequests = append(s.resetRequests, resetRequest{email: email, ip: ip, at: at})
+	return nil
+}
+
+func (s *MemoryStore) CreatePasswordReset(userID int, tokenHash string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.passwordResets = append(s.passwordResets, &passwordReset{userID: userID, tokenHash: tokenHash, expiresAt: expiresAt})
+	return nil
+}
+
+func (s *MemoryStore) ResetPassword(tokenHash, passwordHash string, at time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var userID int
+	for _, reset := range s.passwordResets {
+		if reset.tokenHash == tokenHash && !reset.used && reset.expiresAt.After(at) {
+			userID = reset.userID
+		}
+	}
+	user, ok := s.users[userID]
+	if !ok {
+		return 0, ErrInvalidResetToken
+	}
+
+	for _, reset := range s.passwordResets {
+		if reset.userID == userID {
+			reset.used = true
+		}
+	}
+	user.PasswordHash = passwordHash
+	user.SessionVersion++
+	user.UpdatedAt = at
+	return userID, nil
+}
+
+// SetAdmin grants or revokes admin rights, which only SQL does in Postgres
+func (s *MemoryStore) SetAdmin(userID int, admin bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, ok := s.users[userID]; ok {
+		user.IsAdmin = admin
+	}
+}
+
+func (s *MemoryStore) GetMFA(userID int) (*MFARecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.mfa[userID]
+	if !ok {
+		return nil, ErrMFANotEnrolled
+	}
+	found := *record
+	return &found, nil
+}
+
+func (s *MemoryStore) SaveMFASecret(userID int, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.mfa[userID]; ok && record.EnabledAt != nil {
+		return ErrMFAAlreadyEnabled
+	}
+	s.mfa[userID] = &MFARecord{Secret: secret}
+	return nil
+}
+
+func (s *MemoryStore) EnableMFA(userID int, counter int64, at time.Time, codeHashes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.mfa[userID]
+	if !ok || record.EnabledAt != nil {
+		return ErrMFAAlreadyEnabled
+	}
+	record.EnabledAt = &at
+	record.LastCounter = counter
+	record.FailedAttempts = 0
+
+	codes := map[string]bool{}
+	for _, hash := range codeHashes {
+		codes[hash] = false
+	}
+	s.recoveryCodes[userID] = codes
+	return nil
+}
+
+func (s *MemoryStore) AcceptMFACode(userID int, counter int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.mfa[userID]
+	if !ok || record.LastCounter >= counter {
+		return false, nil
+	}
+	record.LastCounter = counter
+	record.FailedAttempts = 0
+	return true, nil
+}
+
+func (s *MemoryStore) UseRecoveryCode(userID int, codeHash string, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	used, ok := s.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	s.recoveryCodes[userID][codeHash] = true
+	if record, ok := s.mfa[userID]; ok {
+		record.FailedAttempts = 0
+	}
+	return true, nil
+}
+
+func (s *MemoryStore) RecordMFAFailure(userID int, maxAttempts int, lockedUntil time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.mfa[userID]
+	if !ok {
+		return nil
+	}
+	record.FailedAttempts++
+	if record.FailedAttempts >= maxAttempts {
+		record.FailedAttempts = 0
+		record.LockedUntil = &lockedUntil
+	}
+	return nil
+}
+
+func (s *MemoryStore) CountRecoveryCodes(userID int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int
+	for _, used := range s.recoveryCodes[userID] {
+		if !used {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) DeleteMFA(userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.mfa[userID]; !ok {
+		return ErrMFANotEnrolled
+	}
+	delete(s.mfa, userID)
+	delete(s.recoveryCodes, userID)
+	return nil
+}
+
+func (s *MemoryStore) RecordLoginAttempt(attempt models.LoginAttempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastLoginAttemptID++
+	attempt.ID = s.lastLoginAttemptID
+	// The email may be typed in any case; an account with exactly that email wins
+	attempt.UserID = nil
+	for id := 1; id <= s.lastUserID; id++ {
+		user, ok := s.users[id]
+		if !ok || !strings.EqualFold(user.Email, attempt.Email) {
+			continue
+		}
+		if attempt.UserID == nil || user.Email == attempt.Email {
+			userID := user.ID
+			attempt.UserID = &userID
+		}
+		if user.Email == attempt.Email {
+			break
+		}
+	}
+	attempt.Email = strings.ToLower(attempt.Email)
+	s.loginAttempts = append(s.loginAttempts, attempt)
+	return nil
+}
+
+func (s *MemoryStore) CountLoginFailures(email, ip string, since time.Time) (LoginFailures, LoginFailures, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var byEmail, byIP LoginFailures
+	for _, attempt := range s.loginAttempts {
+		if !attempt.CreatedAt.After(since) {
+			continue
+		}
+		switch {
+		case attempt.Email == email && attempt.Result != models.LoginFailed && attempt.Result != models.LoginLocked:
+			// A right password starts the count for the email over
+			byEmail = LoginFailures{}
+		case attempt.Email == email && attempt.Result == models.LoginFailed:
+			byEmail = LoginFailures{Count: byEmail.Count + 1, Last: attempt.CreatedAt}
+		}
+		if attempt.IP == ip && attempt.Result == models.LoginFailed {
+			byIP = LoginFailures{Count: byIP.Count + 1, Last: attempt.CreatedAt}
+		}
+	}
+	return byEmail, byIP, nil
+}
+
+func (s *MemoryStore) LockLoginAttempts(email string, fn func(attempts LoginAttemptRepository) error) error {
+	s.mu.Lock()
+	lock, ok := s.loginLocks[email]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.loginLocks[email] = lock
+	}
+	s.mu.Unlock()
+
+	lock.Lock()
+	defer lock.Unlock()
+	return fn(s)
+}
+
+func (s *MemoryStore) ListLoginAttempts(userID, limit int) ([]models.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempts := []models.LoginAttempt{}
+	for i := len(s.loginAttempts) - 1; i >= 0 && len(attempts) < limit; i-- {
+		if attempt := s.loginAttempts[i]; attempt.UserID != nil && *attempt.UserID == userID {
+			attempts = append(attempts, attempt)
+		}
+	}
+	return attempts, nil
+}
+
+func (s *MemoryStore) CreateAccessToken(token models.AccessToken, tokenHash string) (*models.AccessToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastAccessTokenID++
+	token.ID = s.lastAccessTokenID
+	token.Token = ""
+	token.Scopes = append([]models.TokenScope(nil), token.Scopes...)
+	token.CreatedAt = now()
+	s.accessTokens[token.ID] = &accessToken{token: token, tokenHash: tokenHash}
+	return &token, nil
+}
+
+func (s *MemoryStore) ListAccessTokens(userID int) ([]models.AccessToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens := []models.AccessToken{}
+	for _, stored := range s.accessTokens {
+		if stored.token.UserID == userID && !stored.revoked {
+			tokens = append(tokens, stored.token)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].ID > tokens[j].ID })
+	return tokens, nil
+}
+
+func (s *MemoryStore) RevokeAccessToken(userID, tokenID int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.accessTokens[tokenID]
+	if !ok || stored.token.UserID != userID || stored.revoked {
+		return ErrAccessTokenNotFound
+	}
+	stored.revoked = true
+	return nil
+}
+
+func (s *MemoryStore) UseAccessToken(tokenHash string, at time.Time) (*models.AccessToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, stored := range s.accessTokens {
+		if stored.tokenHash != tokenHash {
+			continue
+		}
+		if stored.revoked || (stored.token.ExpiresAt != nil && !stored.token.ExpiresAt.After(at)) {
+			break
+		}
+		stored.token.LastUsedAt = &at
+		token := stored.token
+		return &token, nil
+	}
+	return nil, ErrInvalidAccessToken
+}
+
+func (s *MemoryStore) ListTasks(query TaskQuery) ([]models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tasks []models.Task
+	for _, task := range s.tasks {
+		if task.Archived != query.Archived ||
+			query.ProjectID != nil && (task.ProjectID == nil || *task.ProjectID != *query.ProjectID) ||
+			query.WatcherID != nil && s.watchers[task.ID][*query.WatcherID].IsZero() ||
+			!s.matchesFilters(task.ID, query.Filters) {
+			continue
+		}
+		tasks = append(tasks, s.taskView(task))
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return s.lessTask(query, tasks[i], tasks[j])
+	})
+
+	if query.Offset >= len(tasks) {
+		return nil, nil
+	}
+	tasks = tasks[query.Offset:]
+	if len(tasks) > query.Limit {
+		tasks = tasks[:query.Limit]
+	}
+	return tasks, nil
+}
+
+func (s *MemoryStore) GetTask(taskID int) (*models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[taskID]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+
+	view := s.taskView(task)
+	return &view, nil
+}
+
+func (s *MemoryStore) CreateTask(task models.Task, values []FieldValue, change Change) (*models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastTaskID++
+	task.ID = s.lastTaskID
+	task.CreatedAt = now()
+	task.UpdatedAt = task.CreatedAt
+	task.CustomFields = nil
+	s.tasks[task.ID] = &task
+	s.values[task.ID] = map[int]interface{}{}
+	s.saveValues(task.ID, values)
+
+	return s.taskChanged(&task, change)
+}
+
+func (s *MemoryStore) UpdateTask(taskID int, req models.UpdateTaskRequest, values []FieldValue, change Change) (*models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[taskID]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+
+	if req.Title != nil {
+		task.Title = *req.Title
+	}
+	if req.Description != nil {
+		task.Description = *req.Description
+	}
+	if req.Status != nil {
+		task.Status = *req.Status
+	}
+	if req.DueDate != nil {
+		dueDate := *req.DueDate
+		task.DueDate = &dueDate
+	}
+	if req.AssigneeID != nil {
+		task.AssigneeID = optionalID(*req.AssigneeID)
+	}
+	if req.StoryPoints != nil {
+		points := *req.StoryPoints
+		task.StoryPoints = &points
+	}
+	if req.SprintID != nil {
+		task.SprintID = optionalID(*req.SprintID)
+	}
+	if req.ProjectID != nil {
+		task.ProjectID = optionalID(*req.ProjectID)
+		for fieldID := range s.values[taskID] {
+			if field, ok := s.field(fieldID); !ok || task.ProjectID == nil || field.ProjectID != *task.ProjectID {
+				delete(s.values[taskID], fieldID)
+			}
+		}
+	}
+	task.UpdatedAt = now()
+	s.saveValues(taskID, values)
+
+	return s.taskChanged(task, change)
+}
+
+func (s *MemoryStore) SetArchived(taskID int, archived bool, change Change) (*models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[taskID]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+
+	task.Archived = archived
+	task.UpdatedAt = now()
+
+	return s.taskChanged(task, change)
+}
+
+// DeleteTask removes a task with its comments and change logs, as the database cascade does
+func (s *MemoryStore) DeleteTask(taskID int, change Change) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[taskID]
+	if !ok {
+		return nil
+	}
+	if err := s.recordChange(change, taskID, nil, models.TaskEventPayload{Task: s.taskView(task)}); err != nil {
+		return err
+	}
+
+	delete(s.tasks, taskID)
+	delete(s.values, taskID)
+	delete(s.watchers, taskID)
+	for id, comment := range s.comments {
+		if comment.TaskID == taskID {
+			delete(s.comments, id)
+			delete(s.revisions, id)
+		}
+	}
+	s.reactions = slices.DeleteFunc(s.reactions, func(r reaction) bool {
+		return r.taskID == taskID || (r.commentID != 0 && s.comments[r.commentID] == nil)
+	})
+	for id, entry := range s.timeEntries {
+		if entry.TaskID == taskID {
+			delete(s.timeEntries, id)
+		}
+	}
+
+	logs := s.logs[:0]
+	for _, log := range s.logs {
+		if log.TaskID != taskID {
+			logs = append(logs, log)
+		}
+	}
+	s.logs = logs
+
+	return nil
+}
+
+func (s *MemoryStore) WatchTask(taskID, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tasks[taskID]; !ok {
+		return ErrTaskNotFound
+	}
+	s.addWatchers(taskID, []int{userID})
+	return nil
+}
+
+func (s *MemoryStore) UnwatchTask(taskID, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.watchers[taskID], userID)
+	return nil
+}
+
+func (s *MemoryStore) ListWatchers(taskID int) ([]models.Watcher, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var watchers []models.Watcher
+	for userID, since := range s.watchers[taskID] {
+		watcher := models.Watcher{UserID: userID, CreatedAt: since}
+		if user, ok := s.users[userID]; ok {
+			watcher.Name = user.Name
+		}
+		watchers = append(watchers, watcher)
+	}
+
+	sort.Slice(watchers, func(i, j int) bool {
+		if !watchers[i].CreatedAt.Equal(watchers[j].CreatedAt) {
+			return watchers[i].CreatedAt.Before(watchers[j].CreatedAt)
+		}
+		return watchers[i].UserID < watchers[j].UserID
+	})
+	return watchers, nil
+}
+
+func (s *MemoryStore) SprintState(sprintID int) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sprint, ok := s.sprints[sprintID]
+	if !ok {
+		return "", false, ErrSprintNotFound
+	}
+	return sprint.Name, sprint.Closed || sprint.ClosedAt != nil, nil
+}
+
+func (s *MemoryStore) ProjectName(projectID int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[projectID]
+	if !ok {
+		return "", ErrProjectNotFound
+	}
+	return project.Name, nil
+}
+
+func (s *MemoryStore) ProjectFields(projectID int) ([]models.CustomField, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fields := []models.CustomField{}
+	if project, ok := s.projects[projectID]; ok {
+		fields = append(fields, project.Fields...)
+	}
+	return fields, nil
+}
+
+func (s *MemoryStore) TaskExists(taskID int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.tasks[taskID]
+	return ok, nil
+}
+
+func (s *MemoryStore) GetComment(commentID int) (*models.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comment, ok := s.comments[commentID]
+	if !ok {
+		return nil, ErrCommentNotFound
+	}
+
+	view := s.commentView(comment)
+	return &view, nil
+}
+
+func (s *MemoryStore) ListComments(taskID int, filter models.CommentFilter, after *CommentCursor) ([]models.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	less := func(a, b models.Comment) bool {
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	}
+	inOrder := func(a, b models.Comment) bool {
+		if filter.Order == "desc" {
+			return less(b, a)
+		}
+		return less(a, b)
+	}
+
+	var comments []models.Comment
+	for _, comment := range s.comments {
+		if comment.TaskID != taskID ||
+			filter.UserID != nil && (comment.UserID != *filter.UserID || comment.Deleted) ||
+			filter.Since != nil && !comment.CreatedAt.After(*filter.Since) {
+			continue
+		}
+		if after != nil && !inOrder(models.Comment{ID: after.ID, CreatedAt: after.CreatedAt}, *comment) {
+			continue
+		}
+		comments = append(comments, s.commentView(comment))
+	}
+
+	sort.Slice(comments, func(i, j int) bool {
+		return inOrder(comments[i], comments[j])
+	})
+
+	if len(comments) > filter.Limit {
+		comments = comments[:filter.Limit]
+	}
+	return comments, nil
+}
+
+func (s *MemoryStore) CreateComment(comment models.Comment, change Change) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastCommentID++
+	comment.ID = s.lastCommentID
+	comment.CreatedAt = now()
+	comment.UpdatedAt = comment.CreatedAt
+	s.comments[comment.ID] = &comment
+	return comment.ID, s.commentChanged(&comment, change)
+}
+
+func (s *MemoryStore) EditComment(commentID int, content string, editorID int, change Change) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comment, ok := s.comments[commentID]
+	if !ok {
+		return ErrCommentNotFound
+	}
+
+	s.lastRevisionID++
+	revision := models.CommentRevision{
+		ID:         s.lastRevisionID,
+		CommentID:  commentID,
+		Revision:   len(s.revisions[commentID]) + 1,
+		Content:    comment.Content,
+		WrittenAt:  comment.UpdatedAt,
+		ReplacedBy: editorID,
+		ReplacedAt: now(),
+	}
+	if editor, ok := s.users[editorID]; ok {
+		revision.ReplacedByName = editor.Name
+	}
+	s.revisions[commentID] = append(s.revisions[commentID], revision)
+
+	comment.Content = content
+	comment.UpdatedAt = revision.ReplacedAt
+	return s.commentChanged(comment, change)
+}
+
+// DeleteComment removes a comment, or turns it into a placeholder when it has replies.
+// Placeholders left without replies are removed as well, walking up the thread.
+func (s *MemoryStore) DeleteComment(commentID int, change Change) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comment, ok := s.comments[commentID]
+	if !ok {
+		return nil
+	}
+	if err := s.commentChanged(comment, change); err != nil {
+		return err
+	}
+	s.reactions = slices.DeleteFunc(s.reactions, func(r reaction) bool { return r.commentID == commentID })
+
+	if s.hasReplies(commentID) {
+		comment.Content = ""
+		comment.Deleted = true
+		delete(s.revisions, commentID)
+		delete(s.mentioned, mentionTarget{comment.TaskID, commentID})
+		return nil
+	}
+
+	delete(s.comments, commentID)
+	delete(s.revisions, commentID)
+	delete(s.mentioned, mentionTarget{comment.TaskID, commentID})
+
+	for parentID := comment.ParentID; parentID != nil; {
+		parent, ok := s.comments[*parentID]
+		if !ok || !parent.Deleted || s.hasReplies(parent.ID) {
+			break
+		}
+		delete(s.comments, parent.ID)
+		parentID = parent.ParentID
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) ListRevisions(commentID int) ([]models.CommentRevision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]models.CommentRevision(nil), s.revisions[commentID]...), nil
+}
+
+func (s *MemoryStore) AddTaskReaction(taskID, userID int, emoji string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tasks[taskID]; !ok {
+		return ErrTaskNotFound
+	}
+	s.addReaction(reaction{taskID: taskID, userID: userID, emoji: emoji})
+	return nil
+}
+
+func (s *MemoryStore) RemoveTaskReaction(taskID, userID int, emoji string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tasks[taskID]; !ok {
+		return ErrTaskNotFound
+	}
+	s.removeReaction(reaction{taskID: taskID, userID: userID, emoji: emoji})
+	return nil
+}
+
+func (s *MemoryStore) AddCommentReaction(commentID, userID int, emoji string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if comment, ok := s.comments[commentID]; !ok || comment.Deleted {
+		return ErrCommentNotFound
+	}
+	s.addReaction(reaction{commentID: commentID, userID: userID, emoji: emoji})
+	return nil
+}
+
+func (s *MemoryStore) RemoveCommentReaction(commentID, userID int, emoji string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if comment, ok := s.comments[commentID]; !ok || comment.Deleted {
+		return ErrCommentNotFound
+	}
+	s.removeReaction(reaction{commentID: commentID, userID: userID, emoji: emoji})
+	return nil
+}
+
+func (s *MemoryStore) TaskReactions(taskIDs []int, userID int) (map[int][]models.ReactionSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.summarizeReactions(taskIDs, userID, func(r reaction) int { return r.taskID }), nil
+}
+
+func (s *MemoryStore) CommentReactions(commentIDs []int, userID int) (map[int][]models.ReactionSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.summarizeReactions(commentIDs, userID, func(r reaction) int { return r.commentID }), nil
+}
+
+// addReaction stores a reaction unless the user already reacted with the emoji
+func (s *MemoryStore) addReaction(added reaction) {
+	for _, r := range s.reactions {
+		if r.taskID == added.taskID && r.commentID == added.commentID && r.userID == added.userID && r.emoji == added.emoji {
+			return
+		}
+	}
+	s.reactions = append(s.reactions, added)
+}
+
+func (s *MemoryStore) removeReaction(removed reaction) {
+	s.reactions = slices.DeleteFunc(s.reactions, func(r reaction) bool {
+		return r.taskID == removed.taskID && r.commentID == removed.commentID && r.userID == removed.userID && r.emoji == removed.emoji
+	})
+}
+
+// summarizeReactions counts the reactions whose target, the task or comment ID
+// returned by target, is in ids. Reactions are kept in the order they were made,
+// so emojis come out in the order of their first use.
+func (s *MemoryStore) summarizeReactions(ids []int, userID int, target func(reaction) int) map[int][]models.ReactionSummary {
+	summaries := map[int][]models.ReactionSummary{}
+	for _, r := range s.reactions {
+		id := target(r)
+		if id == 0 || !slices.Contains(ids, id) {
+			continue
+		}
+		i := slices.IndexFunc(summaries[id], func(summary models.ReactionSummary) bool { return summary.Emoji == r.emoji })
+		if i < 0 {
+			summaries[id] = append(summaries[id], models.ReactionSummary{Emoji: r.emoji})
+			i = len(summaries[id]) - 1
+		}
+		summaries[id][i].Count++
+		summaries[id][i].Reacted = summaries[id][i].Reacted || r.userID == userID
+	}
+	return summaries
+}
+
+func (s *MemoryStore) TaskArchived(taskID int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[taskID]
+	if !ok {
+		return false, ErrTaskNotFound
+	}
+	return task.Archived, nil
+}
+
+func (s *MemoryStore) GetTimeEntry(entryID int) (*models.TimeEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.timeEntries[entryID]
+	if !ok {
+		return nil, ErrTimeEntryNotFound
+	}
+	view := s.timeEntryView(entry)
+	return &view, nil
+}
+
+func (s *MemoryStore) ListTaskTimeEntries(taskID int) ([]models.TimeEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []models.TimeEntry
+	for _, entry := range s.timeEntries {
+		if entry.TaskID == taskID {
+			entries = append(entries, s.timeEntryView(entry))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].StartedAt.After(entries[j].StartedAt) })
+	return entries, nil
+}
+
+func (s *MemoryStore) RunningTimeEntry(userID int) (*models.TimeEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range s.timeEntries {
+		if entry.UserID == userID && entry.EndedAt == nil {
+			view := s.timeEntryView(entry)
+			return &view, nil
+		}
+	}
+	return nil, ErrNoRunningTimer
+}
+
+func (s *MemoryStore) CreateTimeEntry(entry models.TimeEntry) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry.EndedAt == nil {
+		for _, existing := range s.timeEntries {
+			if existing.UserID == entry.UserID && existing.EndedAt == nil {
+				return 0, ErrTimerAlreadyRunning
+			}
+		}
+	}
+
+	s.lastTimeEntryID++
+	entry.ID = s.lastTimeEntryID
+	entry.CreatedAt = now()
+	entry.UpdatedAt = entry.CreatedAt
+	s.timeEntries[entry.ID] = &entry
+	return entry.ID, nil
+}
+
+func (s *MemoryStore) StopTimeEntry(userID int, endedAt time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range s.timeEntries {
+		if entry.UserID == userID && entry.EndedAt == nil {
+			entry.EndedAt = &endedAt
+			entry.DurationSeconds = int64(endedAt.Sub(entry.StartedAt).Seconds())
+			entry.UpdatedAt = now()
+			return entry.ID, nil
+		}
+	}
+	return 0, ErrNoRunningTimer
+}
+
+func (s *MemoryStore) UpdateTimeEntry(entry models.TimeEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.timeEntries[entry.ID]
+	if !ok {
+		return nil
+	}
+	stored.Description = entry.Description
+	stored.StartedAt = entry.StartedAt
+	stored.EndedAt = entry.EndedAt
+	stored.DurationSeconds = entry.DurationSeconds
+	stored.UpdatedAt = now()
+	return nil
+}
+
+func (s *MemoryStore) DeleteTimeEntry(entryID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.timeEntries, entryID)
+	return nil
+}
+
+func (s *MemoryStore) HasOverlappingEntry(userID, excludeID int, startedAt, endedAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range s.timeEntries {
+		if entry.UserID != userID || entry.ID == excludeID || !entry.StartedAt.Before(endedAt) {
+			continue
+		}
+		if entry.EndedAt == nil || entry.EndedAt.After(startedAt) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *MemoryStore) TimeReport(filter models.TimeReportFilter) ([]models.TimeReportRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type key struct{ userID, taskID int }
+	rows := map[key]*models.TimeReportRow{}
+	for _, entry := range s.timeEntries {
+		if entry.EndedAt == nil ||
+			(filter.UserID != nil && entry.UserID != *filter.UserID) ||
+			(filter.TaskID != nil && entry.TaskID != *filter.TaskID) ||
+			(filter.From != nil && entry.StartedAt.Before(*filter.From)) ||
+			(filter.To != nil && !entry.StartedAt.Before(*filter.To)) {
+			continue
+		}
+
+		k := key{entry.UserID, entry.TaskID}
+		row, ok := rows[k]
+		if !ok {
+			row = &models.TimeReportRow{UserID: entry.UserID, TaskID: entry.TaskID}
+			if user, ok := s.users[entry.UserID]; ok {
+				row.UserName = user.Name
+			}
+			if task, ok := s.tasks[entry.TaskID]; ok {
+				row.TaskTitle = task.Title
+			}
+			rows[k] = row
+		}
+		row.EntryCount++
+		row.TotalSeconds += entry.DurationSeconds
+	}
+
+	report := make([]models.TimeReportRow, 0, len(rows))
+	for _, row := range rows {
+		report = append(report, *row)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].UserName != report[j].UserName {
+			return report[i].UserName < report[j].UserName
+		}
+		return report[i].TaskTitle < report[j].TaskTitle
+	})
+	return report, nil
+}
+
+// timeEntryView returns a copy of an entry with its user's name, as the time entry
+// queries return it
+func (s *MemoryStore) timeEntryView(entry *models.TimeEntry) models.TimeEntry {
+	view := *entry
+	if entry.EndedAt != nil {
+		endedAt := *entry.EndedAt
+		view.EndedAt = &endedAt
+	}
+	view.Running = entry.EndedAt == nil
+	if user, ok := s.users[entry.UserID]; ok {
+		view.UserName = user.Name
+	}
+	return view
+}
+
+func (s *MemoryStore) ListChangeLogs(taskID int) ([]models.ChangeLog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var logs []models.ChangeLog
+	for i := len(s.logs) - 1; i >= 0; i-- {
+		if s.logs[i].TaskID == taskID {
+			log := s.logs[i]
+			if user, ok := s.users[log.UserID]; ok {
+				log.UserName = user.Name
+			}
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (s *MemoryStore) CreateChangeLog(log models.ChangeLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.appendLog(log)
+	return nil
+}
+
+func (s *MemoryStore) ClaimEvents(limit int, lease time.Duration) ([]OutboxEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*outboxEntry
+	at := now()
+	for _, entry := range s.outbox {
+		if entry.publishedAt == nil && !entry.nextAttemptAt.After(at) {
+			due = append(due, entry)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].nextAttemptAt.Before(due[j].nextAttemptAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	events := make([]OutboxEvent, len(due))
+	for i, entry := range due {
+		entry.nextAttemptAt = at.Add(lease)
+		events[i] = OutboxEvent{Event: entry.event, Attempts: entry.attempts, Handled: slices.Clone(entry.handled)}
+	}
+	return events, nil
+}
+
+func (s *MemoryStore) MarkPublished(eventID int64, handled []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry := s.outboxEntry(eventID); entry != nil {
+		at := now()
+		entry.attempts++
+		entry.handled = handled
+		entry.publishedAt = &at
+	}
+	return nil
+}
+
+func (s *MemoryStore) MarkFailed(eventID int64, handled []string, lastError string, retryDelay time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry := s.outboxEntry(eventID); entry != nil {
+		entry.attempts++
+		entry.handled = handled
+		entry.lastError = lastError
+		entry.nextAttemptAt = now().Add(retryDelay)
+	}
+	return nil
+}
+
+func (s *MemoryStore) PruneEvents(retention time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := now().Add(-retention)
+	before := len(s.outbox)
+	s.outbox = slices.DeleteFunc(s.outbox, func(entry *outboxEntry) bool {
+		return entry.publishedAt != nil && entry.publishedAt.Before(cutoff)
+	})
+	return int64(before - len(s.outbox)), nil
+}
+
+func (s *MemoryStore) outboxEntry(eventID int64) *outboxEntry {
+	for _, entry := range s.outbox {
+		if entry.event.ID == eventID {
+			return entry
+		}
+	}
+	return nil
+}
+
+// OutboxEvents returns every event recorded in the outbox, oldest first
+func (s *MemoryStore) OutboxEvents() []models.DomainEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := make([]models.DomainEvent, len(s.outbox))
+	for i, entry := range s.outbox {
+		events[i] = entry.event
+	}
+	return events
+}
+
+func (s *MemoryStore) ListWebhooks(userID int) ([]models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.findWebhooks(func(webhook *models.Webhook) bool { return webhook.UserID == userID }), nil
+}
+
+func (s *MemoryStore) GetWebhook(webhookID int) (*models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	webhook, ok := s.webhooks[webhookID]
+	if !ok {
+		return nil, ErrWebhookNotFound
+	}
+	view := webhookView(webhook)
+	return &view, nil
+}
+
+func (s *MemoryStore) CreateWebhook(webhook models.Webhook) (*models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastWebhookID++
+	webhook.ID = s.lastWebhookID
+	webhook.CreatedAt = now()
+	webhook.UpdatedAt = webhook.CreatedAt
+	stored := webhookView(&webhook)
+	s.webhooks[webhook.ID] = &stored
+	return &webhook, nil
+}
+
+func (s *MemoryStore) UpdateWebhook(webhook models.Webhook) (*models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.webhooks[webhook.ID]
+	if !ok {
+		return nil, ErrWebhookNotFound
+	}
+	stored.URL = webhook.URL
+	stored.Secret = webhook.Secret
+	stored.Events = append([]models.WebhookEvent(nil), webhook.Events...)
+	stored.Active = webhook.Active
+	stored.FailureCount = webhook.FailureCount
+	stored.DisabledAt = webhook.DisabledAt
+	stored.UpdatedAt = now()
+
+	view := webhookView(stored)
+	return &view, nil
+}
+
+func (s *MemoryStore) DeleteWebhook(webhookID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.webhooks, webhookID)
+	for id, delivery := range s.deliveries {
+		if delivery.WebhookID == webhookID {
+			delete(s.deliveries, id)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) SubscribedWebhooks(event models.WebhookEvent) ([]models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.findWebhooks(func(webhook *models.Webhook) bool {
+		if !webhook.Active {
+			return false
+		}
+		for _, subscribed := range webhook.Events {
+			if subscribed == event {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (s *MemoryStore) RecordAttempt(webhookID int, succeeded bool, disableAfter int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	webhook, ok := s.webhooks[webhookID]
+	if !ok {
+		return false, nil
+	}
+	if succeeded {
+		webhook.FailureCount = 0
+		return false, nil
+	}
+
+	webhook.FailureCount++
+	if webhook.Active && webhook.FailureCount >= disableAfter {
+		disabledAt := now()
+		webhook.Active = false
+		webhook.DisabledAt = &disabledAt
+		return true, nil
+	}
+	return false, nil
+}
+
+func (s *MemoryStore) CreateDelivery(delivery models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastDeliveryID++
+	delivery.ID = s.lastDeliveryID
+	delivery.CreatedAt = now()
+	stored := delivery
+	s.deliveries[delivery.ID] = &stored
+	return &delivery, nil
+}
+
+func (s *MemoryStore) GetDelivery(deliveryID int) (*models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery, ok := s.deliveries[deliveryID]
+	if !ok {
+		return nil, ErrDeliveryNotFound
+	}
+	view := *delivery
+	return &view, nil
+}
+
+func (s *MemoryStore) ListDeliveries(webhookID, limit, offset int) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deliveries []models.WebhookDelivery
+	for _, delivery := range s.deliveries {
+		if delivery.WebhookID == webhookID {
+			deliveries = append(deliveries, *delivery)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID > deliveries[j].ID })
+
+	if offset >= len(deliveries) {
+		return nil, nil
+	}
+	deliveries = deliveries[offset:]
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (s *MemoryStore) ClaimDueDeliveries(at time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*models.WebhookDelivery
+	for _, delivery := range s.deliveries {
+		webhook, ok := s.webhooks[delivery.WebhookID]
+		if ok && webhook.Active && delivery.Status == models.DeliveryPending &&
+			delivery.NextAttemptAt != nil && !delivery.NextAttemptAt.After(at) {
+			due = append(due, delivery)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextAttemptAt.Equal(*due[j].NextAttemptAt) {
+			return due[i].NextAttemptAt.Before(*due[j].NextAttemptAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	leasedUntil := at.Add(lease)
+	claimed := make([]models.WebhookDelivery, 0, len(due))
+	for _, delivery := range due {
+		delivery.NextAttemptAt = &leasedUntil
+		claimed = append(claimed, *delivery)
+	}
+	return claimed, nil
+}
+
+func (s *MemoryStore) SaveDelivery(delivery models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.deliveries[delivery.ID]
+	if !ok {
+		return nil
+	}
+	stored.Status = delivery.Status
+	stored.Attempts = delivery.Attempts
+	stored.ResponseStatus = delivery.ResponseStatus
+	stored.LastError = delivery.LastError
+	stored.NextAttemptAt = delivery.NextAttemptAt
+	stored.DeliveredAt = delivery.DeliveredAt
+	return nil
+}
+
+func (s *MemoryStore) ListNotifications(userID int, filter models.NotificationFilter) ([]models.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var notifications []models.Notification
+	for i := len(s.notifications) - 1; i >= 0; i-- {
+		notification := *s.notifications[i]
+		if notification.UserID != userID || (filter.UnreadOnly && notification.Read) {
+			continue
+		}
+		notifications = append(notifications, s.notificationView(&notification))
+	}
+
+	if filter.Offset >= len(notifications) {
+		return nil, nil
+	}
+	notifications = notifications[filter.Offset:]
+	if len(notifications) > filter.Limit {
+		notifications = notifications[:filter.Limit]
+	}
+	return notifications, nil
+}
+
+func (s *MemoryStore) CreateNotification(notification models.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if notification.EventID != nil {
+		for _, existing := range s.notifications {
+			if existing.UserID == notification.UserID && existing.Type == notification.Type &&
+				existing.EventID != nil && *existing.EventID == *notification.EventID {
+				return nil
+			}
+		}
+	}
+
+	s.lastNotificationID++
+	notification.ID = s.lastNotificationID
+	notification.Read = false
+	notification.CreatedAt = now()
+	s.notifications = append(s.notifications, &notification)
+	s.emails[notification.ID] = &emailState{status: emailPending}
+	return nil
+}
+
+func (s *MemoryStore) MarkRead(userID, notificationID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, notification := range s.notifications {
+		if notification.ID == notificationID && notification.UserID == userID {
+			notification.Read = true
+			return nil
+		}
+	}
+	return ErrNotificationNotFound
+}
+
+func (s *MemoryStore) MarkAllRead(userID int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	marked := 0
+	for _, notification := range s.notifications {
+		if notification.UserID == userID && !notification.Read {
+			notification.Read = true
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+func (s *MemoryStore) Notified(userID, taskID int, notificationType models.NotificationType, since time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, notification := range s.notifications {
+		if notification.UserID == userID && notification.Type == notificationType &&
+			notification.TaskID != nil && *notification.TaskID == taskID && !notification.CreatedAt.Before(since) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *MemoryStore) NotificationPreferences(userID int) (models.NotificationPreferences, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	preferences := models.NotificationPreferences{}
+	for notificationType, enabled := range s.preferences[userID] {
+		preferences[notificationType] = enabled
+	}
+	return preferences, nil
+}
+
+func (s *MemoryStore) SetNotificationPreferences(userID int, preferences models.NotificationPreferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.preferences[userID] == nil {
+		s.preferences[userID] = models.NotificationPreferences{}
+	}
+	for notificationType, enabled := range preferences {
+		s.preferences[userID][notificationType] = enabled
+	}
+	return nil
+}
+
+func (s *MemoryStore) TasksDueBetween(from, to time.Time) ([]models.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tasks []models.Task
+	for _, task := range s.tasks {
+		if !task.Archived && task.Status != models.StatusDone && task.DueDate != nil &&
+			!task.DueDate.Before(from) && !task.DueDate.After(to) {
+			tasks = append(tasks, s.taskView(task))
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].DueDate.Equal(*tasks[j].DueDate) {
+			return tasks[i].DueDate.Before(*tasks[j].DueDate)
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+	return tasks, nil
+}
+
+func (s *MemoryStore) ClaimNotificationEmails(types []models.NotificationType, limit, maxAttempts int, send func(models.Notification) (bool, error)) (int, error) {
+	s.mu.Lock()
+	var claimed []models.Notification
+	for _, notification := range s.notifications {
+		email := s.emails[notification.ID]
+		if len(claimed) == limit {
+			break
+		}
+		if email.status != emailPending || email.claimed || !slices.Contains(types, notification.Type) {
+			continue
+		}
+		email.claimed = true
+		claimed = append(claimed, s.notificationView(notification))
+	}
+	s.mu.Unlock()
+
+	for _, notification := range claimed {
+		sent, err := send(notification)
+
+		s.mu.Lock()
+		email := s.emails[notification.ID]
+		email.attempts++
+		email.claimed = false
+		switch {
+		case err != nil && email.attempts >= maxAttempts:
+			email.status = emailFailed
+		case err != nil:
+		case sent:
+			email.status = emailSent
+		default:
+			email.status = emailSkipped
+		}
+		s.mu.Unlock()
+	}
+
+	return len(claimed), nil
+}
+
+// NotificationEmailStatus returns whether a notification's email is pending, sent,
+// skipped or failed
+func (s *MemoryStore) NotificationEmailStatus(notificationID int) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if email, ok := s.emails[notificationID]; ok {
+		return email.status
+	}
+	return ""
+}
+
+func (s *MemoryStore) EmailPreferences(userID int) (models.EmailPreferences, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	preferences := models.EmailPreferences{}
+	for kind, enabled := range s.emailPreferences[userID] {
+		preferences[kind] = enabled
+	}
+	return preferences, nil
+}
+
+func (s *MemoryStore) SetEmailPreferences(userID int, preferences models.EmailPreferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.emailPreferences[userID] == nil {
+		s.emailPreferences[userID] = models.EmailPreferences{}
+	}
+	for kind, enabled := range preferences {
+		s.emailPreferences[userID][kind] = enabled
+	}
+	return nil
+}
+
+func (s *MemoryStore) DigestsDue(cutoff time.Time, limit int) ([]models.Digest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var digests []models.Digest
+	for _, user := range s.users {
+		since, ok := s.digests[user.ID]
+		if !ok {
+			since = cutoff
+		} else if !since.Before(cutoff) {
+			continue
+		}
+		if enabled, ok := s.emailPreferences[user.ID][models.EmailDigest]; ok && !enabled {
+			continue
+		}
+
+		digest := models.Digest{User: *user, Since: since}
+		digest.User.PasswordHash = ""
+		for i := len(s.notifications) - 1; i >= 0; i-- {
+			notification := s.notifications[i]
+			if notification.UserID == user.ID && !notification.Read && notification.CreatedAt.After(since) &&
+				len(digest.Notifications) < limit {
+				digest.Notifications = append(digest.Notifications, s.notificationView(notification))
+			}
+		}
+		if len(digest.Notifications) > 0 {
+			digests = append(digests, digest)
+		}
+	}
+
+	sort.Slice(digests, func(i, j int) bool { return digests[i].User.ID < digests[j].User.ID })
+	return digests, nil
+}
+
+func (s *MemoryStore) DigestSent(userID int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.digests[userID] = at
+	return nil
+}
+
+func (s *MemoryStore) appendLog(log models.ChangeLog) {
+	s.lastLogID++
+	log.ID = s.lastLogID
+	log.CreatedAt = now()
+	s.logs = append(s.logs, log)
+}
+
+// recordChange logs a change and adds its event to the outbox, as recordChange does
+// in the mutation's transaction
+func (s *MemoryStore) recordChange(change Change, taskID int, commentID *int, payload interface{}) error {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	if change.Action != "" {
+		s.appendLog(models.ChangeLog{
+			TaskID:  taskID,
+			UserID:  change.ActorID,
+			Action:  change.Action,
+			Details: change.Details,
+		})
+	}
+
+	s.lastEventID++
+	createdAt := now()
+	if commentID != nil {
+		id := *commentID
+		commentID = &id
+	}
+	s.outbox = append(s.outbox, &outboxEntry{
+		event: models.DomainEvent{
+			ID:        s.lastEventID,
+			Type:      change.Event,
+			TaskID:    taskID,
+			CommentID: commentID,
+			ActorID:   change.ActorID,
+			Payload:   encoded,
+			CreatedAt: createdAt,
+		},
+		nextAttemptAt: createdAt,
+	})
+	return nil
+}
+
+// taskChanged records a change with the task as it is now and returns the task
+func (s *MemoryStore) taskChanged(task *models.Task, change Change) (*models.Task, error) {
+	s.addWatchers(task.ID, change.Watchers)
+	view := s.taskView(task)
+	payload := models.TaskEventPayload{Task: view, Changes: change.Changes, Fields: change.Fields}
+	if change.ReplaceMentions {
+		payload.Mentioned = s.replaceMentions(mentionTarget{taskID: task.ID}, change.Mentions)
+	}
+	if err := s.recordChange(change, task.ID, nil, payload); err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+func (s *MemoryStore) commentChanged(comment *models.Comment, change Change) error {
+	s.addWatchers(comment.TaskID, change.Watchers)
+	payload := models.CommentEventPayload{Comment: s.commentView(comment)}
+	if change.ReplaceMentions {
+		payload.Comment.Mentions = change.Mentions
+		payload.Mentioned = s.replaceMentions(mentionTarget{comment.TaskID, comment.ID}, change.Mentions)
+	}
+	return s.recordChange(change, comment.TaskID, &comment.ID, payload)
+}
+
+// mentionTarget is a comment, or a task description when commentID is 0
+type mentionTarget struct {
+	taskID, commentID int
+}
+
+// replaceMentions stores the users mentioned in a comment or task description, as
+// replaceMentions does, and returns the ones not mentioned there before. The spans
+// are not kept.
+func (s *MemoryStore) replaceMentions(target mentionTarget, mentions []models.Mention) []int {
+	previous := s.mentioned[target]
+	users := map[int]bool{}
+	for _, mention := range mentions {
+		users[mention.UserID] = true
+	}
+	s.mentioned[target] = users
+	return newlyMentioned(previous, mentions)
+}
+
+// addWatchers makes users watch a task, keeping when the others started
+func (s *MemoryStore) addWatchers(taskID int, userIDs []int) {
+	for _, userID := range userIDs {
+		if s.watchers[taskID] == nil {
+			s.watchers[taskID] = map[int]time.Time{}
+		}
+		if _, ok := s.watchers[taskID][userID]; !ok {
+			s.watchers[taskID][userID] = now()
+		}
+	}
+}
+
+// notificationView copies a notification with its actor's name, as the Postgres
+// repository joins it in
+func (s *MemoryStore) notificationView(notification *models.Notification) models.Notification {
+	view := *notification
+	if view.ActorID != nil {
+		if actor, ok := s.users[*view.ActorID]; ok {
+			view.ActorName = actor.Name
+		}
+	}
+	view.EventID = nil
+	return view
+}
+
+// findWebhooks returns copies of the webhooks that match, by ID
+func (s *MemoryStore) findWebhooks(match func(*models.Webhook) bool) []models.Webhook {
+	var webhooks []models.Webhook
+	for _, webhook := range s.webhooks {
+		if match(webhook) {
+			webhooks = append(webhooks, webhookView(webhook))
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	return webhooks
+}
+
+// webhookView copies a webhook so callers cannot change the stored events
+func webhookView(webhook *models.Webhook) models.Webhook {
+	view := *webhook
+	view.Events = append([]models.WebhookEvent(nil), webhook.Events...)
+	return view
+}
+
+// taskView copies a task with the values the Postgres repository joins in
+func (s *MemoryStore) taskView(task *models.Task) models.Task {
+	view := *task
+	view.CustomFields = nil
+
+	if creator, ok := s.users[task.CreatorID]; ok {
+		view.CreatorName = creator.Name
+	}
+
+	for _, comment := range s.comments {
+		if comment.TaskID == task.ID && !comment.Deleted {
+			view.CommentCount++
+		}
+	}
+	view.WatcherCount = len(s.watchers[task.ID])
+
+	for fieldID, value := range s.values[task.ID] {
+		field, ok := s.field(fieldID)
+		if !ok {
+			continue
+		}
+		if view.CustomFields == nil {
+			view.CustomFields = map[string]interface{}{}
+		}
+		view.CustomFields[field.Key] = value
+	}
+
+	return view
+}
+
+func (s *MemoryStore) commentView(comment *models.Comment) models.Comment {
+	view := *comment
+	view.EditCount = len(s.revisions[comment.ID])
+	view.Edited = view.EditCount > 0
+	if user, ok := s.users[comment.UserID]; ok {
+		view.UserName = user.Name
+	}
+	return view
+}
+
+func (s *MemoryStore) field(fieldID int) (models.CustomField, bool) {
+	for _, project := range s.projects {
+		for _, field := range project.Fields {
+			if field.ID == fieldID {
+				return field, true
+			}
+		}
+	}
+	return models.CustomField{}, false
+}
+
+func (s *MemoryStore) saveValues(taskID int, values []FieldValue) {
+	for _, value := range values {
+		if value.Value == nil {
+			delete(s.values[taskID], value.Field.ID)
+			continue
+		}
+		s.values[taskID][value.Field.ID] = value.Value
+	}
+}
+
+func (s *MemoryStore) hasReplies(commentID int) bool {
+	for _, comment := range s.comments {
+		if comment.ParentID != nil && *comment.ParentID == commentID {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesFilters reports whether a task has every filtered value; multi-select
+// filters match when the task has all of the filter's options
+func (s *MemoryStore) matchesFilters(taskID int, filters []FieldValue) bool {
+	for _, filter := range filters {
+		value, ok := s.values[taskID][filter.Field.ID]
+		if !ok {
+			return false
+		}
+
+		if filter.Field.Type != models.FieldTypeMultiSelect {
+			if !reflect.DeepEqual(value, filter.Value) {
+				return false
+			}
+			continue
+		}
+
+		selected, _ := value.([]interface{})
+		wanted, _ := filter.Value.([]interface{})
+		for _, option := range wanted {
+			if !containsValue(selected, option) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// lessTask orders tasks like PostgresTaskRepository.ListTasks, with missing values last
+func (s *MemoryStore) lessTask(query TaskQuery, a, b models.Task) bool {
+	var x, y interface{}
+	if query.SortField != nil {
+		x, y = s.values[a.ID][query.SortField.ID], s.values[b.ID][query.SortField.ID]
+	} else {
+		x, y = taskColumn(a, query.Sort), taskColumn(b, query.Sort)
+	}
+
+	switch {
+	case x == nil && y == nil:
+	case x == nil:
+		return false
+	case y == nil:
+		return true
+	default:
+		if c := compareValues(x, y); c != 0 {
+			return c < 0 != query.Descending
+		}
+	}
+
+	if query.SortField != nil && !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.ID > b.ID
+}
+
+// taskColumn returns the value of a sortable column, or nil when it is not set
+func taskColumn(task models.Task, column string) interface{} {
+	switch column {
+	case "created_at":
+		return task.CreatedAt
+	case "updated_at":
+		return task.UpdatedAt
+	case "due_date":
+		if task.DueDate != nil {
+			return *task.DueDate
+		}
+	case "title":
+		return task.Title
+	case "story_points":
+		if task.StoryPoints != nil {
+			return float64(*task.StoryPoints)
+		}
+	}
+	return nil
+}
+
+func compareValues(x, y interface{}) int {
+	switch x := x.(type) {
+	case float64:
+		if y, ok := y.(float64); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	case time.Time:
+		if y, ok := y.(time.Time); ok {
+			return x.Compare(y)
+		}
+	}
+
+	a, b := fmt.Sprint(x), fmt.Sprint(y)
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// optionalID returns nil for 0
+func optionalID(id int) *int {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
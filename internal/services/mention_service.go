@@ -0,0 +1,230 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"database/sql"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+)
+
+// mentionPattern matches "@email" or "@name" where the "@" starts the text or follows
+// a character that cannot be part of a word or an email address. Names are matched
+// against user names with whitespace removed, so "@JaneDoe" mentions "Jane Doe".
+var mentionPattern = regexp.MustCompile(
+	`(?:^|[^\w@.])@([A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)+|[\p{L}\p{N}_](?:[\p{L}\p{N}._-]*[\p{L}\p{N}_])?)`,
+)
+
+// MentionToken is an unresolved "@handle" found in text. Start and End are
+// character offsets of the whole "@handle", End exclusive.
+type MentionToken struct {
+	Handle  string
+	IsEmail bool
+	Start   int
+	End     int
+}
+
+// MentionResolver maps the @mentions in a text to users
+type MentionResolver interface {
+	Resolve(text string) ([]models.Mention, error)
+}
+
+type MentionService struct {
+	db *sql.DB
+}
+
+func NewMentionService(db *sql.DB) *MentionService {
+	return &MentionService{db: db}
+}
+
+// ParseMentions finds "@name" and "@email" tokens in text
+func (s *MentionService) ParseMentions(text string) []MentionToken {
+	var tokens []MentionToken
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+		handleStart, handleEnd := match[2], match[3]
+		handle := text[handleStart:handleEnd]
+
+		tokens = append(tokens, MentionToken{
+			Handle:  handle,
+			IsEmail: strings.Contains(handle, "@"),
+			Start:   utf8.RuneCountInString(text[:handleStart-1]),
+			End:     utf8.RuneCountInString(text[:handleEnd]),
+		})
+	}
+
+	return tokens
+}
+
+// Resolve parses text and maps each token to a user. Tokens that match no user,
+// or a name shared by several users, are left out and stay plain text.
+func (s *MentionService) Resolve(text string) ([]models.Mention, error) {
+	tokens := s.ParseMentions(text)
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	var handles []string
+	for _, token := range tokens {
+		handles = append(handles, strings.ToLower(token.Handle))
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, name, LOWER(email), LOWER(regexp_replace(name, '\s+', '', 'g'))
+		FROM users
+		WHERE LOWER(email) = ANY($1) OR LOWER(regexp_replace(name, '\s+', '', 'g')) = ANY($1)
+	`, pq.Array(handles))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type user struct {
+		id   int
+		name string
+	}
+	byEmail := map[string]user{}
+	byName := map[string][]user{}
+	for rows.Next() {
+		var u user
+		var email, handle string
+		if err := rows.Scan(&u.id, &u.name, &email, &handle); err != nil {
+			return nil, err
+		}
+		byEmail[email] = u
+		byName[handle] = append(byName[handle], u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var mentions []models.Mention
+	for _, token := range tokens {
+		handle := strings.ToLower(token.Handle)
+
+		var match user
+		var found bool
+		if token.IsEmail {
+			match, found = byEmail[handle]
+		} else if candidates := byName[handle]; len(candidates) == 1 {
+			match, found = candidates[0], true
+		}
+
+		if found {
+			mentions = append(mentions, models.Mention{
+				UserID: match.id, UserName: match.name, Start: token.Start, End: token.End,
+			})
+		}
+	}
+
+	return mentions, nil
+}
+
+// resolveMentionsInto resolves the mentions of text with resolver and has change
+// replace the stored ones with them. Without a resolver mentions are left alone.
+func resolveMentionsInto(change *Change, resolver MentionResolver, text string) error {
+	if resolver == nil {
+		return nil
+	}
+
+	mentions, err := resolver.Resolve(text)
+	if err != nil {
+		return err
+	}
+	change.ReplaceMentions = true
+	change.Mentions = mentions
+	return nil
+}
+
+// replaceMentions replaces the stored mentions of a comment, or of a task description
+// when commentID is nil, inside a mutation's transaction. It returns the users that
+// were not mentioned there before.
+func replaceMentions(tx *sql.Tx, taskID int, commentID *int, mentions []models.Mention) ([]int, error) {
+	// Mentions of a description have no comment; NULL never equals NULL, hence IS NOT DISTINCT FROM
+	rows, err := tx.Query(`
+		DELETE FROM mentions
+		WHERE task_id = $1 AND comment_id IS NOT DISTINCT FROM $2
+		RETURNING user_id
+	`, taskID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	previous := map[int]bool{}
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		previous[userID] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, mention := range mentions {
+		_, err := tx.Exec(`
+			INSERT INTO mentions (task_id, comment_id, user_id, start_offset, end_offset)
+			VALUES ($1, $2, $3, $4, $5)
+		`, taskID, commentID, mention.UserID, mention.Start, mention.End)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newlyMentioned(previous, mentions), nil
+}
+
+// newlyMentioned returns the users of mentions that are not in previous, once each
+func newlyMentioned(previous map[int]bool, mentions []models.Mention) []int {
+	var added []int
+	seen := map[int]bool{}
+	for _, mention := range mentions {
+		if !previous[mention.UserID] && !seen[mention.UserID] {
+			added = append(added, mention.UserID)
+		}
+		seen[mention.UserID] = true
+	}
+	return added
+}
+
+// AttachCommentMentions loads the mention spans of a list of comments in a single query
+func (s *MentionService) AttachCommentMentions(comments []models.Comment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(comments))
+	commentIDs := make([]int, 0, len(comments))
+	for i, comment := range comments {
+		index[comment.ID] = i
+		commentIDs = append(commentIDs, comment.ID)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT m.comment_id, m.user_id, u.name, m.start_offset, m.end_offset
+		FROM mentions m
+		JOIN users u ON m.user_id = u.id
+		WHERE m.comment_id = ANY($1)
+		ORDER BY m.comment_id, m.start_offset
+	`, pq.Array(commentIDs))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var commentID int
+		var mention models.Mention
+		if err := rows.Scan(&commentID, &mention.UserID, &mention.UserName, &mention.Start, &mention.End); err != nil {
+			return err
+		}
+
+		comment := &comments[index[commentID]]
+		comment.Mentions = append(comment.Mentions, mention)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	service := &MentionService{}
+
+	tests := []struct {
+		name string
+		text string
+		want []MentionToken
+	}{
+		{"No mentions", "plain text with an email jane@example.com", nil},
+		{"Name at start", "@JaneDoe please check", []MentionToken{
+			{Handle: "JaneDoe", Start: 0, End: 8},
+		}},
+		{"Email with trailing punctuation", "ping @jane.doe@example.com.", []MentionToken{
+			{Handle: "jane.doe@example.com", IsEmail: true, Start: 5, End: 26},
+		}},
+		{"Several mentions", "(@bob, @alice) and @carol.", []MentionToken{
+			{Handle: "bob", Start: 1, End: 5},
+			{Handle: "alice", Start: 7, End: 13},
+			{Handle: "carol", Start: 19, End: 25},
+		}},
+		{"Offsets count characters", "héllo @zoë", []MentionToken{
+			{Handle: "zoë", Start: 6, End: 10},
+		}},
+		{"Lone at sign", "meet @ 5pm", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := service.ParseMentions(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMentions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
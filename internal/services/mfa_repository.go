@@ -0,0 +1,196 @@
+package services
+
+import (
+	"database/sql"
+	"time"
+)
+
+// MFARecord is a user's TOTP setup
+type MFARecord struct {
+	// Secret is the encrypted TOTP secret
+	Secret    string
+	EnabledAt *time.Time
+	// LastCounter is the time step of the last code accepted
+	LastCounter    int64
+	FailedAttempts int
+	LockedUntil    *time.Time
+}
+
+// MFARepository stores TOTP secrets and recovery codes
+type MFARepository interface {
+	// GetMFA returns ErrMFANotEnrolled when the user never enrolled
+	GetMFA(userID int) (*MFARecord, error)
+	// SaveMFASecret stores a pending secret in place of any pending one, or returns
+	// ErrMFAAlreadyEnabled when the user has MFA on
+	SaveMFASecret(userID int, secret string) error
+	// EnableMFA turns MFA on with the code of the given time step accepted, and
+	// replaces the user's recovery codes with the given hashes
+	EnableMFA(userID int, counter int64, at time.Time, codeHashes []string) error
+	// AcceptMFACode records the time step of an accepted code and clears failed
+	// attempts. It reports false when a code of that or a later step was already
+	// accepted, so codes cannot be replayed.
+	AcceptMFACode(userID int, counter int64) (bool, error)
+	// UseRecoveryCode uses up the unused recovery code with the hash and clears
+	// failed attempts, and reports whether there was one
+	UseRecoveryCode(userID int, codeHash string, at time.Time) (bool, error)
+	// RecordMFAFailure counts a wrong code; at maxAttempts the user is locked out
+	// until lockedUntil and the count starts over
+	RecordMFAFailure(userID int, maxAttempts int, lockedUntil time.Time) error
+	// CountRecoveryCodes returns how many unused recovery codes the user has
+	CountRecoveryCodes(userID int) (int, error)
+	// DeleteMFA turns MFA off and removes the recovery codes, or returns
+	// ErrMFANotEnrolled when the user never enrolled
+	DeleteMFA(userID int) error
+}
+
+type PostgresMFARepository struct {
+	db *sql.DB
+}
+
+func NewPostgresMFARepository(db *sql.DB) *PostgresMFARepository {
+	return &PostgresMFARepository{db: db}
+}
+
+func (r *PostgresMFARepository) GetMFA(userID int) (*MFARecord, error) {
+	var record MFARecord
+	err := r.db.QueryRow(`
+		SELECT secret, enabled_at, last_counter, failed_attempts, locked_until
+		FROM user_mfa WHERE user_id = $1
+	`, userID).Scan(&record.Secret, &record.EnabledAt, &record.LastCounter, &record.FailedAttempts, &record.LockedUntil)
+	if err == sql.ErrNoRows {
+		return nil, ErrMFANotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *PostgresMFARepository) SaveMFASecret(userID int, secret string) error {
+	result, err := r.db.Exec(`
+		INSERT INTO user_mfa (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = $2, last_counter = 0, failed_attempts = 0, locked_until = NULL, created_at = CURRENT_TIMESTAMP
+		WHERE user_mfa.enabled_at IS NULL
+	`, userID, secret)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+func (r *PostgresMFARepository) EnableMFA(userID int, counter int64, at time.Time, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE user_mfa SET enabled_at = $2, last_counter = $3, failed_attempts = 0
+		WHERE user_id = $1 AND enabled_at IS NULL
+	`, userID, at, counter)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.Exec("DELETE FROM mfa_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(`
+			INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)
+		`, userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PostgresMFARepository) AcceptMFACode(userID int, counter int64) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE user_mfa SET last_counter = $2, failed_attempts = 0
+		WHERE user_id = $1 AND last_counter < $2
+	`, userID, counter)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *PostgresMFARepository) UseRecoveryCode(userID int, codeHash string, at time.Time) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE mfa_recovery_codes SET used_at = $3
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, codeHash, at)
+	if err != nil {
+		return false, err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec("UPDATE user_mfa SET failed_attempts = 0 WHERE user_id = $1", userID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (r *PostgresMFARepository) RecordMFAFailure(userID int, maxAttempts int, lockedUntil time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE user_mfa
+		SET failed_attempts = CASE WHEN failed_attempts + 1 >= $2 THEN 0 ELSE failed_attempts + 1 END,
+		    locked_until = CASE WHEN failed_attempts + 1 >= $2 THEN $3 ELSE locked_until END
+		WHERE user_id = $1
+	`, userID, maxAttempts, lockedUntil)
+	return err
+}
+
+func (r *PostgresMFARepository) CountRecoveryCodes(userID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL
+	`, userID).Scan(&count)
+	return count, err
+}
+
+func (r *PostgresMFARepository) DeleteMFA(userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM user_mfa WHERE user_id = $1", userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrMFANotEnrolled
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MFASettings configures two-factor authentication
+type MFASettings struct {
+	// Issuer names the app in authenticator apps
+	Issuer string
+	// EncryptionKey encrypts TOTP secrets in the database
+	EncryptionKey string
+}
+
+const (
+	// mfaMaxAttempts wrong codes in a row lock a user out for mfaLockout
+	mfaMaxAttempts = 5
+	mfaLockout     = 15 * time.Minute
+	// recoveryCodeCount is the number of recovery codes handed out when MFA is
+	// turned on
+	recoveryCodeCount = 10
+)
+
+type MFAService struct {
+	repo     MFARepository
+	users    UserRepository
+	settings MFASettings
+	now      func() time.Time
+}
+
+func NewMFAService(repo MFARepository, users UserRepository, settings MFASettings) *MFAService {
+	return &MFAService{repo: repo, users: users, settings: settings, now: now}
+}
+
+// Status returns whether the user has MFA on and how many recovery codes are left
+func (s *MFAService) Status(userID int) (*models.MFAStatus, error) {
+	record, err := s.repo.GetMFA(userID)
+	if err == ErrMFANotEnrolled {
+		return &models.MFAStatus{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if record.EnabledAt == nil {
+		return &models.MFAStatus{}, nil
+	}
+
+	left, err := s.repo.CountRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.MFAStatus{Enabled: true, RecoveryCodesLeft: left}, nil
+}
+
+// Enabled reports whether the user has MFA on, so logging in needs a code
+func (s *MFAService) Enabled(userID int) (bool, error) {
+	record, err := s.repo.GetMFA(userID)
+	if err == ErrMFANotEnrolled {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return record.EnabledAt != nil, nil
+}
+
+// Enroll generates a new TOTP secret for the user to add to an authenticator app.
+// MFA stays off until Confirm is called with a code of the secret; enrolling again
+// before that replaces the secret.
+func (s *MFAService) Enroll(userID int) (*models.MFAEnrollment, error) {
+	user, err := s.users.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	encrypted, err := s.encrypt(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveMFASecret(userID, encrypted); err != nil {
+		return nil, err
+	}
+
+	return &models.MFAEnrollment{
+		Secret:     totpEncoding.EncodeToString(secret),
+		OTPAuthURI: otpauthURI(s.settings.Issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm turns MFA on when the code matches the enrolled secret, and returns the
+// recovery codes, which are shown only this once
+func (s *MFAService) Confirm(userID int, code string) ([]string, error) {
+	record, err := s.repo.GetMFA(userID)
+	if err != nil {
+		return nil, err
+	}
+	if record.EnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := s.decrypt(record.Secret)
+	if err != nil {
+		return nil, err
+	}
+	at := s.now()
+	counter, ok := matchTOTP(secret, normalizeMFACode(code), at)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		if codes[i], err = newRecoveryCode(); err != nil {
+			return nil, err
+		}
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	if err := s.repo.EnableMFA(userID, counter, at, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify checks a code from the authenticator app or an unused recovery code,
+// which is used up. Each code of the app works once. After mfaMaxAttempts wrong
+// codes in a row the user is locked out with ErrMFALocked for mfaLockout.
+func (s *MFAService) Verify(userID int, code string) error {
+	record, err := s.repo.GetMFA(userID)
+	if err != nil {
+		return err
+	}
+	if record.EnabledAt == nil {
+		return ErrMFANotEnrolled
+	}
+	at := s.now()
+	if record.LockedUntil != nil && at.Before(*record.LockedUntil) {
+		return ErrMFALocked
+	}
+
+	code = normalizeMFACode(code)
+	if len(code) == totpDigits {
+		secret, err := s.decrypt(record.Secret)
+		if err != nil {
+			return err
+		}
+		if counter, ok := matchTOTP(secret, code, at); ok {
+			accepted, err := s.repo.AcceptMFACode(userID, counter)
+			if err != nil || accepted {
+				return err
+			}
+		}
+	} else {
+		used, err := s.repo.UseRecoveryCode(userID, hashRecoveryCode(code), at)
+		if err != nil || used {
+			return err
+		}
+	}
+
+	if err := s.repo.RecordMFAFailure(userID, mfaMaxAttempts, at.Add(mfaLockout)); err != nil {
+		return err
+	}
+	return ErrInvalidMFACode
+}
+
+// Disable turns MFA off after checking a code like Verify
+func (s *MFAService) Disable(userID int, code string) error {
+	if err := s.Verify(userID, code); err != nil {
+		return err
+	}
+	return s.repo.DeleteMFA(userID)
+}
+
+// Reset turns MFA off for a user who lost both the authenticator app and the
+// recovery codes; only admins may do so
+func (s *MFAService) Reset(userID string) error {
+	id, err := strconv.Atoi(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if _, err := s.users.GetUser(id); err != nil {
+		return err
+	}
+	return s.repo.DeleteMFA(id)
+}
+
+// encrypt seals a TOTP secret with AES-256-GCM, returning the nonce and the
+// ciphertext in base64
+func (s *MFAService) encrypt(secret []byte) (string, error) {
+	aead, err := s.cipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, secret, nil)), nil
+}
+
+func (s *MFAService) decrypt(encrypted string) ([]byte, error) {
+	aead, err := s.cipher()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("mfa: encrypted secret too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
+
+// mfaKeyLabel is the HKDF info of the TOTP secret key. It keeps the key apart from
+// other keys derived from the same setting, such as when it falls back to the JWT
+// secret.
+const mfaKeyLabel = "candidate-backend mfa totp secret v1"
+
+// cipher derives the AES-256 key from the encryption key setting, which may be any
+// string, with HKDF-SHA256
+func (s *MFAService) cipher() (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, []byte(s.settings.EncryptionKey), nil, mfaKeyLabel, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// newRecoveryCode returns a random code of 16 base32 characters in groups of four,
+// e.g. "k3m2-x7qa-p4nd-w6ty"
+func newRecoveryCode() (string, error) {
+	random := make([]byte, 10)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(totpEncoding.EncodeToString(random))
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16], nil
+}
+
+// hashRecoveryCode returns the hex SHA-256 hash of a recovery code, which is all
+// that is stored of it. Dashes and case do not matter.
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeMFACode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeMFACode drops the spaces and dashes people type codes with
+func normalizeMFACode(code string) string {
+	code = strings.NewReplacer(" ", "", "-", "").Replace(code)
+	return strings.ToLower(code)
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// enrollMFA turns MFA on for a user and returns the TOTP secret and recovery codes
+func enrollMFA(t *testing.T, service *MFAService, userID int) ([]byte, []string) {
+	t.Helper()
+	enrollment, err := service.Enroll(userID)
+	if err != nil {
+		t.Fatalf("Enroll() error = %v", err)
+	}
+	secret, err := totpEncoding.DecodeString(enrollment.Secret)
+	if err != nil {
+		t.Fatalf("Enroll() secret = %q: %v", enrollment.Secret, err)
+	}
+	code, _ := TOTPCode(enrollment.Secret, service.now())
+	codes, err := service.Confirm(userID, code)
+	if err != nil {
+		t.Fatalf("Confirm() error = %v", err)
+	}
+	return secret, codes
+}
+
+func TestMFAEnrollment(t *testing.T) {
+	store := newTaskStore(t)
+	service := NewMFAService(store, store, MFASettings{Issuer: "Task Board", EncryptionKey: "secret"})
+
+	enrollment, err := service.Enroll(1)
+	if err != nil {
+		t.Fatalf("Enroll() error = %v", err)
+	}
+	if !strings.HasPrefix(enrollment.OTPAuthURI, "otpauth://totp/") {
+		t.Errorf("Enroll() = %+v, want an otpauth URI", enrollment)
+	}
+	record, _ := store.GetMFA(1)
+	if strings.Contains(record.Secret, enrollment.Secret) {
+		t.Errorf("stored secret = %s, want it encrypted", record.Secret)
+	}
+	if status, _ := service.Status(1); status.Enabled {
+		t.Errorf("Status() before confirming = %+v, want MFA off", status)
+	}
+	if _, err := service.Confirm(1, "000000"); err != ErrInvalidMFACode {
+		t.Errorf("Confirm() with a wrong code error = %v, want %v", err, ErrInvalidMFACode)
+	}
+
+	_, codes := enrollMFA(t, service, 1)
+	if len(codes) != recoveryCodeCount || len(codes[0]) != len("xxxx-xxxx-xxxx-xxxx") {
+		t.Errorf("Confirm() = %v, want %d recovery codes", codes, recoveryCodeCount)
+	}
+	if status, _ := service.Status(1); !status.Enabled || status.RecoveryCodesLeft != recoveryCodeCount {
+		t.Errorf("Status() = %+v, want MFA on with %d recovery codes", status, recoveryCodeCount)
+	}
+	if _, err := service.Enroll(1); err != ErrMFAAlreadyEnabled {
+		t.Errorf("Enroll() when on error = %v, want %v", err, ErrMFAAlreadyEnabled)
+	}
+}
+
+func TestMFAVerify(t *testing.T) {
+	store := newTaskStore(t)
+	service := NewMFAService(store, store, MFASettings{Issuer: "Task Board", EncryptionKey: "secret"})
+	clock := time.Now()
+	service.now = func() time.Time { return clock }
+	secret, codes := enrollMFA(t, service, 1)
+
+	// code returns the authenticator app's code at the current time
+	code := func() string { return totpCode(secret, totpCounter(clock)) }
+
+	wrong := func() string { return "not-a-code" }
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		userID  int
+		code    func() string
+		wantErr error
+	}{
+		{"Code used to confirm", 0, 1, code, ErrInvalidMFACode},
+		{"Next code", totpStep, 1, code, nil},
+		{"Replayed code", 0, 1, code, ErrInvalidMFACode},
+		{"Recovery code", 0, 1, func() string { return strings.ToUpper(codes[0]) }, nil},
+		{"Used recovery code", 0, 1, func() string { return codes[0] }, ErrInvalidMFACode},
+		{"Second wrong code", 0, 1, func() string { return "123 456" }, ErrInvalidMFACode},
+		{"Third wrong code", 0, 1, wrong, ErrInvalidMFACode},
+		{"Fourth wrong code", 0, 1, wrong, ErrInvalidMFACode},
+		{"Fifth wrong code locks", 0, 1, wrong, ErrInvalidMFACode},
+		{"Locked with a right code", totpStep, 1, code, ErrMFALocked},
+		{"After the lockout", mfaLockout, 1, code, nil},
+		{"Not enrolled", 0, 2, code, ErrMFANotEnrolled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock = clock.Add(tt.advance)
+			if err := service.Verify(tt.userID, tt.code()); err != tt.wantErr {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if status, _ := service.Status(1); status.RecoveryCodesLeft != recoveryCodeCount-1 {
+		t.Errorf("Status() = %+v, want one recovery code used", status)
+	}
+	if err := service.Reset("1"); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	if enabled, _ := service.Enabled(1); enabled {
+		t.Errorf("Enabled() after Reset() = true, want false")
+	}
+}
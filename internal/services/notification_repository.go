@@ -0,0 +1,207 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// NotificationRepository stores notifications and the preferences deciding which
+// types a user gets
+type NotificationRepository interface {
+	// ListNotifications returns a page of a user's notifications, newest first
+	ListNotifications(userID int, filter models.NotificationFilter) ([]models.Notification, error)
+	// CreateNotification stores a notification. A notification about an event the user
+	// was already notified of with the same type is skipped.
+	CreateNotification(notification models.Notification) error
+	// MarkRead returns ErrNotificationNotFound when the user has no notification with the ID
+	MarkRead(userID, notificationID int) error
+	// MarkAllRead marks a user's unread notifications read and returns how many there were
+	MarkAllRead(userID int) (int, error)
+	// Notified reports whether a user got a notification of a type about a task since a time
+	Notified(userID, taskID int, notificationType models.NotificationType, since time.Time) (bool, error)
+
+	// NotificationPreferences returns the types a user turned on or off; other types are on
+	NotificationPreferences(userID int) (models.NotificationPreferences, error)
+	SetNotificationPreferences(userID int, preferences models.NotificationPreferences) error
+
+	// TasksDueBetween returns the open, non-archived tasks due within [from, to]
+	TasksDueBetween(from, to time.Time) ([]models.Task, error)
+}
+
+type PostgresNotificationRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresNotificationRepository(db *sql.DB) *PostgresNotificationRepository {
+	return &PostgresNotificationRepository{db: db}
+}
+
+const notificationSelect = `
+	SELECT n.id, n.user_id, n.actor_id, COALESCE(a.name, ''), n.type, n.task_id, n.comment_id,
+	       n.message, n.read_at IS NOT NULL, n.created_at
+	FROM notifications n
+	LEFT JOIN users a ON n.actor_id = a.id
+`
+
+func scanNotification(row rowScanner) (*models.Notification, error) {
+	var notification models.Notification
+	var actorID, taskID, commentID sql.NullInt64
+	err := row.Scan(
+		&notification.ID, &notification.UserID, &actorID, &notification.ActorName,
+		&notification.Type, &taskID, &commentID, &notification.Message,
+		&notification.Read, &notification.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	notification.ActorID = nullIntPtr(actorID)
+	notification.TaskID = nullIntPtr(taskID)
+	notification.CommentID = nullIntPtr(commentID)
+	return &notification, nil
+}
+
+func (r *PostgresNotificationRepository) ListNotifications(userID int, filter models.NotificationFilter) ([]models.Notification, error) {
+	condition := ""
+	if filter.UnreadOnly {
+		condition = "AND n.read_at IS NULL"
+	}
+
+	rows, err := r.db.Query(fmt.Sprintf(notificationSelect+`
+		WHERE n.user_id = $1 %s
+		ORDER BY n.created_at DESC, n.id DESC
+		LIMIT $2 OFFSET $3
+	`, condition), userID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []models.Notification
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, *notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+func (r *PostgresNotificationRepository) CreateNotification(notification models.Notification) error {
+	_, err := r.db.Exec(`
+		INSERT INTO notifications (user_id, actor_id, type, task_id, comment_id, message, event_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, type, event_id) DO NOTHING
+	`, notification.UserID, notification.ActorID, notification.Type, notification.TaskID,
+		notification.CommentID, notification.Message, notification.EventID)
+	return err
+}
+
+func (r *PostgresNotificationRepository) MarkRead(userID, notificationID int) error {
+	result, err := r.db.Exec(`
+		UPDATE notifications SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+		WHERE id = $1 AND user_id = $2
+	`, notificationID, userID)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (r *PostgresNotificationRepository) MarkAllRead(userID int) (int, error) {
+	result, err := r.db.Exec(`
+		UPDATE notifications SET read_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND read_at IS NULL
+	`, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+func (r *PostgresNotificationRepository) Notified(userID, taskID int, notificationType models.NotificationType, since time.Time) (bool, error) {
+	var notified bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM notifications
+			WHERE user_id = $1 AND task_id = $2 AND type = $3 AND created_at >= $4
+		)
+	`, userID, taskID, notificationType, since).Scan(&notified)
+	return notified, err
+}
+
+func (r *PostgresNotificationRepository) NotificationPreferences(userID int) (models.NotificationPreferences, error) {
+	rows, err := r.db.Query(`
+		SELECT type, enabled FROM notification_preferences WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	preferences := models.NotificationPreferences{}
+	for rows.Next() {
+		var notificationType models.NotificationType
+		var enabled bool
+		if err := rows.Scan(&notificationType, &enabled); err != nil {
+			return nil, err
+		}
+		preferences[notificationType] = enabled
+	}
+
+	return preferences, rows.Err()
+}
+
+func (r *PostgresNotificationRepository) SetNotificationPreferences(userID int, preferences models.NotificationPreferences) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for notificationType, enabled := range preferences {
+		_, err := tx.Exec(`
+			INSERT INTO notification_preferences (user_id, type, enabled)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, type) DO UPDATE SET enabled = $3, updated_at = CURRENT_TIMESTAMP
+		`, userID, notificationType, enabled)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresNotificationRepository) TasksDueBetween(from, to time.Time) ([]models.Task, error) {
+	rows, err := r.db.Query(taskSelect+`
+		WHERE t.archived = FALSE AND t.status <> $1 AND t.due_date BETWEEN $2 AND $3
+		ORDER BY t.due_date, t.id
+	`, models.StatusDone, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []models.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *task)
+	}
+
+	return tasks, rows.Err()
+}
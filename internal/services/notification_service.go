@@ -0,0 +1,299 @@
+package services
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"time"
+)
+
+type NotificationService struct {
+	repo  NotificationRepository
+	tasks TaskRepository
+	users UserRepository
+	now   func() time.Time
+}
+
+func NewNotificationService(repo NotificationRepository, tasks TaskRepository, users UserRepository) *NotificationService {
+	return &NotificationService{repo: repo, tasks: tasks, users: users, now: now}
+}
+
+// GetNotifications retrieves a page of a user's notifications, newest first
+func (s *NotificationService) GetNotifications(userID int, filter models.NotificationFilter) ([]models.Notification, error) {
+	notifications, err := s.repo.ListNotifications(userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if notifications == nil {
+		notifications = []models.Notification{}
+	}
+
+	return notifications, nil
+}
+
+// MarkRead marks one of a user's notifications read
+func (s *NotificationService) MarkRead(notificationID string, userID int) error {
+	id, err := strconv.Atoi(notificationID)
+	if err != nil {
+		return ErrNotificationNotFound
+	}
+
+	return s.repo.MarkRead(userID, id)
+}
+
+// MarkAllRead marks all of a user's notifications read and returns how many were unread
+func (s *NotificationService) MarkAllRead(userID int) (int, error) {
+	return s.repo.MarkAllRead(userID)
+}
+
+// GetPreferences returns for every notification type whether the user gets it
+func (s *NotificationService) GetPreferences(userID int) (models.NotificationPreferences, error) {
+	stored, err := s.repo.NotificationPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := models.NotificationPreferences{}
+	for _, notificationType := range models.NotificationTypes {
+		enabled, ok := stored[notificationType]
+		preferences[notificationType] = enabled || !ok
+	}
+	return preferences, nil
+}
+
+// UpdatePreferences turns the given notification types on or off and returns the
+// resulting preferences
+func (s *NotificationService) UpdatePreferences(userID int, preferences models.NotificationPreferences) (models.NotificationPreferences, error) {
+	if len(preferences) == 0 {
+		return nil, apperrors.Invalid("body", "no preferences to update")
+	}
+	for notificationType := range preferences {
+		if !slices.Contains(models.NotificationTypes, notificationType) {
+			return nil, apperrors.Invalidf(string(notificationType), "unknown notification type '%s'", notificationType)
+		}
+	}
+
+	if err := s.repo.SetNotificationPreferences(userID, preferences); err != nil {
+		return nil, err
+	}
+
+	return s.GetPreferences(userID)
+}
+
+// HandleEvent is an outbox subscriber that notifies users of assignments and mentions,
+// and watchers of status changes and comments. Users never get notified about their
+// own changes.
+func (s *NotificationService) HandleEvent(ctx context.Context, event models.DomainEvent) error {
+	switch event.Type {
+	case models.EventTaskCreated, models.EventTaskUpdated:
+		var payload models.TaskEventPayload
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return err
+		}
+		task := payload.Task
+
+		assigned := event.Type == models.EventTaskCreated || slices.Contains(payload.Fields, "assignee_id")
+		if assigned && task.AssigneeID != nil {
+			_, err := s.notify([]int{*task.AssigneeID}, s.eventNotification(event, models.NotificationAssigned,
+				fmt.Sprintf("%s assigned you to '%s'", s.actorName(event.ActorID), task.Title)))
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(payload.Mentioned) > 0 {
+			_, err := s.notify(payload.Mentioned, s.eventNotification(event, models.NotificationMention,
+				fmt.Sprintf("%s mentioned you in task '%s'", s.actorName(event.ActorID), task.Title)))
+			if err != nil {
+				return err
+			}
+		}
+
+		if slices.Contains(payload.Fields, "status") {
+			watchers, err := s.watchers(task.ID)
+			if err != nil {
+				return err
+			}
+			_, err = s.notify(watchers, s.eventNotification(event, models.NotificationStatus,
+				fmt.Sprintf("%s changed the status of '%s' to '%s'", s.actorName(event.ActorID), task.Title, task.Status)))
+			return err
+		}
+
+	case models.EventCommentCreated, models.EventCommentUpdated:
+		var payload models.CommentEventPayload
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return err
+		}
+		task, err := s.tasks.GetTask(event.TaskID)
+		if errors.Is(err, ErrTaskNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(payload.Mentioned) > 0 {
+			_, err = s.notify(payload.Mentioned, s.eventNotification(event, models.NotificationMention,
+				fmt.Sprintf("%s mentioned you in a comment on '%s'", s.actorName(event.ActorID), task.Title)))
+			if err != nil {
+				return err
+			}
+		}
+		if event.Type == models.EventCommentUpdated {
+			return nil
+		}
+
+		watchers, err := s.watchers(task.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.notify(watchers, s.eventNotification(event, models.NotificationComment,
+			fmt.Sprintf("%s commented on '%s'", s.actorName(event.ActorID), task.Title)))
+		return err
+	}
+
+	return nil
+}
+
+// Run sends due-date reminders every interval until ctx is done
+func (s *NotificationService) Run(ctx context.Context, interval, window time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := s.NotifyDueSoon(window); err != nil {
+			log.Printf("notifications: sending due-date reminders: %v", err)
+		}
+	}
+}
+
+// NotifyDueSoon reminds the watchers of open tasks due within window. Each user
+// is reminded once per due date; moving the due date sends a new reminder. It returns
+// how many reminders it sent.
+func (s *NotificationService) NotifyDueSoon(window time.Duration) (int, error) {
+	now := s.now()
+	tasks, err := s.repo.TasksDueBetween(now, now.Add(window))
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, task := range tasks {
+		watchers, err := s.watchers(task.ID)
+		if err != nil {
+			return sent, err
+		}
+
+		var recipients []int
+		for _, userID := range watchers {
+			notified, err := s.repo.Notified(userID, task.ID, models.NotificationDueSoon, task.DueDate.Add(-window))
+			if err != nil {
+				return sent, err
+			}
+			if !notified {
+				recipients = append(recipients, userID)
+			}
+		}
+
+		taskID := task.ID
+		notified, err := s.notify(recipients, models.Notification{
+			Type:    models.NotificationDueSoon,
+			TaskID:  &taskID,
+			Message: fmt.Sprintf("'%s' is due %s", task.Title, task.DueDate.UTC().Format("Jan 2, 2006 15:04 UTC")),
+		})
+		sent += notified
+		if err != nil {
+			return sent, err
+		}
+	}
+
+	return sent, nil
+}
+
+// watchers returns the IDs of the users watching a task
+func (s *NotificationService) watchers(taskID int) ([]int, error) {
+	watchers, err := s.tasks.ListWatchers(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]int, 0, len(watchers))
+	for _, watcher := range watchers {
+		userIDs = append(userIDs, watcher.UserID)
+	}
+	return userIDs, nil
+}
+
+// notify sends a notification to each user once, except its actor and users who
+// turned its type off, and returns how many users it notified
+func (s *NotificationService) notify(userIDs []int, notification models.Notification) (int, error) {
+	notified := 0
+	seen := map[int]bool{}
+	for _, userID := range userIDs {
+		if seen[userID] || (notification.ActorID != nil && userID == *notification.ActorID) {
+			continue
+		}
+		seen[userID] = true
+
+		preferences, err := s.repo.NotificationPreferences(userID)
+		if err != nil {
+			return notified, err
+		}
+		if enabled, ok := preferences[notification.Type]; ok && !enabled {
+			continue
+		}
+
+		notification.UserID = userID
+		if err := s.repo.CreateNotification(notification); err != nil {
+			return notified, err
+		}
+		notified++
+	}
+
+	return notified, nil
+}
+
+// eventNotification builds a notification about a domain event
+func (s *NotificationService) eventNotification(event models.DomainEvent, notificationType models.NotificationType, message string) models.Notification {
+	taskID, actorID, eventID := event.TaskID, event.ActorID, event.ID
+	return models.Notification{
+		ActorID:   &actorID,
+		Type:      notificationType,
+		TaskID:    &taskID,
+		CommentID: event.CommentID,
+		Message:   message,
+		EventID:   &eventID,
+	}
+}
+
+// actorName returns the name of the user behind a change
+func (s *NotificationService) actorName(userID int) string {
+	user, err := s.users.GetUser(userID)
+	if err != nil {
+		return "Someone"
+	}
+	return user.Name
+}
+
+func nullIntPtr(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	id := int(value.Int64)
+	return &id
+}
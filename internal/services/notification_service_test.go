@@ -0,0 +1,223 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// unread returns the types of a user's unread notifications, oldest first, and marks
+// them read
+func unread(t *testing.T, service *NotificationService, userID int) []models.NotificationType {
+	t.Helper()
+	notifications, err := service.GetNotifications(userID, models.NotificationFilter{UnreadOnly: true, Limit: 100})
+	if err != nil {
+		t.Fatalf("GetNotifications() error = %v", err)
+	}
+	if _, err := service.MarkAllRead(userID); err != nil {
+		t.Fatalf("MarkAllRead() error = %v", err)
+	}
+
+	var types []models.NotificationType
+	for i := len(notifications) - 1; i >= 0; i-- {
+		types = append(types, notifications[i].Type)
+	}
+	return types
+}
+
+// mentionsByHandle resolves the @handles it knows to users, without a database
+type mentionsByHandle map[string]int
+
+func (m mentionsByHandle) Resolve(text string) ([]models.Mention, error) {
+	var mentions []models.Mention
+	for _, token := range NewMentionService(nil).ParseMentions(text) {
+		if userID, ok := m[token.Handle]; ok {
+			mentions = append(mentions, models.Mention{UserID: userID, Start: token.Start, End: token.End})
+		}
+	}
+	return mentions, nil
+}
+
+func TestNotificationEvents(t *testing.T) {
+	store := newTaskStore(t)
+	carol, _ := store.CreateUser(models.User{Email: "carol@example.com", Name: "Carol"})
+	tasks := NewTaskService(store, store)
+	comments := NewCommentService(store)
+	service := NewNotificationService(store, store, store)
+	resolver := mentionsByHandle{"alice": 1, "bob": 2, "carol": carol.ID}
+	tasks.SetMentionResolver(resolver)
+	comments.SetMentionResolver(resolver)
+	dispatcher := NewOutboxDispatcher(store)
+	dispatcher.Subscribe("notifications", service.HandleEvent)
+
+	bob := 2
+	task, err := tasks.CreateTask(models.CreateTaskRequest{Title: "Ship it", AssigneeID: &bob}, 1)
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	id := strconv.Itoa(task.ID)
+	status := func(status models.TaskStatus) *models.TaskStatus { return &status }
+
+	tests := []struct {
+		name  string
+		run   func()
+		alice []models.NotificationType
+		bob   []models.NotificationType
+		carol []models.NotificationType
+	}{
+		{
+			name: "Assignee hears of the assignment",
+			run:  func() {},
+			bob:  []models.NotificationType{models.NotificationAssigned},
+		},
+		{
+			name: "Status change reaches the other watchers",
+			run: func() {
+				_, _, _ = tasks.UpdateTask(id, models.UpdateTaskRequest{Status: status(models.StatusInProgress)}, 1)
+			},
+			bob: []models.NotificationType{models.NotificationStatus},
+		},
+		{
+			name: "Setting the same status notifies nobody",
+			run: func() {
+				_, _, _ = tasks.UpdateTask(id, models.UpdateTaskRequest{Status: status(models.StatusInProgress)}, 1)
+			},
+		},
+		{
+			name: "Comment reaches the creator",
+			run: func() {
+				_, _ = comments.CreateComment(id, models.CreateCommentRequest{Content: "Started"}, 2)
+			},
+			alice: []models.NotificationType{models.NotificationComment},
+		},
+		{
+			name: "Reassigning notifies the new assignee only",
+			run: func() {
+				_, _, _ = tasks.UpdateTask(id, models.UpdateTaskRequest{AssigneeID: &carol.ID}, 1)
+			},
+			carol: []models.NotificationType{models.NotificationAssigned},
+		},
+		{
+			name: "Turned off types are skipped",
+			run: func() {
+				off := models.NotificationPreferences{models.NotificationComment: false}
+				if _, err := service.UpdatePreferences(carol.ID, off); err != nil {
+					t.Fatalf("UpdatePreferences() error = %v", err)
+				}
+				_, _ = comments.CreateComment(id, models.CreateCommentRequest{Content: "Handing over"}, 2)
+			},
+			alice: []models.NotificationType{models.NotificationComment},
+		},
+		{
+			name: "Unwatching stops notifications",
+			run: func() {
+				_, _ = tasks.UnwatchTask(id, 1)
+				_, _ = comments.CreateComment(id, models.CreateCommentRequest{Content: "Still here"}, 2)
+			},
+		},
+		{
+			name: "Watchers hear of status changes",
+			run: func() {
+				_, _, _ = tasks.UpdateTask(id, models.UpdateTaskRequest{Status: status(models.StatusDone)}, 1)
+			},
+			bob:   []models.NotificationType{models.NotificationStatus},
+			carol: []models.NotificationType{models.NotificationStatus},
+		},
+		{
+			name: "Mentions in comments follow preferences too",
+			run: func() {
+				_, _ = comments.CreateComment(id, models.CreateCommentRequest{Content: "@alice @bob @carol done"}, 1)
+			},
+			bob:   []models.NotificationType{models.NotificationMention, models.NotificationComment},
+			carol: []models.NotificationType{models.NotificationMention},
+		},
+		{
+			name: "Mention in the description",
+			run: func() {
+				description := "Ask @carol"
+				_, _, _ = tasks.UpdateTask(id, models.UpdateTaskRequest{Description: &description}, 1)
+			},
+			carol: []models.NotificationType{models.NotificationMention},
+		},
+		{
+			name: "Only new mentions notify",
+			run: func() {
+				description := "Ask @carol and @bob"
+				_, _, _ = tasks.UpdateTask(id, models.UpdateTaskRequest{Description: &description}, 1)
+			},
+			bob: []models.NotificationType{models.NotificationMention},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run()
+			if _, err := dispatcher.DispatchPending(context.Background()); err != nil {
+				t.Fatalf("DispatchPending() error = %v", err)
+			}
+
+			for _, user := range []struct {
+				id   int
+				want []models.NotificationType
+			}{{1, tt.alice}, {2, tt.bob}, {carol.ID, tt.carol}} {
+				if got := unread(t, service, user.id); !reflect.DeepEqual(got, user.want) {
+					t.Errorf("user %d got %v, want %v", user.id, got, user.want)
+				}
+			}
+		})
+	}
+
+	// An event published twice notifies once
+	event := store.OutboxEvents()[0]
+	_ = service.HandleEvent(context.Background(), event)
+	if got := unread(t, service, 2); got != nil {
+		t.Errorf("republished event notified %v, want nothing", got)
+	}
+}
+
+func TestNotifyDueSoon(t *testing.T) {
+	store := newTaskStore(t)
+	tasks := NewTaskService(store, store)
+	service := NewNotificationService(store, store, store)
+	start := now()
+	service.now = func() time.Time { return start }
+
+	bob := 2
+	task, _ := tasks.CreateTask(models.CreateTaskRequest{Title: "Report", AssigneeID: &bob}, 1)
+	done, _ := tasks.CreateTask(models.CreateTaskRequest{Title: "Finished", Status: models.StatusDone}, 1)
+	for _, id := range []int{task.ID, done.ID} {
+		due := start.Add(2 * time.Hour)
+		_, _, _ = tasks.UpdateTask(strconv.Itoa(id), models.UpdateTaskRequest{DueDate: &due}, 1)
+	}
+
+	postpone := func(by time.Duration) {
+		due := start.Add(by)
+		_, _, _ = tasks.UpdateTask(strconv.Itoa(task.ID), models.UpdateTaskRequest{DueDate: &due}, 1)
+	}
+
+	tests := []struct {
+		name  string
+		run   func()
+		later time.Duration
+		want  int
+	}{
+		{"Creator and assignee are reminded", func() {}, 0, 2},
+		{"Reminders are sent once", func() {}, 0, 0},
+		{"Due date outside the window", func() { postpone(30 * time.Hour) }, 0, 0},
+		{"New due date comes within the window", func() {}, 10 * time.Hour, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run()
+			service.now = func() time.Time { return start.Add(tt.later) }
+			sent, err := service.NotifyDueSoon(24 * time.Hour)
+			if err != nil || sent != tt.want {
+				t.Errorf("NotifyDueSoon() = %d, %v, want %d", sent, err, tt.want)
+			}
+		})
+	}
+}
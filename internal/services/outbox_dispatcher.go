@@ -0,0 +1,152 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+)
+
+// OutboxSubscriber handles the domain events published from the outbox. An event a
+// subscriber fails is published to it again, but not to the subscribers that handled
+// it. Delivery is still at least once, so subscribers must tolerate seeing an event
+// more than once; its ID stays the same.
+type OutboxSubscriber func(ctx context.Context, event models.DomainEvent) error
+
+const (
+	// dispatchBatch is the number of events a dispatcher claims at a time
+	dispatchBatch = 100
+	// claimLease is how long other dispatchers skip claimed events. It covers a whole
+	// batch; events of a dispatcher that died are claimed again after it.
+	claimLease = 5 * time.Minute
+	// pruneInterval is how often published events past their retention are deleted
+	pruneInterval = time.Hour
+)
+
+type outboxSubscription struct {
+	name    string
+	handler OutboxSubscriber
+}
+
+// OutboxDispatcher publishes the events task and comment mutations write to the
+// outbox to its subscribers, at least once and in the order they were recorded
+// unless an event has to be retried
+type OutboxDispatcher struct {
+	repo        OutboxRepository
+	subscribers []outboxSubscription
+	// baseDelay is the wait before the first retry of a failed event; it doubles for
+	// each further retry up to maxDelay
+	baseDelay time.Duration
+	maxDelay  time.Duration
+}
+
+func NewOutboxDispatcher(repo OutboxRepository) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		repo:      repo,
+		baseDelay: 5 * time.Second,
+		maxDelay:  10 * time.Minute,
+	}
+}
+
+// Subscribe registers a subscriber for every event under a name that is unique and
+// stays the same across restarts, as the outbox records which subscribers handled an
+// event by name. Subscribers must be registered before the dispatcher runs.
+func (d *OutboxDispatcher) Subscribe(name string, handler OutboxSubscriber) {
+	d.subscribers = append(d.subscribers, outboxSubscription{name: name, handler: handler})
+}
+
+// Run publishes pending events every interval until ctx is done. Every hour it
+// deletes the events published more than retention ago; a retention of 0 keeps them.
+func (d *OutboxDispatcher) Run(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	prune := time.NewTicker(pruneInterval)
+	defer prune.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-prune.C:
+			if retention > 0 {
+				if _, err := d.repo.PruneEvents(retention); err != nil {
+					log.Printf("outbox: pruning published events: %v", err)
+				}
+			}
+			continue
+		case <-ticker.C:
+		}
+
+		if _, err := d.DispatchPending(ctx); err != nil {
+			log.Printf("outbox: dispatching events: %v", err)
+		}
+	}
+}
+
+// DispatchPending publishes the events that are due and returns how many it handled,
+// including the ones that failed and will be retried. Events are claimed and marked
+// in statements of their own, so subscribers run outside any transaction.
+func (d *OutboxDispatcher) DispatchPending(ctx context.Context) (int, error) {
+	dispatched := 0
+	for {
+		if ctx.Err() != nil {
+			return dispatched, ctx.Err()
+		}
+
+		events, err := d.repo.ClaimEvents(dispatchBatch, claimLease)
+		if err != nil {
+			return dispatched, err
+		}
+
+		for _, claimed := range events {
+			handled, err := d.dispatch(ctx, claimed.Event, claimed.Handled)
+			if err != nil {
+				err = d.repo.MarkFailed(claimed.Event.ID, handled, err.Error(), d.retryDelay(claimed.Attempts+1))
+			} else {
+				err = d.repo.MarkPublished(claimed.Event.ID, handled)
+			}
+			if err != nil {
+				return dispatched, err
+			}
+			dispatched++
+		}
+
+		if len(events) < dispatchBatch {
+			return dispatched, nil
+		}
+	}
+}
+
+// dispatch passes an event to every subscriber that has not handled it yet. It
+// returns the subscribers that handled it and fails when any of the others failed.
+func (d *OutboxDispatcher) dispatch(ctx context.Context, event models.DomainEvent, handled []string) ([]string, error) {
+	handled = slices.Clone(handled)
+	var errs []error
+	for _, subscriber := range d.subscribers {
+		if slices.Contains(handled, subscriber.name) {
+			continue
+		}
+		if err := subscriber.handler(ctx, event); err != nil {
+			log.Printf("outbox: %s failed event %d (%s): %v", subscriber.name, event.ID, event.Type, err)
+			errs = append(errs, fmt.Errorf("%s: %w", subscriber.name, err))
+			continue
+		}
+		handled = append(handled, subscriber.name)
+	}
+	return handled, errors.Join(errs...)
+}
+
+// retryDelay returns the wait after the given number of failed attempts
+func (d *OutboxDispatcher) retryDelay(attempts int) time.Duration {
+	delay := d.baseDelay
+	for i := 1; i < attempts && delay < d.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > d.maxDelay {
+		delay = d.maxDelay
+	}
+	return delay
+}
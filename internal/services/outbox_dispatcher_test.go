@@ -0,0 +1,149 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestOutboxRecordsChanges(t *testing.T) {
+	store := newTaskStore(t)
+	tasks := NewTaskService(store, store)
+	archive := NewTaskArchiveService(store)
+	comments := NewCommentService(store)
+
+	task, _ := tasks.CreateTask(models.CreateTaskRequest{Title: "Ship outbox"}, 1)
+	id := strconv.Itoa(task.ID)
+	title := "Ship the outbox"
+	_, _, _ = tasks.UpdateTask(id, models.UpdateTaskRequest{Title: &title}, 1)
+	comment, _ := comments.CreateComment(id, models.CreateCommentRequest{Content: "On it"}, 2)
+	_, _ = comments.DeleteComment(strconv.Itoa(comment.ID), 2)
+	_, _, _ = archive.ArchiveTask(id, 1)
+
+	// Rejected changes record nothing
+	_, _, _ = tasks.UpdateTask(id, models.UpdateTaskRequest{Title: &title}, 2)
+	_, _ = tasks.CreateTask(models.CreateTaskRequest{}, 1)
+	_, _ = comments.CreateComment("99", models.CreateCommentRequest{Content: "Lost"}, 1)
+
+	_, _ = tasks.DeleteTask(id, 1)
+
+	events := store.OutboxEvents()
+	var types []models.EventType
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	want := []models.EventType{
+		models.EventTaskCreated,
+		models.EventTaskUpdated,
+		models.EventCommentCreated,
+		models.EventCommentDeleted,
+		models.EventTaskArchived,
+		models.EventTaskDeleted,
+	}
+	if !reflect.DeepEqual(types, want) {
+		t.Fatalf("event types = %v, want %v", types, want)
+	}
+
+	var updated models.TaskEventPayload
+	if err := json.Unmarshal(events[1].Payload, &updated); err != nil ||
+		updated.Task.Title != title || len(updated.Changes) != 1 || events[1].ActorID != 1 {
+		t.Errorf("task.updated = %+v, payload %s, want the updated task and its changes", events[1], events[1].Payload)
+	}
+
+	var deleted models.CommentEventPayload
+	if err := json.Unmarshal(events[3].Payload, &deleted); err != nil ||
+		deleted.Comment.Content != "On it" || events[3].CommentID == nil || *events[3].CommentID != comment.ID {
+		t.Errorf("comment.deleted = %+v, payload %s, want the comment as it was", events[3], events[3].Payload)
+	}
+}
+
+func TestOutboxDispatch(t *testing.T) {
+	store := newTaskStore(t)
+	tasks := NewTaskService(store, store)
+	dispatcher := NewOutboxDispatcher(store)
+	dispatcher.baseDelay = 0
+
+	var received, failed []int64
+	failing := true
+	dispatcher.Subscribe("recorder", func(ctx context.Context, event models.DomainEvent) error {
+		received = append(received, event.ID)
+		return nil
+	})
+	dispatcher.Subscribe("flaky", func(ctx context.Context, event models.DomainEvent) error {
+		if failing && event.Type == models.EventTaskCreated {
+			failed = append(failed, event.ID)
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+
+	task, _ := tasks.CreateTask(models.CreateTaskRequest{Title: "First"}, 1)
+	status := models.StatusInProgress
+	_, _, _ = tasks.UpdateTask(strconv.Itoa(task.ID), models.UpdateTaskRequest{Status: &status}, 1)
+
+	steps := []struct {
+		name         string
+		recover      bool
+		wantHandled  int
+		wantReceived []int64
+	}{
+		{"Both events are published and the failed one is kept", false, 2, []int64{1, 2}},
+		{"The failed event is only retried for the failed subscriber", false, 1, []int64{1, 2}},
+		{"Once the subscriber recovers the event is published", true, 1, []int64{1, 2}},
+		{"Published events are not dispatched again", false, 0, []int64{1, 2}},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			if step.recover {
+				failing = false
+			}
+			handled, err := dispatcher.DispatchPending(context.Background())
+			if err != nil || handled != step.wantHandled {
+				t.Errorf("DispatchPending() = %d, %v, want %d", handled, err, step.wantHandled)
+			}
+			if !reflect.DeepEqual(received, step.wantReceived) {
+				t.Errorf("received = %v, want %v", received, step.wantReceived)
+			}
+		})
+	}
+
+	if !reflect.DeepEqual(failed, []int64{1, 1}) {
+		t.Errorf("failed = %v, want the task.created event twice", failed)
+	}
+}
+
+func TestOutboxClaimAndPrune(t *testing.T) {
+	store := newTaskStore(t)
+	tasks := NewTaskService(store, store)
+	for _, title := range []string{"First", "Second"} {
+		_, _ = tasks.CreateTask(models.CreateTaskRequest{Title: title}, 1)
+	}
+
+	claimed, err := store.ClaimEvents(10, time.Minute)
+	if err != nil || len(claimed) != 2 {
+		t.Fatalf("ClaimEvents() = %+v, %v, want both events", claimed, err)
+	}
+	if again, _ := store.ClaimEvents(10, time.Minute); len(again) != 0 {
+		t.Errorf("ClaimEvents() during the lease = %+v, want none", again)
+	}
+
+	_ = store.MarkPublished(claimed[0].Event.ID, []string{"recorder"})
+	_ = store.MarkFailed(claimed[1].Event.ID, []string{"recorder"}, "unavailable", 0)
+	if retried, _ := store.ClaimEvents(10, time.Minute); len(retried) != 1 || retried[0].Attempts != 1 || !reflect.DeepEqual(retried[0].Handled, []string{"recorder"}) {
+		t.Errorf("ClaimEvents() after a failure = %+v, want the failed event with its progress", retried)
+	}
+
+	if pruned, _ := store.PruneEvents(time.Hour); pruned != 0 {
+		t.Errorf("PruneEvents(1h) = %d, want 0", pruned)
+	}
+	time.Sleep(time.Millisecond)
+	if pruned, _ := store.PruneEvents(time.Nanosecond); pruned != 1 || len(store.OutboxEvents()) != 1 {
+		t.Errorf("PruneEvents() = %d, %d left, want the published event pruned", pruned, len(store.OutboxEvents()))
+	}
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Change describes a task or comment mutation to the repository making it. The
+// repository records it in the same transaction as the mutation: a domain event in
+// the outbox and, when Action is set, a change log entry on the task.
+type Change struct {
+	Event   models.EventType
+	ActorID int
+	Action  string
+	Details string
+	// Changes describes the updated fields of a task.updated event
+	Changes []string
+	// Fields names the fields a task.updated event changed
+	Fields []string
+	// Watchers are users who start watching the task with the change
+	Watchers []int
+	// ReplaceMentions stores Mentions as the mentions of the comment, or of the task
+	// description for task events, in place of the ones it had
+	ReplaceMentions bool
+	Mentions        []models.Mention
+}
+
+// OutboxEvent is a claimed outbox event with the state of its publication
+type OutboxEvent struct {
+	Event models.DomainEvent
+	// Attempts is the number of times the event was dispatched before
+	Attempts int
+	// Handled names the subscribers that already handled the event
+	Handled []string
+}
+
+// OutboxRepository reads the domain events that task and comment repositories write
+// to the outbox. Each method is a short statement of its own, so no transaction or
+// row lock is held while subscribers handle an event.
+type OutboxRepository interface {
+	// ClaimEvents claims up to limit unpublished events that are due, oldest first.
+	// Other callers skip them until lease has passed, after which the events of a
+	// caller that died are claimed again.
+	ClaimEvents(limit int, lease time.Duration) ([]OutboxEvent, error)
+	// MarkPublished records that every subscriber handled an event
+	MarkPublished(eventID int64, handled []string) error
+	// MarkFailed records a failed attempt at an event and the subscribers that have
+	// handled it so far; the event is due again after retryDelay
+	MarkFailed(eventID int64, handled []string, lastError string, retryDelay time.Duration) error
+	// PruneEvents deletes the events published more than retention ago and returns
+	// how many
+	PruneEvents(retention time.Duration) (int64, error)
+}
+
+type PostgresOutboxRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresOutboxRepository(db *sql.DB) *PostgresOutboxRepository {
+	return &PostgresOutboxRepository{db: db}
+}
+
+func (r *PostgresOutboxRepository) ClaimEvents(limit int, lease time.Duration) ([]OutboxEvent, error) {
+	rows, err := r.db.Query(`
+		UPDATE outbox
+		SET next_attempt_at = CURRENT_TIMESTAMP + $2 * INTERVAL '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE published_at IS NULL AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY next_attempt_at, id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, event_type, task_id, comment_id, actor_id, payload, attempts, handled_by, created_at
+	`, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []OutboxEvent
+	for rows.Next() {
+		var claimed OutboxEvent
+		var commentID sql.NullInt64
+		var payload []byte
+		err := rows.Scan(
+			&claimed.Event.ID, &claimed.Event.Type, &claimed.Event.TaskID, &commentID, &claimed.Event.ActorID,
+			&payload, &claimed.Attempts, pq.Array(&claimed.Handled), &claimed.Event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		claimed.Event.CommentID = nullIntPtr(commentID)
+		claimed.Event.Payload = payload
+		events = append(events, claimed)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// RETURNING has no order; publish in the order the events were recorded
+	sort.Slice(events, func(i, j int) bool { return events[i].Event.ID < events[j].Event.ID })
+	return events, nil
+}
+
+func (r *PostgresOutboxRepository) MarkPublished(eventID int64, handled []string) error {
+	_, err := r.db.Exec(`
+		UPDATE outbox
+		SET attempts = attempts + 1, handled_by = $1, published_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`, pq.Array(handled), eventID)
+	return err
+}
+
+func (r *PostgresOutboxRepository) MarkFailed(eventID int64, handled []string, lastError string, retryDelay time.Duration) error {
+	_, err := r.db.Exec(`
+		UPDATE outbox
+		SET attempts = attempts + 1, last_error = $1, handled_by = $2,
+		    next_attempt_at = CURRENT_TIMESTAMP + $3 * INTERVAL '1 millisecond'
+		WHERE id = $4
+	`, lastError, pq.Array(handled), retryDelay.Milliseconds(), eventID)
+	return err
+}
+
+func (r *PostgresOutboxRepository) PruneEvents(retention time.Duration) (int64, error) {
+	result, err := r.db.Exec(`
+		DELETE FROM outbox WHERE published_at < CURRENT_TIMESTAMP - $1 * INTERVAL '1 millisecond'
+	`, retention.Milliseconds())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// recordChange writes the change log entry and outbox event of a change in the
+// mutation's transaction. payload is the task or comment the event carries.
+func recordChange(tx *sql.Tx, change Change, taskID int, commentID *int, payload interface{}) error {
+	if change.Action != "" {
+		err := insertChangeLog(tx, models.ChangeLog{
+			TaskID:  taskID,
+			UserID:  change.ActorID,
+			Action:  change.Action,
+			Details: change.Details,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO outbox (event_type, task_id, comment_id, actor_id, payload)
+		VALUES ($1, $2, $3, $4, $5)
+	`, change.Event, taskID, commentID, change.ActorID, encoded)
+	return err
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"database/sql"
+	"time"
+)
+
+// PasswordResetRepository stores password reset tokens and the requests for them
+type PasswordResetRepository interface {
+	// CountResetRequests returns how many resets were requested for the email and
+	// from the IP address since the given time
+	CountResetRequests(email, ip string, since time.Time) (byEmail, byIP int, err error)
+	// RecordResetRequest records a reset request and forgets the ones made before expired
+	RecordResetRequest(email, ip string, at, expired time.Time) error
+
+	// CreatePasswordReset stores the hash of a reset token
+	CreatePasswordReset(userID int, tokenHash string, expiresAt time.Time) error
+	// ResetPassword uses the unused, unexpired reset token with the hash to set the
+	// user's password hash and revoke their sessions. The user's other reset tokens
+	// are used up with it. Returns the user's ID, or ErrInvalidResetToken.
+	ResetPassword(tokenHash, passwordHash string, at time.Time) (int, error)
+}
+
+type PostgresPasswordResetRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresPasswordResetRepository(db *sql.DB) *PostgresPasswordResetRepository {
+	return &PostgresPasswordResetRepository{db: db}
+}
+
+func (r *PostgresPasswordResetRepository) CountResetRequests(email, ip string, since time.Time) (int, int, error) {
+	var byEmail, byIP int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FILTER (WHERE email = $1), COUNT(*) FILTER (WHERE ip = $2)
+		FROM password_reset_requests
+		WHERE (email = $1 OR ip = $2) AND created_at > $3
+	`, email, ip, since).Scan(&byEmail, &byIP)
+	return byEmail, byIP, err
+}
+
+func (r *PostgresPasswordResetRepository) RecordResetRequest(email, ip string, at, expired time.Time) error {
+	if _, err := r.db.Exec("DELETE FROM password_reset_requests WHERE created_at <= $1", expired); err != nil {
+		return err
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO password_reset_requests (email, ip, created_at) VALUES ($1, $2, $3)
+	`, email, ip, at)
+	return err
+}
+
+func (r *PostgresPasswordResetRepository) CreatePasswordReset(userID int, tokenHash string, expiresAt time.Time) error {
+	_, err := r.db.Exec(`
+		INSERT INTO password_resets (user_id, token_hash, expires_at) VALUES ($1, $2, $3)
+	`, userID, tokenHash, expiresAt)
+	return err
+}
+
+func (r *PostgresPasswordResetRepository) ResetPassword(tokenHash, passwordHash string, at time.Time) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRow(`
+		UPDATE password_resets SET used_at = $2
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+		RETURNING user_id
+	`, tokenHash, at).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, ErrInvalidResetToken
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE password_resets SET used_at = $2 WHERE user_id = $1 AND used_at IS NULL
+	`, userID, at); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE users
+		SET password_hash = $2, session_version = session_version + 1, updated_at = $3
+		WHERE id = $1
+	`, userID, passwordHash, at); err != nil {
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
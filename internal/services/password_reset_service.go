@@ -0,0 +1,170 @@
+package services
+
+import (
+	"candidate-backend/internal/mail"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordResetSettings configures password resets
+type PasswordResetSettings struct {
+	// URL is the page of the web app reset links open, with the token in the
+	// token query parameter
+	URL string
+	// TTL is how long a reset link works
+	TTL time.Duration
+	// EmailLimit and IPLimit are the resets that may be requested per email and per
+	// IP address within resetThrottleWindow
+	EmailLimit int
+	IPLimit    int
+}
+
+// resetThrottleWindow is the window reset requests are counted in
+const resetThrottleWindow = time.Hour
+
+type PasswordResetService struct {
+	repo     PasswordResetRepository
+	users    UserRepository
+	mailer   mail.Mailer
+	settings PasswordResetSettings
+	now      func() time.Time
+	// background runs the lookup of the user and the sending of the reset email, so
+	// a request for a registered email takes as long to answer as one for an
+	// unknown email
+	background func(func())
+}
+
+func NewPasswordResetService(repo PasswordResetRepository, users UserRepository, mailer mail.Mailer, settings PasswordResetSettings) *PasswordResetService {
+	return &PasswordResetService{
+		repo:       repo,
+		users:      users,
+		mailer:     mailer,
+		settings:   settings,
+		now:        now,
+		background: func(run func()) { go run() },
+	}
+}
+
+// passwordResetEmail fills the password reset template
+type passwordResetEmail struct {
+	Name      string
+	ResetURL  string
+	ExpiresIn string
+}
+
+// RequestPasswordReset emails a reset link to the user with the email, if there is
+// one. It answers the same way whether or not the email is registered, except
+// that it returns ErrTooManyResetRequests when the email or IP address asked too
+// often.
+func (s *PasswordResetService) RequestPasswordReset(email, ip string) error {
+	at := s.now()
+	since := at.Add(-resetThrottleWindow)
+
+	// Throttle variants of an email's case together
+	key := strings.ToLower(email)
+	byEmail, byIP, err := s.repo.CountResetRequests(key, ip, since)
+	if err != nil {
+		return err
+	}
+	if byEmail >= s.settings.EmailLimit || byIP >= s.settings.IPLimit {
+		return ErrTooManyResetRequests
+	}
+	if err := s.repo.RecordResetRequest(key, ip, at, since); err != nil {
+		return err
+	}
+
+	s.background(func() {
+		if err := s.sendResetLink(email, at); err != nil {
+			log.Printf("email: password reset: %v", err)
+		}
+	})
+	return nil
+}
+
+// sendResetLink emails a new reset link to the user with the email, if there is one
+func (s *PasswordResetService) sendResetLink(email string, at time.Time) error {
+	user, err := s.users.GetUserByEmail(email)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := newResetToken()
+	if err != nil {
+		return err
+	}
+	if err := s.repo.CreatePasswordReset(user.ID, hashResetToken(token), at.Add(s.settings.TTL)); err != nil {
+		return err
+	}
+
+	msg, err := mail.Render("password_reset", passwordResetEmail{
+		Name:      user.Name,
+		ResetURL:  s.settings.URL + "?" + url.Values{"token": {token}}.Encode(),
+		ExpiresIn: formatTTL(s.settings.TTL),
+	})
+	if err != nil {
+		return err
+	}
+	msg.To = user.Email
+
+	if err := s.mailer.Send(context.Background(), msg); err != nil {
+		return fmt.Errorf("user %d: %w", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password with a reset token and signs the user out of
+// every session. Returns ErrInvalidResetToken when the token is unknown, used or
+// expired.
+func (s *PasswordResetService) ResetPassword(token, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.repo.ResetPassword(hashResetToken(token), string(hashedPassword), s.now())
+	return err
+}
+
+// newResetToken returns 32 random bytes, base64url-encoded
+func newResetToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// hashResetToken returns the hex SHA-256 hash of a reset token, which is all that
+// is stored of it
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// formatTTL describes how long a link works, e.g. "1 hour" or "30 minutes"
+func formatTTL(ttl time.Duration) string {
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		if hours := int(ttl / time.Hour); hours != 1 {
+			return fmt.Sprintf("%d hours", hours)
+		}
+		return "1 hour"
+	}
+	if minutes := int(ttl / time.Minute); minutes != 1 {
+		return fmt.Sprintf("%d minutes", minutes)
+	}
+	return "1 minute"
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"candidate-backend/internal/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordReset(t *testing.T) {
+	store := newTaskStore(t)
+	mailer := mail.NewMemoryMailer()
+	service := NewPasswordResetService(store, store, mailer, PasswordResetSettings{
+		URL:        "http://app.test/reset-password",
+		TTL:        time.Hour,
+		EmailLimit: 3,
+		IPLimit:    3,
+	})
+	service.background = func(run func()) { run() }
+	clock := time.Now()
+	service.now = func() time.Time { return clock }
+
+	// request asks for a reset of alice's password and returns the token emailed
+	request := func() string {
+		t.Helper()
+		if err := service.RequestPasswordReset("alice@example.com", "192.0.2.1"); err != nil {
+			t.Fatalf("RequestPasswordReset() error = %v", err)
+		}
+		sent := mailer.Messages()
+		if len(sent) != 1 || sent[0].To != "alice@example.com" || !strings.Contains(sent[0].Text, "within 1 hour") {
+			t.Fatalf("sent %+v, want one reset email to alice", sent)
+		}
+		_, token, _ := strings.Cut(sent[0].Text, "?token=")
+		token, _, _ = strings.Cut(token, "\n")
+		return token
+	}
+
+	expired := request()
+	clock = clock.Add(2 * time.Hour)
+	superseded := request()
+	token := request()
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"Expired token", expired, ErrInvalidResetToken},
+		{"Unknown token", "not-a-token", ErrInvalidResetToken},
+		{"Reset", token, nil},
+		{"Token used twice", token, ErrInvalidResetToken},
+		{"Other token after a reset", superseded, ErrInvalidResetToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := service.ResetPassword(tt.token, "new-secret"); err != tt.wantErr {
+				t.Fatalf("ResetPassword() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	user, _ := store.GetUser(1)
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("new-secret")) != nil || user.SessionVersion != 1 {
+		t.Errorf("user = %+v, want the new password and session version 1", user)
+	}
+}
+
+func TestPasswordResetThrottle(t *testing.T) {
+	store := newTaskStore(t)
+	mailer := mail.NewMemoryMailer()
+	service := NewPasswordResetService(store, store, mailer, PasswordResetSettings{
+		URL:        "http://app.test/reset-password",
+		TTL:        time.Hour,
+		EmailLimit: 2,
+		IPLimit:    3,
+	})
+	service.background = func(run func()) { run() }
+	clock := time.Now()
+	service.now = func() time.Time { return clock }
+
+	tests := []struct {
+		name     string
+		email    string
+		ip       string
+		advance  time.Duration
+		wantErr  error
+		wantSent int
+	}{
+		{"First request", "alice@example.com", "192.0.2.1", 0, nil, 1},
+		{"Unknown email", "nobody@example.com", "192.0.2.1", 0, nil, 0},
+		{"Second request for the email", "ALICE@example.com", "198.51.100.1", 0, nil, 0},
+		{"Email limit", "alice@example.com", "198.51.100.1", 0, ErrTooManyResetRequests, 0},
+		{"Third request from the IP", "bob@example.com", "192.0.2.1", time.Minute, nil, 1},
+		{"IP limit reached", "bob@example.com", "192.0.2.1", 0, ErrTooManyResetRequests, 0},
+		{"Window passed", "alice@example.com", "192.0.2.1", time.Hour, nil, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock = clock.Add(tt.advance)
+			if err := service.RequestPasswordReset(tt.email, tt.ip); err != tt.wantErr {
+				t.Fatalf("RequestPasswordReset() error = %v, want %v", err, tt.wantErr)
+			}
+			if sent := mailer.Messages(); len(sent) != tt.wantSent {
+				t.Errorf("sent %d emails, want %d", len(sent), tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestFormatTTL(t *testing.T) {
+	tests := []struct {
+		ttl  time.Duration
+		want string
+	}{
+		{time.Hour, "1 hour"},
+		{48 * time.Hour, "48 hours"},
+		{90 * time.Minute, "90 minutes"},
+		{time.Minute, "1 minute"},
+	}
+
+	for _, tt := range tests {
+		if got := formatTTL(tt.ttl); got != tt.want {
+			t.Errorf("formatTTL(%v) = %q, want %q", tt.ttl, got, tt.want)
+		}
+	}
+}
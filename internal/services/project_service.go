@@ -0,0 +1,309 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/validators"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+type ProjectService struct {
+	db           *sql.DB
+	validator    *validators.ProjectValidator
+	customFields *CustomFieldService
+}
+
+func NewProjectService(db *sql.DB) *ProjectService {
+	return &ProjectService{
+		db:           db,
+		validator:    validators.NewProjectValidator(),
+		customFields: NewCustomFieldService(db),
+	}
+}
+
+// GetProjects retrieves all projects
+func (s *ProjectService) GetProjects() ([]models.Project, error) {
+	rows, err := s.db.Query(`
+		SELECT p.id, p.name, p.description, p.creator_id, u.name as creator_name,
+		       p.created_at, p.updated_at
+		FROM projects p
+		JOIN users u ON p.creator_id = u.id
+		ORDER BY p.name ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []models.Project
+	for rows.Next() {
+		var project models.Project
+		err := rows.Scan(
+			&project.ID, &project.Name, &project.Description, &project.CreatorID,
+			&project.CreatorName, &project.CreatedAt, &project.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+
+	if projects == nil {
+		projects = []models.Project{}
+	}
+
+	return projects, nil
+}
+
+// GetProject retrieves a single project with its custom field definitions
+func (s *ProjectService) GetProject(projectID string) (*models.Project, error) {
+	var project models.Project
+	err := s.db.QueryRow(`
+		SELECT p.id, p.name, p.description, p.creator_id, u.name as creator_name,
+		       p.created_at, p.updated_at
+		FROM projects p
+		JOIN users u ON p.creator_id = u.id
+		WHERE p.id = $1
+	`, projectID).Scan(
+		&project.ID, &project.Name, &project.Description, &project.CreatorID,
+		&project.CreatorName, &project.CreatedAt, &project.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrProjectNotFound
+		}
+		return nil, err
+	}
+
+	project.Fields, err = s.customFields.GetFields(project.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+// CreateProject creates a new project
+func (s *ProjectService) CreateProject(req models.CreateProjectRequest, userID int) (*models.Project, error) {
+	if err := s.validator.ValidateCreateProject(&req); err != nil {
+		return nil, err
+	}
+
+	var projectID int
+	err := s.db.QueryRow(`
+		INSERT INTO projects (name, description, creator_id)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, req.Name, req.Description, userID).Scan(&projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetProject(fmt.Sprint(projectID))
+}
+
+// UpdateProject updates a project
+func (s *ProjectService) UpdateProject(projectID string, req models.UpdateProjectRequest, userID int) (*models.Project, error) {
+	if err := s.validator.ValidateUpdateProject(&req); err != nil {
+		return nil, err
+	}
+
+	if err := s.CheckProjectOwnership(projectID, userID); err != nil {
+		return nil, err
+	}
+
+	_, err := s.db.Exec(`
+		UPDATE projects
+		SET name = COALESCE($1, name), description = COALESCE($2, description)
+		WHERE id = $3
+	`, req.Name, req.Description, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetProject(projectID)
+}
+
+// DeleteProject deletes a project; its tasks stay but lose their custom field values
+func (s *ProjectService) DeleteProject(projectID string, userID int) error {
+	if err := s.CheckProjectOwnership(projectID, userID); err != nil {
+		return err
+	}
+
+	_, err := s.db.Exec("DELETE FROM projects WHERE id = $1", projectID)
+	return err
+}
+
+// GetFields retrieves the custom field definitions of a project
+func (s *ProjectService) GetFields(projectID string) ([]models.CustomField, error) {
+	project, err := s.GetProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return project.Fields, nil
+}
+
+// CreateField adds a custom field definition to a project
+func (s *ProjectService) CreateField(projectID string, req models.CreateCustomFieldRequest, userID int) (*models.CustomField, error) {
+	if err := s.validator.ValidateCreateCustomField(&req); err != nil {
+		return nil, err
+	}
+
+	if err := s.CheckProjectOwnership(projectID, userID); err != nil {
+		return nil, err
+	}
+
+	options := req.Options
+	if options == nil {
+		options = []string{}
+	}
+	encoded, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+
+	field, err := scanCustomField(s.db.QueryRow(`
+		INSERT INTO project_custom_fields (project_id, key, name, field_type, options)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, project_id, key, name, field_type, options, created_at, updated_at
+	`, projectID, req.Key, req.Name, req.Type, string(encoded)))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, ErrFieldKeyExists
+		}
+		return nil, err
+	}
+
+	return field, nil
+}
+
+// UpdateField updates a custom field's name or select options. The type and key
+// cannot change, and options still used by a task cannot be removed.
+func (s *ProjectService) UpdateField(projectID, fieldID string, req models.UpdateCustomFieldRequest, userID int) (*models.CustomField, error) {
+	if err := s.CheckProjectOwnership(projectID, userID); err != nil {
+		return nil, err
+	}
+
+	field, err := s.getField(projectID, fieldID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validator.ValidateUpdateCustomField(field.Type, &req); err != nil {
+		return nil, err
+	}
+
+	name := field.Name
+	if req.Name != nil {
+		name = *req.Name
+	}
+
+	options := field.Options
+	if req.Options != nil {
+		removed := []string{}
+		for _, option := range field.Options {
+			if !containsString(req.Options, option) {
+				removed = append(removed, option)
+			}
+		}
+
+		if len(removed) > 0 {
+			var inUse bool
+			err := s.db.QueryRow(`
+				SELECT EXISTS(
+					SELECT 1 FROM task_custom_field_values
+					WHERE field_id = $1 AND (
+					      (jsonb_typeof(value) = 'string' AND value #>> '{}' = ANY($2))
+					   OR (jsonb_typeof(value) = 'array' AND value ?| $2)
+					)
+				)
+			`, field.ID, pq.Array(removed)).Scan(&inUse)
+			if err != nil {
+				return nil, err
+			}
+			if inUse {
+				return nil, ErrOptionsInUse
+			}
+		}
+
+		options = req.Options
+	}
+	if options == nil {
+		options = []string{}
+	}
+
+	encoded, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanCustomField(s.db.QueryRow(`
+		UPDATE project_custom_fields SET name = $1, options = $2
+		WHERE id = $3
+		RETURNING id, project_id, key, name, field_type, options, created_at, updated_at
+	`, name, string(encoded), field.ID))
+}
+
+// DeleteField removes a custom field definition and all its values
+func (s *ProjectService) DeleteField(projectID, fieldID string, userID int) error {
+	if err := s.CheckProjectOwnership(projectID, userID); err != nil {
+		return err
+	}
+
+	field, err := s.getField(projectID, fieldID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec("DELETE FROM project_custom_fields WHERE id = $1", field.ID)
+	return err
+}
+
+// CheckProjectOwnership checks if user owns the project
+func (s *ProjectService) CheckProjectOwnership(projectID string, userID int) error {
+	var creatorID int
+	err := s.db.QueryRow("SELECT creator_id FROM projects WHERE id = $1", projectID).Scan(&creatorID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrProjectNotFound
+		}
+		return err
+	}
+
+	if creatorID != userID {
+		return ErrNotProjectOwner
+	}
+
+	return nil
+}
+
+func (s *ProjectService) getField(projectID, fieldID string) (*models.CustomField, error) {
+	field, err := scanCustomField(s.db.QueryRow(`
+		SELECT id, project_id, key, name, field_type, options, created_at, updated_at
+		FROM project_custom_fields
+		WHERE id = $1 AND project_id = $2
+	`, fieldID, projectID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrFieldNotFound
+		}
+		return nil, err
+	}
+
+	return field, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// ReactionRepository stores reactions to tasks and comments. A user has at most one
+// reaction per emoji on each task or comment.
+type ReactionRepository interface {
+	// AddTaskReaction stores a user's reaction to a task; reacting again with the
+	// emoji changes nothing. It returns ErrTaskNotFound when no task has the ID.
+	AddTaskReaction(taskID, userID int, emoji string) error
+	// RemoveTaskReaction returns ErrTaskNotFound when no task has the ID
+	RemoveTaskReaction(taskID, userID int, emoji string) error
+	// AddCommentReaction stores a user's reaction to a comment; reacting again with
+	// the emoji changes nothing. It returns ErrCommentNotFound when no comment has
+	// the ID or the comment is deleted.
+	AddCommentReaction(commentID, userID int, emoji string) error
+	// RemoveCommentReaction returns ErrCommentNotFound when no comment has the ID or
+	// the comment is deleted
+	RemoveCommentReaction(commentID, userID int, emoji string) error
+	// TaskReactions counts the reactions per emoji on each of the tasks, with Reacted
+	// set when userID reacted. Emojis are ordered by their first use; tasks without
+	// reactions are left out.
+	TaskReactions(taskIDs []int, userID int) (map[int][]models.ReactionSummary, error)
+	// CommentReactions counts the reactions on comments like TaskReactions
+	CommentReactions(commentIDs []int, userID int) (map[int][]models.ReactionSummary, error)
+}
+
+type PostgresReactionRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresReactionRepository(db *sql.DB) *PostgresReactionRepository {
+	return &PostgresReactionRepository{db: db}
+}
+
+func (r *PostgresReactionRepository) AddTaskReaction(taskID, userID int, emoji string) error {
+	if err := r.taskExists(taskID); err != nil {
+		return err
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO reactions (task_id, user_id, emoji)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (task_id, user_id, emoji) WHERE task_id IS NOT NULL DO NOTHING
+	`, taskID, userID, emoji)
+	return err
+}
+
+func (r *PostgresReactionRepository) RemoveTaskReaction(taskID, userID int, emoji string) error {
+	if err := r.taskExists(taskID); err != nil {
+		return err
+	}
+
+	_, err := r.db.Exec(`
+		DELETE FROM reactions WHERE task_id = $1 AND user_id = $2 AND emoji = $3
+	`, taskID, userID, emoji)
+	return err
+}
+
+func (r *PostgresReactionRepository) AddCommentReaction(commentID, userID int, emoji string) error {
+	if err := r.commentExists(commentID); err != nil {
+		return err
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO reactions (comment_id, user_id, emoji)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (comment_id, user_id, emoji) WHERE comment_id IS NOT NULL DO NOTHING
+	`, commentID, userID, emoji)
+	return err
+}
+
+func (r *PostgresReactionRepository) RemoveCommentReaction(commentID, userID int, emoji string) error {
+	if err := r.commentExists(commentID); err != nil {
+		return err
+	}
+
+	_, err := r.db.Exec(`
+		DELETE FROM reactions WHERE comment_id = $1 AND user_id = $2 AND emoji = $3
+	`, commentID, userID, emoji)
+	return err
+}
+
+func (r *PostgresReactionRepository) TaskReactions(taskIDs []int, userID int) (map[int][]models.ReactionSummary, error) {
+	return r.summarize("task_id", taskIDs, userID)
+}
+
+func (r *PostgresReactionRepository) CommentReactions(commentIDs []int, userID int) (map[int][]models.ReactionSummary, error) {
+	return r.summarize("comment_id", commentIDs, userID)
+}
+
+// summarize counts reactions per emoji for the tasks or comments whose column
+// ("task_id" or "comment_id") is in ids
+func (r *PostgresReactionRepository) summarize(column string, ids []int, userID int) (map[int][]models.ReactionSummary, error) {
+	rows, err := r.db.Query(fmt.Sprintf(`
+		SELECT %[1]s, emoji, COUNT(*), BOOL_OR(user_id = $2)
+		FROM reactions
+		WHERE %[1]s = ANY($1)
+		GROUP BY %[1]s, emoji
+		ORDER BY %[1]s, MIN(created_at), MIN(id)
+	`, column), pq.Array(ids), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reactions := map[int][]models.ReactionSummary{}
+	for rows.Next() {
+		var id int
+		var summary models.ReactionSummary
+		if err := rows.Scan(&id, &summary.Emoji, &summary.Count, &summary.Reacted); err != nil {
+			return nil, err
+		}
+		reactions[id] = append(reactions[id], summary)
+	}
+
+	return reactions, rows.Err()
+}
+
+func (r *PostgresReactionRepository) taskExists(taskID int) error {
+	var id int
+	err := r.db.QueryRow("SELECT id FROM tasks WHERE id = $1", taskID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrTaskNotFound
+	}
+	return err
+}
+
+func (r *PostgresReactionRepository) commentExists(commentID int) error {
+	var id int
+	err := r.db.QueryRow("SELECT id FROM comments WHERE id = $1 AND deleted_at IS NULL", commentID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrCommentNotFound
+	}
+	return err
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"strconv"
+)
+
+type ReactionService struct {
+	repo ReactionRepository
+}
+
+func NewReactionService(repo ReactionRepository) *ReactionService {
+	return &ReactionService{repo: repo}
+}
+
+// AddTaskReaction reacts to a task. Reacting twice with the same emoji has no effect.
+func (s *ReactionService) AddTaskReaction(taskID string, emoji string, userID int) ([]models.ReactionSummary, error) {
+	id, err := parseTaskID(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.AddTaskReaction(id, userID, emoji); err != nil {
+		return nil, err
+	}
+
+	return s.getTaskReactions(id, userID)
+}
+
+// RemoveTaskReaction removes the user's reaction with an emoji from a task
+func (s *ReactionService) RemoveTaskReaction(taskID string, emoji string, userID int) ([]models.ReactionSummary, error) {
+	id, err := parseTaskID(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RemoveTaskReaction(id, userID, emoji); err != nil {
+		return nil, err
+	}
+
+	return s.getTaskReactions(id, userID)
+}
+
+// AddCommentReaction reacts to a comment. Reacting twice with the same emoji has no effect.
+func (s *ReactionService) AddCommentReaction(commentID string, emoji string, userID int) ([]models.ReactionSummary, error) {
+	id, err := parseCommentID(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.AddCommentReaction(id, userID, emoji); err != nil {
+		return nil, err
+	}
+
+	return s.getCommentReactions(id, userID)
+}
+
+// RemoveCommentReaction removes the user's reaction with an emoji from a comment
+func (s *ReactionService) RemoveCommentReaction(commentID string, emoji string, userID int) ([]models.ReactionSummary, error) {
+	id, err := parseCommentID(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RemoveCommentReaction(id, userID, emoji); err != nil {
+		return nil, err
+	}
+
+	return s.getCommentReactions(id, userID)
+}
+
+// AttachTaskReactions loads the reaction counts of a task as seen by userID
+func (s *ReactionService) AttachTaskReactions(task *models.Task, userID int) error {
+	reactions, err := s.getTaskReactions(task.ID, userID)
+	if err != nil {
+		return err
+	}
+
+	task.Reactions = reactions
+	return nil
+}
+
+// AttachCommentReactions loads the reaction counts of a list of comments, as seen
+// by userID, in a single query
+func (s *ReactionService) AttachCommentReactions(comments []models.Comment, userID int) error {
+	if len(comments) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(comments))
+	commentIDs := make([]int, 0, len(comments))
+	for i, comment := range comments {
+		index[comment.ID] = i
+		commentIDs = append(commentIDs, comment.ID)
+	}
+
+	reactions, err := s.repo.CommentReactions(commentIDs, userID)
+	if err != nil {
+		return err
+	}
+
+	for commentID, summaries := range reactions {
+		comments[index[commentID]].Reactions = summaries
+	}
+
+	return nil
+}
+
+func (s *ReactionService) getTaskReactions(taskID, userID int) ([]models.ReactionSummary, error) {
+	reactions, err := s.repo.TaskReactions([]int{taskID}, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return orEmptyReactions(reactions[taskID]), nil
+}
+
+func (s *ReactionService) getCommentReactions(commentID, userID int) ([]models.ReactionSummary, error) {
+	reactions, err := s.repo.CommentReactions([]int{commentID}, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return orEmptyReactions(reactions[commentID]), nil
+}
+
+// orEmptyReactions returns an empty list in place of nil, so responses list no
+// reactions as [] rather than null
+func orEmptyReactions(reactions []models.ReactionSummary) []models.ReactionSummary {
+	if reactions == nil {
+		return []models.ReactionSummary{}
+	}
+	return reactions
+}
+
+func parseCommentID(commentID string) (int, error) {
+	id, err := strconv.Atoi(commentID)
+	if err != nil {
+		return 0, ErrCommentNotFound
+	}
+	return id, nil
+}
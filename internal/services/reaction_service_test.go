@@ -0,0 +1,133 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestTaskReactions(t *testing.T) {
+	store := newCommentStore(2)
+	service := NewReactionService(store)
+
+	steps := []struct {
+		name   string
+		remove bool
+		emoji  string
+		userID int
+		want   []models.ReactionSummary
+	}{
+		{"First reaction", false, "👍", 1, []models.ReactionSummary{{Emoji: "👍", Count: 1, Reacted: true}}},
+		{"Another emoji comes after it", false, "🎉", 2, []models.ReactionSummary{
+			{Emoji: "👍", Count: 1, Reacted: false},
+			{Emoji: "🎉", Count: 1, Reacted: true},
+		}},
+		{"Same emoji by another user", false, "👍", 2, []models.ReactionSummary{
+			{Emoji: "👍", Count: 2, Reacted: true},
+			{Emoji: "🎉", Count: 1, Reacted: true},
+		}},
+		{"Reacting twice is ignored", false, "👍", 2, []models.ReactionSummary{
+			{Emoji: "👍", Count: 2, Reacted: true},
+			{Emoji: "🎉", Count: 1, Reacted: true},
+		}},
+		// The remaining 👍 was used after the 🎉
+		{"Removing the first use", true, "👍", 1, []models.ReactionSummary{
+			{Emoji: "🎉", Count: 1, Reacted: false},
+			{Emoji: "👍", Count: 1, Reacted: false},
+		}},
+		{"Removing a missing reaction", true, "❤️", 1, []models.ReactionSummary{
+			{Emoji: "🎉", Count: 1, Reacted: false},
+			{Emoji: "👍", Count: 1, Reacted: false},
+		}},
+		{"Removing the last reaction of an emoji", true, "🎉", 2, []models.ReactionSummary{
+			{Emoji: "👍", Count: 1, Reacted: true},
+		}},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			react := service.AddTaskReaction
+			if step.remove {
+				react = service.RemoveTaskReaction
+			}
+			got, err := react("1", step.emoji, step.userID)
+			if err != nil {
+				t.Fatalf("react error = %v", err)
+			}
+			if !reflect.DeepEqual(got, step.want) {
+				t.Errorf("reactions = %+v, want %+v", got, step.want)
+			}
+		})
+	}
+
+	// Reactions belong to their task
+	task := models.Task{ID: 2}
+	if err := service.AttachTaskReactions(&task, 1); err != nil {
+		t.Fatalf("AttachTaskReactions() error = %v", err)
+	}
+	if task.Reactions == nil || len(task.Reactions) != 0 {
+		t.Errorf("AttachTaskReactions() on another task = %+v, want none", task.Reactions)
+	}
+
+	for _, taskID := range []string{"3", "abc"} {
+		if _, err := service.AddTaskReaction(taskID, "👍", 1); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("AddTaskReaction(%s) error = %v, want ErrTaskNotFound", taskID, err)
+		}
+	}
+}
+
+func TestCommentReactions(t *testing.T) {
+	store := newCommentStore(1)
+	service := NewReactionService(store)
+	first := addComment(store, models.Comment{TaskID: 1, UserID: 1, Content: "first"}, 0)
+	second := addComment(store, models.Comment{TaskID: 1, UserID: 1, Content: "second"}, 1)
+	deleted := addComment(store, models.Comment{TaskID: 1, UserID: 1, Deleted: true}, 2)
+
+	reactions := []struct {
+		commentID int
+		emoji     string
+		userID    int
+	}{
+		{first, "🎉", 1},
+		{first, "👍", 2},
+		{first, "🎉", 2},
+		{first, "🎉", 2},
+		{second, "👍", 2},
+	}
+	for _, r := range reactions {
+		if _, err := service.AddCommentReaction(strconv.Itoa(r.commentID), r.emoji, r.userID); err != nil {
+			t.Fatalf("AddCommentReaction() error = %v", err)
+		}
+	}
+
+	comments := []models.Comment{{ID: second}, {ID: first}, {ID: deleted}}
+	if err := service.AttachCommentReactions(comments, 1); err != nil {
+		t.Fatalf("AttachCommentReactions() error = %v", err)
+	}
+	want := [][]models.ReactionSummary{
+		{{Emoji: "👍", Count: 1, Reacted: false}},
+		{{Emoji: "🎉", Count: 2, Reacted: true}, {Emoji: "👍", Count: 1, Reacted: false}},
+		nil,
+	}
+	for i := range comments {
+		if !reflect.DeepEqual(comments[i].Reactions, want[i]) {
+			t.Errorf("comment %d reactions = %+v, want %+v", comments[i].ID, comments[i].Reactions, want[i])
+		}
+	}
+
+	// Deleting a comment deletes its reactions
+	if err := store.DeleteComment(first, Change{}); err != nil {
+		t.Fatalf("DeleteComment() error = %v", err)
+	}
+	if reactions, err := store.CommentReactions([]int{first, second}, 1); err != nil || len(reactions) != 1 || len(reactions[second]) != 1 {
+		t.Errorf("CommentReactions() after delete = %+v, %v, want only the second comment's", reactions, err)
+	}
+
+	for _, commentID := range []string{strconv.Itoa(deleted), strconv.Itoa(first), "99", "abc"} {
+		if _, err := service.AddCommentReaction(commentID, "👍", 1); !errors.Is(err, ErrCommentNotFound) {
+			t.Errorf("AddCommentReaction(%s) error = %v, want ErrCommentNotFound", commentID, err)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// clientBuffer is the number of messages a WebSocket client may fall behind before the
+// hub disconnects it
+const clientBuffer = 64
+
+// RealtimeHub routes domain events to the WebSocket clients subscribed to their task
+// or project, and tracks who is viewing each task. Presence and typing indicators are
+// kept per API instance.
+type RealtimeHub struct {
+	mu        sync.Mutex
+	tasks     TaskRepository
+	clients   map[*RealtimeClient]struct{}
+	byTask    map[int]map[*RealtimeClient]struct{}
+	byProject map[int]map[*RealtimeClient]struct{}
+	// slow holds the clients whose buffer overflowed, removed before the lock is released
+	slow []*RealtimeClient
+}
+
+// RealtimeClient is a connected WebSocket client
+type RealtimeClient struct {
+	User models.RealtimeUser
+	// Send carries the messages for the client. It is closed when the client is
+	// disconnected, which happens when it falls too far behind.
+	Send <-chan models.RealtimeMessage
+
+	send chan models.RealtimeMessage
+	// comments is whether the client may receive comment events
+	comments bool
+	tasks    map[int]struct{}
+	projects map[int]struct{}
+	dropped  bool
+}
+
+func NewRealtimeHub(tasks TaskRepository) *RealtimeHub {
+	return &RealtimeHub{
+		tasks:     tasks,
+		clients:   map[*RealtimeClient]struct{}{},
+		byTask:    map[int]map[*RealtimeClient]struct{}{},
+		byProject: map[int]map[*RealtimeClient]struct{}{},
+	}
+}
+
+// Connect registers a client for a user. Clients that may not read comments get no
+// comment events.
+func (h *RealtimeHub) Connect(user models.RealtimeUser, comments bool) *RealtimeClient {
+	h.lock()
+	defer h.unlock()
+
+	send := make(chan models.RealtimeMessage, clientBuffer)
+	client := &RealtimeClient{
+		User:     user,
+		Send:     send,
+		send:     send,
+		comments: comments,
+		tasks:    map[int]struct{}{},
+		projects: map[int]struct{}{},
+	}
+	h.clients[client] = struct{}{}
+	return client
+}
+
+// Disconnect removes a client from its subscriptions and closes its Send channel
+func (h *RealtimeHub) Disconnect(client *RealtimeClient) {
+	h.lock()
+	defer h.unlock()
+
+	h.remove(client)
+}
+
+// Handle carries out a message from a client. Problems with the message are reported
+// to the client as error messages.
+func (h *RealtimeHub) Handle(client *RealtimeClient, req models.RealtimeRequest) {
+	var err error
+	switch req.Type {
+	case models.RealtimeSubscribe:
+		err = h.subscribe(client, req)
+	case models.RealtimeUnsubscribe:
+		err = h.unsubscribe(client, req)
+	case models.RealtimeTyping:
+		err = h.typing(client, req)
+	default:
+		err = errors.New("unknown message type")
+	}
+
+	if err != nil {
+		h.Reject(client, req, err.Error())
+	}
+}
+
+// Reject sends an error message about a request to the client
+func (h *RealtimeHub) Reject(client *RealtimeClient, req models.RealtimeRequest, reason string) {
+	h.lock()
+	defer h.unlock()
+
+	h.deliver(client, models.RealtimeMessage{
+		Type:      models.RealtimeError,
+		TaskID:    req.TaskID,
+		ProjectID: req.ProjectID,
+		Error:     reason,
+	})
+}
+
+// Publish sends a domain event to the clients subscribed to its task or the task's
+// project, leaving out comment events for clients that may not read comments. It is
+// an outbox subscriber, for API instances that share no database notifications;
+// otherwise Run feeds it from the event hub.
+func (h *RealtimeHub) Publish(ctx context.Context, event models.DomainEvent) error {
+	projectID := h.projectOf(event)
+
+	h.lock()
+	defer h.unlock()
+
+	recipients := map[*RealtimeClient]struct{}{}
+	for client := range h.byTask[event.TaskID] {
+		recipients[client] = struct{}{}
+	}
+	if projectID != nil {
+		for client := range h.byProject[*projectID] {
+			recipients[client] = struct{}{}
+		}
+	}
+
+	for client := range recipients {
+		if event.Type.IsComment() && !client.comments {
+			continue
+		}
+		h.deliver(client, models.RealtimeMessage{
+			Type:      models.RealtimeEvent,
+			TaskID:    &event.TaskID,
+			ProjectID: projectID,
+			Event:     &event,
+		})
+	}
+	return nil
+}
+
+// Run publishes the events of the event hub until ctx is done
+func (h *RealtimeHub) Run(ctx context.Context, events *EventHub) {
+	var lastEventID *int64
+	for {
+		// The event hub drops subscribers that fall behind; resume after the last event
+		sub, missed, _ := events.Subscribe(lastEventID)
+		for _, event := range missed {
+			_ = h.Publish(ctx, event)
+		}
+
+		for open := true; open; {
+			select {
+			case <-ctx.Done():
+				events.Unsubscribe(sub)
+				return
+			case event, ok := <-sub.Events:
+				if open = ok; ok {
+					_ = h.Publish(ctx, event)
+					lastEventID = &event.ID
+				}
+			}
+		}
+	}
+}
+
+func (h *RealtimeHub) subscribe(client *RealtimeClient, req models.RealtimeRequest) error {
+	if (req.TaskID == nil) == (req.ProjectID == nil) {
+		return errors.New("either task_id or project_id is required")
+	}
+
+	if req.TaskID != nil {
+		if _, err := h.tasks.GetTask(*req.TaskID); err == ErrTaskNotFound {
+			return errors.New("task not found")
+		} else if err != nil {
+			return errors.New("could not load the task")
+		}
+	} else {
+		if _, err := h.tasks.ProjectName(*req.ProjectID); err == ErrProjectNotFound {
+			return errors.New("project not found")
+		} else if err != nil {
+			return errors.New("could not load the project")
+		}
+	}
+
+	h.lock()
+	defer h.unlock()
+
+	if client.dropped {
+		return nil
+	}
+
+	if req.TaskID != nil {
+		join(h.byTask, *req.TaskID, client)
+		client.tasks[*req.TaskID] = struct{}{}
+	} else {
+		join(h.byProject, *req.ProjectID, client)
+		client.projects[*req.ProjectID] = struct{}{}
+	}
+
+	h.deliver(client, models.RealtimeMessage{
+		Type:      models.RealtimeSubscribed,
+		TaskID:    req.TaskID,
+		ProjectID: req.ProjectID,
+	})
+	if req.TaskID != nil {
+		h.presence(*req.TaskID)
+	}
+	return nil
+}
+
+func (h *RealtimeHub) unsubscribe(client *RealtimeClient, req models.RealtimeRequest) error {
+	if (req.TaskID == nil) == (req.ProjectID == nil) {
+		return errors.New("either task_id or project_id is required")
+	}
+
+	h.lock()
+	defer h.unlock()
+
+	if req.TaskID != nil {
+		leave(h.byTask, *req.TaskID, client)
+		delete(client.tasks, *req.TaskID)
+	} else {
+		leave(h.byProject, *req.ProjectID, client)
+		delete(client.projects, *req.ProjectID)
+	}
+
+	h.deliver(client, models.RealtimeMessage{
+		Type:      models.RealtimeUnsubscribed,
+		TaskID:    req.TaskID,
+		ProjectID: req.ProjectID,
+	})
+	if req.TaskID != nil {
+		h.presence(*req.TaskID)
+	}
+	return nil
+}
+
+// typing relays a typing indicator to the other viewers of a task
+func (h *RealtimeHub) typing(client *RealtimeClient, req models.RealtimeRequest) error {
+	if req.TaskID == nil {
+		return errors.New("task_id is required")
+	}
+
+	h.lock()
+	defer h.unlock()
+
+	if _, ok := client.tasks[*req.TaskID]; !ok {
+		return errors.New("subscribe to the task first")
+	}
+
+	user, typing := client.User, req.Typing
+	for viewer := range h.byTask[*req.TaskID] {
+		if viewer != client {
+			h.deliver(viewer, models.RealtimeMessage{
+				Type:   models.RealtimeTyping,
+				TaskID: req.TaskID,
+				User:   &user,
+				Typing: &typing,
+			})
+		}
+	}
+	return nil
+}
+
+// presence sends the users viewing a task to each of them
+func (h *RealtimeHub) presence(taskID int) {
+	seen := map[int]bool{}
+	var viewers []models.RealtimeUser
+	for client := range h.byTask[taskID] {
+		if !seen[client.User.ID] {
+			seen[client.User.ID] = true
+			viewers = append(viewers, client.User)
+		}
+	}
+	sort.Slice(viewers, func(i, j int) bool { return viewers[i].ID < viewers[j].ID })
+
+	for client := range h.byTask[taskID] {
+		h.deliver(client, models.RealtimeMessage{
+			Type:    models.RealtimePresence,
+			TaskID:  &taskID,
+			Viewers: viewers,
+		})
+	}
+}
+
+// deliver queues a message without blocking. A client whose buffer is full is
+// disconnected once the current operation is done.
+func (h *RealtimeHub) deliver(client *RealtimeClient, msg models.RealtimeMessage) {
+	if client.dropped {
+		return
+	}
+
+	select {
+	case client.send <- msg:
+	default:
+		client.dropped = true
+		h.slow = append(h.slow, client)
+	}
+}
+
+func (h *RealtimeHub) remove(client *RealtimeClient) {
+	if _, ok := h.clients[client]; !ok {
+		return
+	}
+
+	client.dropped = true
+	delete(h.clients, client)
+	for projectID := range client.projects {
+		leave(h.byProject, projectID, client)
+	}
+	for taskID := range client.tasks {
+		leave(h.byTask, taskID, client)
+		h.presence(taskID)
+	}
+	close(client.send)
+}
+
+func (h *RealtimeHub) lock() {
+	h.mu.Lock()
+}
+
+// unlock disconnects the clients that fell behind, then releases the lock
+func (h *RealtimeHub) unlock() {
+	for len(h.slow) > 0 {
+		client := h.slow[0]
+		h.slow = h.slow[1:]
+		h.remove(client)
+	}
+	h.mu.Unlock()
+}
+
+// projectOf returns the project of the task an event belongs to
+func (h *RealtimeHub) projectOf(event models.DomainEvent) *int {
+	var payload models.TaskEventPayload
+	if err := json.Unmarshal(event.Payload, &payload); err == nil && payload.Task.ID != 0 {
+		return payload.Task.ProjectID
+	}
+
+	task, err := h.tasks.GetTask(event.TaskID)
+	if err != nil {
+		return nil
+	}
+	return task.ProjectID
+}
+
+func join(subscribers map[int]map[*RealtimeClient]struct{}, id int, client *RealtimeClient) {
+	if subscribers[id] == nil {
+		subscribers[id] = map[*RealtimeClient]struct{}{}
+	}
+	subscribers[id][client] = struct{}{}
+}
+
+func leave(subscribers map[int]map[*RealtimeClient]struct{}, id int, client *RealtimeClient) {
+	delete(subscribers[id], client)
+	if len(subscribers[id]) == 0 {
+		delete(subscribers, id)
+	}
+}
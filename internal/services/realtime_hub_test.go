@@ -0,0 +1,162 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"context"
+	"reflect"
+	"testing"
+)
+
+// received drains the messages queued for a client and returns their types
+func received(client *RealtimeClient) []models.RealtimeType {
+	var types []models.RealtimeType
+	for {
+		select {
+		case msg, ok := <-client.Send:
+			if !ok {
+				return append(types, "closed")
+			}
+			types = append(types, msg.Type)
+		default:
+			return types
+		}
+	}
+}
+
+func TestRealtimeHub(t *testing.T) {
+	store := NewMemoryStore()
+	projectID := store.AddProject(models.Project{Name: "Launch"})
+	task, _ := store.CreateTask(models.Task{Title: "Live", ProjectID: &projectID}, nil, Change{})
+	other, _ := store.CreateTask(models.Task{Title: "Elsewhere"}, nil, Change{})
+
+	hub := NewRealtimeHub(store)
+	alice := hub.Connect(models.RealtimeUser{ID: 1, Name: "Alice"}, true)
+	bob := hub.Connect(models.RealtimeUser{ID: 2, Name: "Bob"}, true)
+	// Carol uses an access token without the comments scope
+	carol := hub.Connect(models.RealtimeUser{ID: 3, Name: "Carol"}, false)
+
+	hub.Handle(alice, models.RealtimeRequest{Type: models.RealtimeSubscribe, TaskID: &task.ID})
+	hub.Handle(bob, models.RealtimeRequest{Type: models.RealtimeSubscribe, TaskID: &task.ID})
+	hub.Handle(carol, models.RealtimeRequest{Type: models.RealtimeSubscribe, ProjectID: &projectID})
+
+	var presence models.RealtimeMessage
+	for msg := range bob.Send {
+		if msg.Type == models.RealtimePresence {
+			presence = msg
+			break
+		}
+	}
+	want := []models.RealtimeUser{{ID: 1, Name: "Alice"}, {ID: 2, Name: "Bob"}}
+	if !reflect.DeepEqual(presence.Viewers, want) {
+		t.Errorf("presence viewers = %v, want %v", presence.Viewers, want)
+	}
+	received(alice)
+	received(bob)
+	received(carol)
+
+	missing := 999
+	tests := []struct {
+		name  string
+		run   func()
+		alice []models.RealtimeType
+		bob   []models.RealtimeType
+		carol []models.RealtimeType
+	}{
+		{
+			name: "Typing is relayed to the other viewers",
+			run: func() {
+				hub.Handle(alice, models.RealtimeRequest{Type: models.RealtimeTyping, TaskID: &task.ID, Typing: true})
+			},
+			bob: []models.RealtimeType{models.RealtimeTyping},
+		},
+		{
+			name: "Typing without a subscription",
+			run: func() {
+				hub.Handle(carol, models.RealtimeRequest{Type: models.RealtimeTyping, TaskID: &task.ID, Typing: true})
+			},
+			carol: []models.RealtimeType{models.RealtimeError},
+		},
+		{
+			name: "Task events reach task and project subscribers",
+			run: func() {
+				_ = hub.Publish(context.Background(), models.DomainEvent{ID: 1, TaskID: task.ID, Payload: []byte(`{}`)})
+			},
+			alice: []models.RealtimeType{models.RealtimeEvent},
+			bob:   []models.RealtimeType{models.RealtimeEvent},
+			carol: []models.RealtimeType{models.RealtimeEvent},
+		},
+		{
+			name: "Comment events need the comments scope",
+			run: func() {
+				_ = hub.Publish(context.Background(), models.DomainEvent{ID: 3, Type: models.EventCommentCreated, TaskID: task.ID, Payload: []byte(`{}`)})
+			},
+			alice: []models.RealtimeType{models.RealtimeEvent},
+			bob:   []models.RealtimeType{models.RealtimeEvent},
+		},
+		{
+			name: "Events of other tasks are not sent",
+			run: func() {
+				_ = hub.Publish(context.Background(), models.DomainEvent{ID: 2, TaskID: other.ID, Payload: []byte(`{}`)})
+			},
+		},
+		{
+			name: "Subscribing to a missing task",
+			run: func() {
+				hub.Handle(alice, models.RealtimeRequest{Type: models.RealtimeSubscribe, TaskID: &missing})
+			},
+			alice: []models.RealtimeType{models.RealtimeError},
+		},
+		{
+			name: "Subscribing needs exactly one target",
+			run: func() {
+				hub.Handle(alice, models.RealtimeRequest{Type: models.RealtimeSubscribe, TaskID: &task.ID, ProjectID: &projectID})
+			},
+			alice: []models.RealtimeType{models.RealtimeError},
+		},
+		{
+			name:  "Disconnecting updates presence",
+			run:   func() { hub.Disconnect(bob) },
+			alice: []models.RealtimeType{models.RealtimePresence},
+			bob:   []models.RealtimeType{"closed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run()
+			for _, c := range []struct {
+				client *RealtimeClient
+				want   []models.RealtimeType
+			}{{alice, tt.alice}, {bob, tt.bob}, {carol, tt.carol}} {
+				if got := received(c.client); !reflect.DeepEqual(got, c.want) {
+					t.Errorf("%s received %v, want %v", c.client.User.Name, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRealtimeHubDropsSlowClients(t *testing.T) {
+	store := NewMemoryStore()
+	task, _ := store.CreateTask(models.Task{Title: "Busy"}, nil, Change{})
+
+	hub := NewRealtimeHub(store)
+	slow := hub.Connect(models.RealtimeUser{ID: 1, Name: "Slow"}, true)
+	hub.Handle(slow, models.RealtimeRequest{Type: models.RealtimeSubscribe, TaskID: &task.ID})
+
+	for id := int64(1); id <= clientBuffer; id++ {
+		_ = hub.Publish(context.Background(), models.DomainEvent{ID: id, TaskID: task.ID, Payload: []byte(`{}`)})
+	}
+
+	count := 0
+	for range slow.Send {
+		count++
+	}
+	if count != clientBuffer {
+		t.Errorf("slow client received %d messages before being dropped, want %d", count, clientBuffer)
+	}
+
+	// A dropped client gets nothing more, and disconnecting it again is harmless
+	_ = hub.Publish(context.Background(), models.DomainEvent{ID: 99, TaskID: task.ID, Payload: []byte(`{}`)})
+	hub.Disconnect(slow)
+}
@@ -0,0 +1,533 @@
+package services
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/validators"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const sprintSelect = `
+		SELECT s.id, s.name, s.goal, s.start_date, s.end_date, s.creator_id, u.name as creator_name,
+		       s.closed_at, s.created_at, s.updated_at,
+		       COUNT(t.id) as task_count,
+		       COALESCE(SUM(t.story_points), 0) as total_points,
+		       COALESCE(SUM(t.story_points) FILTER (WHERE t.status = 'Done'), 0) as done_points
+		FROM sprints s
+		JOIN users u ON s.creator_id = u.id
+		LEFT JOIN tasks t ON t.sprint_id = s.id AND t.archived = FALSE`
+
+type SprintService struct {
+	db           *sql.DB
+	validator    *validators.SprintValidator
+	customFields *CustomFieldService
+	// tasks records the changes to tasks a sprint close carries over
+	tasks *PostgresTaskRepository
+}
+
+func NewSprintService(db *sql.DB) *SprintService {
+	return &SprintService{
+		db:           db,
+		validator:    validators.NewSprintValidator(),
+		customFields: NewCustomFieldService(db),
+		tasks:        NewPostgresTaskRepository(db),
+	}
+}
+
+// BurndownTask is a task in a sprint's scope together with its status history
+type BurndownTask struct {
+	StoryPoints int
+	CurrentDone bool
+	CreatedAt   time.Time
+	Events      []StatusEvent
+}
+
+// StatusEvent is a status change recorded in the task status history
+type StatusEvent struct {
+	At     time.Time
+	Status models.TaskStatus
+}
+
+func scanSprint(row rowScanner) (*models.Sprint, error) {
+	var sprint models.Sprint
+	err := row.Scan(
+		&sprint.ID, &sprint.Name, &sprint.Goal, &sprint.StartDate, &sprint.EndDate,
+		&sprint.CreatorID, &sprint.CreatorName, &sprint.ClosedAt, &sprint.CreatedAt, &sprint.UpdatedAt,
+		&sprint.TaskCount, &sprint.TotalPoints, &sprint.DonePoints,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sprint.Closed = sprint.ClosedAt != nil
+	return &sprint, nil
+}
+
+// GetSprints retrieves sprints ordered by start date
+func (s *SprintService) GetSprints(includeClosed bool) ([]models.Sprint, error) {
+	query := sprintSelect
+	if !includeClosed {
+		query += " WHERE s.closed_at IS NULL"
+	}
+	query += " GROUP BY s.id, u.name ORDER BY s.start_date ASC, s.id ASC"
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sprints []models.Sprint
+	for rows.Next() {
+		sprint, err := scanSprint(rows)
+		if err != nil {
+			return nil, err
+		}
+		sprints = append(sprints, *sprint)
+	}
+
+	if sprints == nil {
+		sprints = []models.Sprint{}
+	}
+
+	return sprints, nil
+}
+
+// GetSprint retrieves a single sprint by ID
+func (s *SprintService) GetSprint(sprintID string) (*models.Sprint, error) {
+	sprint, err := scanSprint(s.db.QueryRow(sprintSelect+" WHERE s.id = $1 GROUP BY s.id, u.name", sprintID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSprintNotFound
+		}
+		return nil, err
+	}
+
+	return sprint, nil
+}
+
+// CreateSprint creates a new sprint
+func (s *SprintService) CreateSprint(req models.CreateSprintRequest, userID int) (*models.Sprint, error) {
+	if err := s.validator.ValidateCreateSprint(&req); err != nil {
+		return nil, err
+	}
+
+	var sprintID int
+	err := s.db.QueryRow(`
+		INSERT INTO sprints (name, goal, start_date, end_date, creator_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, req.Name, req.Goal, req.StartDate, req.EndDate, userID).Scan(&sprintID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetSprint(fmt.Sprint(sprintID))
+}
+
+// UpdateSprint updates an open sprint
+func (s *SprintService) UpdateSprint(sprintID string, req models.UpdateSprintRequest, userID int) (*models.Sprint, error) {
+	if err := s.validator.ValidateUpdateSprint(&req); err != nil {
+		return nil, err
+	}
+
+	sprint, err := s.GetSprint(sprintID)
+	if err != nil {
+		return nil, err
+	}
+
+	if sprint.CreatorID != userID {
+		return nil, ErrNotSprintOwner
+	}
+
+	if sprint.Closed {
+		return nil, ErrSprintClosed
+	}
+
+	name, goal := sprint.Name, sprint.Goal
+	startDate := sprint.StartDate.Format(validators.SprintDateLayout)
+	endDate := sprint.EndDate.Format(validators.SprintDateLayout)
+	if req.Name != nil {
+		name = *req.Name
+	}
+	if req.Goal != nil {
+		goal = *req.Goal
+	}
+	if req.StartDate != nil {
+		startDate = *req.StartDate
+	}
+	if req.EndDate != nil {
+		endDate = *req.EndDate
+	}
+
+	if err := s.validator.ValidateDates(startDate, endDate); err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Exec(`
+		UPDATE sprints SET name = $1, goal = $2, start_date = $3, end_date = $4
+		WHERE id = $5
+	`, name, goal, startDate, endDate, sprintID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetSprint(sprintID)
+}
+
+// DeleteSprint deletes a sprint, moving its tasks back to the backlog
+func (s *SprintService) DeleteSprint(sprintID string, userID int) (string, error) {
+	var creatorID int
+	var name string
+	err := s.db.QueryRow("SELECT creator_id, name FROM sprints WHERE id = $1", sprintID).Scan(&creatorID, &name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrSprintNotFound
+		}
+		return "", err
+	}
+
+	if creatorID != userID {
+		return "", ErrNotSprintOwner
+	}
+
+	if _, err := s.db.Exec("DELETE FROM sprints WHERE id = $1", sprintID); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// GetSprintTasks retrieves the non-archived tasks assigned to a sprint
+func (s *SprintService) GetSprintTasks(sprintID string) ([]models.Task, error) {
+	if _, err := s.GetSprint(sprintID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(taskSelect+"WHERE t.sprint_id = $1 AND t.archived = FALSE ORDER BY t.created_at ASC", sprintID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []models.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if tasks == nil {
+		tasks = []models.Task{}
+	}
+
+	if err := s.customFields.AttachValues(tasks); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// CloseSprint closes a sprint and carries its unfinished tasks into the next sprint.
+// When no next sprint is given, the earliest open sprint starting after this one is
+// used; if there is none, unfinished tasks go back to the backlog. Each carried
+// task gets a change log entry and a task.updated event, as if moved by the user.
+func (s *SprintService) CloseSprint(sprintID string, req models.CloseSprintRequest, userID int) (*models.CloseSprintResponse, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id, creatorID int
+	var startDate time.Time
+	var closedAt *time.Time
+	err = tx.QueryRow(`
+		SELECT id, creator_id, start_date, closed_at FROM sprints WHERE id = $1 FOR UPDATE
+	`, sprintID).Scan(&id, &creatorID, &startDate, &closedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSprintNotFound
+		}
+		return nil, err
+	}
+
+	if creatorID != userID {
+		return nil, ErrNotSprintOwner
+	}
+
+	if closedAt != nil {
+		return nil, ErrSprintAlreadyClosed
+	}
+
+	var nextSprintID *int
+	var nextSprintName string
+	if req.NextSprintID != nil {
+		if *req.NextSprintID == id {
+			return nil, apperrors.Invalid("next_sprint_id", "a sprint cannot carry tasks into itself")
+		}
+
+		var nextClosedAt *time.Time
+		err = tx.QueryRow("SELECT name, closed_at FROM sprints WHERE id = $1", *req.NextSprintID).Scan(&nextSprintName, &nextClosedAt)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, apperrors.Invalid("next_sprint_id", "next sprint not found")
+			}
+			return nil, err
+		}
+
+		if nextClosedAt != nil {
+			return nil, apperrors.Invalid("next_sprint_id", "cannot carry tasks into a closed sprint")
+		}
+
+		nextSprintID = req.NextSprintID
+	} else {
+		var candidate int
+		err = tx.QueryRow(`
+			SELECT id, name FROM sprints
+			WHERE closed_at IS NULL AND id <> $1 AND start_date > $2
+			ORDER BY start_date ASC, id ASC
+			LIMIT 1
+		`, id, startDate).Scan(&candidate, &nextSprintName)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if err == nil {
+			nextSprintID = &candidate
+		}
+	}
+
+	rows, err := tx.Query(`
+		SELECT id, story_points FROM tasks
+		WHERE sprint_id = $1 AND status <> $2 AND archived = FALSE
+		ORDER BY id
+		FOR UPDATE
+	`, id, models.StatusDone)
+	if err != nil {
+		return nil, err
+	}
+
+	carriedIDs := []int{}
+	carriedPoints := []*int{}
+	for rows.Next() {
+		var taskID int
+		var points *int
+		if err := rows.Scan(&taskID, &points); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		carriedIDs = append(carriedIDs, taskID)
+		carriedPoints = append(carriedPoints, points)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, taskID := range carriedIDs {
+		_, err = tx.Exec(`
+			INSERT INTO sprint_carryovers (sprint_id, task_id, next_sprint_id, story_points)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (sprint_id, task_id) DO NOTHING
+		`, id, taskID, nextSprintID, carriedPoints[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	changes := []string{"removed from sprint"}
+	if nextSprintID != nil {
+		changes = []string{fmt.Sprintf("moved to sprint '%s'", nextSprintName)}
+	}
+	change := Change{
+		Event:   models.EventTaskUpdated,
+		ActorID: userID,
+		Action:  "updated",
+		Details: formatChangeDetails(changes),
+		Changes: changes,
+		Fields:  []string{"sprint_id"},
+	}
+	for _, taskID := range carriedIDs {
+		_, err = tx.Exec(`
+			UPDATE tasks SET sprint_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+		`, nextSprintID, taskID)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.tasks.recordTaskChange(tx, taskID, change); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE sprints SET closed_at = CURRENT_TIMESTAMP WHERE id = $1", id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	sprint, err := s.GetSprint(sprintID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.CloseSprintResponse{
+		Sprint:             *sprint,
+		CarriedOverTaskIDs: carriedIDs,
+	}
+	if nextSprintID != nil {
+		resp.NextSprint, err = s.GetSprint(fmt.Sprint(*nextSprintID))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// GetBurndown computes per-day burndown data for a sprint from the status history of its tasks
+func (s *SprintService) GetBurndown(sprintID string) (*models.Burndown, error) {
+	sprint, err := s.GetSprint(sprintID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Scope is every task still in the sprint plus tasks carried out of it on close
+	rows, err := s.db.Query(`
+		SELECT t.id, COALESCE(t.story_points, 0), t.status, t.created_at
+		FROM tasks t
+		WHERE t.archived = FALSE AND (
+		      t.sprint_id = $1
+		   OR t.id IN (SELECT task_id FROM sprint_carryovers WHERE sprint_id = $1)
+		)
+	`, sprint.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := map[int]*BurndownTask{}
+	taskIDs := []int{}
+	for rows.Next() {
+		var taskID int
+		var status models.TaskStatus
+		task := &BurndownTask{}
+		if err := rows.Scan(&taskID, &task.StoryPoints, &status, &task.CreatedAt); err != nil {
+			return nil, err
+		}
+		task.CurrentDone = status == models.StatusDone
+		tasks[taskID] = task
+		taskIDs = append(taskIDs, taskID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(taskIDs) > 0 {
+		changeRows, err := s.db.Query(`
+			SELECT task_id, status, changed_at
+			FROM task_status_changes
+			WHERE task_id = ANY($1)
+			ORDER BY changed_at ASC, id ASC
+		`, pq.Array(taskIDs))
+		if err != nil {
+			return nil, err
+		}
+		defer changeRows.Close()
+
+		for changeRows.Next() {
+			var taskID int
+			var event StatusEvent
+			if err := changeRows.Scan(&taskID, &event.Status, &event.At); err != nil {
+				return nil, err
+			}
+			tasks[taskID].Events = append(tasks[taskID].Events, event)
+		}
+		if err := changeRows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	scope := make([]BurndownTask, 0, len(taskIDs))
+	for _, taskID := range taskIDs {
+		scope = append(scope, *tasks[taskID])
+	}
+
+	return s.BuildBurndown(sprint, scope, time.Now()), nil
+}
+
+// BuildBurndown computes remaining points at the end of each sprint day up to now
+func (s *SprintService) BuildBurndown(sprint *models.Sprint, tasks []BurndownTask, now time.Time) *models.Burndown {
+	total := 0
+	for _, task := range tasks {
+		total += task.StoryPoints
+	}
+
+	burndown := &models.Burndown{
+		SprintID:    sprint.ID,
+		TotalPoints: total,
+		Points:      []models.BurndownPoint{},
+	}
+
+	start := time.Date(sprint.StartDate.Year(), sprint.StartDate.Month(), sprint.StartDate.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(sprint.EndDate.Year(), sprint.EndDate.Month(), sprint.EndDate.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(end.Sub(start).Hours()/24) + 1
+
+	for i := 0; i < days; i++ {
+		day := start.AddDate(0, 0, i)
+		cutoff := day.AddDate(0, 0, 1)
+
+		ideal := 0.0
+		if days > 1 {
+			ideal = float64(total) * (1 - float64(i)/float64(days-1))
+		}
+
+		point := models.BurndownPoint{
+			Date:        day.Format(validators.SprintDateLayout),
+			IdealPoints: ideal,
+		}
+
+		if !day.After(now) {
+			completed := 0
+			for _, task := range tasks {
+				if taskDoneBy(task, cutoff) {
+					completed += task.StoryPoints
+				}
+			}
+			remaining := total - completed
+			point.CompletedPoints = completed
+			point.RemainingPoints = &remaining
+		}
+
+		burndown.Points = append(burndown.Points, point)
+	}
+
+	return burndown
+}
+
+func taskDoneBy(task BurndownTask, cutoff time.Time) bool {
+	if len(task.Events) == 0 {
+		// No recorded status change: the task has had its current status since creation
+		return task.CurrentDone && task.CreatedAt.Before(cutoff)
+	}
+
+	done := false
+	for _, event := range task.Events {
+		if !event.At.Before(cutoff) {
+			break
+		}
+		done = event.Status == models.StatusDone
+	}
+
+	return done
+}
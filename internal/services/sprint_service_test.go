@@ -0,0 +1,49 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"testing"
+	"time"
+)
+
+func TestBuildBurndown(t *testing.T) {
+	service := &SprintService{}
+	day := func(d, h int) time.Time { return time.Date(2024, 1, d, h, 0, 0, 0, time.UTC) }
+
+	sprint := &models.Sprint{ID: 1, StartDate: day(1, 0), EndDate: day(4, 0)}
+	tasks := []BurndownTask{
+		// Done on day 2
+		{StoryPoints: 5, CreatedAt: day(1, 9), Events: []StatusEvent{{At: day(2, 15), Status: models.StatusDone}}},
+		// Done on day 1, reopened on day 3
+		{StoryPoints: 3, CreatedAt: day(1, 9), Events: []StatusEvent{
+			{At: day(1, 12), Status: models.StatusDone},
+			{At: day(3, 10), Status: models.StatusInProgress},
+		}},
+		// Created as done with no status changes
+		{StoryPoints: 2, CurrentDone: true, CreatedAt: day(2, 8)},
+	}
+
+	burndown := service.BuildBurndown(sprint, tasks, day(3, 18))
+
+	if burndown.TotalPoints != 10 {
+		t.Fatalf("TotalPoints = %d, want 10", burndown.TotalPoints)
+	}
+	if len(burndown.Points) != 4 {
+		t.Fatalf("len(Points) = %d, want 4", len(burndown.Points))
+	}
+
+	wantRemaining := []int{7, 0, 3}
+	for i, want := range wantRemaining {
+		got := burndown.Points[i].RemainingPoints
+		if got == nil || *got != want {
+			t.Errorf("day %d remaining = %v, want %d", i+1, got, want)
+		}
+	}
+
+	if burndown.Points[3].RemainingPoints != nil {
+		t.Errorf("future day remaining = %v, want nil", *burndown.Points[3].RemainingPoints)
+	}
+	if burndown.Points[0].IdealPoints != 10 || burndown.Points[3].IdealPoints != 0 {
+		t.Errorf("ideal line = %v..%v, want 10..0", burndown.Points[0].IdealPoints, burndown.Points[3].IdealPoints)
+	}
+}
@@ -2,121 +2,81 @@ package services
 
 import (
 	"candidate-backend/internal/models"
-	"database/sql"
 	"fmt"
 )
 
 type TaskArchiveService struct {
-	db *sql.DB
+	repo TaskRepository
 }
 
-func NewTaskArchiveService(db *sql.DB) *TaskArchiveService {
-	return &TaskArchiveService{db: db}
+func NewTaskArchiveService(repo TaskRepository) *TaskArchiveService {
+	return &TaskArchiveService{repo: repo}
 }
 
 // ArchiveTask archives a task
 func (s *TaskArchiveService) ArchiveTask(taskID string, userID int) (*models.Task, string, error) {
-	// Check ownership
-	var creatorID int
-	var title string
-	err := s.db.QueryRow("SELECT creator_id, title FROM tasks WHERE id = $1", taskID).Scan(&creatorID, &title)
+	return s.setArchived(taskID, userID, true)
+}
+
+// UnarchiveTask restores an archived task
+func (s *TaskArchiveService) UnarchiveTask(taskID string, userID int) (*models.Task, string, error) {
+	return s.setArchived(taskID, userID, false)
+}
+
+// GetArchivedTasks retrieves archived tasks with pagination, most recently changed first
+func (s *TaskArchiveService) GetArchivedTasks(limit, offset int) ([]models.Task, error) {
+	tasks, err := s.repo.ListTasks(TaskQuery{
+		Archived:   true,
+		Sort:       "updated_at",
+		Descending: true,
+		Limit:      limit,
+		Offset:     offset,
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, "", fmt.Errorf("task not found")
-		}
-		return nil, "", err
+		return nil, err
 	}
 
-	if creatorID != userID {
-		return nil, "", fmt.Errorf("you can only archive your own tasks")
+	if tasks == nil {
+		tasks = []models.Task{}
 	}
 
-	var task models.Task
-	err = s.db.QueryRow(`
-		UPDATE tasks
-		SET archived = TRUE, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1
-		RETURNING id, title, description, status, creator_id, due_date, archived, created_at, updated_at
-	`, taskID).Scan(
-		&task.ID, &task.Title, &task.Description, &task.Status,
-		&task.CreatorID, &task.DueDate, &task.Archived, &task.CreatedAt, &task.UpdatedAt,
-	)
+	return tasks, nil
+}
 
+// setArchived archives or restores a task the user created and returns it with its
+// title
+func (s *TaskArchiveService) setArchived(taskID string, userID int, archived bool) (*models.Task, string, error) {
+	id, err := parseTaskID(taskID)
 	if err != nil {
 		return nil, "", err
 	}
 
-	return &task, title, nil
-}
-
-// UnarchiveTask restores an archived task
-func (s *TaskArchiveService) UnarchiveTask(taskID string, userID int) (*models.Task, string, error) {
 	// Check ownership
-	var creatorID int
-	var title string
-	err := s.db.QueryRow("SELECT creator_id, title FROM tasks WHERE id = $1", taskID).Scan(&creatorID, &title)
+	task, err := s.repo.GetTask(id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, "", fmt.Errorf("task not found")
-		}
 		return nil, "", err
 	}
 
-	if creatorID != userID {
-		return nil, "", fmt.Errorf("you can only unarchive your own tasks")
+	if task.CreatorID != userID {
+		return nil, "", ErrNotTaskOwner
 	}
 
-	var task models.Task
-	err = s.db.QueryRow(`
-		UPDATE tasks
-		SET archived = FALSE, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1
-		RETURNING id, title, description, status, creator_id, due_date, archived, created_at, updated_at
-	`, taskID).Scan(
-		&task.ID, &task.Title, &task.Description, &task.Status,
-		&task.CreatorID, &task.DueDate, &task.Archived, &task.CreatedAt, &task.UpdatedAt,
-	)
-
-	if err != nil {
-		return nil, "", err
+	change := Change{
+		Event:   models.EventTaskArchived,
+		ActorID: userID,
+		Action:  "archived",
+		Details: fmt.Sprintf("Archived task: %s", task.Title),
 	}
-
-	return &task, title, nil
-}
-
-// GetArchivedTasks retrieves archived tasks with pagination
-func (s *TaskArchiveService) GetArchivedTasks(limit, offset int) ([]models.Task, error) {
-	rows, err := s.db.Query(`
-		SELECT t.id, t.title, t.description, t.status, t.creator_id,
-		       u.name as creator_name, t.due_date, t.archived, t.created_at, t.updated_at
-		FROM tasks t
-		JOIN users u ON t.creator_id = u.id
-		WHERE t.archived = TRUE
-		ORDER BY t.updated_at DESC
-		LIMIT $1 OFFSET $2
-	`, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var tasks []models.Task
-	for rows.Next() {
-		var task models.Task
-		err := rows.Scan(
-			&task.ID, &task.Title, &task.Description, &task.Status,
-			&task.CreatorID, &task.CreatorName, &task.DueDate, &task.Archived,
-			&task.CreatedAt, &task.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		tasks = append(tasks, task)
+	if !archived {
+		change.Event = models.EventTaskUnarchived
+		change.Action = "unarchived"
+		change.Details = fmt.Sprintf("Restored task: %s", task.Title)
 	}
 
-	if tasks == nil {
-		tasks = []models.Task{}
+	updated, err := s.repo.SetArchived(id, archived, change)
+	if err != nil {
+		return nil, "", err
 	}
 
-	return tasks, nil
+	return updated, task.Title, nil
 }
@@ -0,0 +1,528 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TaskRepository stores tasks. It only persists data; ownership, archiving and
+// validation rules live in TaskService and TaskArchiveService.
+type TaskRepository interface {
+	ListTasks(query TaskQuery) ([]models.Task, error)
+	// GetTask returns ErrTaskNotFound when no task has the ID
+	GetTask(taskID int) (*models.Task, error)
+	// CreateTask stores a new task for task.CreatorID together with its custom field values
+	CreateTask(task models.Task, values []FieldValue, change Change) (*models.Task, error)
+	// UpdateTask applies the set fields of req, where a SprintID or ProjectID of 0 clears
+	// it. Values on fields outside the task's resulting project are dropped. A change
+	// whose Fields name "status" adds the new status to the task's status history.
+	UpdateTask(taskID int, req models.UpdateTaskRequest, values []FieldValue, change Change) (*models.Task, error)
+	SetArchived(taskID int, archived bool, change Change) (*models.Task, error)
+	// DeleteTask removes a task with its change log, so the change cannot have an Action.
+	// The blobs of its attachments are deleted once the event is published.
+	DeleteTask(taskID int, change Change) error
+
+	// WatchTask makes a user watch a task; watching it again changes nothing. It returns
+	// ErrTaskNotFound when no task has the ID.
+	WatchTask(taskID, userID int) error
+	UnwatchTask(taskID, userID int) error
+	// ListWatchers returns the users watching a task, longest watching first
+	ListWatchers(taskID int) ([]models.Watcher, error)
+
+	// SprintState returns ErrSprintNotFound when no sprint has the ID
+	SprintState(sprintID int) (name string, closed bool, err error)
+	// ProjectName returns ErrProjectNotFound when no project has the ID
+	ProjectName(projectID int) (string, error)
+	ProjectFields(projectID int) ([]models.CustomField, error)
+}
+
+// TaskQuery selects a page of tasks. TaskService builds it from a validated
+// models.TaskFilter, so Sort is always a key of taskSortColumns.
+type TaskQuery struct {
+	Archived  bool
+	ProjectID *int
+	WatcherID *int
+	// Filters match tasks whose value equals Value, or contains it for multi-select fields
+	Filters    []FieldValue
+	Sort       string
+	SortField  *models.CustomField // sorts by a custom field instead of Sort
+	Descending bool
+	Limit      int
+	Offset     int
+}
+
+// FieldValue is a custom field value on a task; a nil Value clears the field
+type FieldValue struct {
+	Field models.CustomField
+	Value interface{}
+}
+
+var taskSortColumns = map[string]string{
+	"created_at":   "t.created_at",
+	"updated_at":   "t.updated_at",
+	"due_date":     "t.due_date",
+	"title":        "t.title",
+	"story_points": "t.story_points",
+}
+
+type PostgresTaskRepository struct {
+	db           *sql.DB
+	customFields *CustomFieldService
+}
+
+func NewPostgresTaskRepository(db *sql.DB) *PostgresTaskRepository {
+	return &PostgresTaskRepository{
+		db:           db,
+		customFields: NewCustomFieldService(db),
+	}
+}
+
+const taskSelect = `
+	SELECT t.id, t.title, t.description, t.status, t.creator_id,
+	       u.name as creator_name, t.assignee_id, t.due_date, t.archived, t.project_id, t.sprint_id, t.story_points,
+	       t.created_at, t.updated_at,
+	       (SELECT COALESCE(SUM(te.duration_seconds), 0) FROM time_entries te
+	        WHERE te.task_id = t.id AND te.ended_at IS NOT NULL) as total_time_seconds,
+	       (SELECT COUNT(*) FROM comments cm
+	        WHERE cm.task_id = t.id AND cm.deleted_at IS NULL) as comment_count,
+	       (SELECT COUNT(*) FROM task_watchers tw WHERE tw.task_id = t.id) as watcher_count
+	FROM tasks t
+	JOIN users u ON t.creator_id = u.id
+`
+
+const taskReturning = `
+	RETURNING id, title, description, status, creator_id, assignee_id, due_date, archived, project_id, sprint_id, story_points, created_at, updated_at`
+
+func scanTask(row rowScanner) (*models.Task, error) {
+	var task models.Task
+	err := row.Scan(
+		&task.ID, &task.Title, &task.Description, &task.Status,
+		&task.CreatorID, &task.CreatorName, &task.AssigneeID, &task.DueDate, &task.Archived,
+		&task.ProjectID, &task.SprintID, &task.StoryPoints,
+		&task.CreatedAt, &task.UpdatedAt, &task.TotalTimeSeconds, &task.CommentCount, &task.WatcherCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// scanReturnedTask scans the columns of taskReturning
+func scanReturnedTask(row rowScanner) (*models.Task, error) {
+	var task models.Task
+	err := row.Scan(
+		&task.ID, &task.Title, &task.Description, &task.Status,
+		&task.CreatorID, &task.AssigneeID, &task.DueDate, &task.Archived, &task.ProjectID, &task.SprintID, &task.StoryPoints,
+		&task.CreatedAt, &task.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *PostgresTaskRepository) ListTasks(query TaskQuery) ([]models.Task, error) {
+	conditions := []string{"t.archived = $1"}
+	args := []interface{}{query.Archived}
+	joins := ""
+
+	if query.ProjectID != nil {
+		args = append(args, *query.ProjectID)
+		conditions = append(conditions, fmt.Sprintf("t.project_id = $%d", len(args)))
+	}
+
+	if query.WatcherID != nil {
+		args = append(args, *query.WatcherID)
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM task_watchers w WHERE w.task_id = t.id AND w.user_id = $%d)", len(args)))
+	}
+
+	for _, filter := range query.Filters {
+		encoded, err := json.Marshal(filter.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		operator := "="
+		if filter.Field.Type == models.FieldTypeMultiSelect {
+			operator = "@>"
+		}
+
+		args = append(args, filter.Field.ID, string(encoded))
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM task_custom_field_values fv
+			WHERE fv.task_id = t.id AND fv.field_id = $%d AND fv.value %s $%d::jsonb)`,
+			len(args)-1, operator, len(args)))
+	}
+
+	direction := "ASC"
+	if query.Descending {
+		direction = "DESC"
+	}
+
+	var orderBy string
+	if query.SortField != nil {
+		expr := "sv.value #>> '{}'"
+		if query.SortField.Type == models.FieldTypeNumber || query.SortField.Type == models.FieldTypeUser {
+			expr = "(sv.value #>> '{}')::numeric"
+		}
+
+		args = append(args, query.SortField.ID)
+		joins = fmt.Sprintf("LEFT JOIN task_custom_field_values sv ON sv.task_id = t.id AND sv.field_id = $%d", len(args))
+		orderBy = fmt.Sprintf("%s %s NULLS LAST, t.created_at DESC", expr, direction)
+	} else {
+		orderBy = fmt.Sprintf("%s %s NULLS LAST, t.id DESC", taskSortColumns[query.Sort], direction)
+	}
+
+	args = append(args, query.Limit, query.Offset)
+	rows, err := r.db.Query(fmt.Sprintf(`%s
+		%s
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, taskSelect, joins, strings.Join(conditions, " AND "), orderBy, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []models.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.customFields.AttachValues(tasks); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (r *PostgresTaskRepository) GetTask(taskID int) (*models.Task, error) {
+	task, err := scanTask(r.db.QueryRow(taskSelect+"WHERE t.id = $1", taskID))
+	if err == sql.ErrNoRows {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return r.withValues(task)
+}
+
+func (r *PostgresTaskRepository) CreateTask(task models.Task, values []FieldValue, change Change) (*models.Task, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	created, err := scanReturnedTask(tx.QueryRow(`
+		INSERT INTO tasks (title, description, status, creator_id, assignee_id, due_date, project_id, sprint_id, story_points)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`+taskReturning,
+		task.Title, task.Description, task.Status, task.CreatorID, task.AssigneeID, task.DueDate,
+		task.ProjectID, task.SprintID, task.StoryPoints,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.customFields.SaveValues(tx, created.ID, values); err != nil {
+		return nil, err
+	}
+
+	return r.commitChange(tx, created.ID, change)
+}
+
+func (r *PostgresTaskRepository) UpdateTask(taskID int, req models.UpdateTaskRequest, values []FieldValue, change Change) (*models.Task, error) {
+	sets := []string{}
+	args := []interface{}{}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if req.Title != nil {
+		set("title", *req.Title)
+	}
+	if req.Description != nil {
+		set("description", *req.Description)
+	}
+	if req.Status != nil {
+		set("status", *req.Status)
+	}
+	if req.DueDate != nil {
+		set("due_date", req.DueDate)
+	}
+	if req.AssigneeID != nil {
+		set("assignee_id", nullableID(*req.AssigneeID))
+	}
+	if req.StoryPoints != nil {
+		set("story_points", *req.StoryPoints)
+	}
+	if req.SprintID != nil {
+		set("sprint_id", nullableID(*req.SprintID))
+	}
+	if req.ProjectID != nil {
+		set("project_id", nullableID(*req.ProjectID))
+	}
+
+	args = append(args, taskID)
+	sets = append(sets, "updated_at = CURRENT_TIMESTAMP")
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	task, err := scanReturnedTask(tx.QueryRow(fmt.Sprintf(
+		"UPDATE tasks SET %s WHERE id = $%d", strings.Join(sets, ", "), len(args),
+	)+taskReturning, args...))
+	if err == sql.ErrNoRows {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ProjectID != nil {
+		if err := r.customFields.ClearForeignValues(tx, task.ID, task.ProjectID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.customFields.SaveValues(tx, task.ID, values); err != nil {
+		return nil, err
+	}
+
+	return r.commitChange(tx, task.ID, change)
+}
+
+func (r *PostgresTaskRepository) SetArchived(taskID int, archived bool, change Change) (*models.Task, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE tasks
+		SET archived = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2`, archived, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	// commitChange reports ErrTaskNotFound when no task was updated
+	return r.commitChange(tx, taskID, change)
+}
+
+func (r *PostgresTaskRepository) DeleteTask(taskID int, change Change) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The event carries the task as it was
+	task, err := r.loadTask(tx, taskID)
+	if err == ErrTaskNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := queueBlobDeletions(tx, "task_id", taskID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM tasks WHERE id = $1", taskID); err != nil {
+		return err
+	}
+
+	if err := recordChange(tx, change, taskID, nil, models.TaskEventPayload{Task: *task}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresTaskRepository) WatchTask(taskID, userID int) error {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM tasks WHERE id = $1)", taskID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrTaskNotFound
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO task_watchers (task_id, user_id) VALUES ($1, $2)
+		ON CONFLICT (task_id, user_id) DO NOTHING
+	`, taskID, userID)
+	return err
+}
+
+func (r *PostgresTaskRepository) UnwatchTask(taskID, userID int) error {
+	_, err := r.db.Exec("DELETE FROM task_watchers WHERE task_id = $1 AND user_id = $2", taskID, userID)
+	return err
+}
+
+func (r *PostgresTaskRepository) ListWatchers(taskID int) ([]models.Watcher, error) {
+	rows, err := r.db.Query(`
+		SELECT w.user_id, u.name, w.created_at
+		FROM task_watchers w
+		JOIN users u ON w.user_id = u.id
+		WHERE w.task_id = $1
+		ORDER BY w.created_at, w.user_id
+	`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var watchers []models.Watcher
+	for rows.Next() {
+		var watcher models.Watcher
+		if err := rows.Scan(&watcher.UserID, &watcher.Name, &watcher.CreatedAt); err != nil {
+			return nil, err
+		}
+		watchers = append(watchers, watcher)
+	}
+
+	return watchers, rows.Err()
+}
+
+func (r *PostgresTaskRepository) SprintState(sprintID int) (string, bool, error) {
+	var name string
+	var closedAt *time.Time
+	err := r.db.QueryRow("SELECT name, closed_at FROM sprints WHERE id = $1", sprintID).Scan(&name, &closedAt)
+	if err == sql.ErrNoRows {
+		return "", false, ErrSprintNotFound
+	}
+	return name, closedAt != nil, err
+}
+
+func (r *PostgresTaskRepository) ProjectName(projectID int) (string, error) {
+	var name string
+	err := r.db.QueryRow("SELECT name FROM projects WHERE id = $1", projectID).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", ErrProjectNotFound
+	}
+	return name, err
+}
+
+func (r *PostgresTaskRepository) ProjectFields(projectID int) ([]models.CustomField, error) {
+	return r.customFields.GetFields(projectID)
+}
+
+// withValues attaches the custom field values of a single task
+func (r *PostgresTaskRepository) withValues(task *models.Task) (*models.Task, error) {
+	tasks := []models.Task{*task}
+	if err := r.customFields.AttachValues(tasks); err != nil {
+		return nil, err
+	}
+	return &tasks[0], nil
+}
+
+// loadTask reads a task with its custom field values inside tx
+func (r *PostgresTaskRepository) loadTask(tx *sql.Tx, taskID int) (*models.Task, error) {
+	task, err := scanTask(tx.QueryRow(taskSelect+"WHERE t.id = $1", taskID))
+	if err == sql.ErrNoRows {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []models.Task{*task}
+	if err := r.customFields.attachValues(tx, tasks); err != nil {
+		return nil, err
+	}
+	return &tasks[0], nil
+}
+
+// commitChange records the change to a task with recordTaskChange and commits tx,
+// returning the task
+func (r *PostgresTaskRepository) commitChange(tx *sql.Tx, taskID int, change Change) (*models.Task, error) {
+	task, err := r.recordTaskChange(tx, taskID, change)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// recordTaskChange records the change to a task with the task as it is now inside
+// tx, and stores the mentions of its description when the change replaces them. It
+// leaves tx open, so a mutation of several tasks records a change for each.
+func (r *PostgresTaskRepository) recordTaskChange(tx *sql.Tx, taskID int, change Change) (*models.Task, error) {
+	if err := addWatchers(tx, taskID, change.Watchers); err != nil {
+		return nil, err
+	}
+
+	task, err := r.loadTask(tx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Burndowns read status history from task_status_changes, not the change log
+	if slices.Contains(change.Fields, "status") {
+		_, err := tx.Exec("INSERT INTO task_status_changes (task_id, status) VALUES ($1, $2)", taskID, task.Status)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	payload := models.TaskEventPayload{Task: *task, Changes: change.Changes, Fields: change.Fields}
+	if change.ReplaceMentions {
+		if payload.Mentioned, err = replaceMentions(tx, taskID, nil, change.Mentions); err != nil {
+			return nil, err
+		}
+	}
+	if err := recordChange(tx, change, taskID, nil, payload); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// addWatchers makes users watch a task inside a mutation's transaction
+func addWatchers(tx *sql.Tx, taskID int, userIDs []int) error {
+	for _, userID := range userIDs {
+		_, err := tx.Exec(`
+			INSERT INTO task_watchers (task_id, user_id) VALUES ($1, $2)
+			ON CONFLICT (task_id, user_id) DO NOTHING
+		`, taskID, userID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nullableID stores 0 as NULL
+func nullableID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
@@ -1,56 +1,117 @@
 package services
 
 import (
+	"candidate-backend/internal/apperrors"
 	"candidate-backend/internal/models"
 	"candidate-backend/internal/validators"
-	"database/sql"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 type TaskService struct {
-	db        *sql.DB
+	repo      TaskRepository
+	users     UserRepository
+	mentions  MentionResolver
 	validator *validators.TaskValidator
 }
 
-func NewTaskService(db *sql.DB) *TaskService {
+func NewTaskService(repo TaskRepository, users UserRepository) *TaskService {
 	return &TaskService{
-		db:        db,
+		repo:      repo,
+		users:     users,
 		validator: validators.NewTaskValidator(),
 	}
 }
 
-// GetTasks retrieves non-archived tasks with pagination
-func (s *TaskService) GetTasks(limit, offset int) ([]models.Task, error) {
-	if err := s.validator.ValidatePagination(limit, offset); err != nil {
+// SetMentionResolver makes the service resolve the @mentions of task descriptions
+// with resolver and store them with the tasks
+func (s *TaskService) SetMentionResolver(resolver MentionResolver) {
+	s.mentions = resolver
+}
+
+// GetTasks retrieves non-archived tasks with pagination, optionally filtered and
+// sorted by project custom fields
+func (s *TaskService) GetTasks(filter models.TaskFilter) ([]models.Task, error) {
+	if err := s.validator.ValidatePagination(filter.Limit, filter.Offset); err != nil {
 		return nil, err
 	}
 
-	rows, err := s.db.Query(`
-		SELECT t.id, t.title, t.description, t.status, t.creator_id,
-		       u.name as creator_name, t.due_date, t.archived, t.created_at, t.updated_at
-		FROM tasks t
-		JOIN users u ON t.creator_id = u.id
-		WHERE t.archived = FALSE
-		ORDER BY t.created_at DESC
-		LIMIT $1 OFFSET $2
-	`, limit, offset)
-	if err != nil {
-		return nil, err
+	query := TaskQuery{
+		ProjectID:  filter.ProjectID,
+		WatcherID:  filter.WatcherID,
+		Sort:       "created_at",
+		Descending: true,
+		Limit:      filter.Limit,
+		Offset:     filter.Offset,
 	}
-	defer rows.Close()
 
-	var tasks []models.Task
-	for rows.Next() {
-		var task models.Task
-		err := rows.Scan(
-			&task.ID, &task.Title, &task.Description, &task.Status,
-			&task.CreatorID, &task.CreatorName, &task.DueDate, &task.Archived,
-			&task.CreatedAt, &task.UpdatedAt,
-		)
+	var fields map[string]models.CustomField
+	if filter.ProjectID != nil {
+		var err error
+		fields, err = s.fieldsByKey(*filter.ProjectID)
 		if err != nil {
 			return nil, err
 		}
-		tasks = append(tasks, task)
+	}
+
+	if len(filter.CustomFields) > 0 && filter.ProjectID == nil {
+		return nil, apperrors.Invalid("project_id", "project_id is required to filter by custom fields")
+	}
+
+	for key, raw := range filter.CustomFields {
+		field, ok := fields[key]
+		if !ok {
+			return nil, apperrors.Invalidf("custom_fields."+key, "unknown custom field '%s'", key)
+		}
+
+		value, err := s.customFieldFilterValue(field, raw)
+		if err != nil {
+			return nil, err
+		}
+
+		query.Filters = append(query.Filters, FieldValue{Field: field, Value: value})
+	}
+
+	if filter.Order != "" {
+		switch strings.ToLower(filter.Order) {
+		case "asc":
+			query.Descending = false
+		case "desc":
+			query.Descending = true
+		default:
+			return nil, apperrors.Invalid("order", "order must be 'asc' or 'desc'")
+		}
+	}
+
+	if filter.Sort == "" {
+		// Without a sort the newest tasks come first whatever the order
+		query.Descending = true
+	} else if key, ok := strings.CutPrefix(filter.Sort, "cf."); ok {
+		if filter.ProjectID == nil {
+			return nil, apperrors.Invalid("project_id", "project_id is required to sort by custom fields")
+		}
+
+		field, ok := fields[key]
+		if !ok {
+			return nil, apperrors.Invalidf("custom_fields."+key, "unknown custom field '%s'", key)
+		}
+		if field.Type == models.FieldTypeMultiSelect {
+			return nil, apperrors.Invalidf("sort", "cannot sort by multi-select field '%s'", key)
+		}
+
+		query.SortField = &field
+	} else {
+		if _, ok := taskSortColumns[filter.Sort]; !ok {
+			return nil, apperrors.Invalidf("sort", "cannot sort by '%s'", filter.Sort)
+		}
+		query.Sort = filter.Sort
+	}
+
+	tasks, err := s.repo.ListTasks(query)
+	if err != nil {
+		return nil, err
 	}
 
 	if tasks == nil {
@@ -62,24 +123,12 @@ func (s *TaskService) GetTasks(limit, offset int) ([]models.Task, error) {
 
 // GetTask retrieves a single task by ID
 func (s *TaskService) GetTask(taskID string) (*models.Task, error) {
-	var task models.Task
-	err := s.db.QueryRow(`
-		SELECT t.id, t.title, t.description, t.status, t.creator_id,
-		       u.name as creator_name, t.due_date, t.archived, t.created_at, t.updated_at
-		FROM tasks t
-		JOIN users u ON t.creator_id = u.id
-		WHERE t.id = $1
-	`, taskID).Scan(
-		&task.ID, &task.Title, &task.Description, &task.Status,
-		&task.CreatorID, &task.CreatorName, &task.DueDate, &task.Archived,
-		&task.CreatedAt, &task.UpdatedAt,
-	)
-
+	id, err := parseTaskID(taskID)
 	if err != nil {
 		return nil, err
 	}
 
-	return &task, nil
+	return s.repo.GetTask(id)
 }
 
 // CreateTask creates a new task
@@ -93,21 +142,57 @@ func (s *TaskService) CreateTask(req models.CreateTaskRequest, userID int) (*mod
 		req.Status = models.StatusToDo
 	}
 
-	var task models.Task
-	err := s.db.QueryRow(`
-		INSERT INTO tasks (title, description, status, creator_id, due_date)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, title, description, status, creator_id, due_date, archived, created_at, updated_at
-	`, req.Title, req.Description, req.Status, userID, req.DueDate).Scan(
-		&task.ID, &task.Title, &task.Description, &task.Status,
-		&task.CreatorID, &task.DueDate, &task.Archived, &task.CreatedAt, &task.UpdatedAt,
-	)
+	if req.SprintID != nil {
+		if _, err := s.checkSprintAssignable(*req.SprintID); err != nil {
+			return nil, err
+		}
+	}
 
+	if req.ProjectID != nil {
+		if _, err := s.checkProjectExists(*req.ProjectID); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.AssigneeID != nil {
+		if _, err := s.checkAssignee(*req.AssigneeID); err != nil {
+			return nil, err
+		}
+	}
+
+	values, err := s.prepareCustomFieldValues(req.ProjectID, req.CustomFields)
 	if err != nil {
 		return nil, err
 	}
 
-	return &task, nil
+	// The creator and the assignee watch the task from the start
+	watchers := []int{userID}
+	if req.AssigneeID != nil {
+		watchers = append(watchers, *req.AssigneeID)
+	}
+
+	change := Change{
+		Event:    models.EventTaskCreated,
+		ActorID:  userID,
+		Action:   "created",
+		Details:  fmt.Sprintf("Created task: %s", req.Title),
+		Watchers: watchers,
+	}
+	if err := resolveMentionsInto(&change, s.mentions, req.Description); err != nil {
+		return nil, err
+	}
+
+	return s.repo.CreateTask(models.Task{
+		Title:       req.Title,
+		Description: req.Description,
+		Status:      req.Status,
+		CreatorID:   userID,
+		AssigneeID:  req.AssigneeID,
+		DueDate:     req.DueDate,
+		ProjectID:   req.ProjectID,
+		SprintID:    req.SprintID,
+		StoryPoints: req.StoryPoints,
+	}, values, change)
 }
 
 // UpdateTask updates an existing task
@@ -117,98 +202,372 @@ func (s *TaskService) UpdateTask(taskID string, req models.UpdateTaskRequest, us
 	}
 
 	// Check ownership
-	if err := s.CheckTaskOwnership(taskID, userID); err != nil {
+	current, err := s.ownTask(taskID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Custom field values are validated against the project the task ends up in
+	projectID := current.ProjectID
+	if req.ProjectID != nil {
+		projectID = nil
+		if *req.ProjectID != 0 {
+			projectID = req.ProjectID
+		}
+	}
+
+	values, err := s.prepareCustomFieldValues(projectID, req.CustomFields)
+	if err != nil {
 		return nil, nil, err
 	}
 
-	// Build dynamic update query
-	query := "UPDATE tasks SET "
-	args := []interface{}{}
-	argCount := 1
 	changes := []string{}
 
 	if req.Title != nil {
-		query += fmt.Sprintf("title = $%d, ", argCount)
-		args = append(args, *req.Title)
-		argCount++
 		changes = append(changes, fmt.Sprintf("changed title to '%s'", *req.Title))
 	}
 	if req.Description != nil {
-		query += fmt.Sprintf("description = $%d, ", argCount)
-		args = append(args, *req.Description)
-		argCount++
 		changes = append(changes, "updated description")
 	}
 	if req.Status != nil {
-		query += fmt.Sprintf("status = $%d, ", argCount)
-		args = append(args, *req.Status)
-		argCount++
 		changes = append(changes, fmt.Sprintf("changed status to '%s'", *req.Status))
 	}
-	if req.DueDate != nil {
-		query += fmt.Sprintf("due_date = $%d, ", argCount)
-		args = append(args, req.DueDate)
-		argCount++
+	if req.AssigneeID != nil {
+		if *req.AssigneeID == 0 {
+			changes = append(changes, "unassigned")
+		} else {
+			assignee, err := s.checkAssignee(*req.AssigneeID)
+			if err != nil {
+				return nil, nil, err
+			}
+			changes = append(changes, fmt.Sprintf("assigned to %s", assignee))
+		}
 	}
-
-	if len(args) == 0 {
-		return nil, nil, fmt.Errorf("no fields to update")
+	if req.StoryPoints != nil {
+		changes = append(changes, fmt.Sprintf("set story points to %d", *req.StoryPoints))
+	}
+	if req.SprintID != nil {
+		if *req.SprintID == 0 {
+			changes = append(changes, "removed from sprint")
+		} else {
+			sprintName, err := s.checkSprintAssignable(*req.SprintID)
+			if err != nil {
+				return nil, nil, err
+			}
+			changes = append(changes, fmt.Sprintf("moved to sprint '%s'", sprintName))
+		}
+	}
+	if req.ProjectID != nil {
+		if projectID == nil {
+			changes = append(changes, "removed from project")
+		} else {
+			projectName, err := s.checkProjectExists(*projectID)
+			if err != nil {
+				return nil, nil, err
+			}
+			changes = append(changes, fmt.Sprintf("moved to project '%s'", projectName))
+		}
+	}
+	for _, value := range values {
+		changes = append(changes, fmt.Sprintf("updated %s", value.Field.Name))
 	}
 
-	query += fmt.Sprintf("updated_at = CURRENT_TIMESTAMP WHERE id = $%d", argCount)
-	args = append(args, taskID)
-
-	query += " RETURNING id, title, description, status, creator_id, due_date, archived, created_at, updated_at"
+	if req.Title == nil && req.Description == nil && req.Status == nil && req.DueDate == nil && req.AssigneeID == nil &&
+		req.StoryPoints == nil && req.SprintID == nil && req.ProjectID == nil && len(values) == 0 {
+		return nil, nil, apperrors.Invalid("body", "no fields to update")
+	}
 
-	var task models.Task
-	err := s.db.QueryRow(query, args...).Scan(
-		&task.ID, &task.Title, &task.Description, &task.Status,
-		&task.CreatorID, &task.DueDate, &task.Archived, &task.CreatedAt, &task.UpdatedAt,
-	)
+	change := Change{
+		Event:   models.EventTaskUpdated,
+		ActorID: userID,
+		Changes: changes,
+		Fields:  changedFields(current, req, values),
+	}
+	if len(changes) > 0 {
+		change.Action = "updated"
+		change.Details = formatChangeDetails(changes)
+	}
+	if req.AssigneeID != nil && *req.AssigneeID != 0 {
+		change.Watchers = []int{*req.AssigneeID}
+	}
+	if req.Description != nil {
+		if err := resolveMentionsInto(&change, s.mentions, *req.Description); err != nil {
+			return nil, nil, err
+		}
+	}
 
+	task, err := s.repo.UpdateTask(current.ID, req, values, change)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return &task, changes, nil
+	return task, changes, nil
 }
 
 // DeleteTask deletes a task
 func (s *TaskService) DeleteTask(taskID string, userID int) (string, error) {
 	// Check ownership and get title
-	var creatorID int
-	var title string
-	err := s.db.QueryRow("SELECT creator_id, title FROM tasks WHERE id = $1", taskID).Scan(&creatorID, &title)
+	task, err := s.ownTask(taskID, userID)
 	if err != nil {
 		return "", err
 	}
 
-	if creatorID != userID {
-		return "", fmt.Errorf("you can only delete your own tasks")
+	// The change log goes with the task, so only the event is recorded
+	if err := s.repo.DeleteTask(task.ID, Change{Event: models.EventTaskDeleted, ActorID: userID}); err != nil {
+		return "", err
 	}
 
-	_, err = s.db.Exec("DELETE FROM tasks WHERE id = $1", taskID)
+	return task.Title, nil
+}
+
+// GetWatchers retrieves the users watching a task
+func (s *TaskService) GetWatchers(taskID string) ([]models.Watcher, error) {
+	task, err := s.GetTask(taskID)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	watchers, err := s.repo.ListWatchers(task.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if watchers == nil {
+		watchers = []models.Watcher{}
+	}
+
+	return watchers, nil
+}
+
+// WatchTask makes a user watch a task and returns its watchers
+func (s *TaskService) WatchTask(taskID string, userID int) ([]models.Watcher, error) {
+	id, err := parseTaskID(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.WatchTask(id, userID); err != nil {
+		return nil, err
+	}
+
+	return s.GetWatchers(taskID)
+}
+
+// UnwatchTask stops a user watching a task and returns its watchers
+func (s *TaskService) UnwatchTask(taskID string, userID int) ([]models.Watcher, error) {
+	task, err := s.GetTask(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UnwatchTask(task.ID, userID); err != nil {
+		return nil, err
 	}
 
-	return title, nil
+	return s.GetWatchers(taskID)
 }
 
 // CheckTaskOwnership checks if user owns the task
 func (s *TaskService) CheckTaskOwnership(taskID string, userID int) error {
-	var creatorID int
-	err := s.db.QueryRow("SELECT creator_id FROM tasks WHERE id = $1", taskID).Scan(&creatorID)
+	_, err := s.ownTask(taskID, userID)
+	return err
+}
+
+// ownTask loads a task the user created
+func (s *TaskService) ownTask(taskID string, userID int) (*models.Task, error) {
+	task, err := s.GetTask(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.CreatorID != userID {
+		return nil, ErrNotTaskOwner
+	}
+
+	return task, nil
+}
+
+// checkSprintAssignable checks that a sprint exists and is still open
+func (s *TaskService) checkSprintAssignable(sprintID int) (string, error) {
+	name, closed, err := s.repo.SprintState(sprintID)
+	if err == ErrSprintNotFound {
+		return "", apperrors.Invalid("sprint_id", "sprint not found")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if closed {
+		return "", apperrors.Invalid("sprint_id", "cannot add tasks to a closed sprint")
+	}
+
+	return name, nil
+}
+
+// checkAssignee checks that the user a task is assigned to exists and returns their name
+func (s *TaskService) checkAssignee(userID int) (string, error) {
+	user, err := s.users.GetUser(userID)
+	if err == ErrUserNotFound {
+		return "", apperrors.Invalid("assignee_id", "user not found")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return user.Name, nil
+}
+
+// checkProjectExists checks that a project exists
+func (s *TaskService) checkProjectExists(projectID int) (string, error) {
+	name, err := s.repo.ProjectName(projectID)
+	if err == ErrProjectNotFound {
+		return "", apperrors.Invalid("project_id", "project not found")
+	}
+
+	return name, err
+}
+
+// fieldsByKey retrieves the custom field definitions of a project keyed by field key
+func (s *TaskService) fieldsByKey(projectID int) (map[string]models.CustomField, error) {
+	fields, err := s.repo.ProjectFields(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]models.CustomField, len(fields))
+	for _, field := range fields {
+		byKey[field.Key] = field
+	}
+
+	return byKey, nil
+}
+
+// prepareCustomFieldValues validates custom field values against the project's field
+// definitions and returns them ordered by field key
+func (s *TaskService) prepareCustomFieldValues(projectID *int, values map[string]interface{}) ([]FieldValue, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	if projectID == nil {
+		return nil, apperrors.Invalid("custom_fields", "custom fields require the task to belong to a project")
+	}
+
+	fields, err := s.fieldsByKey(*projectID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return fmt.Errorf("task not found")
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	prepared := make([]FieldValue, 0, len(keys))
+	userIDs := []int{}
+	for _, key := range keys {
+		field, ok := fields[key]
+		if !ok {
+			return nil, apperrors.Invalidf("custom_fields."+key, "unknown custom field '%s'", key)
+		}
+
+		value := values[key]
+		prepared = append(prepared, FieldValue{Field: field, Value: value})
+
+		// null clears the value
+		if value == nil {
+			continue
+		}
+
+		if err := s.validator.ValidateCustomFieldValue(field, value); err != nil {
+			return nil, err
+		}
+
+		if field.Type == models.FieldTypeUser {
+			userIDs = append(userIDs, int(value.(float64)))
+		}
+	}
+
+	exist, err := s.users.UsersExist(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, apperrors.Invalid("custom_fields", "custom field references a user that does not exist")
+	}
+
+	return prepared, nil
+}
+
+// customFieldFilterValue converts a raw query value into the value stored values are matched against
+func (s *TaskService) customFieldFilterValue(field models.CustomField, raw string) (interface{}, error) {
+	var value interface{} = raw
+
+	switch field.Type {
+	case models.FieldTypeNumber, models.FieldTypeUser:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, apperrors.Invalidf("custom_fields."+field.Key, "%s must be a number", field.Key)
 		}
-		return err
+		value = number
+	case models.FieldTypeMultiSelect:
+		// Filtering a multi-select matches tasks that have the option selected
+		value = []interface{}{raw}
+	}
+
+	if err := s.validator.ValidateCustomFieldValue(field, value); err != nil {
+		return nil, err
 	}
 
-	if creatorID != userID {
-		return fmt.Errorf("you can only modify your own tasks")
+	return value, nil
+}
+
+func parseTaskID(taskID string) (int, error) {
+	id, err := strconv.Atoi(taskID)
+	if err != nil {
+		return 0, ErrTaskNotFound
 	}
+	return id, nil
+}
 
-	return nil
+// changedFields returns the JSON names of the fields an update changes on a task
+func changedFields(current *models.Task, req models.UpdateTaskRequest, values []FieldValue) []string {
+	var fields []string
+	if req.Title != nil && *req.Title != current.Title {
+		fields = append(fields, "title")
+	}
+	if req.Description != nil && *req.Description != current.Description {
+		fields = append(fields, "description")
+	}
+	if req.Status != nil && *req.Status != current.Status {
+		fields = append(fields, "status")
+	}
+	if req.DueDate != nil && (current.DueDate == nil || !req.DueDate.Equal(*current.DueDate)) {
+		fields = append(fields, "due_date")
+	}
+	if req.AssigneeID != nil && !sameID(current.AssigneeID, *req.AssigneeID) {
+		fields = append(fields, "assignee_id")
+	}
+	if req.StoryPoints != nil && (current.StoryPoints == nil || *req.StoryPoints != *current.StoryPoints) {
+		fields = append(fields, "story_points")
+	}
+	if req.SprintID != nil && !sameID(current.SprintID, *req.SprintID) {
+		fields = append(fields, "sprint_id")
+	}
+	if req.ProjectID != nil && !sameID(current.ProjectID, *req.ProjectID) {
+		fields = append(fields, "project_id")
+	}
+	if len(values) > 0 {
+		fields = append(fields, "custom_fields")
+	}
+	return fields
+}
+
+// sameID reports whether an optional ID equals a requested one, where 0 means none
+func sameID(current *int, requested int) bool {
+	if current == nil {
+		return requested == 0
+	}
+	return *current == requested
 }
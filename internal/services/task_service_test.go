@@ -0,0 +1,250 @@
+package services
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/models"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newTaskStore returns a MemoryStore with two users, whose IDs are 1 and 2
+func newTaskStore(t *testing.T) *MemoryStore {
+	store := NewMemoryStore()
+	for _, email := range []string{"alice@example.com", "bob@example.com"} {
+		if _, err := store.CreateUser(models.User{Email: email, Name: email}); err != nil {
+			t.Fatalf("CreateUser() error = %v", err)
+		}
+	}
+	return store
+}
+
+func TestTaskOwnership(t *testing.T) {
+	store := newTaskStore(t)
+	service := NewTaskService(store, store)
+	archive := NewTaskArchiveService(store)
+
+	task, err := service.CreateTask(models.CreateTaskRequest{Title: "Write tests"}, 1)
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	if task.Status != models.StatusToDo || task.CreatorName != "alice@example.com" {
+		t.Errorf("CreateTask() = %+v, want a To Do task by alice", task)
+	}
+	id := strconv.Itoa(task.ID)
+
+	title := "Changed"
+	tests := []struct {
+		name string
+		fn   func() error
+		want error
+	}{
+		{"Update by another user", func() error {
+			_, _, err := service.UpdateTask(id, models.UpdateTaskRequest{Title: &title}, 2)
+			return err
+		}, ErrNotTaskOwner},
+		{"Delete by another user", func() error {
+			_, err := service.DeleteTask(id, 2)
+			return err
+		}, ErrNotTaskOwner},
+		{"Archive by another user", func() error {
+			_, _, err := archive.ArchiveTask(id, 2)
+			return err
+		}, ErrNotTaskOwner},
+		{"Update of a missing task", func() error {
+			_, _, err := service.UpdateTask("99", models.UpdateTaskRequest{Title: &title}, 1)
+			return err
+		}, ErrTaskNotFound},
+		{"Get a non-numeric task", func() error {
+			_, err := service.GetTask("abc")
+			return err
+		}, ErrTaskNotFound},
+		{"Empty update", func() error {
+			_, _, err := service.UpdateTask(id, models.UpdateTaskRequest{}, 1)
+			return err
+		}, apperrors.ErrValidation},
+		{"Blank title", func() error {
+			_, err := service.CreateTask(models.CreateTaskRequest{Title: "  "}, 1)
+			return err
+		}, apperrors.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.fn(); !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	updated, changes, err := service.UpdateTask(id, models.UpdateTaskRequest{Title: &title}, 1)
+	if err != nil || updated.Title != title || !reflect.DeepEqual(changes, []string{"changed title to 'Changed'"}) {
+		t.Errorf("UpdateTask() = %+v, %v, %v, want the changed title", updated, changes, err)
+	}
+
+	if deletedTitle, err := service.DeleteTask(id, 1); err != nil || deletedTitle != title {
+		t.Errorf("DeleteTask() = %q, %v, want %q", deletedTitle, err, title)
+	}
+	if _, err := service.GetTask(id); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("GetTask() after delete: error = %v, want %v", err, ErrTaskNotFound)
+	}
+}
+
+func TestArchiveTask(t *testing.T) {
+	store := newTaskStore(t)
+	service := NewTaskService(store, store)
+	archive := NewTaskArchiveService(store)
+
+	kept, _ := service.CreateTask(models.CreateTaskRequest{Title: "Kept"}, 1)
+	archived, _ := service.CreateTask(models.CreateTaskRequest{Title: "Archived"}, 1)
+
+	task, title, err := archive.ArchiveTask(strconv.Itoa(archived.ID), 1)
+	if err != nil || !task.Archived || title != "Archived" {
+		t.Fatalf("ArchiveTask() = %+v, %q, %v, want the archived task", task, title, err)
+	}
+
+	active, _ := service.GetTasks(models.TaskFilter{Limit: 10})
+	if len(active) != 1 || active[0].ID != kept.ID {
+		t.Errorf("GetTasks() = %+v, want only the kept task", active)
+	}
+
+	inArchive, _ := archive.GetArchivedTasks(10, 0)
+	if len(inArchive) != 1 || inArchive[0].ID != archived.ID {
+		t.Errorf("GetArchivedTasks() = %+v, want only the archived task", inArchive)
+	}
+
+	if task, _, err := archive.UnarchiveTask(strconv.Itoa(archived.ID), 1); err != nil || task.Archived {
+		t.Errorf("UnarchiveTask() = %+v, %v, want a restored task", task, err)
+	}
+}
+
+func TestTaskSprintsAndProjects(t *testing.T) {
+	store := newTaskStore(t)
+	service := NewTaskService(store, store)
+
+	closedAt := time.Now()
+	open := store.AddSprint(models.Sprint{Name: "Sprint 1"})
+	closed := store.AddSprint(models.Sprint{Name: "Sprint 0", ClosedAt: &closedAt})
+	project := store.AddProject(models.Project{Name: "Web"})
+	missing := 99
+
+	tests := []struct {
+		name    string
+		req     models.CreateTaskRequest
+		wantErr bool
+	}{
+		{"Open sprint", models.CreateTaskRequest{Title: "a", SprintID: &open}, false},
+		{"Closed sprint", models.CreateTaskRequest{Title: "a", SprintID: &closed}, true},
+		{"Unknown sprint", models.CreateTaskRequest{Title: "a", SprintID: &missing}, true},
+		{"Project", models.CreateTaskRequest{Title: "a", ProjectID: &project}, false},
+		{"Unknown project", models.CreateTaskRequest{Title: "a", ProjectID: &missing}, true},
+		{"Custom fields without a project", models.CreateTaskRequest{Title: "a", CustomFields: map[string]interface{}{"x": "y"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateTask(tt.req, 1)
+			if tt.wantErr && !errors.Is(err, apperrors.ErrValidation) {
+				t.Errorf("CreateTask() error = %v, want a validation error", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("CreateTask() error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestGetTasksByCustomField(t *testing.T) {
+	store := newTaskStore(t)
+	service := NewTaskService(store, store)
+
+	projectID := store.AddProject(models.Project{Name: "Support", Fields: []models.CustomField{
+		{Key: "severity", Name: "Severity", Type: models.FieldTypeSingleSelect, Options: []string{"low", "high"}},
+		{Key: "cost", Name: "Cost", Type: models.FieldTypeNumber},
+		{Key: "labels", Name: "Labels", Type: models.FieldTypeMultiSelect, Options: []string{"ui", "api"}},
+		{Key: "owner", Name: "Owner", Type: models.FieldTypeUser},
+	}})
+
+	create := func(title string, values map[string]interface{}) int {
+		task, err := service.CreateTask(models.CreateTaskRequest{Title: title, ProjectID: &projectID, CustomFields: values}, 1)
+		if err != nil {
+			t.Fatalf("CreateTask(%s) error = %v", title, err)
+		}
+		return task.ID
+	}
+
+	cheap := create("cheap", map[string]interface{}{"severity": "low", "cost": float64(5), "labels": []interface{}{"ui"}})
+	pricey := create("pricey", map[string]interface{}{"severity": "high", "cost": float64(50), "labels": []interface{}{"ui", "api"}})
+	unpriced := create("unpriced", map[string]interface{}{"severity": "high"})
+
+	ids := func(tasks []models.Task) []int {
+		out := []int{}
+		for _, task := range tasks {
+			out = append(out, task.ID)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		filter models.TaskFilter
+		want   []int
+	}{
+		{"Filter by select", models.TaskFilter{CustomFields: map[string]string{"severity": "high"}}, []int{unpriced, pricey}},
+		{"Filter by number", models.TaskFilter{CustomFields: map[string]string{"cost": "5"}}, []int{cheap}},
+		{"Filter by multi-select option", models.TaskFilter{CustomFields: map[string]string{"labels": "api"}}, []int{pricey}},
+		{"Sort by number ascending, unset last", models.TaskFilter{Sort: "cf.cost", Order: "asc"}, []int{cheap, pricey, unpriced}},
+		{"Sort by number descending, unset last", models.TaskFilter{Sort: "cf.cost", Order: "desc"}, []int{pricey, cheap, unpriced}},
+		{"Sort by title", models.TaskFilter{Sort: "title", Order: "asc"}, []int{cheap, pricey, unpriced}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Limit = 10
+			tt.filter.ProjectID = &projectID
+			tasks, err := service.GetTasks(tt.filter)
+			if err != nil {
+				t.Fatalf("GetTasks() error = %v", err)
+			}
+			if got := ids(tasks); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetTasks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	invalid := []struct {
+		name   string
+		filter models.TaskFilter
+	}{
+		{"Unknown field", models.TaskFilter{CustomFields: map[string]string{"nope": "x"}}},
+		{"Not a number", models.TaskFilter{CustomFields: map[string]string{"cost": "lots"}}},
+		{"Sort by multi-select", models.TaskFilter{Sort: "cf.labels"}},
+		{"Unknown column", models.TaskFilter{Sort: "creator_id"}},
+		{"Bad order", models.TaskFilter{Order: "up"}},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Limit = 10
+			tt.filter.ProjectID = &projectID
+			if _, err := service.GetTasks(tt.filter); !errors.Is(err, apperrors.ErrValidation) {
+				t.Errorf("GetTasks() error = %v, want a validation error", err)
+			}
+		})
+	}
+
+	noOne := 99
+	owner := map[string]interface{}{"owner": float64(noOne)}
+	if _, err := service.CreateTask(models.CreateTaskRequest{Title: "x", ProjectID: &projectID, CustomFields: owner}, 1); !errors.Is(err, apperrors.ErrValidation) {
+		t.Errorf("CreateTask() with an unknown user: error = %v, want a validation error", err)
+	}
+
+	// Leaving the project drops its values
+	none := 0
+	task, _, err := service.UpdateTask(strconv.Itoa(cheap), models.UpdateTaskRequest{ProjectID: &none}, 1)
+	if err != nil || task.ProjectID != nil || len(task.CustomFields) != 0 {
+		t.Errorf("UpdateTask() = %+v, %v, want the task without project and values", task, err)
+	}
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// TimeEntryRepository stores time entries. It only persists data; the rules about
+// ranges, overlaps and ownership live in TimeEntryService. Entries it returns have
+// no duration while they run.
+type TimeEntryRepository interface {
+	// TaskArchived returns whether a task is archived, or ErrTaskNotFound
+	TaskArchived(taskID int) (bool, error)
+	// GetTimeEntry returns ErrTimeEntryNotFound when no entry has the ID
+	GetTimeEntry(entryID int) (*models.TimeEntry, error)
+	// ListTaskTimeEntries returns the entries of a task, latest start first
+	ListTaskTimeEntries(taskID int) ([]models.TimeEntry, error)
+	// RunningTimeEntry returns the user's running entry, or ErrNoRunningTimer
+	RunningTimeEntry(userID int) (*models.TimeEntry, error)
+	// CreateTimeEntry stores an entry, running when it has no EndedAt. A second
+	// running entry of a user fails with ErrTimerAlreadyRunning.
+	CreateTimeEntry(entry models.TimeEntry) (int, error)
+	// StopTimeEntry ends the user's running entry at endedAt and returns its ID, or
+	// ErrNoRunningTimer
+	StopTimeEntry(userID int, endedAt time.Time) (int, error)
+	// UpdateTimeEntry stores the description and range of an entry
+	UpdateTimeEntry(entry models.TimeEntry) error
+	DeleteTimeEntry(entryID int) error
+	// HasOverlappingEntry reports whether another entry of the user than excludeID
+	// overlaps [startedAt, endedAt). Running entries have no end yet.
+	HasOverlappingEntry(userID, excludeID int, startedAt, endedAt time.Time) (bool, error)
+	// TimeReport sums the completed entries matching the filter by user and task,
+	// ordered by user name and task title
+	TimeReport(filter models.TimeReportFilter) ([]models.TimeReportRow, error)
+}
+
+type PostgresTimeEntryRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresTimeEntryRepository(db *sql.DB) *PostgresTimeEntryRepository {
+	return &PostgresTimeEntryRepository{db: db}
+}
+
+const timeEntrySelect = `
+	SELECT te.id, te.task_id, te.user_id, u.name as user_name, te.description,
+	       te.started_at, te.ended_at, te.duration_seconds, te.created_at, te.updated_at
+	FROM time_entries te
+	JOIN users u ON te.user_id = u.id
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTimeEntry(row rowScanner) (*models.TimeEntry, error) {
+	var entry models.TimeEntry
+	err := row.Scan(
+		&entry.ID, &entry.TaskID, &entry.UserID, &entry.UserName, &entry.Description,
+		&entry.StartedAt, &entry.EndedAt, &entry.DurationSeconds, &entry.CreatedAt, &entry.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Running = entry.EndedAt == nil
+	return &entry, nil
+}
+
+func (r *PostgresTimeEntryRepository) TaskArchived(taskID int) (bool, error) {
+	var archived bool
+	err := r.db.QueryRow("SELECT archived FROM tasks WHERE id = $1", taskID).Scan(&archived)
+	if err == sql.ErrNoRows {
+		return false, ErrTaskNotFound
+	}
+	return archived, err
+}
+
+func (r *PostgresTimeEntryRepository) GetTimeEntry(entryID int) (*models.TimeEntry, error) {
+	entry, err := scanTimeEntry(r.db.QueryRow(timeEntrySelect+"WHERE te.id = $1", entryID))
+	if err == sql.ErrNoRows {
+		return nil, ErrTimeEntryNotFound
+	}
+	return entry, err
+}
+
+func (r *PostgresTimeEntryRepository) ListTaskTimeEntries(taskID int) ([]models.TimeEntry, error) {
+	rows, err := r.db.Query(timeEntrySelect+"WHERE te.task_id = $1 ORDER BY te.started_at DESC", taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.TimeEntry
+	for rows.Next() {
+		entry, err := scanTimeEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *PostgresTimeEntryRepository) RunningTimeEntry(userID int) (*models.TimeEntry, error) {
+	entry, err := scanTimeEntry(r.db.QueryRow(timeEntrySelect+"WHERE te.user_id = $1 AND te.ended_at IS NULL", userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNoRunningTimer
+	}
+	return entry, err
+}
+
+func (r *PostgresTimeEntryRepository) CreateTimeEntry(entry models.TimeEntry) (int, error) {
+	var entryID int
+	err := r.db.QueryRow(`
+		INSERT INTO time_entries (task_id, user_id, description, started_at, ended_at, duration_seconds)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, entry.TaskID, entry.UserID, entry.Description, entry.StartedAt, entry.EndedAt, entry.DurationSeconds).Scan(&entryID)
+
+	// At most one running timer per user, enforced by a partial unique index
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return 0, ErrTimerAlreadyRunning
+	}
+	return entryID, err
+}
+
+func (r *PostgresTimeEntryRepository) StopTimeEntry(userID int, endedAt time.Time) (int, error) {
+	var entryID int
+	err := r.db.QueryRow(`
+		UPDATE time_entries
+		SET ended_at = $2,
+		    duration_seconds = FLOOR(EXTRACT(EPOCH FROM ($2 - started_at)))::BIGINT
+		WHERE user_id = $1 AND ended_at IS NULL
+		RETURNING id
+	`, userID, endedAt).Scan(&entryID)
+	if err == sql.ErrNoRows {
+		return 0, ErrNoRunningTimer
+	}
+	return entryID, err
+}
+
+func (r *PostgresTimeEntryRepository) UpdateTimeEntry(entry models.TimeEntry) error {
+	_, err := r.db.Exec(`
+		UPDATE time_entries
+		SET description = $1, started_at = $2, ended_at = $3, duration_seconds = $4
+		WHERE id = $5
+	`, entry.Description, entry.StartedAt, entry.EndedAt, entry.DurationSeconds, entry.ID)
+	return err
+}
+
+func (r *PostgresTimeEntryRepository) DeleteTimeEntry(entryID int) error {
+	_, err := r.db.Exec("DELETE FROM time_entries WHERE id = $1", entryID)
+	return err
+}
+
+func (r *PostgresTimeEntryRepository) HasOverlappingEntry(userID, excludeID int, startedAt, endedAt time.Time) (bool, error) {
+	var overlaps bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM time_entries
+			WHERE user_id = $1 AND id <> $2
+			  AND started_at < $4 AND (ended_at IS NULL OR ended_at > $3)
+		)
+	`, userID, excludeID, startedAt, endedAt).Scan(&overlaps)
+	return overlaps, err
+}
+
+func (r *PostgresTimeEntryRepository) TimeReport(filter models.TimeReportFilter) ([]models.TimeReportRow, error) {
+	conditions := []string{"te.ended_at IS NOT NULL"}
+	args := []interface{}{}
+
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("te.user_id = $%d", len(args)))
+	}
+	if filter.TaskID != nil {
+		args = append(args, *filter.TaskID)
+		conditions = append(conditions, fmt.Sprintf("te.task_id = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("te.started_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("te.started_at < $%d", len(args)))
+	}
+
+	rows, err := r.db.Query(`
+		SELECT te.user_id, u.name, te.task_id, t.title,
+		       COUNT(*), COALESCE(SUM(te.duration_seconds), 0)
+		FROM time_entries te
+		JOIN users u ON te.user_id = u.id
+		JOIN tasks t ON te.task_id = t.id
+		WHERE `+strings.Join(conditions, " AND ")+`
+		GROUP BY te.user_id, u.name, te.task_id, t.title
+		ORDER BY u.name ASC, t.title ASC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var report []models.TimeReportRow
+	for rows.Next() {
+		var row models.TimeReportRow
+		err := rows.Scan(
+			&row.UserID, &row.UserName, &row.TaskID, &row.TaskTitle,
+			&row.EntryCount, &row.TotalSeconds,
+		)
+		if err != nil {
+			return nil, err
+		}
+		report = append(report, row)
+	}
+
+	return report, rows.Err()
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/validators"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+type TimeEntryService struct {
+	repo      TimeEntryRepository
+	validator *validators.TimeEntryValidator
+	now       func() time.Time
+}
+
+func NewTimeEntryService(repo TimeEntryRepository) *TimeEntryService {
+	return &TimeEntryService{
+		repo:      repo,
+		validator: validators.NewTimeEntryValidator(),
+		now:       now,
+	}
+}
+
+// GetTaskTimeEntries retrieves all time entries for a task
+func (s *TimeEntryService) GetTaskTimeEntries(taskID string) ([]models.TimeEntry, error) {
+	id, err := parseTaskID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.TaskArchived(id); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.repo.ListTaskTimeEntries(id)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		s.setRunningDuration(&entries[i])
+	}
+	if entries == nil {
+		entries = []models.TimeEntry{}
+	}
+
+	return entries, nil
+}
+
+// GetTimeEntry retrieves a single time entry by ID
+func (s *TimeEntryService) GetTimeEntry(entryID string) (*models.TimeEntry, error) {
+	id, err := strconv.Atoi(entryID)
+	if err != nil {
+		return nil, ErrTimeEntryNotFound
+	}
+
+	return s.getTimeEntry(id)
+}
+
+// GetRunningTimer retrieves the running timer of a user
+func (s *TimeEntryService) GetRunningTimer(userID int) (*models.TimeEntry, error) {
+	entry, err := s.repo.RunningTimeEntry(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.setRunningDuration(entry)
+	return entry, nil
+}
+
+// StartTimer starts a timer on a task for the user. Each user has at most one
+// running timer.
+func (s *TimeEntryService) StartTimer(taskID string, req models.StartTimerRequest, userID int) (*models.TimeEntry, error) {
+	if err := s.validator.ValidateStartTimer(&req); err != nil {
+		return nil, err
+	}
+
+	id, err := s.checkTaskTrackable(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	entryID, err := s.repo.CreateTimeEntry(models.TimeEntry{
+		TaskID:      id,
+		UserID:      userID,
+		Description: req.Description,
+		StartedAt:   s.now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getTimeEntry(entryID)
+}
+
+// StopTimer stops the running timer of a user
+func (s *TimeEntryService) StopTimer(userID int) (*models.TimeEntry, error) {
+	entryID, err := s.repo.StopTimeEntry(userID, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getTimeEntry(entryID)
+}
+
+// CreateTimeEntry creates a manual time entry. It may not overlap the user's other
+// entries, including a running timer, so the same time is not logged twice.
+func (s *TimeEntryService) CreateTimeEntry(taskID string, req models.CreateTimeEntryRequest, userID int) (*models.TimeEntry, error) {
+	// The columns have no time zone, so times are stored in UTC
+	req.StartedAt, req.EndedAt = req.StartedAt.UTC(), req.EndedAt.UTC()
+	if err := s.validator.ValidateCreateTimeEntry(&req, s.now()); err != nil {
+		return nil, err
+	}
+
+	id, err := s.checkTaskTrackable(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkOverlap(userID, 0, req.StartedAt, req.EndedAt); err != nil {
+		return nil, err
+	}
+
+	entryID, err := s.repo.CreateTimeEntry(models.TimeEntry{
+		TaskID:          id,
+		UserID:          userID,
+		Description:     req.Description,
+		StartedAt:       req.StartedAt,
+		EndedAt:         &req.EndedAt,
+		DurationSeconds: int64(req.EndedAt.Sub(req.StartedAt).Seconds()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getTimeEntry(entryID)
+}
+
+// UpdateTimeEntry updates an existing time entry (only its owner can update). The
+// new range may not overlap the user's other entries.
+func (s *TimeEntryService) UpdateTimeEntry(entryID string, req models.UpdateTimeEntryRequest, userID int) (*models.TimeEntry, error) {
+	if err := s.validator.ValidateUpdateTimeEntry(&req); err != nil {
+		return nil, err
+	}
+
+	entry, err := s.GetTimeEntry(entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.UserID != userID {
+		return nil, ErrNotTimeEntryOwner
+	}
+
+	if entry.Running && req.EndedAt != nil {
+		return nil, apperrors.Invalid("ended_at", "stop the running timer instead of setting ended_at")
+	}
+
+	if req.Description != nil {
+		entry.Description = *req.Description
+	}
+	if req.StartedAt != nil {
+		entry.StartedAt = req.StartedAt.UTC()
+	}
+
+	if entry.Running {
+		at := s.now()
+		if entry.StartedAt.After(at) {
+			return nil, apperrors.Invalid("started_at", "started_at cannot be in the future")
+		}
+		if err := s.checkOverlap(userID, entry.ID, entry.StartedAt, at); err != nil {
+			return nil, err
+		}
+		entry.DurationSeconds = 0
+	} else {
+		if req.EndedAt != nil {
+			endedAt := req.EndedAt.UTC()
+			entry.EndedAt = &endedAt
+		}
+
+		if err := s.validator.ValidateRange(entry.StartedAt, *entry.EndedAt, s.now()); err != nil {
+			return nil, err
+		}
+		if err := s.checkOverlap(userID, entry.ID, entry.StartedAt, *entry.EndedAt); err != nil {
+			return nil, err
+		}
+		entry.DurationSeconds = int64(entry.EndedAt.Sub(entry.StartedAt).Seconds())
+	}
+
+	if err := s.repo.UpdateTimeEntry(*entry); err != nil {
+		return nil, err
+	}
+
+	return s.getTimeEntry(entry.ID)
+}
+
+// DeleteTimeEntry deletes a time entry (only its owner can delete) and returns it
+func (s *TimeEntryService) DeleteTimeEntry(entryID string, userID int) (*models.TimeEntry, error) {
+	entry, err := s.GetTimeEntry(entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.UserID != userID {
+		return nil, ErrNotTimeEntryOwner
+	}
+
+	if err := s.repo.DeleteTimeEntry(entry.ID); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// GetReport aggregates completed time entries by user and task
+func (s *TimeEntryService) GetReport(filter models.TimeReportFilter) (*models.TimeReport, error) {
+	// Entries are stored in UTC, so the range is compared in UTC too
+	if filter.From != nil {
+		from := filter.From.UTC()
+		filter.From = &from
+	}
+	if filter.To != nil {
+		to := filter.To.UTC()
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && filter.To.Before(*filter.From) {
+		return nil, apperrors.Invalid("to", "to must not be before from")
+	}
+
+	rows, err := s.repo.TimeReport(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.TimeReport{
+		From: filter.From,
+		To:   filter.To,
+		Rows: []models.TimeReportRow{},
+	}
+	for _, row := range rows {
+		report.TotalSeconds += row.TotalSeconds
+		report.Rows = append(report.Rows, row)
+	}
+
+	return report, nil
+}
+
+func (s *TimeEntryService) getTimeEntry(entryID int) (*models.TimeEntry, error) {
+	entry, err := s.repo.GetTimeEntry(entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.setRunningDuration(entry)
+	return entry, nil
+}
+
+// setRunningDuration sets the duration of a running entry to the time it has run so far
+func (s *TimeEntryService) setRunningDuration(entry *models.TimeEntry) {
+	if entry.Running {
+		entry.DurationSeconds = int64(s.now().Sub(entry.StartedAt).Seconds())
+	}
+}
+
+// checkTaskTrackable returns the ID of a task time can be logged on
+func (s *TimeEntryService) checkTaskTrackable(taskID string) (int, error) {
+	id, err := parseTaskID(taskID)
+	if err != nil {
+		return 0, err
+	}
+
+	archived, err := s.repo.TaskArchived(id)
+	if err != nil {
+		return 0, err
+	}
+	if archived {
+		return 0, ErrTaskArchived
+	}
+
+	return id, nil
+}
+
+// checkOverlap returns ErrTimeEntryOverlap when another entry of the user than
+// excludeID overlaps the range
+func (s *TimeEntryService) checkOverlap(userID, excludeID int, startedAt, endedAt time.Time) error {
+	overlaps, err := s.repo.HasOverlappingEntry(userID, excludeID, startedAt, endedAt)
+	if err != nil {
+		return err
+	}
+	if overlaps {
+		return ErrTimeEntryOverlap
+	}
+	return nil
+}
+
+// FormatDuration formats a duration in seconds into a readable string
+func (s *TimeEntryService) FormatDuration(seconds int64) string {
+	hours := seconds / 3600
+	minutes := (seconds % 3600) / 60
+
+	if hours == 0 && minutes == 0 {
+		return fmt.Sprintf("%ds", seconds)
+	}
+
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+
+	if minutes == 0 {
+		return fmt.Sprintf("%dh", hours)
+	}
+
+	return fmt.Sprintf("%dh %dm", hours, minutes)
+}
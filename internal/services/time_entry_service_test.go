@@ -0,0 +1,269 @@
+package services
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/models"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newTimeEntryService returns a time entry service on a store with alice (1), bob (2)
+// and two tasks, and a pointer to the clock it reads. hour(h) in the tests is hour h
+// of the day before the clock, in UTC.
+func newTimeEntryService(t *testing.T) (*TimeEntryService, *MemoryStore, *time.Time) {
+	store := newTaskStore(t)
+	tasks := NewTaskService(store, store)
+	for _, title := range []string{"Write tests", "Fix bugs"} {
+		if _, err := tasks.CreateTask(models.CreateTaskRequest{Title: title}, 1); err != nil {
+			t.Fatalf("CreateTask() error = %v", err)
+		}
+	}
+
+	clock := time.Date(2024, 3, 12, 15, 0, 0, 0, time.UTC)
+	service := NewTimeEntryService(store)
+	service.now = func() time.Time { return clock }
+	return service, store, &clock
+}
+
+func TestTimer(t *testing.T) {
+	service, store, clock := newTimeEntryService(t)
+
+	if _, err := service.StopTimer(1); !errors.Is(err, ErrNoRunningTimer) {
+		t.Errorf("StopTimer() without a timer error = %v, want ErrNoRunningTimer", err)
+	}
+
+	started, err := service.StartTimer("1", models.StartTimerRequest{Description: "Coding"}, 1)
+	if err != nil {
+		t.Fatalf("StartTimer() error = %v", err)
+	}
+	if !started.Running || started.EndedAt != nil || started.DurationSeconds != 0 {
+		t.Errorf("StartTimer() = %+v, want a running entry", started)
+	}
+
+	// One running timer per user, on any task
+	if _, err := service.StartTimer("2", models.StartTimerRequest{}, 1); !errors.Is(err, ErrTimerAlreadyRunning) {
+		t.Errorf("second StartTimer() error = %v, want ErrTimerAlreadyRunning", err)
+	}
+	if _, err := service.StartTimer("2", models.StartTimerRequest{}, 2); err != nil {
+		t.Errorf("StartTimer() by another user error = %v", err)
+	}
+
+	*clock = clock.Add(90 * time.Second)
+	running, err := service.GetRunningTimer(1)
+	if err != nil {
+		t.Fatalf("GetRunningTimer() error = %v", err)
+	}
+	if running.ID != started.ID || running.DurationSeconds != 90 {
+		t.Errorf("GetRunningTimer() = %+v, want entry %d running for 90s", running, started.ID)
+	}
+
+	stopped, err := service.StopTimer(1)
+	if err != nil {
+		t.Fatalf("StopTimer() error = %v", err)
+	}
+	if stopped.Running || stopped.EndedAt == nil || !stopped.EndedAt.Equal(*clock) || stopped.DurationSeconds != 90 {
+		t.Errorf("StopTimer() = %+v, want an entry ended after 90s", stopped)
+	}
+	if _, err := service.GetRunningTimer(1); !errors.Is(err, ErrNoRunningTimer) {
+		t.Errorf("GetRunningTimer() after stop error = %v, want ErrNoRunningTimer", err)
+	}
+	if _, err := service.StopTimer(1); !errors.Is(err, ErrNoRunningTimer) {
+		t.Errorf("second StopTimer() error = %v, want ErrNoRunningTimer", err)
+	}
+
+	if _, _, err := NewTaskArchiveService(store).ArchiveTask("1", 1); err != nil {
+		t.Fatalf("ArchiveTask() error = %v", err)
+	}
+	if _, err := service.StartTimer("1", models.StartTimerRequest{}, 1); !errors.Is(err, ErrTaskArchived) {
+		t.Errorf("StartTimer() on an archived task error = %v, want ErrTaskArchived", err)
+	}
+	if _, err := service.StartTimer("99", models.StartTimerRequest{}, 1); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("StartTimer() on a missing task error = %v, want ErrTaskNotFound", err)
+	}
+}
+
+func TestCreateTimeEntry(t *testing.T) {
+	service, _, clock := newTimeEntryService(t)
+	day := clock.Truncate(24 * time.Hour).Add(-24 * time.Hour)
+	hour := func(h int) time.Time { return day.Add(time.Duration(h) * time.Hour) }
+
+	// Alice logged 09:00 to 10:00 and has had a timer running for an hour
+	if _, err := service.CreateTimeEntry("1", models.CreateTimeEntryRequest{StartedAt: hour(9), EndedAt: hour(10)}, 1); err != nil {
+		t.Fatalf("CreateTimeEntry() error = %v", err)
+	}
+	*clock = clock.Add(-time.Hour)
+	if _, err := service.StartTimer("2", models.StartTimerRequest{}, 1); err != nil {
+		t.Fatalf("StartTimer() error = %v", err)
+	}
+	*clock = clock.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		userID    int
+		startedAt time.Time
+		endedAt   time.Time
+		wantErr   error
+	}{
+		{"Valid", 1, hour(11), hour(12), nil},
+		{"Adjacent to an entry", 1, hour(8), hour(9), nil},
+		{"Ends before it starts", 1, hour(14), hour(13), apperrors.ErrValidation},
+		{"Zero length", 1, hour(14), hour(14), apperrors.ErrValidation},
+		{"In the future", 1, clock.Add(time.Hour), clock.Add(2 * time.Hour), apperrors.ErrValidation},
+		{"Overlaps an entry", 1, hour(9).Add(30 * time.Minute), hour(10).Add(30 * time.Minute), ErrTimeEntryOverlap},
+		{"Contains an entry", 1, hour(8).Add(30 * time.Minute), hour(10).Add(30 * time.Minute), ErrTimeEntryOverlap},
+		{"Overlaps the running timer", 1, clock.Add(-30 * time.Minute), *clock, ErrTimeEntryOverlap},
+		{"Same range by another user", 2, hour(9), hour(10), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := models.CreateTimeEntryRequest{StartedAt: tt.startedAt, EndedAt: tt.endedAt}
+			entry, err := service.CreateTimeEntry("1", req, tt.userID)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("CreateTimeEntry() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateTimeEntry() error = %v", err)
+			}
+			if entry.Running || entry.DurationSeconds != 3600 {
+				t.Errorf("CreateTimeEntry() = %+v, want a completed one hour entry", entry)
+			}
+		})
+	}
+}
+
+func TestTimeEntriesAreStoredInUTC(t *testing.T) {
+	service, store, _ := newTimeEntryService(t)
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	startedAt := time.Date(2024, 3, 11, 11, 0, 0, 0, zone)
+
+	entry, err := service.CreateTimeEntry("1", models.CreateTimeEntryRequest{StartedAt: startedAt, EndedAt: startedAt.Add(time.Hour)}, 1)
+	if err != nil {
+		t.Fatalf("CreateTimeEntry() error = %v", err)
+	}
+	stored, err := store.GetTimeEntry(entry.ID)
+	if err != nil {
+		t.Fatalf("GetTimeEntry() error = %v", err)
+	}
+	if stored.StartedAt != time.Date(2024, 3, 11, 9, 0, 0, 0, time.UTC) || *stored.EndedAt != time.Date(2024, 3, 11, 10, 0, 0, 0, time.UTC) {
+		t.Errorf("stored entry = %v to %v, want 09:00 to 10:00 UTC", stored.StartedAt, *stored.EndedAt)
+	}
+
+	endedAt := time.Date(2024, 3, 11, 12, 30, 0, 0, zone)
+	if _, err := service.UpdateTimeEntry(strconv.Itoa(entry.ID), models.UpdateTimeEntryRequest{EndedAt: &endedAt}, 1); err != nil {
+		t.Fatalf("UpdateTimeEntry() error = %v", err)
+	}
+	if stored, _ := store.GetTimeEntry(entry.ID); *stored.EndedAt != time.Date(2024, 3, 11, 10, 30, 0, 0, time.UTC) {
+		t.Errorf("updated entry ends %v, want 10:30 UTC", *stored.EndedAt)
+	}
+
+	from := time.Date(2024, 3, 11, 0, 0, 0, 0, zone)
+	report, err := service.GetReport(models.TimeReportFilter{From: &from})
+	if err != nil {
+		t.Fatalf("GetReport() error = %v", err)
+	}
+	if *report.From != time.Date(2024, 3, 10, 22, 0, 0, 0, time.UTC) || report.TotalSeconds != 5400 {
+		t.Errorf("GetReport() = %+v, want 5400s from 22:00 UTC", report)
+	}
+}
+
+func TestUpdateTimeEntryOverlap(t *testing.T) {
+	service, _, clock := newTimeEntryService(t)
+	day := clock.Truncate(24 * time.Hour).Add(-24 * time.Hour)
+	hour := func(h int) time.Time { return day.Add(time.Duration(h) * time.Hour) }
+
+	first, err := service.CreateTimeEntry("1", models.CreateTimeEntryRequest{StartedAt: hour(9), EndedAt: hour(10)}, 1)
+	if err != nil {
+		t.Fatalf("CreateTimeEntry() error = %v", err)
+	}
+	second, err := service.CreateTimeEntry("1", models.CreateTimeEntryRequest{StartedAt: hour(11), EndedAt: hour(12)}, 1)
+	if err != nil {
+		t.Fatalf("CreateTimeEntry() error = %v", err)
+	}
+
+	// An entry does not overlap itself
+	longer := hour(10).Add(30 * time.Minute)
+	updated, err := service.UpdateTimeEntry(strconv.Itoa(first.ID), models.UpdateTimeEntryRequest{EndedAt: &longer}, 1)
+	if err != nil {
+		t.Fatalf("UpdateTimeEntry() error = %v", err)
+	}
+	if updated.DurationSeconds != 5400 {
+		t.Errorf("UpdateTimeEntry() duration = %d, want 5400", updated.DurationSeconds)
+	}
+
+	earlier := hour(10)
+	if _, err := service.UpdateTimeEntry(strconv.Itoa(second.ID), models.UpdateTimeEntryRequest{StartedAt: &earlier}, 1); !errors.Is(err, ErrTimeEntryOverlap) {
+		t.Errorf("UpdateTimeEntry() into another entry error = %v, want ErrTimeEntryOverlap", err)
+	}
+	if _, err := service.UpdateTimeEntry(strconv.Itoa(second.ID), models.UpdateTimeEntryRequest{StartedAt: &earlier}, 2); !errors.Is(err, ErrNotTimeEntryOwner) {
+		t.Errorf("UpdateTimeEntry() by another user error = %v, want ErrNotTimeEntryOwner", err)
+	}
+}
+
+func TestTimeReport(t *testing.T) {
+	service, _, clock := newTimeEntryService(t)
+	day := clock.Truncate(24 * time.Hour).Add(-24 * time.Hour)
+	hour := func(h int) time.Time { return day.Add(time.Duration(h) * time.Hour) }
+
+	entries := []struct {
+		taskID     string
+		userID     int
+		start, end time.Time
+	}{
+		{"1", 1, hour(9), hour(10)},
+		{"1", 1, hour(10), hour(10).Add(30 * time.Minute)},
+		{"2", 1, hour(11), hour(12)},
+		{"1", 2, hour(9), hour(11)},
+	}
+	for _, e := range entries {
+		req := models.CreateTimeEntryRequest{StartedAt: e.start, EndedAt: e.end}
+		if _, err := service.CreateTimeEntry(e.taskID, req, e.userID); err != nil {
+			t.Fatalf("CreateTimeEntry() error = %v", err)
+		}
+	}
+	// Running timers are not in the report
+	if _, err := service.StartTimer("2", models.StartTimerRequest{}, 2); err != nil {
+		t.Fatalf("StartTimer() error = %v", err)
+	}
+
+	report, err := service.GetReport(models.TimeReportFilter{})
+	if err != nil {
+		t.Fatalf("GetReport() error = %v", err)
+	}
+	want := []models.TimeReportRow{
+		{UserID: 1, UserName: "alice@example.com", TaskID: 2, TaskTitle: "Fix bugs", EntryCount: 1, TotalSeconds: 3600},
+		{UserID: 1, UserName: "alice@example.com", TaskID: 1, TaskTitle: "Write tests", EntryCount: 2, TotalSeconds: 5400},
+		{UserID: 2, UserName: "bob@example.com", TaskID: 1, TaskTitle: "Write tests", EntryCount: 1, TotalSeconds: 7200},
+	}
+	if len(report.Rows) != len(want) {
+		t.Fatalf("GetReport() rows = %+v, want %+v", report.Rows, want)
+	}
+	for i := range want {
+		if report.Rows[i] != want[i] {
+			t.Errorf("GetReport() row %d = %+v, want %+v", i, report.Rows[i], want[i])
+		}
+	}
+	if report.TotalSeconds != 16200 {
+		t.Errorf("GetReport() total = %d, want 16200", report.TotalSeconds)
+	}
+
+	userID := 1
+	from := hour(10)
+	report, err = service.GetReport(models.TimeReportFilter{UserID: &userID, From: &from})
+	if err != nil {
+		t.Fatalf("GetReport() error = %v", err)
+	}
+	if report.TotalSeconds != 5400 || len(report.Rows) != 2 {
+		t.Errorf("GetReport() for alice from 10:00 = %+v, want 5400s in 2 rows", report)
+	}
+
+	to := hour(9)
+	if _, err := service.GetReport(models.TimeReportFilter{From: &from, To: &to}); !errors.Is(err, apperrors.ErrValidation) {
+		t.Errorf("GetReport() with to before from error = %v, want a validation error", err)
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// TOTP parameters (RFC 6238) understood by every authenticator app: HMAC-SHA1,
+// 30 second steps and 6 digits
+const (
+	totpStep   = 30 * time.Second
+	totpDigits = 6
+	// totpModulus is 10^totpDigits
+	totpModulus = 1_000_000
+	// totpSkew is the number of steps a code may be early or late, for clock drift
+	totpSkew = 1
+)
+
+// totpEncoding is the unpadded base32 secrets are shown in
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// totpCode returns the code of a secret for a time step
+func totpCode(secret []byte, counter int64) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation (RFC 4226, section 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%totpModulus)
+}
+
+// TOTPCode returns the code an authenticator app shows at a time for a secret as
+// Enroll returns it
+func TOTPCode(secret string, at time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		return "", err
+	}
+	return totpCode(key, totpCounter(at)), nil
+}
+
+// totpCounter returns the time step of a time
+func totpCounter(at time.Time) int64 {
+	return at.Unix() / int64(totpStep/time.Second)
+}
+
+// matchTOTP returns the time step a code is valid for around at, or false when it
+// matches none
+func matchTOTP(secret []byte, code string, at time.Time) (int64, bool) {
+	counter := totpCounter(at)
+	for step := counter - totpSkew; step <= counter+totpSkew; step++ {
+		if hmac.Equal([]byte(totpCode(secret, step)), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// otpauthURI returns the Key URI authenticator apps read from QR codes
+func otpauthURI(issuer, account string, secret []byte) string {
+	query := url.Values{
+		"secret":    {totpEncoding.EncodeToString(secret)},
+		"issuer":    {issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(totpDigits)},
+		"period":    {fmt.Sprint(int(totpStep / time.Second))},
+	}
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
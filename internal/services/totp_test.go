@@ -0,0 +1,71 @@
+package services
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// Test vectors of RFC 6238, appendix B, for SHA-1, cut to 6 digits
+	secret := []byte("12345678901234567890")
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		if got := totpCode(secret, totpCounter(time.Unix(tt.unix, 0))); got != tt.want {
+			t.Errorf("totpCode() at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	at := time.Unix(1111111111, 0)
+	counter := totpCounter(at)
+
+	tests := []struct {
+		name        string
+		code        string
+		wantCounter int64
+		wantOK      bool
+	}{
+		{"Current step", totpCode(secret, counter), counter, true},
+		{"Previous step", totpCode(secret, counter-1), counter - 1, true},
+		{"Next step", totpCode(secret, counter+1), counter + 1, true},
+		{"Two steps late", totpCode(secret, counter-2), 0, false},
+		{"Wrong code", "000000", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := matchTOTP(secret, tt.code, at)
+			if got != tt.wantCounter || ok != tt.wantOK {
+				t.Errorf("matchTOTP() = %d, %v, want %d, %v", got, ok, tt.wantCounter, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestOTPAuthURI(t *testing.T) {
+	uri, err := url.Parse(otpauthURI("Task Board", "alice@example.com", []byte("12345678901234567890")))
+	if err != nil {
+		t.Fatalf("otpauthURI() error = %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/Task Board:alice@example.com" {
+		t.Errorf("otpauthURI() = %s, want a totp URI labelled with issuer and account", uri)
+	}
+	if got := uri.Query().Get("secret"); got != "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" {
+		t.Errorf("otpauthURI() secret = %s, want the base32 secret", got)
+	}
+}
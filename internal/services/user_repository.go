@@ -0,0 +1,141 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// UserRepository stores user accounts
+type UserRepository interface {
+	// CreateUser stores a user with an already hashed password and returns
+	// ErrEmailTaken when the email is registered
+	CreateUser(user models.User) (*models.User, error)
+	// GetUser returns ErrUserNotFound when no user has the ID
+	GetUser(userID int) (*models.User, error)
+	// GetUserByEmail returns ErrUserNotFound when no user has the email
+	GetUserByEmail(email string) (*models.User, error)
+	// UsersExist reports whether every ID belongs to a user
+	UsersExist(userIDs []int) (bool, error)
+	// SessionVersion returns the version tokens issued to the user must carry, or
+	// ErrUserNotFound when no user has the ID
+	SessionVersion(userID int) (int, error)
+
+	// MarkEmailVerified records when a user verified their email, keeping the first time
+	MarkEmailVerified(userID int, at time.Time) error
+	// RecordVerificationSent records that a verification email is sent to the user at
+	// the given time, unless one was sent after notBefore, and reports whether it did
+	RecordVerificationSent(userID int, at, notBefore time.Time) (bool, error)
+}
+
+type PostgresUserRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresUserRepository(db *sql.DB) *PostgresUserRepository {
+	return &PostgresUserRepository{db: db}
+}
+
+func (r *PostgresUserRepository) CreateUser(user models.User) (*models.User, error) {
+	var created models.User
+	err := r.db.QueryRow(`
+		INSERT INTO users (email, password_hash, name)
+		VALUES ($1, $2, $3)
+		RETURNING id, email, password_hash, name, session_version, email_verified_at, is_admin, created_at, updated_at
+	`, user.Email, user.PasswordHash, user.Name).Scan(
+		&created.ID, &created.Email, &created.PasswordHash, &created.Name, &created.SessionVersion,
+		&created.EmailVerifiedAt, &created.IsAdmin, &created.CreatedAt, &created.UpdatedAt,
+	)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return nil, ErrEmailTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *PostgresUserRepository) GetUser(userID int) (*models.User, error) {
+	return r.getUser("id = $1", userID)
+}
+
+func (r *PostgresUserRepository) GetUserByEmail(email string) (*models.User, error) {
+	return r.getUser("email = $1", email)
+}
+
+func (r *PostgresUserRepository) getUser(condition string, arg interface{}) (*models.User, error) {
+	var user models.User
+	err := r.db.QueryRow(`
+		SELECT id, email, password_hash, name, session_version, email_verified_at, is_admin, created_at, updated_at
+		FROM users WHERE `+condition, arg,
+	).Scan(
+		&user.ID, &user.Email, &user.PasswordHash, &user.Name, &user.SessionVersion,
+		&user.EmailVerifiedAt, &user.IsAdmin, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *PostgresUserRepository) UsersExist(userIDs []int) (bool, error) {
+	unique := map[int]bool{}
+	for _, id := range userIDs {
+		unique[id] = true
+	}
+	if len(unique) == 0 {
+		return true, nil
+	}
+
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM users WHERE id = ANY($1)", pq.Array(userIDs)).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count == len(unique), nil
+}
+
+func (r *PostgresUserRepository) SessionVersion(userID int) (int, error) {
+	var version int
+	err := r.db.QueryRow("SELECT session_version FROM users WHERE id = $1", userID).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, ErrUserNotFound
+	}
+	return version, err
+}
+
+func (r *PostgresUserRepository) MarkEmailVerified(userID int, at time.Time) error {
+	result, err := r.db.Exec(`
+		UPDATE users SET email_verified_at = COALESCE(email_verified_at, $2) WHERE id = $1
+	`, userID, at)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *PostgresUserRepository) RecordVerificationSent(userID int, at, notBefore time.Time) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE users SET verification_sent_at = $2
+		WHERE id = $1 AND (verification_sent_at IS NULL OR verification_sent_at <= $3)
+	`, userID, at, notBefore)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
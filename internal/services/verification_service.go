@@ -0,0 +1,149 @@
+package services
+
+import (
+	"candidate-backend/internal/mail"
+	"candidate-backend/internal/models"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VerificationSettings configures email verification
+type VerificationSettings struct {
+	// URL is the page of the web app verification links open, with the token in the
+	// token query parameter
+	URL        string
+	SigningKey string
+	// TTL is how long a verification link works
+	TTL time.Duration
+	// ResendInterval is how long a user waits before another verification email
+	ResendInterval time.Duration
+}
+
+type VerificationService struct {
+	users    UserRepository
+	mailer   mail.Mailer
+	settings VerificationSettings
+	now      func() time.Time
+	// background runs the sending of verification emails, so registering does not
+	// wait for the mail server
+	background func(func())
+}
+
+func NewVerificationService(users UserRepository, mailer mail.Mailer, settings VerificationSettings) *VerificationService {
+	return &VerificationService{
+		users:      users,
+		mailer:     mailer,
+		settings:   settings,
+		now:        now,
+		background: func(run func()) { go run() },
+	}
+}
+
+// verificationEmail fills the verification template
+type verificationEmail struct {
+	Name      string
+	VerifyURL string
+	ExpiresIn string
+}
+
+// SendVerification emails a verification link to the user, or returns
+// ErrVerificationRecentlySent when one was sent within the resend interval
+func (s *VerificationService) SendVerification(user *models.User) error {
+	at := s.now()
+	recorded, err := s.users.RecordVerificationSent(user.ID, at, at.Add(-s.settings.ResendInterval))
+	if err != nil {
+		return err
+	}
+	if !recorded {
+		return ErrVerificationRecentlySent
+	}
+
+	token := s.VerificationToken(user, at.Add(s.settings.TTL))
+	msg, err := mail.Render("verify_email", verificationEmail{
+		Name:      user.Name,
+		VerifyURL: s.settings.URL + "?" + url.Values{"token": {token}}.Encode(),
+		ExpiresIn: formatTTL(s.settings.TTL),
+	})
+	if err != nil {
+		return err
+	}
+	msg.To = user.Email
+
+	s.background(func() {
+		if err := s.mailer.Send(context.Background(), msg); err != nil {
+			log.Printf("email: verification for user %d: %v", user.ID, err)
+		}
+	})
+	return nil
+}
+
+// ResendVerification emails another verification link to a user whose email is
+// not verified yet
+func (s *VerificationService) ResendVerification(userID int) error {
+	user, err := s.users.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	return s.SendVerification(user)
+}
+
+// VerifyEmail marks the email of the user a verification token is for verified and
+// returns the user. Tokens of another email, expired or tampered with return
+// ErrInvalidVerificationToken.
+func (s *VerificationService) VerifyEmail(token string) (*models.User, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidVerificationToken
+	}
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, ErrInvalidVerificationToken
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	user, err := s.users.GetUser(userID)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, ErrInvalidVerificationToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The signature covers the email, so a link stops working if the email changes
+	at := s.now()
+	expiresAt := time.Unix(expires, 0)
+	if !hmac.Equal([]byte(s.VerificationToken(user, expiresAt)), []byte(token)) || !at.Before(expiresAt) {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	if err := s.users.MarkEmailVerified(user.ID, at); err != nil {
+		return nil, err
+	}
+	return s.users.GetUser(user.ID)
+}
+
+// VerificationToken returns the token of a link that verifies the user's current
+// email until expiresAt: the user ID, the expiry and their HMAC-SHA256 signature
+// with the email, joined by dots
+func (s *VerificationService) VerificationToken(user *models.User, expiresAt time.Time) string {
+	payload := fmt.Sprintf("%d.%d", user.ID, expiresAt.Unix())
+	mac := hmac.New(sha256.New, []byte(s.settings.SigningKey))
+	mac.Write([]byte("verify-email." + payload + "." + strings.ToLower(user.Email)))
+	return payload + "." + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"candidate-backend/internal/mail"
+	"testing"
+	"time"
+)
+
+func TestVerifyEmail(t *testing.T) {
+	store := newTaskStore(t)
+	service := NewVerificationService(store, mail.NewMemoryMailer(), VerificationSettings{
+		URL:            "http://app.test/verify-email",
+		SigningKey:     "secret",
+		TTL:            time.Hour,
+		ResendInterval: time.Minute,
+	})
+	clock := time.Now()
+	service.now = func() time.Time { return clock }
+
+	alice, _ := store.GetUser(1)
+	otherEmail := *alice
+	otherEmail.Email = "mallory@example.com"
+	other := NewVerificationService(store, mail.NewMemoryMailer(), VerificationSettings{SigningKey: "other"})
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"Expired", service.VerificationToken(alice, clock), ErrInvalidVerificationToken},
+		{"Other email", service.VerificationToken(&otherEmail, clock.Add(time.Hour)), ErrInvalidVerificationToken},
+		{"Other key", other.VerificationToken(alice, clock.Add(time.Hour)), ErrInvalidVerificationToken},
+		{"Garbage", "1.2", ErrInvalidVerificationToken},
+		{"Valid", service.VerificationToken(alice, clock.Add(time.Hour)), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := service.VerifyEmail(tt.token)
+			if err != tt.wantErr {
+				t.Fatalf("VerifyEmail() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (user.ID != 1 || user.EmailVerifiedAt == nil) {
+				t.Errorf("VerifyEmail() = %+v, want alice verified", user)
+			}
+		})
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	store := newTaskStore(t)
+	mailer := mail.NewMemoryMailer()
+	service := NewVerificationService(store, mailer, VerificationSettings{
+		URL:            "http://app.test/verify-email",
+		SigningKey:     "secret",
+		TTL:            time.Hour,
+		ResendInterval: time.Minute,
+	})
+	service.background = func(run func()) { run() }
+	clock := time.Now()
+	service.now = func() time.Time { return clock }
+
+	tests := []struct {
+		name     string
+		advance  time.Duration
+		setup    func()
+		wantErr  error
+		wantSent int
+	}{
+		{"First email", 0, nil, nil, 1},
+		{"Too soon", 30 * time.Second, nil, ErrVerificationRecentlySent, 0},
+		{"After the interval", 30 * time.Second, nil, nil, 1},
+		{"Already verified", time.Hour, func() { _ = store.MarkEmailVerified(1, clock) }, ErrEmailAlreadyVerified, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock = clock.Add(tt.advance)
+			if tt.setup != nil {
+				tt.setup()
+			}
+			if err := service.ResendVerification(1); err != tt.wantErr {
+				t.Fatalf("ResendVerification() error = %v, want %v", err, tt.wantErr)
+			}
+			sent := mailer.Messages()
+			if len(sent) != tt.wantSent {
+				t.Fatalf("sent %d emails, want %d", len(sent), tt.wantSent)
+			}
+			if len(sent) == 1 && (sent[0].To != "alice@example.com" || sent[0].Subject != "Verify your email address") {
+				t.Errorf("sent %+v, want a verification email to alice", sent[0])
+			}
+		})
+	}
+}
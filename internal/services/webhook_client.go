@@ -0,0 +1,98 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// newWebhookClient returns the client deliveries are sent with. It never follows
+// redirects, so a receiver cannot bounce a delivery elsewhere. Unless
+// allowPrivateNetworks is set, it refuses to connect to the loopback, private,
+// link-local and other non-public ranges in nonPublicPrefixes. The check runs on
+// the address each connection is actually made to, after DNS resolution, so a
+// host that resolves to a public address when checked and a private one when used
+// is refused too.
+func newWebhookClient(timeout time.Duration, allowPrivateNetworks bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivateNetworks {
+		dialer.Control = refuseNonPublicAddress
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// A proxy would make the connection for us, past the check
+	transport.Proxy = nil
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// nonPublicPrefixes are the address ranges webhooks may not reach without
+// allowPrivateNetworks: special-purpose ranges from the IANA registries, and the
+// IPv6 ranges that translate to or embed IPv4 addresses
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this network"
+	netip.MustParsePrefix("10.0.0.0/8"),      // private
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("127.0.0.0/8"),     // loopback
+	netip.MustParsePrefix("169.254.0.0/16"),  // link-local, cloud metadata
+	netip.MustParsePrefix("172.16.0.0/12"),   // private
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("192.168.0.0/16"),  // private
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("224.0.0.0/4"),     // multicast
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, broadcast
+
+	netip.MustParsePrefix("::/128"),         // unspecified
+	netip.MustParsePrefix("::1/128"),        // loopback
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+	netip.MustParsePrefix("100::/64"),       // discard
+	netip.MustParsePrefix("2001:db8::/32"),  // documentation
+	netip.MustParsePrefix("2002::/16"),      // 6to4
+	netip.MustParsePrefix("fc00::/7"),       // unique local
+	netip.MustParsePrefix("fe80::/10"),      // link-local
+	netip.MustParsePrefix("ff00::/8"),       // multicast
+}
+
+// refuseNonPublicAddress is a net.Dialer Control hook that fails connections to
+// addresses that are not on the public internet
+func refuseNonPublicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("webhook target %s is not an IP address", host)
+	}
+	if !isPublicIP(ip) {
+		return fmt.Errorf("webhook target %s is not a public address", ip)
+	}
+	return nil
+}
+
+// isPublicIP reports whether the address is in none of nonPublicPrefixes. An
+// IPv4-mapped IPv6 address is checked as the IPv4 address it carries.
+func isPublicIP(ip netip.Addr) bool {
+	// Prefixes never contain addresses with a zone
+	ip = ip.Unmap().WithZone("")
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// WebhookRepository stores webhook subscriptions and their delivery log
+type WebhookRepository interface {
+	// ListWebhooks returns a user's webhooks, oldest first
+	ListWebhooks(userID int) ([]models.Webhook, error)
+	// GetWebhook returns the webhook with its secret, or ErrWebhookNotFound
+	GetWebhook(webhookID int) (*models.Webhook, error)
+	CreateWebhook(webhook models.Webhook) (*models.Webhook, error)
+	// UpdateWebhook saves the URL, secret, events and state of a webhook
+	UpdateWebhook(webhook models.Webhook) (*models.Webhook, error)
+	DeleteWebhook(webhookID int) error
+	// SubscribedWebhooks returns the active webhooks subscribed to an event
+	SubscribedWebhooks(event models.WebhookEvent) ([]models.Webhook, error)
+	// RecordAttempt resets the failure count of a webhook after a successful attempt, or
+	// increments it after a failed one and disables the webhook once it reaches
+	// disableAfter. It reports whether this attempt disabled the webhook.
+	RecordAttempt(webhookID int, succeeded bool, disableAfter int) (bool, error)
+
+	CreateDelivery(delivery models.WebhookDelivery) (*models.WebhookDelivery, error)
+	// GetDelivery returns ErrDeliveryNotFound when no delivery has the ID
+	GetDelivery(deliveryID int) (*models.WebhookDelivery, error)
+	// ListDeliveries returns a page of a webhook's deliveries, newest first
+	ListDeliveries(webhookID, limit, offset int) ([]models.WebhookDelivery, error)
+	// ClaimDueDeliveries returns pending deliveries of active webhooks that are due at
+	// now, and moves their next attempt to now+lease so other workers skip them
+	ClaimDueDeliveries(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+	// SaveDelivery saves the outcome of an attempt
+	SaveDelivery(delivery models.WebhookDelivery) error
+}
+
+type PostgresWebhookRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresWebhookRepository(db *sql.DB) *PostgresWebhookRepository {
+	return &PostgresWebhookRepository{db: db}
+}
+
+const webhookColumns = `id, user_id, url, secret, events, active, failure_count, disabled_at, created_at, updated_at`
+
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	var webhook models.Webhook
+	var events pq.StringArray
+	err := row.Scan(
+		&webhook.ID, &webhook.UserID, &webhook.URL, &webhook.Secret, &events,
+		&webhook.Active, &webhook.FailureCount, &webhook.DisabledAt,
+		&webhook.CreatedAt, &webhook.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.Events = make([]models.WebhookEvent, len(events))
+	for i, event := range events {
+		webhook.Events[i] = models.WebhookEvent(event)
+	}
+	return &webhook, nil
+}
+
+func eventArray(events []models.WebhookEvent) pq.StringArray {
+	array := make(pq.StringArray, len(events))
+	for i, event := range events {
+		array[i] = string(event)
+	}
+	return array
+}
+
+func (r *PostgresWebhookRepository) queryWebhooks(query string, args ...interface{}) ([]models.Webhook, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []models.Webhook
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+func (r *PostgresWebhookRepository) ListWebhooks(userID int) ([]models.Webhook, error) {
+	return r.queryWebhooks("SELECT "+webhookColumns+" FROM webhooks WHERE user_id = $1 ORDER BY id", userID)
+}
+
+func (r *PostgresWebhookRepository) GetWebhook(webhookID int) (*models.Webhook, error) {
+	webhook, err := scanWebhook(r.db.QueryRow("SELECT "+webhookColumns+" FROM webhooks WHERE id = $1", webhookID))
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, err
+}
+
+func (r *PostgresWebhookRepository) CreateWebhook(webhook models.Webhook) (*models.Webhook, error) {
+	return scanWebhook(r.db.QueryRow(`
+		INSERT INTO webhooks (user_id, url, secret, events, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+webhookColumns,
+		webhook.UserID, webhook.URL, webhook.Secret, eventArray(webhook.Events), webhook.Active,
+	))
+}
+
+func (r *PostgresWebhookRepository) UpdateWebhook(webhook models.Webhook) (*models.Webhook, error) {
+	updated, err := scanWebhook(r.db.QueryRow(`
+		UPDATE webhooks
+		SET url = $1, secret = $2, events = $3, active = $4, failure_count = $5, disabled_at = $6,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $7
+		RETURNING `+webhookColumns,
+		webhook.URL, webhook.Secret, eventArray(webhook.Events), webhook.Active,
+		webhook.FailureCount, webhook.DisabledAt, webhook.ID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	return updated, err
+}
+
+func (r *PostgresWebhookRepository) DeleteWebhook(webhookID int) error {
+	_, err := r.db.Exec("DELETE FROM webhooks WHERE id = $1", webhookID)
+	return err
+}
+
+func (r *PostgresWebhookRepository) SubscribedWebhooks(event models.WebhookEvent) ([]models.Webhook, error) {
+	return r.queryWebhooks(
+		"SELECT "+webhookColumns+" FROM webhooks WHERE active AND $1 = ANY(events) ORDER BY id", string(event),
+	)
+}
+
+func (r *PostgresWebhookRepository) RecordAttempt(webhookID int, succeeded bool, disableAfter int) (bool, error) {
+	if succeeded {
+		_, err := r.db.Exec("UPDATE webhooks SET failure_count = 0 WHERE id = $1 AND failure_count > 0", webhookID)
+		return false, err
+	}
+
+	var disabled bool
+	err := r.db.QueryRow(`
+		UPDATE webhooks w
+		SET failure_count = w.failure_count + 1,
+		    active = w.active AND w.failure_count + 1 < $2,
+		    disabled_at = CASE WHEN w.active AND w.failure_count + 1 >= $2 THEN CURRENT_TIMESTAMP ELSE w.disabled_at END
+		FROM webhooks old
+		WHERE w.id = $1 AND old.id = w.id
+		RETURNING old.active AND NOT w.active
+	`, webhookID, disableAfter).Scan(&disabled)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return disabled, err
+}
+
+const deliveryColumns = `id, webhook_id, event, payload, status, attempts, response_status,
+	last_error, next_attempt_at, delivered_at, created_at`
+
+func scanDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var payload []byte
+	var responseStatus sql.NullInt64
+	err := row.Scan(
+		&delivery.ID, &delivery.WebhookID, &delivery.Event, &payload, &delivery.Status,
+		&delivery.Attempts, &responseStatus, &delivery.LastError,
+		&delivery.NextAttemptAt, &delivery.DeliveredAt, &delivery.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Payload = payload
+	delivery.ResponseStatus = nullIntPtr(responseStatus)
+	return &delivery, nil
+}
+
+func (r *PostgresWebhookRepository) queryDeliveries(query string, args ...interface{}) ([]models.WebhookDelivery, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (r *PostgresWebhookRepository) CreateDelivery(delivery models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	return scanDelivery(r.db.QueryRow(`
+		INSERT INTO webhook_deliveries (webhook_id, event, payload, status, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+deliveryColumns,
+		delivery.WebhookID, delivery.Event, []byte(delivery.Payload), delivery.Status, delivery.NextAttemptAt,
+	))
+}
+
+func (r *PostgresWebhookRepository) GetDelivery(deliveryID int) (*models.WebhookDelivery, error) {
+	delivery, err := scanDelivery(r.db.QueryRow(
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE id = $1", deliveryID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrDeliveryNotFound
+	}
+	return delivery, err
+}
+
+func (r *PostgresWebhookRepository) ListDeliveries(webhookID, limit, offset int) ([]models.WebhookDelivery, error) {
+	return r.queryDeliveries(`
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, webhookID, limit, offset)
+}
+
+func (r *PostgresWebhookRepository) ClaimDueDeliveries(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	return r.queryDeliveries(`
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT d.id
+			FROM webhook_deliveries d
+			JOIN webhooks w ON d.webhook_id = w.id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= $1 AND w.active
+			ORDER BY d.next_attempt_at
+			LIMIT $3
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		RETURNING `+deliveryColumns,
+		now, now.Add(lease), limit,
+	)
+}
+
+func (r *PostgresWebhookRepository) SaveDelivery(delivery models.WebhookDelivery) error {
+	var responseStatus interface{}
+	if delivery.ResponseStatus != nil {
+		responseStatus = *delivery.ResponseStatus
+	}
+
+	_, err := r.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, response_status = $3, last_error = $4,
+		    next_attempt_at = $5, delivered_at = $6
+		WHERE id = $7
+	`, delivery.Status, delivery.Attempts, responseStatus, delivery.LastError,
+		delivery.NextAttemptAt, delivery.DeliveredAt, delivery.ID)
+	return err
+}
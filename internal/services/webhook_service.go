@@ -0,0 +1,459 @@
+package services
+
+import (
+	"bytes"
+	"candidate-backend/internal/models"
+	"candidate-backend/internal/validators"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// SignatureHeader carries the HMAC-SHA256 signature of a delivery body
+const SignatureHeader = "X-Signature"
+
+// WebhookPolicy controls how deliveries are attempted and retried
+type WebhookPolicy struct {
+	// MaxAttempts is the number of attempts before a delivery fails
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry; it doubles for each further retry up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// DisableAfter is the number of consecutive failed attempts that disables a webhook
+	DisableAfter int
+	// Timeout bounds a single attempt
+	Timeout time.Duration
+	// AllowPrivateNetworks lets webhooks reach loopback, private and link-local
+	// addresses, which are refused by default so webhooks cannot probe the
+	// server's own network
+	AllowPrivateNetworks bool
+}
+
+// DefaultWebhookPolicy retries for about 15 minutes before a delivery fails
+var DefaultWebhookPolicy = WebhookPolicy{
+	MaxAttempts:  6,
+	BaseDelay:    30 * time.Second,
+	MaxDelay:     time.Hour,
+	DisableAfter: 15,
+	Timeout:      10 * time.Second,
+}
+
+// claimBatch is the number of due deliveries a worker claims at a time
+const claimBatch = 20
+
+type WebhookService struct {
+	repo      WebhookRepository
+	validator *validators.WebhookValidator
+	policy    WebhookPolicy
+	client    *http.Client
+	now       func() time.Time
+	wake      chan struct{}
+}
+
+func NewWebhookService(repo WebhookRepository, policy WebhookPolicy) *WebhookService {
+	return &WebhookService{
+		repo:      repo,
+		validator: validators.NewWebhookValidator(),
+		policy:    policy,
+		client:    newWebhookClient(policy.Timeout, policy.AllowPrivateNetworks),
+		now:       now,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// SignPayload returns the X-Signature value of a body: "sha256=" followed by the
+// hex HMAC-SHA256 of the body keyed with the webhook secret
+func SignPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// GetWebhooks retrieves the user's webhooks
+func (s *WebhookService) GetWebhooks(userID int) ([]models.Webhook, error) {
+	webhooks, err := s.repo.ListWebhooks(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	if webhooks == nil {
+		webhooks = []models.Webhook{}
+	}
+
+	return webhooks, nil
+}
+
+// GetWebhook retrieves one of the user's webhooks
+func (s *WebhookService) GetWebhook(webhookID string, userID int) (*models.Webhook, error) {
+	webhook, err := s.ownWebhook(webhookID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// CreateWebhook subscribes a URL to events. The response is the only one that
+// includes the secret.
+func (s *WebhookService) CreateWebhook(req models.CreateWebhookRequest, userID int) (*models.Webhook, error) {
+	if err := s.validator.ValidateCreateWebhook(&req); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = randomHex(32); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repo.CreateWebhook(models.Webhook{
+		UserID: userID,
+		URL:    req.URL,
+		Secret: secret,
+		Events: req.Events,
+		Active: true,
+	})
+}
+
+// UpdateWebhook updates a webhook. Setting active to true re-enables a disabled
+// webhook and resets its failure count.
+func (s *WebhookService) UpdateWebhook(webhookID string, req models.UpdateWebhookRequest, userID int) (*models.Webhook, error) {
+	if err := s.validator.ValidateUpdateWebhook(&req); err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.ownWebhook(webhookID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		webhook.URL = *req.URL
+	}
+	if req.Secret != nil {
+		webhook.Secret = *req.Secret
+	}
+	if req.Events != nil {
+		webhook.Events = req.Events
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+		if *req.Active {
+			webhook.FailureCount = 0
+			webhook.DisabledAt = nil
+		}
+	}
+
+	updated, err := s.repo.UpdateWebhook(*webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	updated.Secret = ""
+	s.notify()
+	return updated, nil
+}
+
+// DeleteWebhook deletes a webhook with its delivery log
+func (s *WebhookService) DeleteWebhook(webhookID string, userID int) error {
+	webhook, err := s.ownWebhook(webhookID, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteWebhook(webhook.ID)
+}
+
+// GetDeliveries retrieves a page of a webhook's delivery log, newest first
+func (s *WebhookService) GetDeliveries(webhookID string, userID, limit, offset int) ([]models.WebhookDelivery, error) {
+	webhook, err := s.ownWebhook(webhookID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.repo.ListDeliveries(webhook.ID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+
+	return deliveries, nil
+}
+
+// ReplayDelivery sends the payload of a past delivery again as a new delivery and
+// attempts it right away, even when the webhook is disabled. A failed replay is
+// retried like any other delivery.
+func (s *WebhookService) ReplayDelivery(ctx context.Context, webhookID, deliveryID string, userID int) (*models.WebhookDelivery, error) {
+	webhook, err := s.ownWebhook(webhookID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.Atoi(deliveryID)
+	if err != nil {
+		return nil, ErrDeliveryNotFound
+	}
+
+	original, err := s.repo.GetDelivery(id)
+	if err != nil {
+		return nil, err
+	}
+	if original.WebhookID != webhook.ID {
+		return nil, ErrDeliveryNotFound
+	}
+
+	// Keep workers from claiming the replay while it is being attempted here
+	nextAttemptAt := s.now().Add(s.policy.Timeout + time.Second)
+	delivery, err := s.repo.CreateDelivery(models.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: &nextAttemptAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.attempt(ctx, webhook, delivery); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// HandleEvent queues a delivery of a domain event to every active webhook subscribed
+// to it; the deliveries are sent by Run. It is an outbox subscriber. The payload ID is
+// the outbox event ID, so receivers can drop the copies of an event published twice.
+func (s *WebhookService) HandleEvent(ctx context.Context, event models.DomainEvent) error {
+	webhookEvent := models.WebhookEvent(event.Type)
+	if !slices.Contains(models.WebhookEvents, webhookEvent) {
+		return nil
+	}
+
+	webhooks, err := s.repo.SubscribedWebhooks(webhookEvent)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(models.WebhookPayload{
+		ID:         strconv.FormatInt(event.ID, 10),
+		Event:      webhookEvent,
+		OccurredAt: event.CreatedAt,
+		ActorID:    event.ActorID,
+		Data:       event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	queuedAt := s.now()
+	for _, webhook := range webhooks {
+		_, err := s.repo.CreateDelivery(models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			Event:         webhookEvent,
+			Payload:       payload,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: &queuedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	s.notify()
+	return nil
+}
+
+// Run sends due deliveries every interval, and right after HandleEvent queues new
+// ones, until ctx is done
+func (s *WebhookService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+
+		if _, err := s.ProcessDue(ctx); err != nil {
+			log.Printf("webhooks: processing deliveries: %v", err)
+		}
+	}
+}
+
+// ProcessDue attempts every delivery that is due and returns how many it attempted
+func (s *WebhookService) ProcessDue(ctx context.Context) (int, error) {
+	attempted := 0
+	for {
+		// Leave time for every attempt of the batch before other workers may claim it again
+		lease := time.Duration(claimBatch) * (s.policy.Timeout + time.Second)
+		deliveries, err := s.repo.ClaimDueDeliveries(s.now(), lease, claimBatch)
+		if err != nil || len(deliveries) == 0 {
+			return attempted, err
+		}
+
+		for i := range deliveries {
+			if ctx.Err() != nil {
+				return attempted, ctx.Err()
+			}
+
+			webhook, err := s.repo.GetWebhook(deliveries[i].WebhookID)
+			if err == ErrWebhookNotFound {
+				continue
+			}
+			if err != nil {
+				return attempted, err
+			}
+
+			if err := s.attempt(ctx, webhook, &deliveries[i]); err != nil {
+				return attempted, err
+			}
+			attempted++
+		}
+
+		if len(deliveries) < claimBatch {
+			return attempted, nil
+		}
+	}
+}
+
+// attempt posts a delivery to its webhook and records the outcome on both
+func (s *WebhookService) attempt(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) error {
+	status, sendErr := s.send(ctx, webhook, delivery)
+	attemptedAt := s.now()
+
+	delivery.Attempts++
+	delivery.ResponseStatus = nil
+	if status != 0 {
+		delivery.ResponseStatus = &status
+	}
+
+	succeeded := sendErr == nil
+	switch {
+	case succeeded:
+		delivery.Status = models.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &attemptedAt
+	case delivery.Attempts >= s.policy.MaxAttempts:
+		delivery.Status = models.DeliveryFailed
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = nil
+	default:
+		delivery.LastError = sendErr.Error()
+		nextAttemptAt := attemptedAt.Add(s.retryDelay(delivery.Attempts))
+		delivery.NextAttemptAt = &nextAttemptAt
+	}
+
+	if err := s.repo.SaveDelivery(*delivery); err != nil {
+		return err
+	}
+
+	disabled, err := s.repo.RecordAttempt(webhook.ID, succeeded, s.policy.DisableAfter)
+	if err != nil {
+		return err
+	}
+	if disabled {
+		log.Printf("webhooks: disabled webhook %d after %d consecutive failures", webhook.ID, s.policy.DisableAfter)
+	}
+
+	return nil
+}
+
+// send posts the payload and returns the response status. Anything but a 2xx
+// response is an error, redirects included.
+func (s *WebhookService) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.policy.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "candidate-backend-webhooks")
+	req.Header.Set("X-Webhook-Event", string(delivery.Event))
+	req.Header.Set("X-Webhook-Delivery", strconv.Itoa(delivery.ID))
+	req.Header.Set(SignatureHeader, SignPayload(webhook.Secret, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// retryDelay returns the wait after the given number of failed attempts
+func (s *WebhookService) retryDelay(attempts int) time.Duration {
+	delay := s.policy.BaseDelay
+	for i := 1; i < attempts && delay < s.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.policy.MaxDelay {
+		delay = s.policy.MaxDelay
+	}
+	return delay
+}
+
+// notify wakes Run without blocking when it is already awake
+func (s *WebhookService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *WebhookService) ownWebhook(webhookID string, userID int) (*models.Webhook, error) {
+	id, err := strconv.Atoi(webhookID)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	webhook, err := s.repo.GetWebhook(id)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.UserID != userID {
+		return nil, ErrNotWebhookOwner
+	}
+
+	return webhook, nil
+}
+
+func randomHex(size int) (string, error) {
+	random := make([]byte, size)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(random), nil
+}
@@ -0,0 +1,390 @@
+package services
+
+import (
+	"candidate-backend/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver records the deliveries posted to it and answers with status
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	bodies   [][]byte
+	requests []*http.Request
+}
+
+func newReceiver(t *testing.T) (*receiver, *httptest.Server) {
+	r := &receiver{status: http.StatusOK}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.bodies = append(r.bodies, body)
+		r.requests = append(r.requests, req)
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(server.Close)
+	return r, server
+}
+
+func (r *receiver) setStatus(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+func (r *receiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.bodies)
+}
+
+// newWebhookService returns a service on a MemoryStore whose clock only moves with advance
+func newWebhookService(policy WebhookPolicy) (*WebhookService, *MemoryStore, func(time.Duration)) {
+	store := NewMemoryStore()
+	service := NewWebhookService(store, policy)
+	clock := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return clock }
+	return service, store, func(d time.Duration) { clock = clock.Add(d) }
+}
+
+var testPolicy = WebhookPolicy{
+	MaxAttempts:  3,
+	BaseDelay:    time.Minute,
+	MaxDelay:     time.Hour,
+	DisableAfter: 10,
+	Timeout:      5 * time.Second,
+	// The receivers run on localhost
+	AllowPrivateNetworks: true,
+}
+
+func subscribe(t *testing.T, service *WebhookService, url string, events ...models.WebhookEvent) *models.Webhook {
+	t.Helper()
+	webhook, err := service.CreateWebhook(models.CreateWebhookRequest{
+		URL:    url,
+		Secret: "0123456789abcdef",
+		Events: events,
+	}, 1)
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+	return webhook
+}
+
+// domainEvent returns an event as the outbox dispatcher passes it to subscribers
+func domainEvent(id int64, eventType models.EventType, payload string) models.DomainEvent {
+	return models.DomainEvent{ID: id, Type: eventType, ActorID: 1, Payload: json.RawMessage(payload), CreatedAt: now()}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	recv, server := newReceiver(t)
+	service, _, _ := newWebhookService(testPolicy)
+	webhook := subscribe(t, service, server.URL, models.WebhookTaskCreated)
+	subscribe(t, service, server.URL, models.WebhookCommentCreated)
+
+	if err := service.HandleEvent(context.Background(), domainEvent(1, models.EventTaskDeleted, `{}`)); err != nil {
+		t.Fatalf("HandleEvent() error = %v", err)
+	}
+	if err := service.HandleEvent(context.Background(), domainEvent(2, models.EventTaskCreated, `{"id": 7}`)); err != nil {
+		t.Fatalf("HandleEvent() error = %v", err)
+	}
+	if attempted, err := service.ProcessDue(context.Background()); err != nil || attempted != 1 {
+		t.Fatalf("ProcessDue() = %d, %v, want 1 delivery to the subscribed webhook", attempted, err)
+	}
+
+	req, body := recv.requests[0], recv.bodies[0]
+	if got, want := req.Header.Get(SignatureHeader), SignPayload("0123456789abcdef", body); got != want {
+		t.Errorf("X-Signature = %q, want %q", got, want)
+	}
+	if got := req.Header.Get("X-Webhook-Event"); got != "task.created" {
+		t.Errorf("X-Webhook-Event = %q, want task.created", got)
+	}
+
+	var payload struct {
+		ID    string             `json:"id"`
+		Event string             `json:"event"`
+		Data  map[string]float64 `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.ID != "2" || payload.Data["id"] != 7 {
+		t.Errorf("payload = %s, want the event with its ID and data", body)
+	}
+
+	deliveries, _ := service.GetDeliveries(strconv.Itoa(webhook.ID), 1, 10, 0)
+	if len(deliveries) != 1 || deliveries[0].Status != models.DeliverySucceeded || *deliveries[0].ResponseStatus != 200 {
+		t.Errorf("deliveries = %+v, want one succeeded delivery", deliveries)
+	}
+}
+
+func TestWebhookRetry(t *testing.T) {
+	recv, server := newReceiver(t)
+	recv.setStatus(http.StatusInternalServerError)
+	service, _, advance := newWebhookService(testPolicy)
+	webhook := subscribe(t, service, server.URL, models.WebhookTaskUpdated)
+	id := strconv.Itoa(webhook.ID)
+
+	_ = service.HandleEvent(context.Background(), domainEvent(1, models.EventTaskUpdated, `{}`))
+
+	steps := []struct {
+		name          string
+		advance       time.Duration
+		wantAttempted int
+		wantStatus    models.DeliveryStatus
+	}{
+		{"First attempt fails", 0, 1, models.DeliveryPending},
+		{"Retry is not due yet", 59 * time.Second, 0, models.DeliveryPending},
+		{"Retry after the base delay", time.Second, 1, models.DeliveryPending},
+		{"Delay doubles", time.Minute, 0, models.DeliveryPending},
+		{"Last attempt fails the delivery", time.Minute, 1, models.DeliveryFailed},
+		{"Failed deliveries are not retried", time.Hour, 0, models.DeliveryFailed},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			advance(step.advance)
+			attempted, err := service.ProcessDue(context.Background())
+			if err != nil || attempted != step.wantAttempted {
+				t.Errorf("ProcessDue() = %d, %v, want %d", attempted, err, step.wantAttempted)
+			}
+
+			deliveries, _ := service.GetDeliveries(id, 1, 10, 0)
+			if deliveries[0].Status != step.wantStatus {
+				t.Errorf("status = %s, want %s", deliveries[0].Status, step.wantStatus)
+			}
+		})
+	}
+
+	if recv.count() != 3 {
+		t.Errorf("receiver got %d requests, want %d", recv.count(), 3)
+	}
+}
+
+func TestWebhookRefusesPrivateTargets(t *testing.T) {
+	recv, server := newReceiver(t)
+	policy := testPolicy
+	policy.AllowPrivateNetworks = false
+	service, _, _ := newWebhookService(policy)
+	webhook := subscribe(t, service, server.URL, models.WebhookTaskCreated)
+
+	_ = service.HandleEvent(context.Background(), domainEvent(1, models.EventTaskCreated, `{}`))
+	if attempted, err := service.ProcessDue(context.Background()); err != nil || attempted != 1 {
+		t.Fatalf("ProcessDue() = %d, %v, want 1", attempted, err)
+	}
+
+	if recv.count() != 0 {
+		t.Errorf("receiver on loopback got %d requests, want none", recv.count())
+	}
+	deliveries, _ := service.GetDeliveries(strconv.Itoa(webhook.ID), 1, 10, 0)
+	if !strings.Contains(deliveries[0].LastError, "not a public address") {
+		t.Errorf("last error = %q, want the loopback target refused", deliveries[0].LastError)
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"0.1.2.3", false},
+		{"10.1.2.3", false},
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"127.0.0.1", false},
+		{"169.254.169.254", false},
+		{"172.16.0.1", false},
+		{"172.31.255.254", false},
+		{"192.0.0.1", false},
+		{"192.0.2.1", false},
+		{"192.168.1.1", false},
+		{"198.18.0.1", false},
+		{"198.19.255.254", false},
+		{"198.51.100.1", false},
+		{"203.0.113.1", false},
+		{"224.0.0.1", false},
+		{"240.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::", false},
+		{"::1", false},
+		{"64:ff9b::a00:1", false},
+		{"64:ff9b:1::1", false},
+		{"100::1", false},
+		{"2001:db8::1", false},
+		{"2002:a00:1::1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"fe80::1%eth0", false},
+		{"ff02::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"::ffff:100.64.0.1", false},
+
+		{"8.8.8.8", true},
+		{"100.63.255.255", true},
+		{"100.128.0.1", true},
+		{"172.32.0.1", true},
+		{"198.20.0.1", true},
+		{"223.255.255.254", true},
+		{"2606:4700::1111", true},
+		{"::ffff:8.8.8.8", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := isPublicIP(netip.MustParseAddr(tt.ip)); got != tt.public {
+				t.Errorf("isPublicIP(%s) = %v, want %v", tt.ip, got, tt.public)
+			}
+		})
+	}
+}
+
+func TestWebhookDoesNotFollowRedirects(t *testing.T) {
+	recv, target := newReceiver(t)
+	redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	t.Cleanup(redirect.Close)
+	service, _, _ := newWebhookService(testPolicy)
+	webhook := subscribe(t, service, redirect.URL, models.WebhookTaskCreated)
+
+	_ = service.HandleEvent(context.Background(), domainEvent(1, models.EventTaskCreated, `{}`))
+	if _, err := service.ProcessDue(context.Background()); err != nil {
+		t.Fatalf("ProcessDue() error = %v", err)
+	}
+
+	if recv.count() != 0 {
+		t.Errorf("redirect target got %d requests, want none", recv.count())
+	}
+	deliveries, _ := service.GetDeliveries(strconv.Itoa(webhook.ID), 1, 10, 0)
+	if status := deliveries[0].ResponseStatus; status == nil || *status != http.StatusTemporaryRedirect {
+		t.Errorf("response status = %v, want the redirect as a failed attempt", status)
+	}
+}
+
+func TestWebhookDisabledAfterFailures(t *testing.T) {
+	recv, server := newReceiver(t)
+	recv.setStatus(http.StatusBadGateway)
+	policy := testPolicy
+	policy.DisableAfter = 2
+	service, _, advance := newWebhookService(policy)
+	webhook := subscribe(t, service, server.URL, models.WebhookTaskArchived)
+	id := strconv.Itoa(webhook.ID)
+
+	_ = service.HandleEvent(context.Background(), domainEvent(1, models.EventTaskArchived, `{}`))
+	_, _ = service.ProcessDue(context.Background())
+	advance(time.Minute)
+	_, _ = service.ProcessDue(context.Background())
+
+	disabled, _ := service.GetWebhook(id, 1)
+	if disabled.Active || disabled.DisabledAt == nil || disabled.FailureCount != 2 {
+		t.Fatalf("webhook = %+v, want it disabled after 2 failures", disabled)
+	}
+
+	// Disabled webhooks get no new deliveries and their retries wait
+	_ = service.HandleEvent(context.Background(), domainEvent(2, models.EventTaskArchived, `{}`))
+	advance(time.Hour)
+	if attempted, _ := service.ProcessDue(context.Background()); attempted != 0 {
+		t.Errorf("ProcessDue() = %d for a disabled webhook, want 0", attempted)
+	}
+
+	// A manual replay still reaches the receiver
+	recv.setStatus(http.StatusOK)
+	deliveries, _ := service.GetDeliveries(id, 1, 10, 0)
+	replayed, err := service.ReplayDelivery(context.Background(), id, strconv.Itoa(deliveries[0].ID), 1)
+	if err != nil || replayed.Status != models.DeliverySucceeded || replayed.ID == deliveries[0].ID {
+		t.Fatalf("ReplayDelivery() = %+v, %v, want a new succeeded delivery", replayed, err)
+	}
+
+	// Re-enabling resumes the pending retry
+	active := true
+	enabled, err := service.UpdateWebhook(id, models.UpdateWebhookRequest{Active: &active}, 1)
+	if err != nil || !enabled.Active || enabled.FailureCount != 0 || enabled.DisabledAt != nil {
+		t.Fatalf("UpdateWebhook() = %+v, %v, want an active webhook", enabled, err)
+	}
+	if attempted, _ := service.ProcessDue(context.Background()); attempted != 1 {
+		t.Errorf("ProcessDue() = %d after re-enabling, want 1", attempted)
+	}
+}
+
+func TestWebhookOwnership(t *testing.T) {
+	service, _, _ := newWebhookService(testPolicy)
+	webhook := subscribe(t, service, "https://example.com/hooks", models.WebhookTaskCreated)
+	id := strconv.Itoa(webhook.ID)
+
+	if webhook.Secret == "" {
+		t.Errorf("CreateWebhook() secret is empty, want it returned once")
+	}
+	if got, _ := service.GetWebhook(id, 1); got.Secret != "" {
+		t.Errorf("GetWebhook() secret = %q, want it hidden", got.Secret)
+	}
+
+	generated, _ := service.CreateWebhook(models.CreateWebhookRequest{
+		URL: "https://example.com/hooks", Events: []models.WebhookEvent{models.WebhookTaskCreated},
+	}, 1)
+	if len(generated.Secret) != 64 {
+		t.Errorf("generated secret = %q, want 32 random bytes in hex", generated.Secret)
+	}
+
+	tests := []struct {
+		name string
+		fn   func() error
+		want error
+	}{
+		{"Get by another user", func() error {
+			_, err := service.GetWebhook(id, 2)
+			return err
+		}, ErrNotWebhookOwner},
+		{"Delete by another user", func() error {
+			return service.DeleteWebhook(id, 2)
+		}, ErrNotWebhookOwner},
+		{"Deliveries of another user", func() error {
+			_, err := service.GetDeliveries(id, 2, 10, 0)
+			return err
+		}, ErrNotWebhookOwner},
+		{"Unknown webhook", func() error {
+			_, err := service.GetWebhook("99", 1)
+			return err
+		}, ErrWebhookNotFound},
+		{"Replay of an unknown delivery", func() error {
+			_, err := service.ReplayDelivery(context.Background(), id, "99", 1)
+			return err
+		}, ErrDeliveryNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.fn(); !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	service := NewWebhookService(NewMemoryStore(), WebhookPolicy{BaseDelay: 30 * time.Second, MaxDelay: 5 * time.Minute})
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{5, 5 * time.Minute},
+		{20, 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := service.retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalStorage keeps objects on the local filesystem. Signed URLs point back at
+// the API, which serves the file after checking the HMAC signature.
+type LocalStorage struct {
+	root       string
+	baseURL    string
+	signingKey []byte
+	now        func() time.Time
+}
+
+// NewLocalStorage creates a filesystem backend rooted at root. baseURL is the
+// public URL of the file download route, e.g. http://localhost:8080/files.
+func NewLocalStorage(root, baseURL, signingKey string) (*LocalStorage, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, err
+	}
+
+	return &LocalStorage{
+		root:       root,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		signingKey: []byte(signingKey),
+		now:        time.Now,
+	}, nil
+}
+
+// Put writes the object to a temporary file and renames it into place
+func (s *LocalStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, io.LimitReader(body, size+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if written != size {
+		return fmt.Errorf("expected %d bytes, got %d", size, written)
+	}
+
+	return os.Rename(tmp.Name(), target)
+}
+
+// Get opens the object for reading
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	file, err := s.Open(key)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Open opens the object as a file so it can be served with range support
+func (s *LocalStorage) Open(key string) (*os.File, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(target)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Delete removes the object
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(target)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// SignedURL returns a download URL carrying an expiry and an HMAC signature
+func (s *LocalStorage) SignedURL(ctx context.Context, key string, opts URLOptions) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+
+	expires := strconv.FormatInt(s.now().Add(opts.TTL).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	if opts.Filename != "" {
+		query.Set("filename", opts.Filename)
+	}
+	if opts.ContentType != "" {
+		query.Set("content_type", opts.ContentType)
+	}
+	query.Set("signature", s.sign(key, expires, opts.Filename, opts.ContentType))
+
+	return s.baseURL + "/" + (&url.URL{Path: key}).EscapedPath() + "?" + query.Encode(), nil
+}
+
+// Verify checks a signed URL's parameters and returns the download options it was signed with
+func (s *LocalStorage) Verify(key string, query url.Values) (URLOptions, error) {
+	opts := URLOptions{
+		Filename:    query.Get("filename"),
+		ContentType: query.Get("content_type"),
+	}
+
+	expires := query.Get("expires")
+	expected := s.sign(key, expires, opts.Filename, opts.ContentType)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("signature"))) {
+		return opts, errors.New("invalid signature")
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || s.now().Unix() > expiresAt {
+		return opts, errors.New("link has expired")
+	}
+
+	return opts, nil
+}
+
+func (s *LocalStorage) sign(key, expires, filename, contentType string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(strings.Join([]string{key, expires, filename, contentType}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path maps a key to a file below the storage root, rejecting keys that would escape it
+func (s *LocalStorage) path(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if key == "" || cleaned != "/"+key {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
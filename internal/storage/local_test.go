@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalStorageRoundTrip(t *testing.T) {
+	local, err := NewLocalStorage(t.TempDir(), "http://localhost:8080/files", "secret")
+	if err != nil {
+		t.Fatalf("NewLocalStorage() error = %v", err)
+	}
+
+	ctx := context.Background()
+	content := []byte("\x89PNG fake image")
+
+	if err := local.Put(ctx, "tasks/1/abc.png", bytes.NewReader(content), int64(len(content)), "image/png"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	body, err := local.Get(ctx, "tasks/1/abc.png")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	got, _ := io.ReadAll(body)
+	body.Close()
+	if !bytes.Equal(got, content) {
+		t.Errorf("Get() = %q, want %q", got, content)
+	}
+
+	if err := local.Put(ctx, "tasks/1/short.png", bytes.NewReader(content), int64(len(content))+1, "image/png"); err == nil {
+		t.Error("Put() with a size mismatch should fail")
+	}
+
+	if err := local.Delete(ctx, "tasks/1/abc.png"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := local.Get(ctx, "tasks/1/abc.png"); err != ErrNotFound {
+		t.Errorf("Get() after delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocalStorageRejectsEscapingKeys(t *testing.T) {
+	local, err := NewLocalStorage(t.TempDir(), "http://localhost:8080/files", "secret")
+	if err != nil {
+		t.Fatalf("NewLocalStorage() error = %v", err)
+	}
+
+	for _, key := range []string{"", "../etc/passwd", "tasks/../../x", "/abs", "tasks//x"} {
+		if err := local.Put(context.Background(), key, strings.NewReader("x"), 1, ""); err == nil {
+			t.Errorf("Put(%q) should fail", key)
+		}
+	}
+}
+
+func TestLocalStorageSignedURL(t *testing.T) {
+	local, err := NewLocalStorage(t.TempDir(), "http://localhost:8080/files/", "secret")
+	if err != nil {
+		t.Fatalf("NewLocalStorage() error = %v", err)
+	}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	local.now = func() time.Time { return now }
+
+	signed, err := local.SignedURL(context.Background(), "tasks/1/abc.png", URLOptions{
+		TTL: 5 * time.Minute, Filename: "screen shot.png", ContentType: "image/png",
+	})
+	if err != nil {
+		t.Fatalf("SignedURL() error = %v", err)
+	}
+
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	if u.Path != "/files/tasks/1/abc.png" {
+		t.Errorf("path = %q, want /files/tasks/1/abc.png", u.Path)
+	}
+
+	opts, err := local.Verify("tasks/1/abc.png", u.Query())
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if opts.Filename != "screen shot.png" || opts.ContentType != "image/png" {
+		t.Errorf("Verify() options = %+v", opts)
+	}
+
+	if _, err := local.Verify("tasks/2/abc.png", u.Query()); err == nil {
+		t.Error("Verify() should reject a URL signed for another key")
+	}
+
+	tampered := u.Query()
+	tampered.Set("content_type", "text/html")
+	if _, err := local.Verify("tasks/1/abc.png", tampered); err == nil {
+		t.Error("Verify() should reject tampered parameters")
+	}
+
+	now = now.Add(6 * time.Minute)
+	if _, err := local.Verify("tasks/1/abc.png", u.Query()); err == nil {
+		t.Error("Verify() should reject an expired URL")
+	}
+}
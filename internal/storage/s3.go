@@ -0,0 +1,332 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm      = "AWS4-HMAC-SHA256"
+	s3Service        = "s3"
+	s3UnsignedBody   = "UNSIGNED-PAYLOAD"
+	s3AmzDateLayout  = "20060102T150405Z"
+	s3DateLayout     = "20060102"
+	s3MaxPresignTime = 7 * 24 * time.Hour
+)
+
+// S3Config configures an S3-compatible backend such as AWS S3 or MinIO
+type S3Config struct {
+	// Endpoint is the service URL, e.g. https://s3.us-east-1.amazonaws.com or http://minio:9000.
+	// It defaults to the AWS endpoint of the region.
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// UsePathStyle addresses objects as /bucket/key instead of bucket.host/key (MinIO needs this)
+	UsePathStyle bool
+}
+
+// S3Storage stores objects in an S3-compatible bucket using Signature Version 4
+type S3Storage struct {
+	endpoint *url.URL
+	config   S3Config
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3Storage creates an S3-compatible backend
+func NewS3Storage(config S3Config) (*S3Storage, error) {
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("S3 bucket is required")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("S3 credentials are required")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", config.Region)
+	}
+
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", config.Endpoint)
+	}
+
+	return &S3Storage{
+		endpoint: endpoint,
+		config:   config,
+		client:   &http.Client{Timeout: 5 * time.Minute},
+		now:      time.Now,
+	}, nil
+}
+
+// Put uploads the object with a single PUT request
+func (s *S3Storage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// Get downloads the object
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// Delete removes the object
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// SignedURL returns a presigned GET URL for the object
+func (s *S3Storage) SignedURL(ctx context.Context, key string, opts URLOptions) (string, error) {
+	if opts.TTL <= 0 || opts.TTL > s3MaxPresignTime {
+		return "", fmt.Errorf("signed URL lifetime must be between 1s and %s", s3MaxPresignTime)
+	}
+
+	extra := url.Values{}
+	if opts.Filename != "" {
+		extra.Set("response-content-disposition", ContentDisposition(opts.Filename))
+	}
+	if opts.ContentType != "" {
+		extra.Set("response-content-type", opts.ContentType)
+	}
+
+	return s.presign(http.MethodGet, key, opts.TTL, extra, s.now()), nil
+}
+
+// objectURL returns the URL of an object, honouring the addressing style
+func (s *S3Storage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	basePath := strings.TrimRight(u.Path, "/")
+
+	if s.config.UsePathStyle {
+		u.Path = basePath + "/" + s.config.Bucket + "/" + key
+	} else {
+		u.Host = s.config.Bucket + "." + u.Host
+		u.Path = basePath + "/" + key
+	}
+	u.RawPath = s3Escape(u.Path, false)
+	u.RawQuery = ""
+
+	return &u
+}
+
+func (s *S3Storage) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	s.signRequest(req, s.now())
+	return req, nil
+}
+
+// do sends a signed request and turns error responses into errors
+func (s *S3Storage) do(req *http.Request) (*http.Response, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf("s3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(detail)))
+}
+
+// signRequest adds SigV4 authorization headers. The payload is sent unsigned so
+// uploads can be streamed without hashing the body first.
+func (s *S3Storage) signRequest(req *http.Request, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(s3AmzDateLayout)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedBody)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": s3UnsignedBody,
+		"x-amz-date":           amzDate,
+	}
+	canonicalHeaders, signedHeaders := s3CanonicalHeaders(headers)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		s3UnsignedBody,
+	}, "\n")
+
+	scope := s.scope(now)
+	signature := s.signature(now, amzDate, scope, canonicalRequest)
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// presign builds a query-string authenticated URL
+func (s *S3Storage) presign(method, key string, ttl time.Duration, extra url.Values, now time.Time) string {
+	now = now.UTC()
+	amzDate := now.Format(s3AmzDateLayout)
+	scope := s.scope(now)
+	u := s.objectURL(key)
+
+	query := url.Values{}
+	for name, values := range extra {
+		query[name] = values
+	}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", s.config.AccessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalQuery := s3CanonicalQuery(query)
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		canonicalQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		s3UnsignedBody,
+	}, "\n")
+
+	u.RawQuery = canonicalQuery + "&X-Amz-Signature=" + s.signature(now, amzDate, scope, canonicalRequest)
+	return u.String()
+}
+
+func (s *S3Storage) scope(now time.Time) string {
+	return strings.Join([]string{now.Format(s3DateLayout), s.config.Region, s3Service, "aws4_request"}, "/")
+}
+
+func (s *S3Storage) signature(now time.Time, amzDate, scope, canonicalRequest string) string {
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{s3Algorithm, amzDate, scope, hex.EncodeToString(hashed[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), now.Format(s3DateLayout))
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3CanonicalHeaders returns the canonical header block and the signed header list
+func s3CanonicalHeaders(headers map[string]string) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+
+	return canonical.String(), strings.Join(names, ";")
+}
+
+// s3CanonicalQuery encodes query parameters sorted by name using SigV4 escaping
+func s3CanonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var pairs []string
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, s3Escape(name, true)+"="+s3Escape(value, true))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// s3Escape percent-encodes everything except RFC 3986 unreserved characters,
+// optionally keeping slashes as path separators
+func s3Escape(value string, encodeSlash bool) string {
+	var escaped strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			escaped.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			escaped.WriteByte(c)
+		default:
+			fmt.Fprintf(&escaped, "%%%02X", c)
+		}
+	}
+	return escaped.String()
+}
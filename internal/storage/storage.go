@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"time"
+)
+
+// ErrNotFound is returned when an object does not exist in the backend
+var ErrNotFound = errors.New("object not found")
+
+// URLOptions controls how a signed download URL is generated
+type URLOptions struct {
+	// TTL is how long the URL stays valid
+	TTL time.Duration
+	// Filename is sent back in the Content-Disposition header when set
+	Filename string
+	// ContentType overrides the Content-Type of the download when set
+	ContentType string
+}
+
+// Storage stores attachment blobs and hands out short-lived download URLs
+type Storage interface {
+	// Put stores size bytes read from body under key
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that downloads the object without further authentication until it expires
+	SignedURL(ctx context.Context, key string, opts URLOptions) (string, error)
+}
+
+// ContentDisposition builds an attachment Content-Disposition value for a filename
+func ContentDisposition(filename string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+}
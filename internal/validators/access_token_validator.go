@@ -0,0 +1,53 @@
+package validators
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/models"
+	"slices"
+	"strings"
+	"time"
+)
+
+type AccessTokenValidator struct{}
+
+func NewAccessTokenValidator() *AccessTokenValidator {
+	return &AccessTokenValidator{}
+}
+
+// ValidateCreateAccessToken validates access token creation request made at the
+// given time
+func (v *AccessTokenValidator) ValidateCreateAccessToken(req *models.CreateAccessTokenRequest, at time.Time) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return apperrors.Invalid("name", "name is required")
+	}
+	if len(name) > 100 {
+		return apperrors.Invalid("name", "name must be less than 100 characters")
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(at) {
+		return apperrors.Invalid("expires_at", "expires_at must be in the future")
+	}
+
+	return v.ValidateScopes(req.Scopes)
+}
+
+// ValidateScopes validates that scopes is a non-empty list of known scopes without duplicates
+func (v *AccessTokenValidator) ValidateScopes(scopes []models.TokenScope) error {
+	if len(scopes) == 0 {
+		return apperrors.Invalid("scopes", "at least one scope is required")
+	}
+
+	seen := make(map[models.TokenScope]bool, len(scopes))
+	for _, scope := range scopes {
+		if !slices.Contains(models.TokenScopes, scope) {
+			return apperrors.Invalidf("scopes", "unknown scope '%s'", scope)
+		}
+		if seen[scope] {
+			return apperrors.Invalidf("scopes", "duplicate scope '%s'", scope)
+		}
+		seen[scope] = true
+	}
+
+	return nil
+}
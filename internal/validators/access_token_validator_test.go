@@ -0,0 +1,40 @@
+package validators
+
+import (
+	"candidate-backend/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateCreateAccessToken(t *testing.T) {
+	validator := NewAccessTokenValidator()
+	at := time.Now()
+	past, future := at.Add(-time.Hour), at.Add(time.Hour)
+	scopes := []models.TokenScope{models.ScopeTasksRead}
+
+	tests := []struct {
+		name    string
+		req     models.CreateAccessTokenRequest
+		wantErr bool
+	}{
+		{"Valid", models.CreateAccessTokenRequest{Name: "CI", Scopes: scopes}, false},
+		{"Valid with expiry", models.CreateAccessTokenRequest{Name: "CI", Scopes: scopes, ExpiresAt: &future}, false},
+		{"Every scope", models.CreateAccessTokenRequest{Name: "CI", Scopes: models.TokenScopes}, false},
+		{"Blank name", models.CreateAccessTokenRequest{Name: "  ", Scopes: scopes}, true},
+		{"Long name", models.CreateAccessTokenRequest{Name: strings.Repeat("a", 101), Scopes: scopes}, true},
+		{"Expired", models.CreateAccessTokenRequest{Name: "CI", Scopes: scopes, ExpiresAt: &past}, true},
+		{"No scopes", models.CreateAccessTokenRequest{Name: "CI"}, true},
+		{"Unknown scope", models.CreateAccessTokenRequest{Name: "CI", Scopes: []models.TokenScope{"tasks:delete"}}, true},
+		{"Duplicate scope", models.CreateAccessTokenRequest{Name: "CI", Scopes: []models.TokenScope{models.ScopeComments, models.ScopeComments}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateCreateAccessToken(&tt.req, at)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreateAccessToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
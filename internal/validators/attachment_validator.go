@@ -0,0 +1,83 @@
+package validators
+
+import (
+	"candidate-backend/internal/apperrors"
+	"mime"
+	"strings"
+	"unicode"
+)
+
+// AllowedAttachmentTypes lists the sniffed media types accepted for uploads
+var AllowedAttachmentTypes = map[string]bool{
+	"image/png":          true,
+	"image/jpeg":         true,
+	"image/gif":          true,
+	"image/webp":         true,
+	"application/pdf":    true,
+	"text/plain":         true,
+	"application/zip":    true,
+	"application/x-gzip": true,
+	"video/mp4":          true,
+	"video/webm":         true,
+}
+
+type AttachmentValidator struct {
+	maxSize int64
+}
+
+func NewAttachmentValidator(maxSize int64) *AttachmentValidator {
+	return &AttachmentValidator{maxSize: maxSize}
+}
+
+// ValidateUpload validates an uploaded file's name, size and sniffed content type
+func (v *AttachmentValidator) ValidateUpload(filename string, size int64, contentType string) error {
+	if err := v.ValidateFilename(filename); err != nil {
+		return err
+	}
+
+	if err := v.ValidateSize(size); err != nil {
+		return err
+	}
+
+	return v.ValidateContentType(contentType)
+}
+
+// ValidateFilename validates the original file name
+func (v *AttachmentValidator) ValidateFilename(filename string) error {
+	if strings.TrimSpace(filename) == "" {
+		return apperrors.Invalid("filename", "filename is required")
+	}
+
+	if len(filename) > 255 {
+		return apperrors.Invalid("filename", "filename must be less than 255 characters")
+	}
+
+	if strings.ContainsAny(filename, `/\`) || strings.ContainsFunc(filename, unicode.IsControl) {
+		return apperrors.Invalid("filename", "filename contains invalid characters")
+	}
+
+	return nil
+}
+
+// ValidateSize validates the file size against the configured limit
+func (v *AttachmentValidator) ValidateSize(size int64) error {
+	if size <= 0 {
+		return apperrors.Invalid("file", "file is empty")
+	}
+
+	if size > v.maxSize {
+		return apperrors.Invalidf("file", "file must be at most %d bytes", v.maxSize)
+	}
+
+	return nil
+}
+
+// ValidateContentType validates a sniffed content type such as "text/plain; charset=utf-8"
+func (v *AttachmentValidator) ValidateContentType(contentType string) error {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !AllowedAttachmentTypes[mediaType] {
+		return apperrors.Invalidf("file", "file type %s is not allowed", contentType)
+	}
+
+	return nil
+}
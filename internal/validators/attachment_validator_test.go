@@ -0,0 +1,34 @@
+package validators
+
+import "testing"
+
+func TestValidateUpload(t *testing.T) {
+	validator := NewAttachmentValidator(1024)
+
+	tests := []struct {
+		name        string
+		filename    string
+		size        int64
+		contentType string
+		wantErr     bool
+	}{
+		{"Valid image", "screenshot.png", 512, "image/png", false},
+		{"Valid log with charset", "server.log", 1024, "text/plain; charset=utf-8", false},
+		{"Empty filename", "  ", 512, "image/png", true},
+		{"Filename with path", "../secret.png", 512, "image/png", true},
+		{"Filename with control character", "a\nb.png", 512, "image/png", true},
+		{"Empty file", "empty.txt", 0, "text/plain; charset=utf-8", true},
+		{"Too large", "big.png", 1025, "image/png", true},
+		{"HTML not allowed", "page.html", 512, "text/html; charset=utf-8", true},
+		{"Unknown binary not allowed", "tool.exe", 512, "application/octet-stream", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateUpload(tt.filename, tt.size, tt.contentType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateUpload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,11 +1,14 @@
 package validators
 
 import (
+	"candidate-backend/internal/apperrors"
 	"candidate-backend/internal/models"
-	"errors"
 	"strings"
 )
 
+// MaxCommentDepth is the deepest reply level; top-level comments have depth 0
+const MaxCommentDepth = 3
+
 type CommentValidator struct{}
 
 func NewCommentValidator() *CommentValidator {
@@ -15,11 +18,11 @@ func NewCommentValidator() *CommentValidator {
 // ValidateCreateComment validates comment creation request
 func (v *CommentValidator) ValidateCreateComment(req *models.CreateCommentRequest) error {
 	if strings.TrimSpace(req.Content) == "" {
-		return errors.New("comment content is required")
+		return apperrors.Invalid("content", "comment content is required")
 	}
 
 	if len(req.Content) > 5000 {
-		return errors.New("comment must be less than 5000 characters")
+		return apperrors.Invalid("content", "comment must be less than 5000 characters")
 	}
 
 	return nil
@@ -28,11 +31,33 @@ func (v *CommentValidator) ValidateCreateComment(req *models.CreateCommentReques
 // ValidateUpdateComment validates comment update request
 func (v *CommentValidator) ValidateUpdateComment(req *models.UpdateCommentRequest) error {
 	if strings.TrimSpace(req.Content) == "" {
-		return errors.New("comment content is required")
+		return apperrors.Invalid("content", "comment content is required")
 	}
 
 	if len(req.Content) > 5000 {
-		return errors.New("comment must be less than 5000 characters")
+		return apperrors.Invalid("content", "comment must be less than 5000 characters")
+	}
+
+	return nil
+}
+
+// ValidateCommentFilter validates comment listing parameters
+func (v *CommentValidator) ValidateCommentFilter(filter *models.CommentFilter) error {
+	if filter.Limit < 1 || filter.Limit > 100 {
+		return apperrors.Invalid("limit", "limit must be between 1 and 100")
+	}
+
+	if filter.Order != "asc" && filter.Order != "desc" {
+		return apperrors.Invalid("order", "order must be 'asc' or 'desc'")
+	}
+
+	return nil
+}
+
+// ValidateReplyDepth validates that a reply to a comment at parentDepth stays within MaxCommentDepth
+func (v *CommentValidator) ValidateReplyDepth(parentDepth int) error {
+	if parentDepth+1 > MaxCommentDepth {
+		return apperrors.Invalidf("parent_id", "replies can only be nested %d levels deep", MaxCommentDepth)
 	}
 
 	return nil
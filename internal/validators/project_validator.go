@@ -0,0 +1,150 @@
+package validators
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/models"
+	"regexp"
+	"strings"
+)
+
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+type ProjectValidator struct{}
+
+func NewProjectValidator() *ProjectValidator {
+	return &ProjectValidator{}
+}
+
+// ValidateCreateProject validates project creation request
+func (v *ProjectValidator) ValidateCreateProject(req *models.CreateProjectRequest) error {
+	return v.ValidateName(req.Name)
+}
+
+// ValidateUpdateProject validates project update request
+func (v *ProjectValidator) ValidateUpdateProject(req *models.UpdateProjectRequest) error {
+	if req.Name == nil && req.Description == nil {
+		return apperrors.Invalid("body", "no fields to update")
+	}
+
+	if req.Name != nil {
+		return v.ValidateName(*req.Name)
+	}
+
+	return nil
+}
+
+// ValidateName validates project name
+func (v *ProjectValidator) ValidateName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return apperrors.Invalid("name", "name is required")
+	}
+
+	if len(name) > 255 {
+		return apperrors.Invalid("name", "name must be less than 255 characters")
+	}
+
+	return nil
+}
+
+// ValidateCreateCustomField validates custom field definition request
+func (v *ProjectValidator) ValidateCreateCustomField(req *models.CreateCustomFieldRequest) error {
+	if !customFieldKeyPattern.MatchString(req.Key) {
+		return apperrors.Invalid("key", "key must start with a lowercase letter and contain only lowercase letters, digits and underscores (max 64)")
+	}
+
+	if err := v.ValidateFieldName(req.Name); err != nil {
+		return err
+	}
+
+	if err := v.ValidateFieldType(req.Type); err != nil {
+		return err
+	}
+
+	return v.ValidateOptions(req.Type, req.Options)
+}
+
+// ValidateUpdateCustomField validates custom field update request
+func (v *ProjectValidator) ValidateUpdateCustomField(fieldType models.CustomFieldType, req *models.UpdateCustomFieldRequest) error {
+	if req.Name == nil && req.Options == nil {
+		return apperrors.Invalid("body", "no fields to update")
+	}
+
+	if req.Name != nil {
+		if err := v.ValidateFieldName(*req.Name); err != nil {
+			return err
+		}
+	}
+
+	if req.Options != nil {
+		return v.ValidateOptions(fieldType, req.Options)
+	}
+
+	return nil
+}
+
+// ValidateFieldName validates custom field display name
+func (v *ProjectValidator) ValidateFieldName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return apperrors.Invalid("name", "field name is required")
+	}
+
+	if len(name) > 255 {
+		return apperrors.Invalid("name", "field name must be less than 255 characters")
+	}
+
+	return nil
+}
+
+// ValidateFieldType validates custom field type
+func (v *ProjectValidator) ValidateFieldType(fieldType models.CustomFieldType) error {
+	validTypes := map[models.CustomFieldType]bool{
+		models.FieldTypeText:         true,
+		models.FieldTypeNumber:       true,
+		models.FieldTypeDate:         true,
+		models.FieldTypeSingleSelect: true,
+		models.FieldTypeMultiSelect:  true,
+		models.FieldTypeUser:         true,
+	}
+
+	if !validTypes[fieldType] {
+		return apperrors.Invalid("type", "invalid type: must be 'text', 'number', 'date', 'single_select', 'multi_select', or 'user'")
+	}
+
+	return nil
+}
+
+// ValidateOptions validates select options for a field type
+func (v *ProjectValidator) ValidateOptions(fieldType models.CustomFieldType, options []string) error {
+	isSelect := fieldType == models.FieldTypeSingleSelect || fieldType == models.FieldTypeMultiSelect
+
+	if !isSelect {
+		if len(options) > 0 {
+			return apperrors.Invalid("options", "options are only allowed on select fields")
+		}
+		return nil
+	}
+
+	if len(options) == 0 {
+		return apperrors.Invalid("options", "select fields need at least one option")
+	}
+
+	if len(options) > 100 {
+		return apperrors.Invalid("options", "select fields can have at most 100 options")
+	}
+
+	seen := map[string]bool{}
+	for _, option := range options {
+		if strings.TrimSpace(option) == "" {
+			return apperrors.Invalid("options", "options cannot be empty")
+		}
+		if len(option) > 100 {
+			return apperrors.Invalid("options", "options must be less than 100 characters")
+		}
+		if seen[option] {
+			return apperrors.Invalidf("options", "duplicate option '%s'", option)
+		}
+		seen[option] = true
+	}
+
+	return nil
+}
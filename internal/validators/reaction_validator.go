@@ -0,0 +1,38 @@
+package validators
+
+import "candidate-backend/internal/apperrors"
+
+// DefaultReactionEmojis is the allow-list used when none is configured
+var DefaultReactionEmojis = []string{"+1", "-1", "laugh", "hooray", "confused", "heart", "rocket", "eyes"}
+
+type ReactionValidator struct {
+	emojis  []string
+	allowed map[string]bool
+}
+
+func NewReactionValidator(emojis []string) *ReactionValidator {
+	if len(emojis) == 0 {
+		emojis = DefaultReactionEmojis
+	}
+
+	allowed := make(map[string]bool, len(emojis))
+	for _, emoji := range emojis {
+		allowed[emoji] = true
+	}
+
+	return &ReactionValidator{emojis: emojis, allowed: allowed}
+}
+
+// Emojis returns the allowed emojis in their configured order
+func (v *ReactionValidator) Emojis() []string {
+	return v.emojis
+}
+
+// ValidateEmoji validates that an emoji is on the allow-list
+func (v *ReactionValidator) ValidateEmoji(emoji string) error {
+	if !v.allowed[emoji] {
+		return apperrors.Invalidf("emoji", "emoji '%s' is not allowed", emoji)
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package validators
+
+import "testing"
+
+func TestValidateEmoji(t *testing.T) {
+	tests := []struct {
+		name    string
+		emojis  []string
+		emoji   string
+		wantErr bool
+	}{
+		{"Default allow-list", nil, "+1", false},
+		{"Not on default allow-list", nil, "party", true},
+		{"Configured emoji", []string{"👍", "🎉"}, "🎉", false},
+		{"Default replaced by configured list", []string{"👍", "🎉"}, "+1", true},
+		{"Empty emoji", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewReactionValidator(tt.emojis).ValidateEmoji(tt.emoji)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateEmoji() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
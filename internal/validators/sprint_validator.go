@@ -0,0 +1,85 @@
+package validators
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/models"
+	"strings"
+	"time"
+)
+
+// SprintDateLayout is the date format accepted for sprint start and end dates
+const SprintDateLayout = "2006-01-02"
+
+type SprintValidator struct{}
+
+func NewSprintValidator() *SprintValidator {
+	return &SprintValidator{}
+}
+
+// ValidateCreateSprint validates sprint creation request
+func (v *SprintValidator) ValidateCreateSprint(req *models.CreateSprintRequest) error {
+	if err := v.ValidateName(req.Name); err != nil {
+		return err
+	}
+
+	return v.ValidateDates(req.StartDate, req.EndDate)
+}
+
+// ValidateUpdateSprint validates sprint update request
+func (v *SprintValidator) ValidateUpdateSprint(req *models.UpdateSprintRequest) error {
+	if req.Name == nil && req.Goal == nil && req.StartDate == nil && req.EndDate == nil {
+		return apperrors.Invalid("body", "no fields to update")
+	}
+
+	if req.Name != nil {
+		if err := v.ValidateName(*req.Name); err != nil {
+			return err
+		}
+	}
+
+	if req.StartDate != nil {
+		if _, err := time.Parse(SprintDateLayout, *req.StartDate); err != nil {
+			return apperrors.Invalid("start_date", "start_date must be in YYYY-MM-DD format")
+		}
+	}
+
+	if req.EndDate != nil {
+		if _, err := time.Parse(SprintDateLayout, *req.EndDate); err != nil {
+			return apperrors.Invalid("end_date", "end_date must be in YYYY-MM-DD format")
+		}
+	}
+
+	return nil
+}
+
+// ValidateName validates sprint name
+func (v *SprintValidator) ValidateName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return apperrors.Invalid("name", "name is required")
+	}
+
+	if len(name) > 255 {
+		return apperrors.Invalid("name", "name must be less than 255 characters")
+	}
+
+	return nil
+}
+
+// ValidateDates validates sprint start and end dates
+func (v *SprintValidator) ValidateDates(startDate, endDate string) error {
+	start, err := time.Parse(SprintDateLayout, startDate)
+	if err != nil {
+		return apperrors.Invalid("start_date", "start_date must be in YYYY-MM-DD format")
+	}
+
+	end, err := time.Parse(SprintDateLayout, endDate)
+	if err != nil {
+		return apperrors.Invalid("end_date", "end_date must be in YYYY-MM-DD format")
+	}
+
+	if end.Before(start) {
+		return apperrors.Invalid("end_date", "end_date must not be before start_date")
+	}
+
+	return nil
+}
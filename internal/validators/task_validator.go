@@ -1,9 +1,11 @@
 package validators
 
 import (
+	"candidate-backend/internal/apperrors"
 	"candidate-backend/internal/models"
-	"errors"
+	"math"
 	"strings"
+	"time"
 )
 
 type TaskValidator struct{}
@@ -15,11 +17,11 @@ func NewTaskValidator() *TaskValidator {
 // ValidateCreateTask validates task creation request
 func (v *TaskValidator) ValidateCreateTask(req *models.CreateTaskRequest) error {
 	if strings.TrimSpace(req.Title) == "" {
-		return errors.New("title is required")
+		return apperrors.Invalid("title", "title is required")
 	}
 
 	if len(req.Title) > 500 {
-		return errors.New("title must be less than 500 characters")
+		return apperrors.Invalid("title", "title must be less than 500 characters")
 	}
 
 	if req.Status != "" {
@@ -28,6 +30,12 @@ func (v *TaskValidator) ValidateCreateTask(req *models.CreateTaskRequest) error
 		}
 	}
 
+	if req.StoryPoints != nil {
+		if err := v.ValidateStoryPoints(*req.StoryPoints); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -35,10 +43,10 @@ func (v *TaskValidator) ValidateCreateTask(req *models.CreateTaskRequest) error
 func (v *TaskValidator) ValidateUpdateTask(req *models.UpdateTaskRequest) error {
 	if req.Title != nil {
 		if strings.TrimSpace(*req.Title) == "" {
-			return errors.New("title cannot be empty")
+			return apperrors.Invalid("title", "title cannot be empty")
 		}
 		if len(*req.Title) > 500 {
-			return errors.New("title must be less than 500 characters")
+			return apperrors.Invalid("title", "title must be less than 500 characters")
 		}
 	}
 
@@ -48,6 +56,16 @@ func (v *TaskValidator) ValidateUpdateTask(req *models.UpdateTaskRequest) error
 		}
 	}
 
+	if req.StoryPoints != nil {
+		if err := v.ValidateStoryPoints(*req.StoryPoints); err != nil {
+			return err
+		}
+	}
+
+	if req.SprintID != nil && *req.SprintID < 0 {
+		return apperrors.Invalid("sprint_id", "invalid sprint_id")
+	}
+
 	return nil
 }
 
@@ -60,20 +78,102 @@ func (v *TaskValidator) ValidateStatus(status models.TaskStatus) error {
 	}
 
 	if !validStatuses[status] {
-		return errors.New("invalid status: must be 'To Do', 'In Progress', or 'Done'")
+		return apperrors.Invalid("status", "invalid status: must be 'To Do', 'In Progress', or 'Done'")
 	}
 
 	return nil
 }
 
+// ValidateStoryPoints validates a story point estimate
+func (v *TaskValidator) ValidateStoryPoints(points int) error {
+	if points < 0 || points > 100 {
+		return apperrors.Invalid("story_points", "story_points must be between 0 and 100")
+	}
+
+	return nil
+}
+
+// ValidateCustomFieldValue validates a custom field value against the field definition.
+// Values arrive decoded from JSON, so numbers are float64 and lists are []interface{}.
+func (v *TaskValidator) ValidateCustomFieldValue(field models.CustomField, value interface{}) error {
+	switch field.Type {
+	case models.FieldTypeText:
+		text, ok := value.(string)
+		if !ok {
+			return apperrors.Invalidf("custom_fields."+field.Key, "%s must be a string", field.Key)
+		}
+		if len(text) > 5000 {
+			return apperrors.Invalidf("custom_fields."+field.Key, "%s must be less than 5000 characters", field.Key)
+		}
+
+	case models.FieldTypeNumber:
+		number, ok := value.(float64)
+		if !ok || math.IsNaN(number) || math.IsInf(number, 0) {
+			return apperrors.Invalidf("custom_fields."+field.Key, "%s must be a number", field.Key)
+		}
+
+	case models.FieldTypeDate:
+		date, ok := value.(string)
+		if !ok {
+			return apperrors.Invalidf("custom_fields."+field.Key, "%s must be a date string", field.Key)
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return apperrors.Invalidf("custom_fields."+field.Key, "%s must be in YYYY-MM-DD format", field.Key)
+		}
+
+	case models.FieldTypeSingleSelect:
+		option, ok := value.(string)
+		if !ok || !containsOption(field.Options, option) {
+			return apperrors.Invalidf("custom_fields."+field.Key, "%s must be one of: %s", field.Key, strings.Join(field.Options, ", "))
+		}
+
+	case models.FieldTypeMultiSelect:
+		values, ok := value.([]interface{})
+		if !ok {
+			return apperrors.Invalidf("custom_fields."+field.Key, "%s must be a list of options", field.Key)
+		}
+		seen := map[string]bool{}
+		for _, item := range values {
+			option, ok := item.(string)
+			if !ok || !containsOption(field.Options, option) {
+				return apperrors.Invalidf("custom_fields."+field.Key, "%s values must be among: %s", field.Key, strings.Join(field.Options, ", "))
+			}
+			if seen[option] {
+				return apperrors.Invalidf("custom_fields."+field.Key, "%s contains '%s' more than once", field.Key, option)
+			}
+			seen[option] = true
+		}
+
+	case models.FieldTypeUser:
+		userID, ok := value.(float64)
+		if !ok || userID < 1 || userID != math.Trunc(userID) {
+			return apperrors.Invalidf("custom_fields."+field.Key, "%s must be a user id", field.Key)
+		}
+
+	default:
+		return apperrors.Invalidf("custom_fields."+field.Key, "%s has an unsupported type", field.Key)
+	}
+
+	return nil
+}
+
+func containsOption(options []string, value string) bool {
+	for _, option := range options {
+		if option == value {
+			return true
+		}
+	}
+	return false
+}
+
 // ValidatePagination validates pagination parameters
 func (v *TaskValidator) ValidatePagination(limit, offset int) error {
 	if limit < 1 || limit > 100 {
-		return errors.New("limit must be between 1 and 100")
+		return apperrors.Invalid("limit", "limit must be between 1 and 100")
 	}
 
 	if offset < 0 {
-		return errors.New("offset must be >= 0")
+		return apperrors.Invalid("offset", "offset must be >= 0")
 	}
 
 	return nil
@@ -45,6 +45,22 @@ func TestValidateCreateTask(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Negative story points",
+			req: models.CreateTaskRequest{
+				Title:       "Test",
+				StoryPoints: intPtr(-1),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Valid story points",
+			req: models.CreateTaskRequest{
+				Title:       "Test",
+				StoryPoints: intPtr(8),
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -107,3 +123,43 @@ func TestValidatePagination(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateCustomFieldValue(t *testing.T) {
+	validator := NewTaskValidator()
+	options := []string{"low", "high"}
+
+	tests := []struct {
+		name    string
+		field   models.CustomField
+		value   interface{}
+		wantErr bool
+	}{
+		{"Valid text", models.CustomField{Key: "note", Type: models.FieldTypeText}, "hello", false},
+		{"Invalid text", models.CustomField{Key: "note", Type: models.FieldTypeText}, 12.0, true},
+		{"Valid number", models.CustomField{Key: "cost", Type: models.FieldTypeNumber}, 12.5, false},
+		{"Invalid number", models.CustomField{Key: "cost", Type: models.FieldTypeNumber}, "12", true},
+		{"Valid date", models.CustomField{Key: "launch", Type: models.FieldTypeDate}, "2024-05-01", false},
+		{"Invalid date", models.CustomField{Key: "launch", Type: models.FieldTypeDate}, "05/01/2024", true},
+		{"Valid single select", models.CustomField{Key: "risk", Type: models.FieldTypeSingleSelect, Options: options}, "low", false},
+		{"Unknown single select option", models.CustomField{Key: "risk", Type: models.FieldTypeSingleSelect, Options: options}, "medium", true},
+		{"Valid multi select", models.CustomField{Key: "tags", Type: models.FieldTypeMultiSelect, Options: options}, []interface{}{"low", "high"}, false},
+		{"Duplicate multi select option", models.CustomField{Key: "tags", Type: models.FieldTypeMultiSelect, Options: options}, []interface{}{"low", "low"}, true},
+		{"Multi select not a list", models.CustomField{Key: "tags", Type: models.FieldTypeMultiSelect, Options: options}, "low", true},
+		{"Valid user", models.CustomField{Key: "owner", Type: models.FieldTypeUser}, 3.0, false},
+		{"Fractional user", models.CustomField{Key: "owner", Type: models.FieldTypeUser}, 3.5, true},
+		{"Non-positive user", models.CustomField{Key: "owner", Type: models.FieldTypeUser}, 0.0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateCustomFieldValue(tt.field, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCustomFieldValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
@@ -0,0 +1,73 @@
+package validators
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/models"
+	"time"
+)
+
+type TimeEntryValidator struct{}
+
+func NewTimeEntryValidator() *TimeEntryValidator {
+	return &TimeEntryValidator{}
+}
+
+// ValidateStartTimer validates timer start request
+func (v *TimeEntryValidator) ValidateStartTimer(req *models.StartTimerRequest) error {
+	return v.ValidateDescription(req.Description)
+}
+
+// ValidateCreateTimeEntry validates manual time entry request made at the given time
+func (v *TimeEntryValidator) ValidateCreateTimeEntry(req *models.CreateTimeEntryRequest, at time.Time) error {
+	if err := v.ValidateDescription(req.Description); err != nil {
+		return err
+	}
+
+	return v.ValidateRange(req.StartedAt, req.EndedAt, at)
+}
+
+// ValidateUpdateTimeEntry validates time entry update request
+func (v *TimeEntryValidator) ValidateUpdateTimeEntry(req *models.UpdateTimeEntryRequest) error {
+	if req.Description == nil && req.StartedAt == nil && req.EndedAt == nil {
+		return apperrors.Invalid("body", "no fields to update")
+	}
+
+	if req.Description != nil {
+		if err := v.ValidateDescription(*req.Description); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidateRange validates that a time range is well formed and has ended by the
+// given time
+func (v *TimeEntryValidator) ValidateRange(startedAt, endedAt, at time.Time) error {
+	if startedAt.IsZero() || endedAt.IsZero() {
+		return apperrors.Invalid("started_at", "started_at and ended_at are required")
+	}
+
+	if !endedAt.After(startedAt) {
+		return apperrors.Invalid("ended_at", "ended_at must be after started_at")
+	}
+
+	if endedAt.After(at.Add(time.Minute)) {
+		return apperrors.Invalid("ended_at", "ended_at cannot be in the future")
+	}
+
+	if endedAt.Sub(startedAt) > 24*time.Hour {
+		return apperrors.Invalid("ended_at", "a time entry cannot be longer than 24 hours")
+	}
+
+	return nil
+}
+
+// ValidateDescription validates time entry description
+func (v *TimeEntryValidator) ValidateDescription(description string) error {
+	if len(description) > 1000 {
+		return apperrors.Invalid("description", "description must be less than 1000 characters")
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package validators
+
+import (
+	"candidate-backend/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateCreateTimeEntry(t *testing.T) {
+	validator := NewTimeEntryValidator()
+	now := time.Date(2024, 3, 12, 15, 0, 0, 0, time.UTC)
+	end := now.Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		req     models.CreateTimeEntryRequest
+		wantErr bool
+	}{
+		{
+			name: "Valid entry",
+			req: models.CreateTimeEntryRequest{
+				Description: "Code review",
+				StartedAt:   end.Add(-90 * time.Minute),
+				EndedAt:     end,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Ends before it starts",
+			req: models.CreateTimeEntryRequest{
+				StartedAt: end,
+				EndedAt:   end.Add(-time.Minute),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Ends in the future",
+			req: models.CreateTimeEntryRequest{
+				StartedAt: now,
+				EndedAt:   now.Add(2 * time.Hour),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Longer than a day",
+			req: models.CreateTimeEntryRequest{
+				StartedAt: end.Add(-25 * time.Hour),
+				EndedAt:   end,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Description too long",
+			req: models.CreateTimeEntryRequest{
+				Description: strings.Repeat("a", 1001),
+				StartedAt:   end.Add(-time.Hour),
+				EndedAt:     end,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateCreateTimeEntry(&tt.req, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreateTimeEntry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateUpdateTimeEntry(t *testing.T) {
+	validator := NewTimeEntryValidator()
+	description := "Pairing"
+
+	tests := []struct {
+		name    string
+		req     models.UpdateTimeEntryRequest
+		wantErr bool
+	}{
+		{"Valid description", models.UpdateTimeEntryRequest{Description: &description}, false},
+		{"No fields", models.UpdateTimeEntryRequest{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateUpdateTimeEntry(&tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateUpdateTimeEntry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package validators
+
+import (
+	"candidate-backend/internal/apperrors"
+	"candidate-backend/internal/models"
+	"net/url"
+)
+
+// MinWebhookSecretLength is the shortest secret accepted for signing deliveries
+const MinWebhookSecretLength = 16
+
+type WebhookValidator struct{}
+
+func NewWebhookValidator() *WebhookValidator {
+	return &WebhookValidator{}
+}
+
+// ValidateCreateWebhook validates webhook creation request
+func (v *WebhookValidator) ValidateCreateWebhook(req *models.CreateWebhookRequest) error {
+	if err := v.ValidateURL(req.URL); err != nil {
+		return err
+	}
+
+	if req.Secret != "" {
+		if err := v.ValidateSecret(req.Secret); err != nil {
+			return err
+		}
+	}
+
+	return v.ValidateEvents(req.Events)
+}
+
+// ValidateUpdateWebhook validates webhook update request
+func (v *WebhookValidator) ValidateUpdateWebhook(req *models.UpdateWebhookRequest) error {
+	if req.URL == nil && req.Secret == nil && req.Events == nil && req.Active == nil {
+		return apperrors.Invalid("body", "no fields to update")
+	}
+
+	if req.URL != nil {
+		if err := v.ValidateURL(*req.URL); err != nil {
+			return err
+		}
+	}
+
+	if req.Secret != nil {
+		if err := v.ValidateSecret(*req.Secret); err != nil {
+			return err
+		}
+	}
+
+	if req.Events != nil {
+		return v.ValidateEvents(req.Events)
+	}
+
+	return nil
+}
+
+// ValidateURL validates that a webhook URL is an absolute http or https URL
+func (v *WebhookValidator) ValidateURL(rawURL string) error {
+	if len(rawURL) > 2048 {
+		return apperrors.Invalid("url", "url must be less than 2048 characters")
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return apperrors.Invalid("url", "url must be an absolute http or https URL")
+	}
+
+	return nil
+}
+
+// ValidateSecret validates the length of a signing secret
+func (v *WebhookValidator) ValidateSecret(secret string) error {
+	if len(secret) < MinWebhookSecretLength {
+		return apperrors.Invalidf("secret", "secret must be at least %d characters", MinWebhookSecretLength)
+	}
+
+	if len(secret) > 255 {
+		return apperrors.Invalid("secret", "secret must be less than 255 characters")
+	}
+
+	return nil
+}
+
+// ValidateEvents validates that events is a non-empty list of known events without duplicates
+func (v *WebhookValidator) ValidateEvents(events []models.WebhookEvent) error {
+	if len(events) == 0 {
+		return apperrors.Invalid("events", "at least one event is required")
+	}
+
+	seen := make(map[models.WebhookEvent]bool, len(events))
+	for _, event := range events {
+		if !isWebhookEvent(event) {
+			return apperrors.Invalidf("events", "unknown event '%s'", event)
+		}
+		if seen[event] {
+			return apperrors.Invalidf("events", "duplicate event '%s'", event)
+		}
+		seen[event] = true
+	}
+
+	return nil
+}
+
+func isWebhookEvent(event models.WebhookEvent) bool {
+	for _, known := range models.WebhookEvents {
+		if event == known {
+			return true
+		}
+	}
+	return false
+}
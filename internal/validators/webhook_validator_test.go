@@ -0,0 +1,35 @@
+package validators
+
+import (
+	"candidate-backend/internal/models"
+	"testing"
+)
+
+func TestValidateCreateWebhook(t *testing.T) {
+	validator := NewWebhookValidator()
+	events := []models.WebhookEvent{models.WebhookTaskCreated}
+
+	tests := []struct {
+		name    string
+		req     models.CreateWebhookRequest
+		wantErr bool
+	}{
+		{"Valid", models.CreateWebhookRequest{URL: "https://example.com/hooks", Events: events}, false},
+		{"Valid with secret", models.CreateWebhookRequest{URL: "http://localhost:9000", Secret: "0123456789abcdef", Events: events}, false},
+		{"Relative URL", models.CreateWebhookRequest{URL: "/hooks", Events: events}, true},
+		{"Unsupported scheme", models.CreateWebhookRequest{URL: "ftp://example.com", Events: events}, true},
+		{"Short secret", models.CreateWebhookRequest{URL: "https://example.com", Secret: "short", Events: events}, true},
+		{"No events", models.CreateWebhookRequest{URL: "https://example.com"}, true},
+		{"Unknown event", models.CreateWebhookRequest{URL: "https://example.com", Events: []models.WebhookEvent{"task.exploded"}}, true},
+		{"Duplicate event", models.CreateWebhookRequest{URL: "https://example.com", Events: []models.WebhookEvent{models.WebhookTaskCreated, models.WebhookTaskCreated}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateCreateWebhook(&tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreateWebhook() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+-- Create time_entries table
+CREATE TABLE IF NOT EXISTS time_entries (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    description TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP,
+    duration_seconds BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT time_entries_range_check CHECK (ended_at IS NULL OR ended_at >= started_at)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_time_entries_task_id ON time_entries(task_id);
+CREATE INDEX IF NOT EXISTS idx_time_entries_user_id ON time_entries(user_id);
+CREATE INDEX IF NOT EXISTS idx_time_entries_started_at ON time_entries(started_at);
+
+-- At most one running timer per user
+CREATE UNIQUE INDEX IF NOT EXISTS idx_time_entries_one_running
+    ON time_entries(user_id) WHERE ended_at IS NULL;
+
+-- Create trigger for updated_at
+DROP TRIGGER IF EXISTS update_time_entries_updated_at ON time_entries;
+CREATE TRIGGER update_time_entries_updated_at BEFORE UPDATE ON time_entries
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,38 @@
+-- Create sprints table
+CREATE TABLE IF NOT EXISTS sprints (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    goal TEXT NOT NULL DEFAULT '',
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    creator_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    closed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT sprints_date_check CHECK (end_date >= start_date)
+);
+
+-- Add sprint and estimate columns to tasks table
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS sprint_id INTEGER REFERENCES sprints(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS story_points INTEGER;
+
+-- Tasks carried over when a sprint is closed
+CREATE TABLE IF NOT EXISTS sprint_carryovers (
+    id SERIAL PRIMARY KEY,
+    sprint_id INTEGER NOT NULL REFERENCES sprints(id) ON DELETE CASCADE,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    next_sprint_id INTEGER REFERENCES sprints(id) ON DELETE SET NULL,
+    story_points INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT sprint_carryovers_unique UNIQUE (sprint_id, task_id)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_tasks_sprint_id ON tasks(sprint_id);
+CREATE INDEX IF NOT EXISTS idx_sprints_start_date ON sprints(start_date);
+CREATE INDEX IF NOT EXISTS idx_sprint_carryovers_sprint_id ON sprint_carryovers(sprint_id);
+
+-- Create trigger for updated_at
+DROP TRIGGER IF EXISTS update_sprints_updated_at ON sprints;
+CREATE TRIGGER update_sprints_updated_at BEFORE UPDATE ON sprints
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
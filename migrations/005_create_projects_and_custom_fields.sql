@@ -0,0 +1,55 @@
+-- Create projects table
+CREATE TABLE IF NOT EXISTS projects (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    creator_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Add project column to tasks table
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS project_id INTEGER REFERENCES projects(id) ON DELETE SET NULL;
+
+-- Create project_custom_fields table
+CREATE TABLE IF NOT EXISTS project_custom_fields (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    key VARCHAR(64) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    field_type VARCHAR(32) NOT NULL,
+    options JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT project_custom_fields_key_unique UNIQUE (project_id, key),
+    CONSTRAINT project_custom_fields_type_check CHECK (
+        field_type IN ('text', 'number', 'date', 'single_select', 'multi_select', 'user')
+    )
+);
+
+-- Create task_custom_field_values table
+CREATE TABLE IF NOT EXISTS task_custom_field_values (
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    field_id INTEGER NOT NULL REFERENCES project_custom_fields(id) ON DELETE CASCADE,
+    value JSONB NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (task_id, field_id)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_tasks_project_id ON tasks(project_id);
+CREATE INDEX IF NOT EXISTS idx_task_custom_field_values_field_id ON task_custom_field_values(field_id);
+CREATE INDEX IF NOT EXISTS idx_task_custom_field_values_value ON task_custom_field_values USING GIN (value);
+
+-- Create triggers for updated_at
+DROP TRIGGER IF EXISTS update_projects_updated_at ON projects;
+CREATE TRIGGER update_projects_updated_at BEFORE UPDATE ON projects
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+DROP TRIGGER IF EXISTS update_project_custom_fields_updated_at ON project_custom_fields;
+CREATE TRIGGER update_project_custom_fields_updated_at BEFORE UPDATE ON project_custom_fields
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+DROP TRIGGER IF EXISTS update_task_custom_field_values_updated_at ON task_custom_field_values;
+CREATE TRIGGER update_task_custom_field_values_updated_at BEFORE UPDATE ON task_custom_field_values
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,9 @@
+-- Add reply threading to comments
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES comments(id) ON DELETE CASCADE;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS depth INTEGER NOT NULL DEFAULT 0;
+
+-- Comments with replies are soft-deleted so the thread keeps its shape
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments(parent_id);
@@ -0,0 +1,12 @@
+-- Create comment_revisions table
+-- Each row keeps a comment's content as it was before an edit
+CREATE TABLE IF NOT EXISTS comment_revisions (
+    id SERIAL PRIMARY KEY,
+    comment_id INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    written_at TIMESTAMP NOT NULL,
+    replaced_by INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    replaced_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT comment_revisions_revision_unique UNIQUE (comment_id, revision)
+);
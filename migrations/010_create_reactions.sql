@@ -0,0 +1,15 @@
+-- Create reactions table
+-- A reaction belongs to either a task or a comment
+CREATE TABLE IF NOT EXISTS reactions (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER REFERENCES tasks(id) ON DELETE CASCADE,
+    comment_id INTEGER REFERENCES comments(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    emoji VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((task_id IS NULL) <> (comment_id IS NULL))
+);
+
+-- One reaction per user and emoji on each task or comment
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reactions_task_user_emoji ON reactions(task_id, user_id, emoji) WHERE task_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reactions_comment_user_emoji ON reactions(comment_id, user_id, emoji) WHERE comment_id IS NOT NULL;
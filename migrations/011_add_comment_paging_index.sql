@@ -0,0 +1,2 @@
+-- Comment pages are read by task in creation order
+CREATE INDEX IF NOT EXISTS idx_comments_task_id_created_at ON comments(task_id, created_at, id);
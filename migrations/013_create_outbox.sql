@@ -0,0 +1,20 @@
+-- Create outbox table
+-- Domain events are written in the same transaction as the change they describe
+-- and published to subscribers by the outbox dispatcher. task_id has no foreign
+-- key so the events of deleted tasks are kept until they are published.
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(50) NOT NULL,
+    task_id INTEGER NOT NULL,
+    comment_id INTEGER,
+    actor_id INTEGER NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON outbox(next_attempt_at, id) WHERE published_at IS NULL;
@@ -0,0 +1,5 @@
+-- Add assignee column to tasks table
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS assignee_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+-- Create index for assignee column
+CREATE INDEX IF NOT EXISTS idx_tasks_assignee_id ON tasks(assignee_id);
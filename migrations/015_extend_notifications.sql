@@ -0,0 +1,20 @@
+-- Add event column to notifications table
+-- Notifications about a domain event keep its ID, so an event published twice
+-- notifies each user once
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS event_id BIGINT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_user_id_type_event_id ON notifications(user_id, type, event_id);
+
+-- Create notification preferences table
+-- A user gets every notification type unless a row turns it off
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, type)
+);
+
+-- Create indexes for the unread inbox and due-date reminders
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id_unread ON notifications(user_id, created_at DESC) WHERE read_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_notifications_task_id_type ON notifications(task_id, type);
+CREATE INDEX IF NOT EXISTS idx_tasks_due_date ON tasks(due_date) WHERE archived = FALSE;
@@ -0,0 +1,25 @@
+-- Create task_watchers table
+-- Migrations run on every start, so existing creators, assignees and commenters
+-- are only subscribed when the table is first created; users who stopped watching
+-- a task stay unsubscribed
+DO $$
+BEGIN
+    IF to_regclass('task_watchers') IS NULL THEN
+        CREATE TABLE task_watchers (
+            task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (task_id, user_id)
+        );
+
+        INSERT INTO task_watchers (task_id, user_id)
+        SELECT id, creator_id FROM tasks
+        UNION
+        SELECT id, assignee_id FROM tasks WHERE assignee_id IS NOT NULL
+        UNION
+        SELECT task_id, user_id FROM comments;
+    END IF;
+END $$;
+
+-- Create index for listing the tasks a user watches
+CREATE INDEX IF NOT EXISTS idx_task_watchers_user_id ON task_watchers(user_id);
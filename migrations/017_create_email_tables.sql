@@ -0,0 +1,23 @@
+-- Add email columns to notifications table
+-- Notifications stored before emails existed are marked skipped; new ones wait
+-- for the mailer as pending, then become sent, skipped or failed
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS email_status VARCHAR(20) NOT NULL DEFAULT 'skipped';
+ALTER TABLE notifications ALTER COLUMN email_status SET DEFAULT 'pending';
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS email_attempts INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_notifications_email_pending ON notifications(created_at, id) WHERE email_status = 'pending';
+
+-- Create email preferences table
+-- A user gets every kind of email unless a row turns it off
+CREATE TABLE IF NOT EXISTS email_preferences (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, kind)
+);
+
+-- Create email digests table
+CREATE TABLE IF NOT EXISTS email_digests (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    sent_at TIMESTAMP NOT NULL
+);
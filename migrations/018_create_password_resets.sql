@@ -0,0 +1,28 @@
+-- Add session version to users table
+-- Tokens carry the version they were issued with; bumping it signs the user out everywhere
+ALTER TABLE users ADD COLUMN IF NOT EXISTS session_version INTEGER NOT NULL DEFAULT 0;
+
+-- Create password resets table
+-- Only a SHA-256 hash of each reset token is stored
+CREATE TABLE IF NOT EXISTS password_resets (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_resets_user_id ON password_resets(user_id);
+
+-- Create password reset requests table
+-- Every request is recorded, for known and unknown emails alike, to throttle them
+CREATE TABLE IF NOT EXISTS password_reset_requests (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    ip VARCHAR(45) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_requests_email ON password_reset_requests(email, created_at);
+CREATE INDEX IF NOT EXISTS idx_password_reset_requests_ip ON password_reset_requests(ip, created_at);
@@ -0,0 +1,5 @@
+-- Add email verification columns to users table
+-- Users who registered before verification existed count as verified
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE users ALTER COLUMN email_verified_at DROP DEFAULT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verification_sent_at TIMESTAMP;
@@ -0,0 +1,26 @@
+-- Add admin flag to users table
+-- Admins are granted with SQL: UPDATE users SET is_admin = true WHERE email = '...'
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT false;
+
+-- Create user MFA table
+-- secret is encrypted; MFA is pending until enabled_at is set by a first code.
+-- last_counter is the TOTP time step of the last accepted code, so codes are not replayed.
+CREATE TABLE IF NOT EXISTS user_mfa (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    enabled_at TIMESTAMP,
+    last_counter BIGINT NOT NULL DEFAULT 0,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create MFA recovery codes table
+-- Only a SHA-256 hash of each code is stored
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    UNIQUE (user_id, code_hash)
+);
@@ -0,0 +1,17 @@
+-- Create login attempts table
+-- Every login attempt is kept for auditing, for known and unknown emails alike.
+-- Failed attempts are counted per email and per IP address to throttle logins.
+-- email is lowercased; user_id is the account the email was typed for, if any.
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    ip VARCHAR(45) NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    result VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_email ON login_attempts(email, created_at);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_ip ON login_attempts(ip, created_at);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_user_id ON login_attempts(user_id, created_at);
@@ -0,0 +1,17 @@
+-- Create personal access tokens table
+-- Only a SHA-256 hash of each token is stored; prefix is its start, shown to tell
+-- tokens apart. Revoked tokens are kept with revoked_at set.
+CREATE TABLE IF NOT EXISTS access_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    prefix VARCHAR(20) NOT NULL,
+    scopes TEXT[] NOT NULL,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_access_tokens_user_id ON access_tokens(user_id);
@@ -0,0 +1,26 @@
+-- Create task_status_changes table
+-- Each row is a status a task moved to, written in the transaction of the change.
+-- Sprint burndowns read it instead of the change log text. Migrations run on every
+-- start, so the history is only backfilled from the task.updated events in the
+-- outbox when the table is first created.
+DO $$
+BEGIN
+    IF to_regclass('task_status_changes') IS NULL THEN
+        CREATE TABLE task_status_changes (
+            id SERIAL PRIMARY KEY,
+            task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+            status VARCHAR(50) NOT NULL,
+            changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        );
+
+        INSERT INTO task_status_changes (task_id, status, changed_at)
+        SELECT o.task_id, o.payload->'task'->>'status', o.created_at
+        FROM outbox o
+        JOIN tasks t ON t.id = o.task_id
+        WHERE o.event_type = 'task.updated' AND o.payload->'fields' ? 'status'
+        ORDER BY o.id;
+    END IF;
+END $$;
+
+-- Create index for reading the history of a sprint's tasks
+CREATE INDEX IF NOT EXISTS idx_task_status_changes_task_id ON task_status_changes(task_id, changed_at);
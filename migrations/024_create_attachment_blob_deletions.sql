@@ -0,0 +1,14 @@
+-- Create attachment_blob_deletions table
+-- Deleting a task or comment cascades to its attachments, so their storage keys are
+-- queued here in the same transaction. The attachment service deletes the blobs when
+-- the outbox publishes the task.deleted or comment.deleted event. task_id has no
+-- foreign key because the task is usually gone by then.
+CREATE TABLE IF NOT EXISTS attachment_blob_deletions (
+    id BIGSERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL,
+    storage_key VARCHAR(512) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create index for finding the blobs of a deleted task or comment
+CREATE INDEX IF NOT EXISTS idx_attachment_blob_deletions_task_id ON attachment_blob_deletions(task_id);
@@ -0,0 +1,4 @@
+-- Track which subscribers handled each outbox event
+-- A failed event is retried only for the subscribers missing from handled_by, so
+-- the others do not see it again.
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS handled_by TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,3 @@
+-- Index published outbox events by publication time
+-- The dispatcher deletes events published longer ago than OUTBOX_RETENTION.
+CREATE INDEX IF NOT EXISTS idx_outbox_published_at ON outbox(published_at) WHERE published_at IS NOT NULL;